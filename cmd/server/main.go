@@ -1,9 +1,18 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator"
+	orcherrors "github.com/nixlim/codedoc-mcp-server/internal/orchestrator/errors"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
@@ -30,6 +39,98 @@ func main() {
 		Str("log_level", logLevel).
 		Msg("Starting CodeDoc MCP Server")
 	
+	metricsEnabled, _ := strconv.ParseBool(os.Getenv("METRICS_ENABLED"))
+	probesEnabled, _ := strconv.ParseBool(os.Getenv("PROBES_ENABLED"))
+
+	// Run the orchestrator behind the metrics and probe servers when either
+	// is enabled
+	var orch *orchestrator.OrchestratorImpl
+	if metricsEnabled || probesEnabled {
+		config := orchestrator.DefaultConfig()
+		recovery, err := orcherrors.NewRecoveryManager(config.Workflow)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to create recovery manager")
+		}
+		opts := []orchestrator.Option{orchestrator.WithRecoveryManager(recovery)}
+
+		// The orchestrator records into the same metrics the server exposes
+		var metrics *orchestrator.PrometheusMetrics
+		if metricsEnabled {
+			metrics = orchestrator.NewPrometheusMetrics()
+			opts = append(opts, orchestrator.WithMetrics(metrics))
+		}
+
+		// An unreachable database doesn't fail construction; /readyz
+		// reports it until the database comes back
+		orch, err = orchestrator.NewOrchestrator(config, opts...)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to create orchestrator")
+		}
+
+		// Expose Prometheus metrics when enabled
+		if metricsEnabled {
+			metricsAddr := os.Getenv("METRICS_ADDR")
+			if metricsAddr == "" {
+				metricsAddr = ":9090"
+			}
+			startMetricsServer(metricsAddr, metrics)
+		}
+
+		// Serve Kubernetes liveness and readiness probes when enabled
+		if probesEnabled {
+			probesAddr := os.Getenv("PROBES_ADDR")
+			if probesAddr == "" {
+				probesAddr = ":8081"
+			}
+			startProbeServer(probesAddr, orch)
+		}
+	}
+
 	// Placeholder for server initialization
 	fmt.Println("CodeDoc MCP Server - Foundation Ready")
-}
\ No newline at end of file
+
+	if orch != nil {
+		waitForShutdown(orch)
+	}
+}
+
+// waitForShutdown blocks until SIGINT or SIGTERM, then shuts down orch.
+func waitForShutdown(orch *orchestrator.OrchestratorImpl) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-signals
+	log.Info().Str("signal", sig.String()).Msg("Shutting down")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := orch.Shutdown(ctx); err != nil {
+		log.Error().Err(err).Msg("Orchestrator shutdown failed")
+	}
+}
+
+// startMetricsServer serves the metrics handler on addr in the background.
+func startMetricsServer(addr string, metrics *orchestrator.PrometheusMetrics) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Str("addr", addr).Msg("Metrics server stopped")
+		}
+	}()
+
+	log.Info().Str("addr", addr).Msg("Serving Prometheus metrics")
+}
+
+// startProbeServer serves /healthz and /readyz for p on addr in the
+// background. Liveness never touches dependencies, so an unreachable
+// database stops traffic to the process without getting it restarted.
+func startProbeServer(addr string, p orchestrator.Prober) {
+	go func() {
+		if err := http.ListenAndServe(addr, orchestrator.ProbeHandler(p)); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Str("addr", addr).Msg("Probe server stopped")
+		}
+	}()
+
+	log.Info().Str("addr", addr).Msg("Serving health probes")
+}
@@ -0,0 +1,57 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/session"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/workflow"
+)
+
+// SessionExpiredError is returned when an operation targets a session whose
+// ExpiresAt has passed. The errors package converts it into an
+// OrchestratorError whose Details["session_id"] names the session.
+type SessionExpiredError struct {
+	// SessionID identifies the expired session
+	SessionID string `json:"session_id"`
+
+	// ExpiresAt is when the session expired
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Error implements the error interface.
+func (e *SessionExpiredError) Error() string {
+	return fmt.Sprintf("session %s has expired", e.SessionID)
+}
+
+// ensureActive rejects sessions that have expired or already finished. Every
+// method that changes a session's state calls it before doing any work, so
+// a session can't be revived after its expiry.
+func ensureActive(sess *DocumentationSession) error {
+	if sess.State == WorkflowStateExpired || time.Now().After(sess.ExpiresAt) {
+		return &SessionExpiredError{SessionID: sess.ID, ExpiresAt: sess.ExpiresAt}
+	}
+	if sess.IsTerminal() {
+		return fmt.Errorf("session %s cannot be modified in state %s", sess.ID, sess.State)
+	}
+	return nil
+}
+
+// failSession moves a session to the failed state, recording note as the
+// reason.
+func (o *OrchestratorImpl) failSession(ctx context.Context, sess *DocumentationSession, note session.SessionNote) error {
+	if err := o.workflowEngine.Transition(ctx, sess.ID, workflow.WorkflowStateFailed); err != nil {
+		return fmt.Errorf("failed to transition to failed state: %w", err)
+	}
+	o.metrics().StateTransition(sess.State, WorkflowStateFailed)
+
+	sessionUUID, _ := parseSessionID(sess.ID)
+	o.progressMu.Lock()
+	err := o.sessionManager.Update(sessionUUID, session.NewSessionUpdate().WithStatus(session.StatusFailed).WithNote(note).Build())
+	o.progressMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsureActive(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name        string
+		sess        *DocumentationSession
+		wantExpired bool
+		errMsg      string
+	}{
+		{
+			name: "active session",
+			sess: &DocumentationSession{ID: "s-1", State: WorkflowStateProcessing, ExpiresAt: now.Add(time.Hour)},
+		},
+		{
+			name:        "expired session",
+			sess:        &DocumentationSession{ID: "s-2", State: WorkflowStateProcessing, ExpiresAt: now.Add(-time.Hour)},
+			wantExpired: true,
+			errMsg:      "session s-2 has expired",
+		},
+		{
+			name:        "expiry wins over terminal state",
+			sess:        &DocumentationSession{ID: "s-3", State: WorkflowStateComplete, ExpiresAt: now.Add(-time.Hour)},
+			wantExpired: true,
+			errMsg:      "session s-3 has expired",
+		},
+		{
+			name:        "expired state before expiry",
+			sess:        &DocumentationSession{ID: "s-5", State: WorkflowStateExpired, ExpiresAt: now.Add(time.Hour)},
+			wantExpired: true,
+			errMsg:      "session s-5 has expired",
+		},
+		{
+			name:   "terminal session",
+			sess:   &DocumentationSession{ID: "s-4", State: WorkflowStateFailed, ExpiresAt: now.Add(time.Hour)},
+			errMsg: "session s-4 cannot be modified in state failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ensureActive(tt.sess)
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.errMsg)
+
+			var serr *SessionExpiredError
+			assert.Equal(t, tt.wantExpired, errors.As(err, &serr))
+			if tt.wantExpired {
+				assert.Equal(t, tt.sess.ID, serr.SessionID)
+				assert.Equal(t, tt.sess.ExpiresAt, serr.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestExpiredSessionOperations(t *testing.T) {
+	ctx := context.Background()
+
+	newExpiredSession := func(t *testing.T, sessionID string) (*OrchestratorImpl, time.Time) {
+		t.Helper()
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		sess := createMockSession(sessionID, "workspace-123", "/project")
+		sess.Status = session.StatusInProgress
+		sess.ExpiresAt = time.Now().Add(-time.Minute)
+		mockSession.On("Get", uuid.MustParse(sessionID)).Return(sess, nil)
+		return o, sess.ExpiresAt
+	}
+
+	assertExpired := func(t *testing.T, err error, sessionID string, expiresAt time.Time) {
+		t.Helper()
+		var serr *SessionExpiredError
+		require.ErrorAs(t, err, &serr)
+		assert.Equal(t, sessionID, serr.SessionID)
+		assert.Equal(t, expiresAt, serr.ExpiresAt)
+	}
+
+	t.Run("process next file", func(t *testing.T) {
+		sessionID := "550e8400-e29b-41d4-a716-446655440740"
+		o, expiresAt := newExpiredSession(t, sessionID)
+
+		// The mocks have no GetNext expectation, so reaching the queue would panic
+		_, err := o.ProcessNextFile(ctx, sessionID)
+		assertExpired(t, err, sessionID, expiresAt)
+	})
+
+	t.Run("complete session", func(t *testing.T) {
+		sessionID := "550e8400-e29b-41d4-a716-446655440741"
+		o, expiresAt := newExpiredSession(t, sessionID)
+
+		err := o.CompleteSession(ctx, sessionID, CompleteOptions{})
+		assertExpired(t, err, sessionID, expiresAt)
+	})
+
+	t.Run("add files", func(t *testing.T) {
+		sessionID := "550e8400-e29b-41d4-a716-446655440742"
+		o, expiresAt := newExpiredSession(t, sessionID)
+
+		err := o.AddFiles(ctx, sessionID, []string{"/project/new.go"}, 0)
+		assertExpired(t, err, sessionID, expiresAt)
+	})
+}
+
+func TestExpiredSessionState(t *testing.T) {
+	ctx := context.Background()
+	o, mockSession, _, _ := createTestOrchestrator(t)
+
+	failed := createMockSession("550e8400-e29b-41d4-a716-446655440750", "workspace-123", "/project/failed")
+	failed.Status = session.StatusFailed
+	failed.ExpiresAt = time.Now().Add(time.Hour)
+
+	swept := createMockSession("550e8400-e29b-41d4-a716-446655440751", "workspace-123", "/project/swept")
+	swept.Status = session.StatusExpired
+	swept.ExpiresAt = time.Now().Add(time.Hour)
+
+	lapsed := createMockSession("550e8400-e29b-41d4-a716-446655440752", "workspace-123", "/project/lapsed")
+	lapsed.Status = session.StatusInProgress
+	lapsed.ExpiresAt = time.Now().Add(-time.Minute)
+
+	mockSession.On("Search", "workspace-123", "project").Return([]*session.Session{failed, swept, lapsed}, nil)
+	for _, sess := range []*session.Session{failed, swept, lapsed} {
+		mockSession.On("Get", sess.ID).Return(sess, nil)
+	}
+
+	sessions, err := o.SearchSessions(ctx, "workspace-123", "project")
+	require.NoError(t, err)
+	require.Len(t, sessions, 3)
+	assert.Equal(t, WorkflowStateFailed, sessions[0].State)
+	assert.Equal(t, WorkflowStateExpired, sessions[1].State)
+	assert.Equal(t, WorkflowStateExpired, sessions[2].State)
+	for _, sess := range sessions {
+		assert.True(t, sess.IsTerminal())
+		assert.False(t, sess.IsActive())
+	}
+
+	// GetSession agrees with the mapping: expired sessions are rejected as
+	// expired, failed ones are returned as failed
+	got, err := o.GetSession(ctx, failed.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, WorkflowStateFailed, got.State)
+
+	for _, sess := range []*session.Session{swept, lapsed} {
+		_, err := o.GetSession(ctx, sess.ID.String())
+		var serr *SessionExpiredError
+		require.ErrorAs(t, err, &serr)
+		assert.Equal(t, sess.ID.String(), serr.SessionID)
+	}
+}
@@ -0,0 +1,81 @@
+package orchestrator
+
+import (
+	"context"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/services"
+)
+
+// aiService returns the registered AI service called name. Services that
+// declare a MaxConcurrency are wrapped so that no more than that many of
+// their requests are in flight across all workers and sessions, keeping a
+// slow or rate-limited provider from exceeding its limit.
+func (o *OrchestratorImpl) aiService(name string) (services.AIService, error) {
+	ai, err := o.serviceRegistry.GetAIService(name)
+	if err != nil {
+		return nil, err
+	}
+
+	limited, ok := ai.(services.ConcurrencyLimited)
+	if !ok || limited.MaxConcurrency() <= 0 {
+		return ai, nil
+	}
+	return &limitedAIService{AIService: ai, slots: o.aiSlots(name, limited.MaxConcurrency())}, nil
+}
+
+// aiSlots returns the semaphore shared by every caller of the AI service
+// called name, creating it with size slots on first use.
+func (o *OrchestratorImpl) aiSlots(name string, size int) chan struct{} {
+	o.aiLimitsMu.Lock()
+	defer o.aiLimitsMu.Unlock()
+
+	if o.aiLimits == nil {
+		o.aiLimits = make(map[string]chan struct{})
+	}
+	slots, ok := o.aiLimits[name]
+	if !ok {
+		slots = make(chan struct{}, size)
+		o.aiLimits[name] = slots
+	}
+	return slots
+}
+
+// limitedAIService holds a slot of its service's semaphore for the duration
+// of each analysis or documentation request. Token counting is local and
+// cheap, so it is not limited.
+type limitedAIService struct {
+	services.AIService
+	slots chan struct{}
+}
+
+// acquire waits for a free slot, giving up when ctx is done.
+func (s *limitedAIService) acquire(ctx context.Context) error {
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *limitedAIService) release() {
+	<-s.slots
+}
+
+// AnalyzeFile implements services.AIService.
+func (s *limitedAIService) AnalyzeFile(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error) {
+	if err := s.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer s.release()
+	return s.AIService.AnalyzeFile(ctx, req)
+}
+
+// GenerateDocumentation implements services.AIService.
+func (s *limitedAIService) GenerateDocumentation(ctx context.Context, req services.DocumentationRequest) (*services.DocumentationResponse, error) {
+	if err := s.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer s.release()
+	return s.AIService.GenerateDocumentation(ctx, req)
+}
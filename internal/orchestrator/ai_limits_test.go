@@ -0,0 +1,124 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cappedAIService is a stub AI service that declares a concurrency limit and
+// records the most requests it ever had in flight.
+type cappedAIService struct {
+	stubAIService
+	limit  int
+	delay  time.Duration
+	active int32
+	peak   int32
+}
+
+func newCappedAIService(limit int, delay time.Duration) *cappedAIService {
+	s := &cappedAIService{limit: limit, delay: delay}
+	s.analyzeFunc = func(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error) {
+		n := atomic.AddInt32(&s.active, 1)
+		defer atomic.AddInt32(&s.active, -1)
+		for {
+			p := atomic.LoadInt32(&s.peak)
+			if n <= p || atomic.CompareAndSwapInt32(&s.peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(s.delay)
+		return &services.FileAnalysisResponse{Summary: req.FilePath}, nil
+	}
+	return s
+}
+
+func (s *cappedAIService) MaxConcurrency() int {
+	return s.limit
+}
+
+func TestAIServiceConcurrencyLimits(t *testing.T) {
+	o, _, _, _ := createTestOrchestrator(t)
+	slow := newCappedAIService(2, 5*time.Millisecond)
+	fast := newCappedAIService(5, time.Millisecond)
+	unlimited := newCappedAIService(0, 20*time.Millisecond)
+	require.NoError(t, o.serviceRegistry.RegisterAIService("slow", slow))
+	require.NoError(t, o.serviceRegistry.RegisterAIService("fast", fast))
+	require.NoError(t, o.serviceRegistry.RegisterAIService("unlimited", unlimited))
+
+	// Hammer every provider from many goroutines at once
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		for _, name := range []string{"slow", "fast", "unlimited"} {
+			wg.Add(1)
+			go func(name string, i int) {
+				defer wg.Done()
+				ai, err := o.aiService(name)
+				if !assert.NoError(t, err) {
+					return
+				}
+				_, err = ai.AnalyzeFile(context.Background(), services.FileAnalysisRequest{FilePath: fmt.Sprintf("/file%d.go", i)})
+				assert.NoError(t, err)
+			}(name, i)
+		}
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, atomic.LoadInt32(&slow.peak), int32(2))
+	assert.LessOrEqual(t, atomic.LoadInt32(&fast.peak), int32(5))
+	assert.Greater(t, atomic.LoadInt32(&unlimited.peak), int32(5))
+
+	// A provider's limit doesn't consume another provider's slots
+	assert.Equal(t, 2, cap(o.aiSlots("slow", 0)))
+	assert.Equal(t, 5, cap(o.aiSlots("fast", 0)))
+}
+
+func TestAIServiceConcurrencyLimitHonorsContext(t *testing.T) {
+	o, _, _, _ := createTestOrchestrator(t)
+	release := make(chan struct{})
+	blocked := &cappedAIService{limit: 1}
+	blocked.analyzeFunc = func(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error) {
+		<-release
+		return &services.FileAnalysisResponse{}, nil
+	}
+	require.NoError(t, o.serviceRegistry.RegisterAIService("blocked", blocked))
+
+	ai, err := o.aiService("blocked")
+	require.NoError(t, err)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = ai.AnalyzeFile(context.Background(), services.FileAnalysisRequest{})
+	}()
+	require.Eventually(t, func() bool { return len(o.aiSlots("blocked", 1)) == 1 }, time.Second, time.Millisecond)
+
+	// The only slot is taken, so a second request waits until its context ends
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = ai.AnalyzeFile(ctx, services.FileAnalysisRequest{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+	<-done
+}
+
+func TestProcessFilesRespectsProviderLimit(t *testing.T) {
+	o, mockSession, _, _ := createTestOrchestrator(t)
+	sessionID := "550e8400-e29b-41d4-a716-446655440940"
+	setupBatchSession(t, o, mockSession, sessionID, 12)
+
+	provider := newCappedAIService(2, 5*time.Millisecond)
+	require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, provider))
+
+	results, err := o.ProcessFiles(context.Background(), sessionID, 6)
+	require.NoError(t, err)
+	assert.Len(t, results, 12)
+	assert.LessOrEqual(t, atomic.LoadInt32(&provider.peak), int32(2))
+}
@@ -0,0 +1,228 @@
+package orchestrator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/services"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/session"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/todolist"
+)
+
+// DefaultAIProvider is the AI service name used when none is configured.
+const DefaultAIProvider = "openai"
+
+// analyzeFile produces the analysis for a single file. When an AI service is
+// registered the file is read through the file system service (if available)
+// and sent for analysis; otherwise a placeholder analysis is returned.
+func (o *OrchestratorImpl) analyzeFile(ctx context.Context, sessionID, filePath string) (*FileAnalysis, error) {
+	ai, err := o.aiService(o.aiProviderName())
+	if err != nil {
+		// No AI service configured yet, fall back to a placeholder analysis
+		return &FileAnalysis{
+			FilePath: filePath,
+			Content:  "// TODO: Implement actual file analysis",
+			Metadata: FileMetadata{
+				Language:     "go",
+				Functions:    []string{},
+				Classes:      []string{},
+				Dependencies: []string{},
+				Complexity:   0,
+			},
+			TokenCount:  0,
+			ProcessedAt: time.Now(),
+		}, nil
+	}
+
+	var content []byte
+	var hash string
+	streamed := false
+	fs, fsErr := o.serviceRegistry.GetFileSystem()
+	if fsErr == nil {
+		content, err = fs.ReadFile(ctx, filePath)
+		switch {
+		case errors.Is(err, services.ErrFileTooLarge) && o.oversizePolicy() == OversizeChunk:
+			// Too large to read whole, so it is streamed in windows
+			streamed = true
+		case err != nil:
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		default:
+			hash = contentHash(content)
+
+			// The hash is of the file as stored, so processors can't
+			// defeat change detection
+			content, err = o.processContent(ctx, filePath, content)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	language := DetectLanguage(filePath)
+	prompt, err := o.promptRegistry().Render(PromptData{FilePath: filePath, Language: language})
+	if err != nil {
+		return nil, err
+	}
+
+	model := o.analysisModel(sessionID, language)
+	if err := o.checkModel(model); err != nil {
+		return nil, err
+	}
+
+	req := services.FileAnalysisRequest{
+		FilePath: filePath,
+		Content:  string(content),
+		Language: language,
+		Prompt:   prompt,
+		Model:    model,
+	}
+
+	if streamed {
+		return o.analyzeStreamed(ctx, ai, fs, req)
+	}
+
+	// Check the file fits the model's context window before sending it
+	if limit := o.maxContextTokens(); limit > 0 && len(content) > 0 {
+		tokens, err := ai.CountTokens(ctx, req.Content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count tokens: %w", err)
+		}
+		if tokens > limit {
+			if o.oversizePolicy() != OversizeChunk {
+				return nil, &FileTooLargeError{FilePath: filePath, Tokens: tokens, MaxTokens: limit}
+			}
+			return o.analyzeChunked(ctx, ai, req, hash, tokens, limit)
+		}
+	}
+
+	resp, err := ai.AnalyzeFile(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze file: %w", err)
+	}
+
+	return analysisFromResponse(req, hash, resp), nil
+}
+
+// analysisFromResponse builds the FileAnalysis for an AI analysis response.
+func analysisFromResponse(req services.FileAnalysisRequest, hash string, resp *services.FileAnalysisResponse) *FileAnalysis {
+	return &FileAnalysis{
+		FilePath: req.FilePath,
+		Content:  resp.Summary,
+		Metadata: FileMetadata{
+			Language:     req.Language,
+			Functions:    resp.Functions,
+			Classes:      resp.Classes,
+			Dependencies: resp.Dependencies,
+		},
+		TokenCount:  resp.TokenCount,
+		ContentHash: hash,
+		ProcessedAt: time.Now(),
+	}
+}
+
+// FileTooLargeError reports that a file has more tokens than the model's
+// context window allows and was not sent for analysis.
+type FileTooLargeError struct {
+	FilePath  string
+	Tokens    int
+	MaxTokens int
+}
+
+func (e *FileTooLargeError) Error() string {
+	return fmt.Sprintf("file %s has %d tokens, exceeding the limit of %d", e.FilePath, e.Tokens, e.MaxTokens)
+}
+
+// maxContextTokens returns the configured per-file token limit, 0 if none.
+func (o *OrchestratorImpl) maxContextTokens() int {
+	if o.config == nil {
+		return 0
+	}
+	return o.config.Services.MaxContextTokens
+}
+
+// oversizePolicy returns the configured oversize policy, defaulting to
+// OversizeSkip.
+func (o *OrchestratorImpl) oversizePolicy() string {
+	if o.config == nil || o.config.Services.OversizePolicy == "" {
+		return OversizeSkip
+	}
+	return o.config.Services.OversizePolicy
+}
+
+// contentHash returns the hex-encoded SHA-256 of file content.
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// aiProviderName returns the name of the AI service used for analysis.
+func (o *OrchestratorImpl) aiProviderName() string {
+	if o.config != nil && o.config.Services.AIProvider != "" {
+		return o.config.Services.AIProvider
+	}
+	return DefaultAIProvider
+}
+
+// defaultPrompts serves the built-in prompt to orchestrators built without
+// a prompt registry.
+var defaultPrompts = NewPromptRegistry()
+
+// promptRegistry returns the configured prompt templates, or the built-in
+// default prompt when none were loaded.
+func (o *OrchestratorImpl) promptRegistry() *PromptRegistry {
+	if o.prompts == nil {
+		return defaultPrompts
+	}
+	return o.prompts
+}
+
+// skipFile marks a file the session will not analyze as skipped, records it
+// in the session's progress with a note giving the reason, and returns the
+// FileSkippedError for ProcessNextFile to report.
+func (o *OrchestratorImpl) skipFile(ctx context.Context, sessionID, filePath string, reason error) error {
+	logger := LoggerFromContext(ctx)
+	logger.Warn().
+		Err(reason).
+		Str("file", filePath).
+		Msg("Skipping file")
+
+	if err := o.todoManager.UpdateProgress(ctx, sessionID, filePath, todolist.ItemStatusSkipped); err != nil {
+		logger.Error().
+			Err(err).
+			Str("file", filePath).
+			Msg("Failed to mark file as skipped")
+	}
+	o.publishProgress(sessionID, filePath, ProgressSkipped)
+
+	sessionUUID, _ := parseSessionID(sessionID)
+	o.progressMu.Lock()
+	defer o.progressMu.Unlock()
+	current, err := o.sessionManager.Get(sessionUUID)
+	if err != nil {
+		return fmt.Errorf("session not found: %w", err)
+	}
+	progress := current.Progress
+	if progress.CurrentFile == filePath {
+		progress.CurrentFile = ""
+	}
+	progress.SkippedFiles = append(append([]string{}, progress.SkippedFiles...), filePath)
+	err = o.sessionManager.Update(sessionUUID, session.NewSessionUpdate().
+		WithProgress(progress).
+		WithNote(session.SessionNote{
+			FilePath: filePath,
+			Status:   string(todolist.ItemStatusSkipped),
+			Severity: session.NoteSeverityWarn,
+			Source:   NoteSourceProcessor,
+			Message:  reason.Error(),
+		}).
+		Build())
+	if err != nil {
+		return fmt.Errorf("failed to update session progress: %w", err)
+	}
+
+	return &FileSkippedError{FilePath: filePath, Err: reason}
+}
@@ -0,0 +1,248 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/session"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultMaxConcurrency is the global worker limit used when none is configured.
+const DefaultMaxConcurrency = 4
+
+// FileProcessingError reports that a single file failed analysis. The file
+// has been marked as failed and the rest of the queue can still be processed.
+type FileProcessingError struct {
+	FilePath string
+	Err      error
+}
+
+func (e *FileProcessingError) Error() string {
+	return fmt.Sprintf("failed to process file %s: %v", e.FilePath, e.Err)
+}
+
+func (e *FileProcessingError) Unwrap() error {
+	return e.Err
+}
+
+// ErrSessionFailedFast is returned by ProcessNextFile when a file fails in a
+// session started with DocumentationOptions.FailFast. The session has been
+// moved to the failed state; the error also wraps the FileProcessingError.
+var ErrSessionFailedFast = errors.New("session failed on the first file error")
+
+// failFast fails a FailFast session because of fileErr, recording note as
+// the cause.
+func (o *OrchestratorImpl) failFast(ctx context.Context, sess *DocumentationSession, fileErr *FileProcessingError, note session.SessionNote) error {
+	LoggerFromContext(ctx).Error().
+		Err(fileErr.Err).
+		Str("file", fileErr.FilePath).
+		Msg("File failed in fail-fast session, failing session")
+
+	if err := o.failSession(ctx, sess, note); err != nil {
+		return errors.Join(fileErr, err)
+	}
+	return fmt.Errorf("%w: %w", ErrSessionFailedFast, fileErr)
+}
+
+// FileSkippedError reports that a file was skipped without being analyzed,
+// for example because it exceeds the model's context window. The file has
+// been marked as skipped and the rest of the queue can still be processed.
+type FileSkippedError struct {
+	FilePath string
+	Err      error
+}
+
+func (e *FileSkippedError) Error() string {
+	return fmt.Sprintf("skipped file %s: %v", e.FilePath, e.Err)
+}
+
+func (e *FileSkippedError) Unwrap() error {
+	return e.Err
+}
+
+// ProcessFiles drains a session's TODO queue with a pool of workers.
+func (o *OrchestratorImpl) ProcessFiles(ctx context.Context, sessionID string, concurrency int) ([]*FileAnalysis, error) {
+	if concurrency < 0 {
+		return nil, fmt.Errorf("concurrency cannot be negative")
+	}
+
+	// Workers share the batch's logger so their lines correlate
+	ctx = ContextWithSessionLogger(ctx, sessionID)
+
+	sess, err := o.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureActive(sess); err != nil {
+		return nil, err
+	}
+
+	// Enter the processing state once, before workers race to do it
+	if err := o.enterProcessing(ctx, sess); err != nil {
+		return nil, err
+	}
+
+	workers := o.resolveConcurrency(sessionID, concurrency)
+
+	// A failure that fails the session stops the other workers, returning
+	// their files to the queue
+	batchCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		results  []*FileAnalysis
+		failures []error
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batchCtx.Err() == nil {
+				analysis, err := o.ProcessNextFile(batchCtx, sessionID)
+
+				var fileErr *FileProcessingError
+				var skipErr *FileSkippedError
+				switch {
+				case errors.Is(err, ErrNoMoreFiles):
+					return
+				case errors.Is(err, ErrSessionFailedFast), errors.Is(err, ErrConsecutiveFailures):
+					mu.Lock()
+					failures = append(failures, err)
+					mu.Unlock()
+					stop()
+					return
+				case err != nil && batchCtx.Err() != nil && ctx.Err() == nil:
+					// Interrupted by another worker failing fast
+					return
+				case errors.As(err, &skipErr):
+					// Skipped files are recorded on the session, not failures
+					continue
+				case errors.As(err, &fileErr):
+					// A single bad file doesn't stop the pool
+					mu.Lock()
+					failures = append(failures, err)
+					mu.Unlock()
+					continue
+				case err != nil:
+					mu.Lock()
+					failures = append(failures, err)
+					mu.Unlock()
+					return
+				}
+
+				mu.Lock()
+				results = append(results, analysis)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	LoggerFromContext(ctx).Info().
+		Int("workers", workers).
+		Int("processed", len(results)).
+		Int("failed", len(failures)).
+		Msg("Batch processing finished")
+
+	if err := ctx.Err(); err != nil {
+		failures = append(failures, err)
+	}
+	if len(failures) > 0 {
+		return results, fmt.Errorf("batch processing of session %s had errors: %w", sessionID, errors.Join(failures...))
+	}
+	return results, nil
+}
+
+// resolveConcurrency picks the worker count for a batch: the caller's
+// override, else the session's configured MaxConcurrency, clamped to the
+// global limit.
+func (o *OrchestratorImpl) resolveConcurrency(sessionID string, override int) int {
+	limit := DefaultMaxConcurrency
+	if o.config != nil && o.config.Workflow.MaxConcurrency > 0 {
+		limit = o.config.Workflow.MaxConcurrency
+	}
+
+	requested := override
+	if requested == 0 {
+		requested = o.getSessionOptions(sessionID).MaxConcurrency
+	}
+	if requested == 0 {
+		return limit
+	}
+
+	if requested > limit {
+		log.Warn().
+			Str("session_id", sessionID).
+			Int("requested", requested).
+			Int("limit", limit).
+			Msg("Requested concurrency exceeds the global limit, clamping")
+		return limit
+	}
+	return requested
+}
+
+// sessionRequest is the part of a DocumentationRequest a session keeps
+// beyond its stored state: its options and any explicit file list.
+type sessionRequest struct {
+	options             DocumentationOptions
+	files               []string
+	includeDependencies bool
+}
+
+// setSessionOptions remembers the options a session was started with.
+func (o *OrchestratorImpl) setSessionOptions(sessionID string, opts DocumentationOptions) {
+	o.optionsMu.Lock()
+	defer o.optionsMu.Unlock()
+
+	if o.sessionOptions == nil {
+		o.sessionOptions = make(map[string]sessionRequest)
+	}
+	req := o.sessionOptions[sessionID]
+	req.options = opts
+	o.sessionOptions[sessionID] = req
+}
+
+// getSessionOptions returns the options a session was started with, or the
+// zero value if the session was started elsewhere.
+func (o *OrchestratorImpl) getSessionOptions(sessionID string) DocumentationOptions {
+	o.optionsMu.RLock()
+	defer o.optionsMu.RUnlock()
+	return o.sessionOptions[sessionID].options
+}
+
+// setSessionFiles remembers the explicit file list a session was started
+// with, and whether their dependencies were included.
+func (o *OrchestratorImpl) setSessionFiles(sessionID string, files []string, includeDependencies bool) {
+	o.optionsMu.Lock()
+	defer o.optionsMu.Unlock()
+
+	if o.sessionOptions == nil {
+		o.sessionOptions = make(map[string]sessionRequest)
+	}
+	req := o.sessionOptions[sessionID]
+	req.files = files
+	req.includeDependencies = includeDependencies
+	o.sessionOptions[sessionID] = req
+}
+
+// getSessionFiles returns the explicit file list a session was started
+// with, empty if it was started with discovery.
+func (o *OrchestratorImpl) getSessionFiles(sessionID string) ([]string, bool) {
+	o.optionsMu.RLock()
+	defer o.optionsMu.RUnlock()
+	req := o.sessionOptions[sessionID]
+	return req.files, req.includeDependencies
+}
+
+// clearSessionOptions forgets the options of a finished session.
+func (o *OrchestratorImpl) clearSessionOptions(sessionID string) {
+	o.optionsMu.Lock()
+	defer o.optionsMu.Unlock()
+	delete(o.sessionOptions, sessionID)
+}
@@ -0,0 +1,260 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/services"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/session"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/todolist"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/workflow"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// setupBatchSession prepares an in-progress session with n queued files
+// backed by a real TODO list.
+func setupBatchSession(t *testing.T, o *OrchestratorImpl, mockSession *mockSessionManager, sessionID string, n int) {
+	t.Helper()
+	ctx := context.Background()
+
+	sess := createMockSession(sessionID, "workspace-123", "/project")
+	sess.Status = session.StatusInProgress
+	sess.Progress.TotalFiles = n
+	mockSession.On("Get", sess.ID).Return(sess, nil)
+	mockSession.On("Update", sess.ID, mock.AnythingOfType("session.SessionUpdate")).Return(nil)
+	if engine, ok := o.workflowEngine.(*mockWorkflowEngine); ok {
+		engine.On("GetState", mock.Anything, sessionID).Return(workflow.WorkflowStateProcessing, nil).Maybe()
+	}
+
+	o.todoManager = todolist.NewManager()
+	require.NoError(t, o.todoManager.CreateList(ctx, sessionID))
+	for i := 0; i < n; i++ {
+		require.NoError(t, o.todoManager.AddItem(ctx, sessionID, todolist.TodoItem{FilePath: fmt.Sprintf("/project/file%d.go", i)}))
+	}
+}
+
+func TestProcessFiles(t *testing.T) {
+	t.Run("session concurrency is honored by the worker pool", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		o.config.Workflow.MaxConcurrency = 8
+		sessionID := "550e8400-e29b-41d4-a716-446655440700"
+		setupBatchSession(t, o, mockSession, sessionID, 9)
+		o.setSessionOptions(sessionID, DocumentationOptions{MaxConcurrency: 3})
+
+		// Hold the first calls until three are in flight, then let all run
+		var active, peak int32
+		release := make(chan struct{})
+		var releaseOnce sync.Once
+		require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &stubAIService{
+			analyzeFunc: func(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error) {
+				n := atomic.AddInt32(&active, 1)
+				defer atomic.AddInt32(&active, -1)
+				for {
+					p := atomic.LoadInt32(&peak)
+					if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+						break
+					}
+				}
+				if n == 3 {
+					releaseOnce.Do(func() { close(release) })
+				}
+				select {
+				case <-release:
+				case <-time.After(2 * time.Second):
+				}
+				return &services.FileAnalysisResponse{Summary: req.FilePath}, nil
+			},
+		}))
+
+		results, err := o.ProcessFiles(context.Background(), sessionID, 0)
+		require.NoError(t, err)
+		assert.Len(t, results, 9)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&peak))
+
+		progress, err := o.todoManager.GetProgress(context.Background(), sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, 9, progress.Complete)
+	})
+
+	t.Run("file failures are collected without stopping the pool", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		sessionID := "550e8400-e29b-41d4-a716-446655440701"
+		setupBatchSession(t, o, mockSession, sessionID, 4)
+
+		require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &stubAIService{
+			analyzeFunc: func(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error) {
+				if req.FilePath == "/project/file2.go" {
+					return nil, errors.New("model unavailable")
+				}
+				return &services.FileAnalysisResponse{Summary: req.FilePath}, nil
+			},
+		}))
+
+		results, err := o.ProcessFiles(context.Background(), sessionID, 2)
+		assert.Len(t, results, 3)
+		require.Error(t, err)
+
+		var fileErr *FileProcessingError
+		require.ErrorAs(t, err, &fileErr)
+		assert.Equal(t, "/project/file2.go", fileErr.FilePath)
+
+		progress, err := o.todoManager.GetProgress(context.Background(), sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, 3, progress.Complete)
+		assert.Equal(t, 1, progress.Failed)
+	})
+
+	t.Run("negative concurrency is rejected", func(t *testing.T) {
+		o, _, _, _ := createTestOrchestrator(t)
+		_, err := o.ProcessFiles(context.Background(), "550e8400-e29b-41d4-a716-446655440702", -1)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "concurrency cannot be negative")
+	})
+}
+
+func TestResolveConcurrency(t *testing.T) {
+	tests := []struct {
+		name        string
+		globalLimit int
+		session     int
+		override    int
+		want        int
+		wantWarning bool
+	}{
+		{name: "session value used", globalLimit: 8, session: 3, want: 3},
+		{name: "override wins over session", globalLimit: 8, session: 3, override: 5, want: 5},
+		{name: "unset uses global limit", globalLimit: 8, want: 8},
+		{name: "unset global uses default", want: DefaultMaxConcurrency},
+		{name: "session above limit is clamped", globalLimit: 4, session: 16, want: 4, wantWarning: true},
+		{name: "override above limit is clamped", globalLimit: 4, override: 10, want: 4, wantWarning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			original := log.Logger
+			log.Logger = zerolog.New(&buf)
+			defer func() { log.Logger = original }()
+
+			o, _, _, _ := createTestOrchestrator(t)
+			o.config.Workflow.MaxConcurrency = tt.globalLimit
+			o.setSessionOptions("session-1", DocumentationOptions{MaxConcurrency: tt.session})
+
+			assert.Equal(t, tt.want, o.resolveConcurrency("session-1", tt.override))
+			assert.Equal(t, tt.wantWarning, bytes.Contains(buf.Bytes(), []byte("clamping")))
+		})
+	}
+}
+
+func TestProcessNextFileDoneSignal(t *testing.T) {
+	o, mockSession, _, _ := createTestOrchestrator(t)
+	sessionID := "550e8400-e29b-41d4-a716-446655440710"
+	setupBatchSession(t, o, mockSession, sessionID, 2)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		analysis, err := o.ProcessNextFile(ctx, sessionID)
+		require.NoError(t, err)
+		require.NotNil(t, analysis)
+	}
+
+	// The drained queue is reported explicitly, and stays drained
+	for i := 0; i < 2; i++ {
+		analysis, err := o.ProcessNextFile(ctx, sessionID)
+		assert.ErrorIs(t, err, ErrNoMoreFiles)
+		assert.Nil(t, analysis)
+	}
+}
+
+func TestProcessFilesFailFast(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T, failFast bool) (*OrchestratorImpl, *session.Session, string, *[]string) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		sessionID := "550e8400-e29b-41d4-a716-446655440795"
+		sess := newStatefulSession(t, o, mockSession, sessionID)
+		o.setSessionOptions(sessionID, DocumentationOptions{FailFast: failFast})
+
+		for _, item := range []todolist.TodoItem{
+			{FilePath: "/project/first.go", Priority: 3},
+			{FilePath: "/project/bad.go", Priority: 2},
+			{FilePath: "/project/last.go", Priority: 1},
+		} {
+			require.NoError(t, o.todoManager.AddItem(ctx, sessionID, item))
+		}
+
+		var analyzed []string
+		require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &stubAIService{
+			analyzeFunc: func(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error) {
+				analyzed = append(analyzed, req.FilePath)
+				if req.FilePath == "/project/bad.go" {
+					return nil, errors.New("model refused")
+				}
+				return &services.FileAnalysisResponse{Summary: "ok"}, nil
+			},
+		}))
+		return o, sess, sessionID, &analyzed
+	}
+
+	t.Run("fail fast fails the session on the first error", func(t *testing.T) {
+		o, sess, sessionID, analyzed := setup(t, true)
+
+		results, err := o.ProcessFiles(ctx, sessionID, 1)
+		require.ErrorIs(t, err, ErrSessionFailedFast)
+		var fileErr *FileProcessingError
+		require.ErrorAs(t, err, &fileErr)
+		assert.Equal(t, "/project/bad.go", fileErr.FilePath)
+		assert.Len(t, results, 1)
+
+		// Nothing after the failing file was analyzed
+		assert.Equal(t, []string{"/project/first.go", "/project/bad.go"}, *analyzed)
+		assert.Equal(t, session.StatusFailed, sess.Status)
+		state, err := o.workflowEngine.GetState(ctx, sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, workflow.WorkflowStateFailed, state)
+
+		// The cause is recorded on the session
+		require.Len(t, sess.Notes, 1)
+		assert.Equal(t, session.NoteSeverityError, sess.Notes[0].Severity)
+		assert.Equal(t, "/project/bad.go", sess.Notes[0].FilePath)
+		assert.Contains(t, sess.Notes[0].Message, "model refused")
+
+		progress, err := o.todoManager.GetProgress(ctx, sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, progress.Pending)
+
+		_, err = o.ProcessNextFile(ctx, sessionID)
+		assert.ErrorContains(t, err, "cannot be modified in state failed")
+	})
+
+	t.Run("tolerant mode continues and completes with a failed count", func(t *testing.T) {
+		o, sess, sessionID, analyzed := setup(t, false)
+
+		results, err := o.ProcessFiles(ctx, sessionID, 1)
+		var fileErr *FileProcessingError
+		require.ErrorAs(t, err, &fileErr)
+		assert.NotErrorIs(t, err, ErrSessionFailedFast)
+		assert.Len(t, results, 2)
+		assert.Equal(t, []string{"/project/first.go", "/project/bad.go", "/project/last.go"}, *analyzed)
+
+		progress, err := o.todoManager.GetProgress(ctx, sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, progress.Failed)
+		assert.Equal(t, 2, progress.Complete)
+
+		require.NoError(t, o.CompleteSession(ctx, sessionID, CompleteOptions{}))
+		assert.Equal(t, session.StatusCompleted, sess.Status)
+		assert.Equal(t, []string{"/project/bad.go"}, sess.Progress.FailedFiles)
+		assert.Equal(t, 2, sess.Progress.ProcessedFiles)
+	})
+}
@@ -0,0 +1,325 @@
+package orchestrator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"strings"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/services"
+)
+
+// analyzeChunked analyzes content too large for one request by splitting it
+// into chunks of at most limit tokens, analyzing each and merging the
+// results into a single FileAnalysis.
+func (o *OrchestratorImpl) analyzeChunked(ctx context.Context, ai services.AIService, req services.FileAnalysisRequest, hash string, tokens, limit int) (*FileAnalysis, error) {
+	chunks, err := chunkContent(ctx, ai, req.Language, req.Content, tokens, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to chunk %s: %w", req.FilePath, err)
+	}
+
+	LoggerFromContext(ctx).Debug().
+		Str("file", req.FilePath).
+		Int("tokens", tokens).
+		Int("chunks", len(chunks)).
+		Msg("Analyzing oversized file in chunks")
+
+	parts := make([]*FileAnalysis, 0, len(chunks))
+	for i, chunk := range chunks {
+		chunkReq := req
+		chunkReq.Content = chunk
+		resp, err := ai.AnalyzeFile(ctx, chunkReq)
+		if err != nil {
+			return nil, fmt.Errorf("failed to analyze chunk %d of %d: %w", i+1, len(chunks), err)
+		}
+		parts = append(parts, analysisFromResponse(chunkReq, hash, resp))
+	}
+	return mergeAnalyses(parts), nil
+}
+
+// analyzeStreamed analyzes a file too large for ReadFile by streaming it
+// through OpenFile in windows of whole lines no larger than the file
+// system's read limit. Each window is processed and analyzed on its own,
+// chunked further when it exceeds the token limit, and the results are
+// merged. The content hash covers the whole file as stored.
+func (o *OrchestratorImpl) analyzeStreamed(ctx context.Context, ai services.AIService, fs services.FileSystemService, req services.FileAnalysisRequest) (*FileAnalysis, error) {
+	file, err := fs.OpenFile(ctx, req.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	size := streamWindowSize(fs)
+	reader := bufio.NewReaderSize(io.TeeReader(file, hasher), size)
+	limit := o.maxContextTokens()
+
+	var parts []*FileAnalysis
+	for windows := 1; ; windows++ {
+		window, readErr := readWindow(reader, size)
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return nil, fmt.Errorf("failed to read file: %w", readErr)
+		}
+		if len(window) > 0 {
+			content, err := o.processContent(ctx, req.FilePath, window)
+			if err != nil {
+				return nil, err
+			}
+			windowReq := req
+			windowReq.Content = string(content)
+
+			part, err := o.analyzeWindow(ctx, ai, windowReq, limit)
+			if err != nil {
+				return nil, fmt.Errorf("failed to analyze window %d: %w", windows, err)
+			}
+			parts = append(parts, part)
+		}
+		if readErr != nil {
+			break
+		}
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("file %s is empty", req.FilePath)
+	}
+
+	LoggerFromContext(ctx).Debug().
+		Str("file", req.FilePath).
+		Int("windows", len(parts)).
+		Msg("Analyzed file too large to read whole")
+
+	merged := mergeAnalyses(parts)
+	merged.ContentHash = hex.EncodeToString(hasher.Sum(nil))
+	return merged, nil
+}
+
+// analyzeWindow analyzes one window of a streamed file, chunking it when it
+// has more than limit tokens. A limit of 0 sends it whole.
+func (o *OrchestratorImpl) analyzeWindow(ctx context.Context, ai services.AIService, req services.FileAnalysisRequest, limit int) (*FileAnalysis, error) {
+	if limit > 0 {
+		tokens, err := ai.CountTokens(ctx, req.Content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count tokens: %w", err)
+		}
+		if tokens > limit {
+			return o.analyzeChunked(ctx, ai, req, "", tokens, limit)
+		}
+	}
+	resp, err := ai.AnalyzeFile(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return analysisFromResponse(req, "", resp), nil
+}
+
+// streamWindowSize returns the window size for streaming a file from fs:
+// its read limit when it reports one, DefaultMaxReadSize otherwise.
+func streamWindowSize(fs services.FileSystemService) int {
+	if limited, ok := fs.(interface{ MaxReadSize() int64 }); ok && limited.MaxReadSize() > 0 {
+		return int(limited.MaxReadSize())
+	}
+	return int(services.DefaultMaxReadSize)
+}
+
+// readWindow reads size bytes from r, then up to size more to finish the
+// last line, so windows split between lines unless a line is longer than
+// size. r must buffer at least size bytes. It returns io.EOF, along with
+// any final bytes, at the end of the input.
+func readWindow(r *bufio.Reader, size int) ([]byte, error) {
+	window := make([]byte, size, 2*size)
+	n, err := io.ReadFull(r, window)
+	window = window[:n]
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return window, io.EOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	if window[n-1] == '\n' {
+		return window, nil
+	}
+
+	rest, err := r.Peek(size)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+		rest = rest[:i+1]
+	}
+	window = append(window, rest...)
+	if _, err := r.Discard(len(rest)); err != nil {
+		return nil, err
+	}
+	return window, nil
+}
+
+// chunkContent splits content into chunks of at most limit tokens. Go
+// source is split between top-level declarations so no function or type is
+// cut in half; other content, and Go that doesn't parse, is split into line
+// windows.
+func chunkContent(ctx context.Context, ai services.AIService, language, content string, tokens, limit int) ([]string, error) {
+	if language == "go" {
+		if segments, ok := goDeclSegments(content); ok {
+			return packSegments(ctx, ai, segments, limit)
+		}
+	}
+	return chunkLines(ctx, ai, content, tokens, limit)
+}
+
+// goDeclSegments splits Go source before each top-level declaration other
+// than imports, keeping doc comments with their declaration. The package
+// clause and imports stay in the first segment. It reports false if the
+// source doesn't parse.
+func goDeclSegments(content string) ([]string, bool) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, false
+	}
+	tokenFile := fset.File(file.Pos())
+
+	boundaries := []int{0}
+	for _, decl := range file.Decls {
+		start := decl.Pos()
+		switch d := decl.(type) {
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT {
+				continue
+			}
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		case *ast.FuncDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		}
+		// Cut at the start of the declaration's line
+		offset := tokenFile.Offset(start)
+		offset = strings.LastIndexByte(content[:offset], '\n') + 1
+		if offset > boundaries[len(boundaries)-1] {
+			boundaries = append(boundaries, offset)
+		}
+	}
+
+	segments := make([]string, 0, len(boundaries))
+	for i, start := range boundaries {
+		end := len(content)
+		if i+1 < len(boundaries) {
+			end = boundaries[i+1]
+		}
+		segments = append(segments, content[start:end])
+	}
+	return segments, true
+}
+
+// packSegments joins consecutive segments into chunks of at most limit
+// tokens. A segment too large on its own is split into line windows.
+func packSegments(ctx context.Context, ai services.AIService, segments []string, limit int) ([]string, error) {
+	var chunks []string
+	var current strings.Builder
+	currentTokens := 0
+	flush := func() {
+		if current.Len() > 0 {
+			chunks = append(chunks, current.String())
+			current.Reset()
+			currentTokens = 0
+		}
+	}
+
+	for _, segment := range segments {
+		tokens, err := ai.CountTokens(ctx, segment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count tokens: %w", err)
+		}
+		if tokens > limit {
+			flush()
+			pieces, err := chunkLines(ctx, ai, segment, tokens, limit)
+			if err != nil {
+				return nil, err
+			}
+			chunks = append(chunks, pieces...)
+			continue
+		}
+		if currentTokens+tokens > limit {
+			flush()
+		}
+		current.WriteString(segment)
+		currentTokens += tokens
+	}
+	flush()
+	return chunks, nil
+}
+
+// chunkLines splits content into contiguous windows of whole lines that
+// each count at most limit tokens. It starts from the fewest windows the
+// total allows and adds more until every window fits.
+func chunkLines(ctx context.Context, ai services.AIService, content string, tokens, limit int) ([]string, error) {
+	lines := strings.SplitAfter(content, "\n")
+	for pieces := (tokens + limit - 1) / limit; pieces <= len(lines); {
+		chunks := splitLines(lines, pieces)
+		fits := true
+		for _, chunk := range chunks {
+			n, err := ai.CountTokens(ctx, chunk)
+			if err != nil {
+				return nil, fmt.Errorf("failed to count tokens: %w", err)
+			}
+			if n > limit {
+				fits = false
+				break
+			}
+		}
+		if fits {
+			return chunks, nil
+		}
+		if pieces == len(lines) {
+			break
+		}
+		// Grow geometrically so pathological content can't cost a count
+		// per line, but never past one window per line
+		pieces = min(max(pieces+1, pieces*3/2), len(lines))
+	}
+	return nil, fmt.Errorf("content cannot be split into chunks of at most %d tokens", limit)
+}
+
+// splitLines joins lines into n windows of nearly equal line counts.
+func splitLines(lines []string, n int) []string {
+	chunks := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		start, end := i*len(lines)/n, (i+1)*len(lines)/n
+		if start < end {
+			chunks = append(chunks, strings.Join(lines[start:end], ""))
+		}
+	}
+	return chunks
+}
+
+// mergeAnalyses combines the analyses of a file's chunks: content is
+// concatenated in order, functions, classes and dependencies are unioned and
+// token counts are summed.
+func mergeAnalyses(parts []*FileAnalysis) *FileAnalysis {
+	merged := *parts[0]
+	merged.Metadata.Functions = nil
+	merged.Metadata.Classes = nil
+	merged.Metadata.Dependencies = nil
+	merged.TokenCount = 0
+
+	contents := make([]string, 0, len(parts))
+	for _, part := range parts {
+		contents = append(contents, part.Content)
+		merged.Metadata.Functions = appendUnique(merged.Metadata.Functions, part.Metadata.Functions...)
+		merged.Metadata.Classes = appendUnique(merged.Metadata.Classes, part.Metadata.Classes...)
+		merged.Metadata.Dependencies = appendUnique(merged.Metadata.Dependencies, part.Metadata.Dependencies...)
+		merged.Metadata.Complexity = max(merged.Metadata.Complexity, part.Metadata.Complexity)
+		merged.TokenCount += part.TokenCount
+	}
+	merged.Content = strings.Join(contents, "\n\n")
+	return &merged
+}
@@ -0,0 +1,331 @@
+package orchestrator
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/services"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/session"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/todolist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// oversizedFile returns content of lines lines, each 10 bytes long.
+func oversizedFile(lines int) []byte {
+	return []byte(strings.Repeat("line 0123\n", lines))
+}
+
+func TestTokenPreflight(t *testing.T) {
+	ctx := context.Background()
+
+	// setup registers a stub AI that counts one token per byte and records
+	// what it was asked to analyze
+	setup := func(t *testing.T, policy string) (*OrchestratorImpl, *session.Session, string, *[]string) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		o.config.Services.MaxContextTokens = 100
+		o.config.Services.OversizePolicy = policy
+
+		sessionID := "550e8400-e29b-41d4-a716-446655440790"
+		sess := newStatefulSession(t, o, mockSession, sessionID)
+		sess.Progress.TotalFiles = 2
+		require.NoError(t, o.todoManager.AddItem(ctx, sessionID, todolist.TodoItem{FilePath: "/project/big.go", Priority: 2}))
+		require.NoError(t, o.todoManager.AddItem(ctx, sessionID, todolist.TodoItem{FilePath: "/project/small.go", Priority: 1}))
+
+		require.NoError(t, o.serviceRegistry.RegisterFileSystem(&fakeFileSystem{files: map[string][]byte{
+			"/project/big.go":   oversizedFile(25),
+			"/project/small.go": oversizedFile(3),
+		}}))
+
+		var mu sync.Mutex
+		var analyzed []string
+		require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &stubAIService{
+			analyzeFunc: func(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error) {
+				mu.Lock()
+				analyzed = append(analyzed, req.FilePath)
+				mu.Unlock()
+				return &services.FileAnalysisResponse{Summary: "ok", TokenCount: len(req.Content)}, nil
+			},
+		}))
+		return o, sess, sessionID, &analyzed
+	}
+
+	t.Run("skip policy skips the oversized file with a note", func(t *testing.T) {
+		o, sess, sessionID, analyzed := setup(t, OversizeSkip)
+
+		_, err := o.ProcessNextFile(ctx, sessionID)
+		var skipErr *FileSkippedError
+		require.ErrorAs(t, err, &skipErr)
+		assert.Equal(t, "/project/big.go", skipErr.FilePath)
+		var tooLarge *FileTooLargeError
+		require.ErrorAs(t, err, &tooLarge)
+		assert.Equal(t, 250, tooLarge.Tokens)
+		assert.Equal(t, 100, tooLarge.MaxTokens)
+
+		// The normal file still proceeds
+		analysis, err := o.ProcessNextFile(ctx, sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, "/project/small.go", analysis.FilePath)
+
+		assert.Equal(t, []string{"/project/small.go"}, *analyzed)
+		assert.Equal(t, []string{"/project/big.go"}, sess.Progress.SkippedFiles)
+		require.Len(t, sess.Notes, 1)
+		assert.Equal(t, session.NoteSeverityWarn, sess.Notes[0].Severity)
+		assert.Equal(t, NoteSourceProcessor, sess.Notes[0].Source)
+		assert.Equal(t, "/project/big.go", sess.Notes[0].FilePath)
+		assert.Contains(t, sess.Notes[0].Message, "exceeding the limit of 100")
+
+		progress, err := o.todoManager.GetProgress(ctx, sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, progress.Skipped)
+		assert.Equal(t, 1, progress.Complete)
+	})
+
+	t.Run("skipped files do not fail a batch", func(t *testing.T) {
+		o, _, sessionID, analyzed := setup(t, OversizeSkip)
+
+		results, err := o.ProcessFiles(ctx, sessionID, 1)
+		require.NoError(t, err)
+		assert.Len(t, results, 1)
+		assert.Equal(t, []string{"/project/small.go"}, *analyzed)
+	})
+
+	t.Run("chunk policy analyzes the oversized file in pieces", func(t *testing.T) {
+		o, sess, sessionID, analyzed := setup(t, OversizeChunk)
+
+		analysis, err := o.ProcessNextFile(ctx, sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, "/project/big.go", analysis.FilePath)
+		assert.Equal(t, 250, analysis.TokenCount)
+
+		// 250 tokens under a limit of 100 need three chunks
+		assert.Equal(t, []string{"/project/big.go", "/project/big.go", "/project/big.go"}, *analyzed)
+		assert.Empty(t, sess.Progress.SkippedFiles)
+
+		_, err = o.ProcessNextFile(ctx, sessionID)
+		require.NoError(t, err)
+		assert.Len(t, *analyzed, 4)
+	})
+}
+
+func TestChunkContent(t *testing.T) {
+	ctx := context.Background()
+	ai := &stubAIService{}
+
+	content := string(oversizedFile(25))
+	chunks, err := chunkContent(ctx, ai, "", content, len(content), 100)
+	require.NoError(t, err)
+	require.Len(t, chunks, 3)
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, len(chunk), 100)
+		assert.True(t, strings.HasSuffix(chunk, "\n"), "chunks end on line boundaries")
+	}
+	assert.Equal(t, content, strings.Join(chunks, ""))
+
+	// A single line over the limit cannot be split
+	_, err = chunkContent(ctx, ai, "", strings.Repeat("x", 150), 150, 100)
+	assert.ErrorContains(t, err, "cannot be split into chunks of at most 100 tokens")
+
+	// Content that only fits one line per chunk is split that far, even
+	// when growing the chunk count would step past the number of lines
+	content = string(oversizedFile(4)) + strings.Repeat("x", 60)
+	chunks, err = chunkContent(ctx, ai, "", content, len(content), 60)
+	require.NoError(t, err)
+	assert.Len(t, chunks, 5)
+	assert.Equal(t, content, strings.Join(chunks, ""))
+
+	// and gives up once one chunk per line still doesn't fit
+	_, err = chunkContent(ctx, ai, "", "ab\n"+strings.Repeat("x", 150), 153, 100)
+	assert.ErrorContains(t, err, "cannot be split into chunks of at most 100 tokens")
+}
+
+// syntheticGoFile returns Go source declaring n documented functions and n
+// types, each function importing a distinct dependency through its body.
+func syntheticGoFile(n int) string {
+	var b strings.Builder
+	b.WriteString("package big\n\nimport \"fmt\"\n\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "// Func%d does step %d.\nfunc Func%d() string {\n\treturn fmt.Sprint(\"dep%d\")\n}\n\n", i, i, i, i)
+		fmt.Fprintf(&b, "// Type%d holds step %d.\ntype Type%d struct {\n\tValue int\n}\n\n", i, i, i)
+	}
+	return b.String()
+}
+
+// declaredNames returns the names following prefix in content, such as the
+// functions declared by a chunk.
+func declaredNames(content, prefix string) []string {
+	var names []string
+	for _, line := range strings.Split(content, "\n") {
+		if rest, ok := strings.CutPrefix(line, prefix); ok {
+			names = append(names, strings.FieldsFunc(rest, func(r rune) bool { return r == '(' || r == ' ' })[0])
+		}
+	}
+	return names
+}
+
+func TestAnalyzeChunkedGoFile(t *testing.T) {
+	ctx := context.Background()
+	o, _, _, _ := createTestOrchestrator(t)
+	o.config.Services.MaxContextTokens = 400
+	o.config.Services.OversizePolicy = OversizeChunk
+
+	content := syntheticGoFile(20)
+	require.NoError(t, o.serviceRegistry.RegisterFileSystem(&fakeFileSystem{files: map[string][]byte{
+		"/project/big.go": []byte(content),
+	}}))
+
+	var chunks []string
+	require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &stubAIService{
+		analyzeFunc: func(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error) {
+			chunks = append(chunks, req.Content)
+			var deps []string
+			if strings.Contains(req.Content, "fmt.") {
+				deps = []string{"fmt"}
+			}
+			return &services.FileAnalysisResponse{
+				Summary:      fmt.Sprintf("chunk %d", len(chunks)),
+				Functions:    declaredNames(req.Content, "func "),
+				Classes:      declaredNames(req.Content, "type "),
+				Dependencies: deps,
+				TokenCount:   len(req.Content),
+			}, nil
+		},
+	}))
+
+	analysis, err := o.analyzeFile(ctx, "", "/project/big.go")
+	require.NoError(t, err)
+
+	require.Greater(t, len(chunks), 1, "the file is chunked")
+	assert.Equal(t, content, strings.Join(chunks, ""), "chunks cover the file in order")
+	for i, chunk := range chunks {
+		assert.LessOrEqual(t, len(chunk), 400, "chunk %d fits the limit", i)
+		// Every declaration is whole: braces balance within each chunk
+		assert.Equal(t, strings.Count(chunk, "{"), strings.Count(chunk, "}"), "chunk %d splits a declaration", i)
+	}
+
+	// The merged analysis has the union of every chunk's metadata
+	assert.Len(t, analysis.Metadata.Functions, 20)
+	assert.Len(t, analysis.Metadata.Classes, 20)
+	assert.Contains(t, analysis.Metadata.Functions, "Func0")
+	assert.Contains(t, analysis.Metadata.Functions, "Func19")
+	assert.Contains(t, analysis.Metadata.Classes, "Type19")
+	assert.Equal(t, []string{"fmt"}, analysis.Metadata.Dependencies)
+	assert.Equal(t, len(content), analysis.TokenCount)
+	assert.True(t, strings.HasPrefix(analysis.Content, "chunk 1\n\nchunk 2"))
+	assert.Equal(t, "go", analysis.Metadata.Language)
+}
+
+func TestGoDeclSegments(t *testing.T) {
+	src := "package p\n\nimport \"fmt\"\n\n// A is documented.\nfunc A() { fmt.Println() }\n\ntype B struct{}\n"
+	segments, ok := goDeclSegments(src)
+	require.True(t, ok)
+	assert.Equal(t, []string{
+		"package p\n\nimport \"fmt\"\n\n",
+		"// A is documented.\nfunc A() { fmt.Println() }\n\n",
+		"type B struct{}\n",
+	}, segments)
+
+	_, ok = goDeclSegments("not go")
+	assert.False(t, ok)
+}
+
+func TestMergeAnalyses(t *testing.T) {
+	merged := mergeAnalyses([]*FileAnalysis{
+		{
+			FilePath:    "/project/big.go",
+			Content:     "first",
+			Metadata:    FileMetadata{Language: "go", Functions: []string{"A", "B"}, Classes: []string{"T"}, Dependencies: []string{"fmt"}, Complexity: 2},
+			TokenCount:  10,
+			ContentHash: "hash",
+		},
+		{
+			FilePath:    "/project/big.go",
+			Content:     "second",
+			Metadata:    FileMetadata{Language: "go", Functions: []string{"B", "C"}, Dependencies: []string{"fmt", "os"}, Complexity: 5},
+			TokenCount:  15,
+			ContentHash: "hash",
+		},
+	})
+
+	assert.Equal(t, "first\n\nsecond", merged.Content)
+	assert.Equal(t, []string{"A", "B", "C"}, merged.Metadata.Functions)
+	assert.Equal(t, []string{"T"}, merged.Metadata.Classes)
+	assert.Equal(t, []string{"fmt", "os"}, merged.Metadata.Dependencies)
+	assert.Equal(t, 5, merged.Metadata.Complexity)
+	assert.Equal(t, 25, merged.TokenCount)
+	assert.Equal(t, "hash", merged.ContentHash)
+}
+
+func TestAnalyzeStreamedFile(t *testing.T) {
+	ctx := context.Background()
+	o, _, _, _ := createTestOrchestrator(t)
+	o.config.Services.MaxContextTokens = 300
+	o.config.Services.OversizePolicy = OversizeChunk
+
+	// A file five times the read limit, with a line that straddles it
+	root := t.TempDir()
+	content := append(oversizedFile(250), "// a line crossing the first window boundary\n"...)
+	content = append(oversizedFile(100), content...)
+	path := filepath.Join(root, "big.txt")
+	require.NoError(t, os.WriteFile(path, content, 0o644))
+
+	fs, err := services.NewLocalFileSystem(root, 1024)
+	require.NoError(t, err)
+	require.NoError(t, o.serviceRegistry.RegisterFileSystem(fs))
+	_, err = fs.ReadFile(ctx, path)
+	require.ErrorIs(t, err, services.ErrFileTooLarge)
+
+	var chunks []string
+	require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &stubAIService{
+		analyzeFunc: func(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error) {
+			chunks = append(chunks, req.Content)
+			return &services.FileAnalysisResponse{Summary: "ok", TokenCount: len(req.Content)}, nil
+		},
+	}))
+
+	analysis, err := o.analyzeFile(ctx, "", path)
+	require.NoError(t, err)
+
+	assert.Equal(t, string(content), strings.Join(chunks, ""), "chunks cover the file in order")
+	for i, chunk := range chunks {
+		assert.LessOrEqual(t, len(chunk), 300, "chunk %d fits the limit", i)
+		assert.True(t, strings.HasSuffix(chunk, "\n"), "chunk %d ends on a line", i)
+	}
+	assert.Equal(t, contentHash(content), analysis.ContentHash)
+	assert.Equal(t, len(content), analysis.TokenCount)
+
+	// Under the skip policy the file is not read at all
+	o.config.Services.OversizePolicy = OversizeSkip
+	_, err = o.analyzeFile(ctx, "", path)
+	assert.ErrorIs(t, err, services.ErrFileTooLarge)
+}
+
+func TestReadWindow(t *testing.T) {
+	read := func(input string, size int) []string {
+		r := bufio.NewReaderSize(strings.NewReader(input), size)
+		var windows []string
+		for {
+			window, err := readWindow(r, size)
+			if len(window) > 0 {
+				windows = append(windows, string(window))
+			}
+			if errors.Is(err, io.EOF) {
+				return windows
+			}
+			require.NoError(t, err)
+		}
+	}
+
+	assert.Equal(t, []string{"aaaa\nbb\n", "cc\n"}, read("aaaa\nbb\ncc\n", 6), "windows end on a line")
+	assert.Equal(t, []string{"ab\ncd\n", "ef"}, read("ab\ncd\nef", 4), "the last line is finished")
+	assert.Equal(t, []string{"abcdefgh", "ijklmnop", "q\n"}, read("abcdefghijklmnopq\n", 4), "long lines are cut, not lost")
+	assert.Empty(t, read("", 4))
+}
@@ -3,6 +3,7 @@ package orchestrator
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"time"
 )
 
@@ -14,7 +15,7 @@ func LoadConfig(cfg *Config) error {
 		return fmt.Errorf("invalid configuration: configuration cannot be nil")
 	}
 
-	if err := validateConfig(cfg); err != nil {
+	if err := ValidateConfig(cfg); err != nil {
 		return fmt.Errorf("invalid configuration: %w", err)
 	}
 
@@ -22,6 +23,19 @@ func LoadConfig(cfg *Config) error {
 	return nil
 }
 
+// ValidateConfig checks every field of the configuration and then checks
+// that related fields agree with each other once defaults are applied.
+// The configuration itself is not modified.
+func ValidateConfig(cfg *Config) error {
+	if err := validateConfig(cfg); err != nil {
+		return err
+	}
+
+	resolved := *cfg
+	setDefaults(&resolved)
+	return validateConsistency(&resolved)
+}
+
 // validateConfig checks that all required configuration fields are present
 // and have valid values.
 func validateConfig(cfg *Config) error {
@@ -38,6 +52,23 @@ func validateConfig(cfg *Config) error {
 	if cfg.Database.User == "" {
 		return fmt.Errorf("database.user is required")
 	}
+	if cfg.Database.ConnMaxIdleTime < 0 {
+		return fmt.Errorf("database.conn_max_idle_time cannot be negative")
+	}
+
+	// Validate services configuration
+	if err := validatePromptTemplates(cfg.Services.PromptTemplates); err != nil {
+		return err
+	}
+	if cfg.Services.MaxContextTokens < 0 {
+		return fmt.Errorf("services.max_context_tokens cannot be negative")
+	}
+	switch cfg.Services.OversizePolicy {
+	case OversizeSkip, OversizeChunk, "":
+		// Valid policies (empty string will use default)
+	default:
+		return fmt.Errorf("invalid services.oversize_policy: %s", cfg.Services.OversizePolicy)
+	}
 
 	// Validate session configuration
 	if cfg.Session.Timeout <= 0 {
@@ -46,11 +77,84 @@ func validateConfig(cfg *Config) error {
 	if cfg.Session.MaxConcurrent <= 0 {
 		return fmt.Errorf("session.max_concurrent must be positive")
 	}
+	// Sessions would outlive their timeout waiting for the next cleanup
+	// pass. Only an explicit interval is checked; the default never
+	// exceeds the timeout.
+	if cfg.Session.CleanupInterval > cfg.Session.Timeout {
+		return fmt.Errorf("session.cleanup_interval (%s) cannot exceed session.timeout (%s)",
+			cfg.Session.CleanupInterval, cfg.Session.Timeout)
+	}
+	if cfg.Session.TerminalRetention < 0 {
+		return fmt.Errorf("session.terminal_retention cannot be negative")
+	}
+	if cfg.Session.MaxLifetime < 0 {
+		return fmt.Errorf("session.max_lifetime cannot be negative")
+	}
+	if cfg.Session.MaxNotes < 0 {
+		return fmt.Errorf("session.max_notes cannot be negative")
+	}
+	if cfg.Session.TodoMaxIdle < 0 {
+		return fmt.Errorf("session.todo_max_idle cannot be negative")
+	}
+	if cfg.Session.MaxQueueWait < 0 {
+		return fmt.Errorf("session.max_queue_wait cannot be negative")
+	}
+	switch cfg.Session.StarvationAction {
+	case StarvationBoost, StarvationFail, "":
+		// Valid actions (empty string will use default)
+	default:
+		return fmt.Errorf("invalid session.starvation_action: %s", cfg.Session.StarvationAction)
+	}
+	if !validSessionIDPrefix(cfg.Session.IDPrefix) {
+		return fmt.Errorf("invalid session.id_prefix: %q", cfg.Session.IDPrefix)
+	}
+	if cfg.Session.DefaultMaxDepth < UnlimitedDepth {
+		return fmt.Errorf("session.default_max_depth must be positive, or -1 for no limit")
+	}
+	if cfg.Session.ProjectBaseDir != "" && !filepath.IsAbs(cfg.Session.ProjectBaseDir) {
+		return fmt.Errorf("session.project_base_dir must be absolute: %s", cfg.Session.ProjectBaseDir)
+	}
 
 	// Validate workflow configuration
 	if cfg.Workflow.MaxRetries < 0 {
 		return fmt.Errorf("workflow.max_retries cannot be negative")
 	}
+	if cfg.Workflow.MaxConcurrency < 0 {
+		return fmt.Errorf("workflow.max_concurrency cannot be negative")
+	}
+	switch cfg.Workflow.BackoffStrategy {
+	case BackoffExponential, BackoffLinear, BackoffFixed, "":
+		// Valid strategies (empty string will use default)
+	default:
+		return fmt.Errorf("invalid workflow.backoff_strategy: %s", cfg.Workflow.BackoffStrategy)
+	}
+	switch cfg.Workflow.InitialState {
+	case WorkflowStateIdle, WorkflowStateInitialized, "":
+		// Valid initial states (empty string will use default)
+	default:
+		return fmt.Errorf("invalid workflow.initial_state: %s", cfg.Workflow.InitialState)
+	}
+	if cfg.Workflow.BackoffBaseDelay < 0 {
+		return fmt.Errorf("workflow.backoff_base_delay cannot be negative")
+	}
+	if cfg.Workflow.BackoffMaxDelay < 0 {
+		return fmt.Errorf("workflow.backoff_max_delay cannot be negative")
+	}
+	if cfg.Workflow.MaxRetryAfter < 0 {
+		return fmt.Errorf("workflow.max_retry_after cannot be negative")
+	}
+	if cfg.Workflow.MaxConsecutiveFailures < 0 {
+		return fmt.Errorf("workflow.max_consecutive_failures cannot be negative")
+	}
+	if cfg.Workflow.ConsecutiveFailureWindow < 0 {
+		return fmt.Errorf("workflow.consecutive_failure_window cannot be negative")
+	}
+	if cfg.Workflow.DiscoveryConcurrency < 0 {
+		return fmt.Errorf("workflow.discovery_concurrency cannot be negative")
+	}
+	if cfg.Workflow.ShutdownTimeout < 0 {
+		return fmt.Errorf("workflow.shutdown_timeout cannot be negative")
+	}
 
 	// Validate logging configuration
 	switch cfg.Logging.Level {
@@ -70,6 +174,25 @@ func validateConfig(cfg *Config) error {
 	return nil
 }
 
+// validateConsistency checks rules that span several fields. It expects
+// defaults to have been applied so unset fields compare at their effective
+// values.
+func validateConsistency(cfg *Config) error {
+	// database/sql silently lowers the idle pool to the open limit
+	if cfg.Database.MaxOpenConns > 0 && cfg.Database.MaxIdleConns > cfg.Database.MaxOpenConns {
+		return fmt.Errorf("database.max_idle_conns (%d) cannot exceed database.max_open_conns (%d)",
+			cfg.Database.MaxIdleConns, cfg.Database.MaxOpenConns)
+	}
+
+	// Sliding expiry could never extend a session past its first timeout
+	if cfg.Session.SlidingExpiry && cfg.Session.MaxLifetime > 0 && cfg.Session.MaxLifetime < cfg.Session.Timeout {
+		return fmt.Errorf("session.max_lifetime (%s) cannot be shorter than session.timeout (%s)",
+			cfg.Session.MaxLifetime, cfg.Session.Timeout)
+	}
+
+	return nil
+}
+
 // setDefaults sets default values for optional configuration fields.
 func setDefaults(cfg *Config) {
 	// Database defaults
@@ -85,10 +208,30 @@ func setDefaults(cfg *Config) {
 	if cfg.Database.ConnMaxLifetime == 0 {
 		cfg.Database.ConnMaxLifetime = 5 * time.Minute
 	}
+	if cfg.Database.ConnMaxIdleTime == 0 {
+		cfg.Database.ConnMaxIdleTime = 1 * time.Minute
+	}
+
+	// Services defaults
+	if cfg.Services.AIProvider == "" {
+		cfg.Services.AIProvider = DefaultAIProvider
+	}
+	if cfg.Services.OversizePolicy == "" {
+		cfg.Services.OversizePolicy = OversizeSkip
+	}
 
 	// Session defaults
 	if cfg.Session.CleanupInterval == 0 {
 		cfg.Session.CleanupInterval = 1 * time.Hour
+		if cfg.Session.Timeout > 0 {
+			cfg.Session.CleanupInterval = min(cfg.Session.CleanupInterval, cfg.Session.Timeout)
+		}
+	}
+	if cfg.Session.StarvationAction == "" {
+		cfg.Session.StarvationAction = StarvationBoost
+	}
+	if cfg.Session.DefaultMaxDepth == 0 {
+		cfg.Session.DefaultMaxDepth = DefaultMaxDepth
 	}
 
 	// Workflow defaults
@@ -98,6 +241,24 @@ func setDefaults(cfg *Config) {
 	if cfg.Workflow.TransitionTimeout == 0 {
 		cfg.Workflow.TransitionTimeout = 30 * time.Second
 	}
+	if cfg.Workflow.MaxConcurrency == 0 {
+		cfg.Workflow.MaxConcurrency = DefaultMaxConcurrency
+	}
+	if cfg.Workflow.BackoffStrategy == "" {
+		cfg.Workflow.BackoffStrategy = BackoffExponential
+	}
+	if cfg.Workflow.InitialState == "" {
+		cfg.Workflow.InitialState = WorkflowStateIdle
+	}
+	if cfg.Workflow.BackoffBaseDelay == 0 {
+		cfg.Workflow.BackoffBaseDelay = 1 * time.Second
+	}
+	if cfg.Workflow.BackoffMaxDelay == 0 {
+		cfg.Workflow.BackoffMaxDelay = 30 * time.Second
+	}
+	if cfg.Workflow.MaxRetryAfter == 0 {
+		cfg.Workflow.MaxRetryAfter = DefaultMaxRetryAfter
+	}
 
 	// Logging defaults
 	if cfg.Logging.Level == "" {
@@ -135,21 +296,31 @@ func DefaultConfig() *Config {
 			MaxOpenConns:    25,
 			MaxIdleConns:    5,
 			ConnMaxLifetime: 5 * time.Minute,
+			ConnMaxIdleTime: 1 * time.Minute,
 		},
 		Services: ServicesConfig{
 			ChromaDBURL: "http://localhost:8000",
 			OpenAIKey:   "",
 			GeminiKey:   "",
+			AIProvider:  DefaultAIProvider,
 		},
 		Session: SessionConfig{
-			Timeout:         24 * time.Hour,
-			MaxConcurrent:   100,
-			CleanupInterval: 1 * time.Hour,
+			Timeout:          24 * time.Hour,
+			MaxConcurrent:    100,
+			CleanupInterval:  1 * time.Hour,
+			StarvationAction: StarvationBoost,
+			DefaultMaxDepth:  DefaultMaxDepth,
 		},
 		Workflow: WorkflowConfig{
 			MaxRetries:        3,
 			RetryDelay:        1 * time.Second,
 			TransitionTimeout: 30 * time.Second,
+			MaxConcurrency:    DefaultMaxConcurrency,
+			BackoffStrategy:   BackoffExponential,
+			BackoffBaseDelay:  1 * time.Second,
+			BackoffMaxDelay:   30 * time.Second,
+			MaxRetryAfter:     DefaultMaxRetryAfter,
+			InitialState:      WorkflowStateIdle,
 		},
 		Logging: LoggingConfig{
 			Level:  "info",
@@ -1,6 +1,7 @@
 package orchestrator
 
 import (
+	"database/sql"
 	"os"
 	"testing"
 	"time"
@@ -88,6 +89,7 @@ func TestLoadConfig(t *testing.T) {
 				assert.Equal(t, 1*time.Hour, cfg.Session.CleanupInterval)
 				assert.Equal(t, 1*time.Second, cfg.Workflow.RetryDelay)
 				assert.Equal(t, 30*time.Second, cfg.Workflow.TransitionTimeout)
+				assert.Equal(t, DefaultMaxRetryAfter, cfg.Workflow.MaxRetryAfter)
 				assert.Equal(t, "info", cfg.Logging.Level)
 				assert.Equal(t, "console", cfg.Logging.Format)
 				assert.Equal(t, "stdout", cfg.Logging.Output)
@@ -182,6 +184,186 @@ func TestLoadConfig(t *testing.T) {
 			wantErr: true,
 			errMsg:  "session.max_concurrent must be positive",
 		},
+		{
+			name: "negative terminal retention",
+			config: &Config{
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "testdb",
+					User:     "testuser",
+				},
+				Session: SessionConfig{
+					Timeout:           24 * time.Hour,
+					MaxConcurrent:     10,
+					TerminalRetention: -time.Hour,
+				},
+			},
+			wantErr: true,
+			errMsg:  "session.terminal_retention cannot be negative",
+		},
+		{
+			name: "negative max queue wait",
+			config: &Config{
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "testdb",
+					User:     "testuser",
+				},
+				Session: SessionConfig{
+					Timeout:       24 * time.Hour,
+					MaxConcurrent: 10,
+					MaxQueueWait:  -time.Minute,
+				},
+			},
+			wantErr: true,
+			errMsg:  "session.max_queue_wait cannot be negative",
+		},
+		{
+			name: "negative max lifetime",
+			config: &Config{
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "testdb",
+					User:     "testuser",
+				},
+				Session: SessionConfig{
+					Timeout:       24 * time.Hour,
+					MaxConcurrent: 10,
+					MaxLifetime:   -time.Hour,
+				},
+			},
+			wantErr: true,
+			errMsg:  "session.max_lifetime cannot be negative",
+		},
+		{
+			name: "negative max notes",
+			config: &Config{
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "testdb",
+					User:     "testuser",
+				},
+				Session: SessionConfig{
+					Timeout:       24 * time.Hour,
+					MaxConcurrent: 10,
+					MaxNotes:      -1,
+				},
+			},
+			wantErr: true,
+			errMsg:  "session.max_notes cannot be negative",
+		},
+		{
+			name: "negative TODO list max idle",
+			config: &Config{
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "testdb",
+					User:     "testuser",
+				},
+				Session: SessionConfig{
+					Timeout:       24 * time.Hour,
+					MaxConcurrent: 10,
+					TodoMaxIdle:   -time.Minute,
+				},
+			},
+			wantErr: true,
+			errMsg:  "session.todo_max_idle cannot be negative",
+		},
+		{
+			name: "invalid starvation action",
+			config: &Config{
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "testdb",
+					User:     "testuser",
+				},
+				Session: SessionConfig{
+					Timeout:          24 * time.Hour,
+					MaxConcurrent:    10,
+					StarvationAction: "requeue",
+				},
+			},
+			wantErr: true,
+			errMsg:  "invalid session.starvation_action: requeue",
+		},
+		{
+			name: "invalid session ID prefix",
+			config: &Config{
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "testdb",
+					User:     "testuser",
+				},
+				Session: SessionConfig{
+					Timeout:       24 * time.Hour,
+					MaxConcurrent: 10,
+					IDPrefix:      "doc_",
+				},
+			},
+			wantErr: true,
+			errMsg:  `invalid session.id_prefix: "doc_"`,
+		},
+		{
+			name: "relative project base dir",
+			config: &Config{
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "testdb",
+					User:     "testuser",
+				},
+				Session: SessionConfig{
+					Timeout:        24 * time.Hour,
+					MaxConcurrent:  10,
+					ProjectBaseDir: "projects",
+				},
+			},
+			wantErr: true,
+			errMsg:  "session.project_base_dir must be absolute: projects",
+		},
+		{
+			name: "negative max context tokens",
+			config: &Config{
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "testdb",
+					User:     "testuser",
+				},
+				Services: ServicesConfig{MaxContextTokens: -1},
+				Session: SessionConfig{
+					Timeout:       24 * time.Hour,
+					MaxConcurrent: 10,
+				},
+			},
+			wantErr: true,
+			errMsg:  "services.max_context_tokens cannot be negative",
+		},
+		{
+			name: "invalid oversize policy",
+			config: &Config{
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "testdb",
+					User:     "testuser",
+				},
+				Services: ServicesConfig{OversizePolicy: "truncate"},
+				Session: SessionConfig{
+					Timeout:       24 * time.Hour,
+					MaxConcurrent: 10,
+				},
+			},
+			wantErr: true,
+			errMsg:  "invalid services.oversize_policy: truncate",
+		},
 		{
 			name: "negative workflow max retries",
 			config: &Config{
@@ -319,6 +501,195 @@ func TestValidateConfig(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "negative conn max idle time",
+			config: &Config{
+				Database: DatabaseConfig{
+					Host:            "localhost",
+					Port:            5432,
+					Database:        "testdb",
+					User:            "testuser",
+					ConnMaxIdleTime: -time.Second,
+				},
+				Session: SessionConfig{
+					Timeout:       24 * time.Hour,
+					MaxConcurrent: 100,
+				},
+				Workflow: WorkflowConfig{
+					MaxRetries: 3,
+				},
+			},
+			wantErr: true,
+			errMsg:  "database.conn_max_idle_time cannot be negative",
+		},
+		{
+			name: "negative workflow max concurrency",
+			config: &Config{
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "testdb",
+					User:     "testuser",
+				},
+				Session: SessionConfig{
+					Timeout:       24 * time.Hour,
+					MaxConcurrent: 100,
+				},
+				Workflow: WorkflowConfig{
+					MaxRetries:     3,
+					MaxConcurrency: -1,
+				},
+			},
+			wantErr: true,
+			errMsg:  "workflow.max_concurrency cannot be negative",
+		},
+		{
+			name: "negative max consecutive failures",
+			config: &Config{
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "testdb",
+					User:     "testuser",
+				},
+				Session: SessionConfig{
+					Timeout:       24 * time.Hour,
+					MaxConcurrent: 100,
+				},
+				Workflow: WorkflowConfig{
+					MaxRetries:             3,
+					MaxConsecutiveFailures: -1,
+				},
+			},
+			wantErr: true,
+			errMsg:  "workflow.max_consecutive_failures cannot be negative",
+		},
+		{
+			name: "negative discovery concurrency",
+			config: &Config{
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "testdb",
+					User:     "testuser",
+				},
+				Session: SessionConfig{
+					Timeout:       24 * time.Hour,
+					MaxConcurrent: 100,
+				},
+				Workflow: WorkflowConfig{
+					MaxRetries:           3,
+					DiscoveryConcurrency: -1,
+				},
+			},
+			wantErr: true,
+			errMsg:  "workflow.discovery_concurrency cannot be negative",
+		},
+		{
+			name: "negative shutdown timeout",
+			config: &Config{
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "testdb",
+					User:     "testuser",
+				},
+				Session: SessionConfig{
+					Timeout:       24 * time.Hour,
+					MaxConcurrent: 100,
+				},
+				Workflow: WorkflowConfig{
+					MaxRetries:      3,
+					ShutdownTimeout: -time.Second,
+				},
+			},
+			wantErr: true,
+			errMsg:  "workflow.shutdown_timeout cannot be negative",
+		},
+		{
+			name: "negative max retry after",
+			config: &Config{
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "testdb",
+					User:     "testuser",
+				},
+				Session: SessionConfig{
+					Timeout:       24 * time.Hour,
+					MaxConcurrent: 100,
+				},
+				Workflow: WorkflowConfig{
+					MaxRetries:    3,
+					MaxRetryAfter: -time.Second,
+				},
+			},
+			wantErr: true,
+			errMsg:  "workflow.max_retry_after cannot be negative",
+		},
+		{
+			name: "default max depth below unlimited",
+			config: &Config{
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "testdb",
+					User:     "testuser",
+				},
+				Session: SessionConfig{
+					Timeout:         24 * time.Hour,
+					MaxConcurrent:   100,
+					DefaultMaxDepth: -2,
+				},
+				Workflow: WorkflowConfig{
+					MaxRetries: 3,
+				},
+			},
+			wantErr: true,
+			errMsg:  "session.default_max_depth must be positive, or -1 for no limit",
+		},
+		{
+			name: "unknown backoff strategy",
+			config: &Config{
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "testdb",
+					User:     "testuser",
+				},
+				Session: SessionConfig{
+					Timeout:       24 * time.Hour,
+					MaxConcurrent: 100,
+				},
+				Workflow: WorkflowConfig{
+					MaxRetries:      3,
+					BackoffStrategy: "random",
+				},
+			},
+			wantErr: true,
+			errMsg:  "invalid workflow.backoff_strategy: random",
+		},
+		{
+			name: "unsupported initial state",
+			config: &Config{
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Port:     5432,
+					Database: "testdb",
+					User:     "testuser",
+				},
+				Session: SessionConfig{
+					Timeout:       24 * time.Hour,
+					MaxConcurrent: 100,
+				},
+				Workflow: WorkflowConfig{
+					MaxRetries:   3,
+					InitialState: WorkflowStateProcessing,
+				},
+			},
+			wantErr: true,
+			errMsg:  "invalid workflow.initial_state: processing",
+		},
 		{
 			name: "all valid logging levels",
 			config: &Config{
@@ -406,6 +777,129 @@ func TestValidateConfig(t *testing.T) {
 	}
 }
 
+func TestValidateConfigConsistency(t *testing.T) {
+	base := func() *Config {
+		return &Config{
+			Database: DatabaseConfig{
+				Host:     "localhost",
+				Port:     5432,
+				Database: "testdb",
+				User:     "testuser",
+			},
+			Session: SessionConfig{
+				Timeout:       24 * time.Hour,
+				MaxConcurrent: 10,
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(cfg *Config)
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name: "consistent config",
+			modify: func(cfg *Config) {
+				cfg.Database.MaxOpenConns = 20
+				cfg.Database.MaxIdleConns = 20
+				cfg.Session.CleanupInterval = time.Hour
+			},
+			wantErr: false,
+		},
+		{
+			name: "idle connections exceed open connections",
+			modify: func(cfg *Config) {
+				cfg.Database.MaxOpenConns = 10
+				cfg.Database.MaxIdleConns = 20
+			},
+			wantErr: true,
+			errMsg:  "database.max_idle_conns (20) cannot exceed database.max_open_conns (10)",
+		},
+		{
+			name: "idle connections exceed default open connections",
+			modify: func(cfg *Config) {
+				cfg.Database.MaxIdleConns = 30
+			},
+			wantErr: true,
+			errMsg:  "database.max_idle_conns (30) cannot exceed database.max_open_conns (25)",
+		},
+		{
+			name: "unlimited open connections",
+			modify: func(cfg *Config) {
+				cfg.Database.MaxOpenConns = -1
+				cfg.Database.MaxIdleConns = 30
+			},
+			wantErr: false,
+		},
+		{
+			name: "cleanup interval exceeds timeout",
+			modify: func(cfg *Config) {
+				cfg.Session.Timeout = 10 * time.Minute
+				cfg.Session.CleanupInterval = 30 * time.Minute
+			},
+			wantErr: true,
+			errMsg:  "session.cleanup_interval (30m0s) cannot exceed session.timeout (10m0s)",
+		},
+		{
+			name: "default cleanup interval with short timeout",
+			modify: func(cfg *Config) {
+				cfg.Session.Timeout = 10 * time.Minute
+			},
+			wantErr: false,
+		},
+		{
+			name: "max lifetime shorter than timeout",
+			modify: func(cfg *Config) {
+				cfg.Session.SlidingExpiry = true
+				cfg.Session.MaxLifetime = 12 * time.Hour
+			},
+			wantErr: true,
+			errMsg:  "session.max_lifetime (12h0m0s) cannot be shorter than session.timeout (24h0m0s)",
+		},
+		{
+			name: "max lifetime shorter than timeout without sliding expiry",
+			modify: func(cfg *Config) {
+				cfg.Session.MaxLifetime = 12 * time.Hour
+			},
+			wantErr: false,
+		},
+		{
+			name: "sliding expiry with max lifetime",
+			modify: func(cfg *Config) {
+				cfg.Session.SlidingExpiry = true
+				cfg.Session.MaxLifetime = 72 * time.Hour
+			},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base()
+			tt.modify(cfg)
+			before := *cfg
+
+			err := ValidateConfig(cfg)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			// Validation never applies defaults to the caller's config
+			assert.Equal(t, before, *cfg)
+
+			// LoadConfig applies the same rules
+			if tt.wantErr {
+				assert.Error(t, LoadConfig(cfg))
+			}
+		})
+	}
+}
+
 func TestSetDefaults(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -426,13 +920,19 @@ func TestSetDefaults(t *testing.T) {
 				assert.Equal(t, 25, cfg.Database.MaxOpenConns)
 				assert.Equal(t, 5, cfg.Database.MaxIdleConns)
 				assert.Equal(t, 5*time.Minute, cfg.Database.ConnMaxLifetime)
+				assert.Equal(t, 1*time.Minute, cfg.Database.ConnMaxIdleTime)
 
 				// Session defaults
 				assert.Equal(t, 1*time.Hour, cfg.Session.CleanupInterval)
+				assert.Equal(t, DefaultMaxDepth, cfg.Session.DefaultMaxDepth)
 
 				// Workflow defaults
 				assert.Equal(t, 1*time.Second, cfg.Workflow.RetryDelay)
 				assert.Equal(t, 30*time.Second, cfg.Workflow.TransitionTimeout)
+				assert.Equal(t, DefaultMaxConcurrency, cfg.Workflow.MaxConcurrency)
+				assert.Equal(t, BackoffExponential, cfg.Workflow.BackoffStrategy)
+				assert.Equal(t, 1*time.Second, cfg.Workflow.BackoffBaseDelay)
+				assert.Equal(t, 30*time.Second, cfg.Workflow.BackoffMaxDelay)
 
 				// Logging defaults
 				assert.Equal(t, "info", cfg.Logging.Level)
@@ -448,9 +948,11 @@ func TestSetDefaults(t *testing.T) {
 					MaxOpenConns:    100,
 					MaxIdleConns:    20,
 					ConnMaxLifetime: 15 * time.Minute,
+					ConnMaxIdleTime: 30 * time.Second,
 				},
 				Session: SessionConfig{
 					CleanupInterval: 3 * time.Hour,
+					DefaultMaxDepth: UnlimitedDepth,
 				},
 				Workflow: WorkflowConfig{
 					RetryDelay:        5 * time.Second,
@@ -468,7 +970,9 @@ func TestSetDefaults(t *testing.T) {
 				assert.Equal(t, 100, cfg.Database.MaxOpenConns)
 				assert.Equal(t, 20, cfg.Database.MaxIdleConns)
 				assert.Equal(t, 15*time.Minute, cfg.Database.ConnMaxLifetime)
+				assert.Equal(t, 30*time.Second, cfg.Database.ConnMaxIdleTime)
 				assert.Equal(t, 3*time.Hour, cfg.Session.CleanupInterval)
+				assert.Equal(t, UnlimitedDepth, cfg.Session.DefaultMaxDepth)
 				assert.Equal(t, 5*time.Second, cfg.Workflow.RetryDelay)
 				assert.Equal(t, 2*time.Minute, cfg.Workflow.TransitionTimeout)
 				assert.Equal(t, "debug", cfg.Logging.Level)
@@ -510,6 +1014,17 @@ func TestSetDefaults(t *testing.T) {
 				assert.Equal(t, "stdout", cfg.Logging.Output)  // default
 			},
 		},
+		{
+			name: "cleanup interval capped at a short timeout",
+			config: &Config{
+				Session: SessionConfig{
+					Timeout: 10 * time.Minute,
+				},
+			},
+			verify: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, 10*time.Minute, cfg.Session.CleanupInterval)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -520,6 +1035,39 @@ func TestSetDefaults(t *testing.T) {
 	}
 }
 
+// recordingPool captures the pool settings applied by configurePool.
+type recordingPool struct {
+	maxOpen     int
+	maxIdle     int
+	maxLifetime time.Duration
+	maxIdleTime time.Duration
+}
+
+func (p *recordingPool) SetMaxOpenConns(n int)              { p.maxOpen = n }
+func (p *recordingPool) SetMaxIdleConns(n int)              { p.maxIdle = n }
+func (p *recordingPool) SetConnMaxLifetime(d time.Duration) { p.maxLifetime = d }
+func (p *recordingPool) SetConnMaxIdleTime(d time.Duration) { p.maxIdleTime = d }
+
+func TestConfigurePool(t *testing.T) {
+	cfg := &DatabaseConfig{
+		MaxOpenConns:    10,
+		MaxIdleConns:    2,
+		ConnMaxLifetime: 10 * time.Minute,
+		ConnMaxIdleTime: 45 * time.Second,
+	}
+
+	pool := &recordingPool{}
+	configurePool(pool, cfg)
+
+	assert.Equal(t, 10, pool.maxOpen)
+	assert.Equal(t, 2, pool.maxIdle)
+	assert.Equal(t, 10*time.Minute, pool.maxLifetime)
+	assert.Equal(t, 45*time.Second, pool.maxIdleTime)
+
+	// *sql.DB must satisfy connPool so InitDatabase can use it
+	var _ connPool = (*sql.DB)(nil)
+}
+
 func TestDefaultConfig(t *testing.T) {
 	// Save current environment variables
 	origDBPassword := os.Getenv("DB_PASSWORD")
@@ -591,6 +1139,7 @@ func TestDefaultConfig(t *testing.T) {
 			assert.Equal(t, 25, cfg.Database.MaxOpenConns)
 			assert.Equal(t, 5, cfg.Database.MaxIdleConns)
 			assert.Equal(t, 5*time.Minute, cfg.Database.ConnMaxLifetime)
+			assert.Equal(t, 1*time.Minute, cfg.Database.ConnMaxIdleTime)
 
 			assert.Equal(t, "http://localhost:8000", cfg.Services.ChromaDBURL)
 			assert.Equal(t, "", cfg.Services.OpenAIKey)
@@ -599,6 +1148,7 @@ func TestDefaultConfig(t *testing.T) {
 			assert.Equal(t, 24*time.Hour, cfg.Session.Timeout)
 			assert.Equal(t, 100, cfg.Session.MaxConcurrent)
 			assert.Equal(t, 1*time.Hour, cfg.Session.CleanupInterval)
+			assert.Equal(t, DefaultMaxDepth, cfg.Session.DefaultMaxDepth)
 
 			assert.Equal(t, 3, cfg.Workflow.MaxRetries)
 			assert.Equal(t, 1*time.Second, cfg.Workflow.RetryDelay)
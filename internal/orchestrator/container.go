@@ -1,10 +1,27 @@
 package orchestrator
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
 	"sync"
 )
 
+var (
+	// ErrServiceNotRegistered is returned when no service exists under a name
+	ErrServiceNotRegistered = errors.New("not registered")
+
+	// ErrNilService is returned when a nil service is registered or retrieved
+	ErrNilService = errors.New("service is nil")
+)
+
+// Option supplies an optional component, such as a RecoveryManager, to
+// NewOrchestrator, which registers it in the container under its name.
+type Option struct {
+	name    string
+	service interface{}
+}
+
 // DefaultContainer implements the Container interface providing thread-safe
 // dependency injection capabilities for the orchestrator system.
 type DefaultContainer struct {
@@ -22,21 +39,29 @@ func NewContainer() *DefaultContainer {
 
 // Register adds a service to the container with the given name.
 // If a service with the same name already exists, it will be replaced.
+// Nil services, including typed nil pointers, are rejected with ErrNilService.
 // This method is thread-safe and can be called concurrently.
 //
 // Example:
 //
 //	container := NewContainer()
-//	container.Register("logger", logger)
-//	container.Register("database", dbConn)
-func (c *DefaultContainer) Register(name string, service interface{}) {
+//	if err := container.Register("logger", logger); err != nil {
+//	    return err
+//	}
+func (c *DefaultContainer) Register(name string, service interface{}) error {
+	if isNilService(service) {
+		return fmt.Errorf("cannot register service %s: %w", name, ErrNilService)
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.services[name] = service
+	return nil
 }
 
 // Get retrieves a service from the container by name.
-// Returns an error if the service is not found.
+// Returns ErrServiceNotRegistered if the service is not found and
+// ErrNilService if the name holds a nil service.
 // This method is thread-safe and can be called concurrently.
 //
 // Example:
@@ -52,7 +77,10 @@ func (c *DefaultContainer) Get(name string) (interface{}, error) {
 
 	service, exists := c.services[name]
 	if !exists {
-		return nil, fmt.Errorf("service %s not registered", name)
+		return nil, fmt.Errorf("service %s %w", name, ErrServiceNotRegistered)
+	}
+	if isNilService(service) {
+		return nil, fmt.Errorf("service %s: %w", name, ErrNilService)
 	}
 	return service, nil
 }
@@ -102,3 +130,18 @@ func (c *DefaultContainer) Clear() {
 	defer c.mu.Unlock()
 	c.services = make(map[string]interface{})
 }
+
+// isNilService reports whether a service is nil, either as an untyped nil or
+// as a nil pointer, map, slice, func, channel or interface value.
+func isNilService(service interface{}) bool {
+	if service == nil {
+		return true
+	}
+	v := reflect.ValueOf(service)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.Interface:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
@@ -1,6 +1,8 @@
 package orchestrator
 
 import (
+	"errors"
+	"strings"
 	"testing"
 )
 
@@ -27,6 +29,60 @@ func TestContainer_Register(t *testing.T) {
 	}
 }
 
+func TestContainer_RegisterNil(t *testing.T) {
+	var nilPointer *DefaultContainer
+	var nilMap map[string]string
+
+	tests := []struct {
+		name    string
+		service interface{}
+	}{
+		{"untyped nil", nil},
+		{"typed nil pointer", nilPointer},
+		{"nil map", nilMap},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			container := NewContainer()
+
+			err := container.Register("ai", tt.service)
+			if !errors.Is(err, ErrNilService) {
+				t.Fatalf("expected ErrNilService, got %v", err)
+			}
+			if !strings.Contains(err.Error(), "cannot register service ai") {
+				t.Errorf("error should name the service, got %q", err.Error())
+			}
+			if container.Has("ai") {
+				t.Error("nil service should not be registered")
+			}
+		})
+	}
+}
+
+func TestContainer_GetDistinguishesMissingFromNil(t *testing.T) {
+	container := NewContainer()
+
+	// A nil can only appear by bypassing Register, e.g. in tests
+	container.services["ai"] = nil
+
+	_, err := container.Get("ai")
+	if !errors.Is(err, ErrNilService) {
+		t.Errorf("expected ErrNilService for nil service, got %v", err)
+	}
+	if errors.Is(err, ErrServiceNotRegistered) {
+		t.Error("nil service should not be reported as unregistered")
+	}
+
+	_, err = container.Get("missing")
+	if !errors.Is(err, ErrServiceNotRegistered) {
+		t.Errorf("expected ErrServiceNotRegistered, got %v", err)
+	}
+	if err.Error() != "service missing not registered" {
+		t.Errorf("unexpected error message %q", err.Error())
+	}
+}
+
 func TestContainer_Get(t *testing.T) {
 	container := NewContainer()
 
@@ -0,0 +1,97 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+// DependencyResolverName is the container name under which a
+// DependencyResolver is registered.
+const DependencyResolverName = "dependency_resolver"
+
+// ErrNoDependencyResolver is returned when a request asks for dependencies
+// but no DependencyResolver is registered.
+var ErrNoDependencyResolver = errors.New("no dependency resolver registered")
+
+// DependencyResolver reports the files a file depends on, such as the files
+// an AI client names through provide_dependency_files. When registered in
+// the container under DependencyResolverName, it lets a request with
+// IncludeDependencies document the direct dependencies of its files.
+type DependencyResolver interface {
+	// DependencyFiles returns the files filePath directly depends on.
+	// Relative paths are resolved against projectPath
+	DependencyFiles(ctx context.Context, projectPath, filePath string) ([]string, error)
+}
+
+// withDependencies appends the direct dependencies of files to the list,
+// skipping files already in it. Dependencies of the added files are not
+// followed. A dependency outside the project is skipped with a warning,
+// since the resolver, not the caller, declared it.
+func (o *OrchestratorImpl) withDependencies(ctx context.Context, projectPath string, files []string) ([]string, error) {
+	resolver, ok := o.dependencyResolver()
+	if !ok {
+		return nil, ErrNoDependencyResolver
+	}
+	fs, fsErr := o.serviceRegistry.GetFileSystem()
+
+	seen := make(map[string]bool, len(files))
+	for _, file := range files {
+		seen[file] = true
+	}
+
+	expanded := files
+	for _, file := range files {
+		deps, err := resolver.DependencyFiles(ctx, projectPath, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve dependencies of %s: %w", file, err)
+		}
+		for _, dep := range deps {
+			if !filepath.IsAbs(dep) {
+				dep = filepath.Join(projectPath, dep)
+			}
+			dep = filepath.Clean(dep)
+			if seen[dep] {
+				continue
+			}
+			seen[dep] = true
+
+			var invalid error
+			if rel, err := filepath.Rel(projectPath, dep); err != nil || !filepath.IsLocal(rel) {
+				invalid = fmt.Errorf("outside the project")
+			} else if fsErr == nil {
+				invalid = fs.ValidatePath(ctx, dep)
+			}
+			if invalid != nil {
+				LoggerFromContext(ctx).Warn().
+					Err(invalid).
+					Str("file", file).
+					Str("dependency", dep).
+					Msg("Skipping dependency")
+				continue
+			}
+			expanded = append(expanded, dep)
+		}
+	}
+
+	LoggerFromContext(ctx).Debug().
+		Int("files", len(files)).
+		Int("dependencies", len(expanded)-len(files)).
+		Msg("Added dependencies of explicit files")
+	return expanded, nil
+}
+
+// dependencyResolver returns the DependencyResolver registered in the
+// container, if any.
+func (o *OrchestratorImpl) dependencyResolver() (DependencyResolver, bool) {
+	if o.container == nil {
+		return nil, false
+	}
+	service, err := o.container.Get(DependencyResolverName)
+	if err != nil {
+		return nil, false
+	}
+	resolver, ok := service.(DependencyResolver)
+	return resolver, ok
+}
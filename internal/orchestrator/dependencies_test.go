@@ -0,0 +1,120 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/todolist"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mapDependencyResolver declares dependencies from a map keyed by file.
+type mapDependencyResolver struct {
+	deps  map[string][]string
+	err   error
+	calls []string
+}
+
+func (r *mapDependencyResolver) DependencyFiles(ctx context.Context, projectPath, filePath string) ([]string, error) {
+	r.calls = append(r.calls, filePath)
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.deps[filePath], nil
+}
+
+func TestStartDocumentationChangedFilesWithDependencies(t *testing.T) {
+	const project = "/project"
+	ctx := context.Background()
+	changed := []string{"handlers/auth.go", "handlers/user.go"}
+
+	newResolver := func() *mapDependencyResolver {
+		return &mapDependencyResolver{deps: map[string][]string{
+			"/project/handlers/auth.go": {"models/user.go", "/project/database/user_repo.go"},
+			"/project/handlers/user.go": {"models/user.go", "handlers/auth.go", "../vendor/lib.go"},
+			// Dependencies of dependencies are not followed
+			"/project/models/user.go": {"models/base.go"},
+		}}
+	}
+
+	start := func(t *testing.T, o *OrchestratorImpl, mockSession *mockSessionManager, mockWorkflow *mockWorkflowEngine, includeDeps bool, want []string) {
+		t.Helper()
+		sess := createMockSession("550e8400-e29b-41d4-a716-446655440530", "workspace-123", project)
+		mockSession.On("Create", "workspace-123", project, "", want).Return(sess, nil)
+		mockWorkflow.On("Initialize", mock.Anything, sess.GetID(), workflow.WorkflowStateIdle).Return(nil)
+		mockWorkflow.On("Trigger", mock.Anything, sess.GetID(), workflow.EventStart).Return(nil)
+
+		docSess, err := o.StartDocumentation(ctx, DocumentationRequest{
+			ProjectPath:         project,
+			WorkspaceID:         "workspace-123",
+			Files:               changed,
+			IncludeDependencies: includeDeps,
+		})
+		require.NoError(t, err)
+
+		progress, err := o.todoManager.GetProgress(ctx, docSess.ID)
+		require.NoError(t, err)
+		assert.Equal(t, len(want), progress.Pending)
+		mockSession.AssertExpectations(t)
+	}
+
+	t.Run("direct dependencies are enqueued after the changed files", func(t *testing.T) {
+		o, mockSession, mockWorkflow, _ := createTestOrchestrator(t)
+		o.todoManager = todolist.NewManager()
+		resolver := newResolver()
+		o.container.Register(DependencyResolverName, resolver)
+
+		start(t, o, mockSession, mockWorkflow, true, []string{
+			"/project/handlers/auth.go",
+			"/project/handlers/user.go",
+			"/project/models/user.go",
+			"/project/database/user_repo.go",
+		})
+		assert.Equal(t, []string{"/project/handlers/auth.go", "/project/handlers/user.go"}, resolver.calls)
+	})
+
+	t.Run("without the flag only the changed files are enqueued", func(t *testing.T) {
+		o, mockSession, mockWorkflow, _ := createTestOrchestrator(t)
+		o.todoManager = todolist.NewManager()
+		resolver := newResolver()
+		o.container.Register(DependencyResolverName, resolver)
+
+		start(t, o, mockSession, mockWorkflow, false, []string{
+			"/project/handlers/auth.go",
+			"/project/handlers/user.go",
+		})
+		assert.Empty(t, resolver.calls)
+	})
+
+	t.Run("no resolver registered", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+
+		_, err := o.StartDocumentation(ctx, DocumentationRequest{
+			ProjectPath:         project,
+			WorkspaceID:         "workspace-123",
+			Files:               changed,
+			IncludeDependencies: true,
+		})
+		assert.ErrorIs(t, err, ErrNoDependencyResolver)
+		mockSession.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("resolver failure prevents the session", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		o.container.Register(DependencyResolverName, &mapDependencyResolver{err: errors.New("client went away")})
+
+		_, err := o.StartDocumentation(ctx, DocumentationRequest{
+			ProjectPath:         project,
+			WorkspaceID:         "workspace-123",
+			Files:               changed,
+			IncludeDependencies: true,
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to resolve dependencies of /project/handlers/auth.go: client went away")
+		mockSession.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,97 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/session"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/todolist"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/workflow"
+)
+
+// DetailNoteLimit is how many of a session's most recent notes
+// GetSessionDetail returns.
+const DetailNoteLimit = 20
+
+// GetSessionDetail returns a session together with its merged progress, TODO
+// items, workflow history and most recent notes. The session is validated
+// first; the TODO list and the history are then fetched concurrently.
+// Finished sessions whose TODO list has been removed report no queue.
+func (o *OrchestratorImpl) GetSessionDetail(ctx context.Context, sessionID string) (*SessionDetail, error) {
+	sess, err := o.loadSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	docSess := o.toDocumentationSession(sess)
+
+	var (
+		wg                   sync.WaitGroup
+		queue                *todolist.Progress
+		items                []todolist.TodoItem
+		history              []workflow.StateTransition
+		queueErr, historyErr error
+	)
+
+	// Terminal sessions have usually had their TODO list removed
+	if !docSess.IsTerminal() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if queue, queueErr = o.todoManager.GetProgress(ctx, sessionID); queueErr != nil {
+				return
+			}
+			items, queueErr = o.todoManager.ListItems(ctx, sessionID)
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		history, historyErr = o.workflowEngine.GetHistory(ctx, sessionID)
+	}()
+	wg.Wait()
+
+	if queueErr != nil {
+		queueErr = fmt.Errorf("failed to get TODO list: %w", queueErr)
+	}
+	if historyErr != nil {
+		historyErr = fmt.Errorf("failed to get workflow history: %w", historyErr)
+	}
+	if err := errors.Join(queueErr, historyErr); err != nil {
+		return nil, err
+	}
+
+	if queue != nil {
+		mergeQueueProgress(&docSess.Progress, queue)
+	}
+	if items == nil {
+		items = []todolist.TodoItem{}
+	}
+	if history == nil {
+		history = []workflow.StateTransition{}
+	}
+
+	notes := sess.Notes
+	if len(notes) > DetailNoteLimit {
+		notes = notes[len(notes)-DetailNoteLimit:]
+	}
+
+	return &SessionDetail{
+		Session: docSess,
+		Queue:   queue,
+		Items:   items,
+		History: history,
+		Notes:   append([]session.SessionNote{}, notes...),
+	}, nil
+}
+
+// mergeQueueProgress folds the TODO list's counts into a session's progress.
+// Failures and skips are tracked per file by the TODO list, so its counts
+// win when they are higher than those the session recorded.
+func mergeQueueProgress(progress *SessionProgress, queue *todolist.Progress) {
+	progress.TotalFiles = max(progress.TotalFiles, queue.Total)
+	progress.FailedFiles = max(progress.FailedFiles, queue.Failed)
+	progress.SkippedFiles = max(progress.SkippedFiles, queue.Skipped)
+}
@@ -0,0 +1,105 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/session"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/todolist"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSessionDetail(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("composite response contains every section", func(t *testing.T) {
+		o, mockSession, mockWorkflow, mockTodo := createTestOrchestrator(t)
+		sessionID := "550e8400-e29b-41d4-a716-446655440800"
+
+		sess := createMockSession(sessionID, "workspace-123", "/project")
+		sess.Status = session.StatusInProgress
+		sess.Progress = session.Progress{TotalFiles: 3, ProcessedFiles: 1, FailedFiles: []string{}}
+		for i := 0; i < DetailNoteLimit+5; i++ {
+			sess.Notes = append(sess.Notes, session.SessionNote{Message: fmt.Sprintf("note %d", i)})
+		}
+		mockSession.On("Get", sess.ID).Return(sess, nil)
+
+		queue := &todolist.Progress{Total: 3, Pending: 1, Complete: 1, Failed: 1}
+		items := []todolist.TodoItem{
+			{FilePath: "/project/a.go", Status: todolist.ItemStatusComplete},
+			{FilePath: "/project/b.go", Status: todolist.ItemStatusFailed},
+			{FilePath: "/project/c.go", Status: todolist.ItemStatusPending},
+		}
+		history := []workflow.StateTransition{
+			{From: workflow.WorkflowStateInitialized, To: workflow.WorkflowStateProcessing, Timestamp: time.Now()},
+		}
+		mockTodo.On("GetProgress", ctx, sessionID).Return(queue, nil)
+		mockTodo.On("ListItems", ctx, sessionID).Return(items, nil)
+		mockWorkflow.On("GetHistory", ctx, sessionID).Return(history, nil)
+
+		detail, err := o.GetSessionDetail(ctx, sessionID)
+		require.NoError(t, err)
+
+		assert.Equal(t, sessionID, detail.Session.ID)
+		assert.Equal(t, 3, detail.Session.Progress.TotalFiles)
+		assert.Equal(t, 1, detail.Session.Progress.ProcessedFiles)
+		assert.Equal(t, 1, detail.Session.Progress.FailedFiles, "failures come from the TODO list")
+		assert.Equal(t, queue, detail.Queue)
+		assert.Equal(t, items, detail.Items)
+		assert.Equal(t, history, detail.History)
+
+		// Only the most recent notes are returned, oldest first
+		require.Len(t, detail.Notes, DetailNoteLimit)
+		assert.Equal(t, "note 5", detail.Notes[0].Message)
+		assert.Equal(t, fmt.Sprintf("note %d", DetailNoteLimit+4), detail.Notes[DetailNoteLimit-1].Message)
+	})
+
+	t.Run("finished sessions skip the removed TODO list", func(t *testing.T) {
+		o, mockSession, mockWorkflow, mockTodo := createTestOrchestrator(t)
+		sessionID := "550e8400-e29b-41d4-a716-446655440801"
+
+		sess := createMockSession(sessionID, "workspace-123", "/project")
+		sess.Status = session.StatusCompleted
+		mockSession.On("Get", sess.ID).Return(sess, nil)
+		mockWorkflow.On("GetHistory", ctx, sessionID).Return([]workflow.StateTransition{}, nil)
+
+		detail, err := o.GetSessionDetail(ctx, sessionID)
+		require.NoError(t, err)
+		assert.Nil(t, detail.Queue)
+		assert.Empty(t, detail.Items)
+		assert.Empty(t, detail.Notes)
+		mockTodo.AssertNotCalled(t, "GetProgress", mock.Anything, mock.Anything)
+	})
+
+	t.Run("not found session fails before any sub-fetch", func(t *testing.T) {
+		o, mockSession, mockWorkflow, mockTodo := createTestOrchestrator(t)
+		sessionID := "550e8400-e29b-41d4-a716-446655440802"
+		mockSession.On("Get", uuid.MustParse(sessionID)).Return(nil, assert.AnError)
+
+		_, err := o.GetSessionDetail(ctx, sessionID)
+		assert.ErrorContains(t, err, "session not found")
+		mockTodo.AssertNotCalled(t, "GetProgress", mock.Anything, mock.Anything)
+		mockTodo.AssertNotCalled(t, "ListItems", mock.Anything, mock.Anything)
+		mockWorkflow.AssertNotCalled(t, "GetHistory", mock.Anything, mock.Anything)
+	})
+
+	t.Run("sub-fetch errors are reported", func(t *testing.T) {
+		o, mockSession, mockWorkflow, mockTodo := createTestOrchestrator(t)
+		sessionID := "550e8400-e29b-41d4-a716-446655440803"
+
+		sess := createMockSession(sessionID, "workspace-123", "/project")
+		sess.Status = session.StatusInProgress
+		mockSession.On("Get", sess.ID).Return(sess, nil)
+		mockTodo.On("GetProgress", ctx, sessionID).Return(nil, assert.AnError)
+		mockWorkflow.On("GetHistory", ctx, sessionID).Return([]workflow.StateTransition{}, nil)
+
+		_, err := o.GetSessionDetail(ctx, sessionID)
+		assert.ErrorContains(t, err, "failed to get TODO list")
+	})
+}
@@ -0,0 +1,349 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/services"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/session"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/todolist"
+	"github.com/rs/zerolog/log"
+)
+
+// AnalysisStoreName is the container name under which an AnalysisStore is registered.
+const AnalysisStoreName = "analysis_store"
+
+// discoverFiles returns the files to enqueue for a documentation request.
+// It lists the project through the registered file system service, applies
+// the include/exclude patterns and language filter, and filters the result
+// through the reprocessing policy. When no file system service is
+// registered, discovery is skipped and an empty list is returned. An
+// explicit req.Files list replaces discovery entirely, followed by the
+// files' direct dependencies when req.IncludeDependencies is set.
+func (o *OrchestratorImpl) discoverFiles(ctx context.Context, req DocumentationRequest, maxDepth int) ([]string, error) {
+	if len(req.Files) > 0 {
+		files, err := o.explicitFiles(ctx, req)
+		if err != nil || !req.IncludeDependencies {
+			return files, err
+		}
+		return o.withDependencies(ctx, req.ProjectPath, files)
+	}
+
+	files := []string{}
+
+	fs, err := o.serviceRegistry.GetFileSystem()
+	if err != nil {
+		return files, nil
+	}
+
+	infos, err := fs.ListFiles(ctx, services.ListFilesRequest{
+		RootPath:    req.ProjectPath,
+		MaxDepth:    maxDepth,
+		Concurrency: o.discoveryConcurrency(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	store, hasStore := o.analysisStore()
+
+	for _, info := range infos {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if info.IsDir {
+			continue
+		}
+		if !matchesFilePatterns(req.ProjectPath, info.Path, req.Options) {
+			continue
+		}
+		if !matchesLanguages(info, req.Options.Languages) {
+			continue
+		}
+
+		if hasStore {
+			enqueue, err := shouldReprocess(ctx, fs, store, req, info.Path)
+			if err != nil {
+				return nil, err
+			}
+			if !enqueue {
+				continue
+			}
+		}
+
+		files = append(files, info.Path)
+	}
+
+	return files, nil
+}
+
+// DefaultMaxDepth is the directory depth discovery walks when neither the
+// request nor Session.DefaultMaxDepth sets one.
+const DefaultMaxDepth = 10
+
+// UnlimitedDepth, as Session.DefaultMaxDepth, lets discovery walk the whole
+// project tree when a request sets no MaxDepth.
+const UnlimitedDepth = -1
+
+// discoveryDepth returns the directory depth discovery walks for opts,
+// applying the configured default when MaxDepth is not set. 0 means no
+// limit, as it does for ListFiles.
+func (o *OrchestratorImpl) discoveryDepth(opts DocumentationOptions) int {
+	if opts.MaxDepth > 0 {
+		return opts.MaxDepth
+	}
+	depth := DefaultMaxDepth
+	if o.config != nil && o.config.Session.DefaultMaxDepth != 0 {
+		depth = o.config.Session.DefaultMaxDepth
+	}
+	if depth == UnlimitedDepth {
+		return 0
+	}
+	return depth
+}
+
+// Rediscover runs file discovery again for a session's project with the
+// options it was started with, and enqueues the files its TODO list doesn't
+// have yet, growing the session's total. A session started with an explicit
+// file list is not walked; only dependencies its files have gained since
+// are added, and only if it included dependencies. Files already in the list, queued
+// or finished, are left as they are. It is safe to call while the session
+// is processing, and returns how many files were added.
+func (o *OrchestratorImpl) Rediscover(ctx context.Context, sessionID string) (int, error) {
+	if err := o.beginOperation(); err != nil {
+		return 0, err
+	}
+	defer o.endOperation()
+	ctx = ContextWithSessionLogger(ctx, sessionID)
+
+	docSess, err := o.GetSession(ctx, sessionID)
+	if err != nil {
+		return 0, err
+	}
+	if err := ensureActive(docSess); err != nil {
+		return 0, err
+	}
+
+	req := DocumentationRequest{
+		ProjectPath: docSess.ProjectPath,
+		WorkspaceID: docSess.WorkspaceID,
+		ModuleName:  docSess.ModuleName,
+		Options:     o.getSessionOptions(sessionID),
+	}
+	req.Files, req.IncludeDependencies = o.getSessionFiles(sessionID)
+	files, err := o.discoverFiles(ctx, req, o.discoveryDepth(req.Options))
+	if err != nil {
+		return 0, fmt.Errorf("failed to discover files: %w", err)
+	}
+
+	items, err := o.todoManager.ListItems(ctx, sessionID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list TODO items: %w", err)
+	}
+	known := make(map[string]bool, len(items))
+	for _, item := range items {
+		known[item.FilePath] = true
+	}
+
+	added := 0
+	for _, file := range files {
+		if known[file] {
+			continue
+		}
+		// A file queued since the listing is updated, not duplicated
+		inserted, err := o.todoManager.UpsertItem(ctx, sessionID, todolist.TodoItem{FilePath: file})
+		if err != nil {
+			return added, fmt.Errorf("failed to enqueue %s: %w", file, err)
+		}
+		if inserted {
+			added++
+		}
+	}
+	if added == 0 {
+		return 0, nil
+	}
+
+	sessionUUID, _ := parseSessionID(sessionID)
+	o.progressMu.Lock()
+	current, err := o.sessionManager.Get(sessionUUID)
+	if err != nil {
+		o.progressMu.Unlock()
+		return added, fmt.Errorf("session not found: %w", err)
+	}
+	progress := current.Progress
+	progress.TotalFiles += added
+	err = o.sessionManager.Update(sessionUUID, session.NewSessionUpdate().WithProgress(progress).Build())
+	o.progressMu.Unlock()
+	if err != nil {
+		return added, fmt.Errorf("failed to update session progress: %w", err)
+	}
+
+	LoggerFromContext(ctx).Info().
+		Int("added", added).
+		Int("total", progress.TotalFiles).
+		Msg("Rediscovered files added to session")
+
+	return added, nil
+}
+
+// discoveryConcurrency returns the configured discovery walk concurrency,
+// 0 if none.
+func (o *OrchestratorImpl) discoveryConcurrency() int {
+	if o.config == nil {
+		return 0
+	}
+	return o.config.Workflow.DiscoveryConcurrency
+}
+
+// explicitFiles resolves and validates the files listed in the request,
+// dropping duplicates. Relative paths must stay inside the project, and
+// every path is checked with the file system service's ValidatePath when
+// one is registered; all problems are reported together.
+func (o *OrchestratorImpl) explicitFiles(ctx context.Context, req DocumentationRequest) ([]string, error) {
+	var invalid []error
+	files := make([]string, 0, len(req.Files))
+	seen := make(map[string]bool, len(req.Files))
+	for _, file := range req.Files {
+		if !filepath.IsAbs(file) {
+			if !filepath.IsLocal(file) {
+				invalid = append(invalid, fmt.Errorf("%s: relative path escapes the project root", file))
+				continue
+			}
+			file = filepath.Join(req.ProjectPath, file)
+		}
+		if seen[file] {
+			continue
+		}
+		seen[file] = true
+		files = append(files, file)
+	}
+
+	if fs, err := o.serviceRegistry.GetFileSystem(); err == nil {
+		for _, file := range files {
+			if err := fs.ValidatePath(ctx, file); err != nil {
+				invalid = append(invalid, fmt.Errorf("%s: %w", file, err))
+			}
+		}
+	}
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid file paths: %w", errors.Join(invalid...))
+	}
+
+	return files, nil
+}
+
+// matchesFilePatterns reports whether a file passes the include and exclude
+// patterns. Patterns are matched against the file name and the path relative
+// to the project root; exclude patterns also match any directory component.
+// With opts.CaseInsensitive, patterns and paths are compared in lower case.
+func matchesFilePatterns(root, path string, opts DocumentationOptions) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = path
+	}
+	base := filepath.Base(path)
+
+	match := matchPattern
+	if opts.CaseInsensitive {
+		match = matchPatternFold
+	}
+
+	for _, pattern := range opts.ExcludePatterns {
+		if match(pattern, base) || match(pattern, rel) {
+			return false
+		}
+		for _, dir := range strings.Split(filepath.Dir(rel), string(filepath.Separator)) {
+			if match(pattern, dir) {
+				return false
+			}
+		}
+	}
+
+	if len(opts.FilePatterns) == 0 {
+		return true
+	}
+	for _, pattern := range opts.FilePatterns {
+		if match(pattern, base) || match(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesLanguages reports whether a file is in one of the requested
+// languages. An empty set matches every file.
+func matchesLanguages(info services.FileInfo, languages []string) bool {
+	if len(languages) == 0 {
+		return true
+	}
+	language := info.Language
+	if language == "" {
+		language = DetectLanguage(info.Path)
+	}
+	for _, want := range languages {
+		if strings.EqualFold(want, language) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPattern matches a glob pattern against a name. Malformed patterns
+// never match.
+func matchPattern(pattern, name string) bool {
+	matched, err := filepath.Match(pattern, name)
+	return err == nil && matched
+}
+
+// matchPatternFold is matchPattern ignoring case.
+func matchPatternFold(pattern, name string) bool {
+	return matchPattern(strings.ToLower(pattern), strings.ToLower(name))
+}
+
+// shouldReprocess applies the request's reprocessing policy to a file that
+// may already have a stored analysis.
+func shouldReprocess(ctx context.Context, fs services.FileSystemService, store AnalysisStore, req DocumentationRequest, path string) (bool, error) {
+	policy := req.Options.ReprocessPolicy
+	if policy == "" || policy == ReprocessAll {
+		return true, nil
+	}
+
+	stored, err := store.GetAnalysis(ctx, req.ProjectPath, path)
+	if err != nil {
+		return false, fmt.Errorf("failed to load stored analysis for %s: %w", path, err)
+	}
+	if stored == nil {
+		return true, nil
+	}
+	if policy == ReprocessMissing {
+		return false, nil
+	}
+
+	// ReprocessChanged: compare the current content with the stored hash
+	content, err := fs.ReadFile(ctx, path)
+	if err != nil {
+		// Let processing surface the read error rather than hiding the file
+		log.Warn().
+			Err(err).
+			Str("file", path).
+			Msg("Failed to read file for change detection, enqueueing it")
+		return true, nil
+	}
+	return contentHash(content) != stored.ContentHash, nil
+}
+
+// analysisStore returns the AnalysisStore registered in the container, if any.
+func (o *OrchestratorImpl) analysisStore() (AnalysisStore, bool) {
+	if o.container == nil {
+		return nil, false
+	}
+	service, err := o.container.Get(AnalysisStoreName)
+	if err != nil {
+		return nil, false
+	}
+	store, ok := service.(AnalysisStore)
+	return store, ok
+}
@@ -0,0 +1,598 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/services"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/session"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/todolist"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartDocumentationReprocessPolicy(t *testing.T) {
+	const project = "/project"
+
+	// unchanged.go and changed.go have stored analyses; new.go does not
+	fs := &fakeFileSystem{files: map[string][]byte{
+		"/project/changed.go":   []byte("package main // edited"),
+		"/project/new.go":       []byte("package main"),
+		"/project/unchanged.go": []byte("package main"),
+	}}
+
+	newStore := func() *memoryAnalysisStore {
+		store := newMemoryAnalysisStore()
+		ctx := context.Background()
+		require.NoError(t, store.SaveAnalysis(ctx, project, &FileAnalysis{
+			FilePath:    "/project/changed.go",
+			ContentHash: contentHash([]byte("package main")),
+		}))
+		require.NoError(t, store.SaveAnalysis(ctx, project, &FileAnalysis{
+			FilePath:    "/project/unchanged.go",
+			ContentHash: contentHash([]byte("package main")),
+		}))
+		return store
+	}
+
+	tests := []struct {
+		name      string
+		policy    ReprocessPolicy
+		withStore bool
+		want      []string
+	}{
+		{
+			name:      "default enqueues everything",
+			policy:    "",
+			withStore: true,
+			want:      []string{"/project/changed.go", "/project/new.go", "/project/unchanged.go"},
+		},
+		{
+			name:      "all enqueues everything",
+			policy:    ReprocessAll,
+			withStore: true,
+			want:      []string{"/project/changed.go", "/project/new.go", "/project/unchanged.go"},
+		},
+		{
+			name:      "missing enqueues files without a stored analysis",
+			policy:    ReprocessMissing,
+			withStore: true,
+			want:      []string{"/project/new.go"},
+		},
+		{
+			name:      "changed enqueues new and modified files",
+			policy:    ReprocessChanged,
+			withStore: true,
+			want:      []string{"/project/changed.go", "/project/new.go"},
+		},
+		{
+			name:      "policy is ignored without a store",
+			policy:    ReprocessMissing,
+			withStore: false,
+			want:      []string{"/project/changed.go", "/project/new.go", "/project/unchanged.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, mockSession, mockWorkflow, _ := createTestOrchestrator(t)
+			o.todoManager = todolist.NewManager()
+			require.NoError(t, o.serviceRegistry.RegisterFileSystem(fs))
+			if tt.withStore {
+				o.container.Register(AnalysisStoreName, newStore())
+			}
+
+			sess := createMockSession("550e8400-e29b-41d4-a716-446655440500", "workspace-123", project)
+			mockSession.On("Create", "workspace-123", project, "", tt.want).Return(sess, nil)
+			mockWorkflow.On("Initialize", mock.Anything, sess.GetID(), workflow.WorkflowStateIdle).Return(nil)
+			mockWorkflow.On("Trigger", mock.Anything, sess.GetID(), workflow.EventStart).Return(nil)
+
+			docSess, err := o.StartDocumentation(context.Background(), DocumentationRequest{
+				ProjectPath: project,
+				WorkspaceID: "workspace-123",
+				Options:     DocumentationOptions{ReprocessPolicy: tt.policy},
+			})
+			require.NoError(t, err)
+
+			progress, err := o.todoManager.GetProgress(context.Background(), docSess.ID)
+			require.NoError(t, err)
+			assert.Equal(t, len(tt.want), progress.Pending)
+
+			mockSession.AssertExpectations(t)
+		})
+	}
+
+	t.Run("invalid policy is rejected", func(t *testing.T) {
+		o, _, _, _ := createTestOrchestrator(t)
+
+		_, err := o.StartDocumentation(context.Background(), DocumentationRequest{
+			ProjectPath: project,
+			WorkspaceID: "workspace-123",
+			Options:     DocumentationOptions{ReprocessPolicy: "sometimes"},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid reprocess_policy")
+	})
+}
+
+func TestProcessNextFileSavesAnalysis(t *testing.T) {
+	o, mockSession, mockWorkflow, _ := createTestOrchestrator(t)
+	o.todoManager = todolist.NewManager()
+	ctx := context.Background()
+
+	fs := &fakeFileSystem{files: map[string][]byte{"/project/main.go": []byte("package main")}}
+	require.NoError(t, o.serviceRegistry.RegisterFileSystem(fs))
+	require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &stubAIService{}))
+	store := newMemoryAnalysisStore()
+	o.container.Register(AnalysisStoreName, store)
+
+	sessionID := "550e8400-e29b-41d4-a716-446655440501"
+	sess := createMockSession(sessionID, "workspace-123", "/project")
+	sess.Status = session.StatusInProgress
+	mockSession.On("Get", sess.ID).Return(sess, nil)
+	mockSession.On("Update", sess.ID, mock.AnythingOfType("session.SessionUpdate")).Return(nil)
+	mockWorkflow.On("GetState", mock.Anything, sessionID).Return(workflow.WorkflowStateProcessing, nil)
+
+	require.NoError(t, o.todoManager.CreateList(ctx, sessionID))
+	require.NoError(t, o.todoManager.AddItem(ctx, sessionID, todolist.TodoItem{FilePath: "/project/main.go"}))
+
+	_, err := o.ProcessNextFile(ctx, sessionID)
+	require.NoError(t, err)
+
+	stored, err := store.GetAnalysis(ctx, "/project", "/project/main.go")
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, contentHash([]byte("package main")), stored.ContentHash)
+}
+
+func TestStartDocumentationLanguageFilter(t *testing.T) {
+	const project = "/project"
+
+	fs := &fakeFileSystem{files: map[string][]byte{
+		"/project/main.go":         []byte("package main"),
+		"/project/util/strings.go": []byte("package util"),
+		"/project/scripts/gen.py":  []byte("print()"),
+		"/project/web/app.ts":      []byte("export {}"),
+		"/project/README.md":       []byte("# project"),
+	}}
+
+	tests := []struct {
+		name    string
+		options DocumentationOptions
+		want    []string
+	}{
+		{
+			name:    "go only despite match-all pattern",
+			options: DocumentationOptions{FilePatterns: []string{"*"}, Languages: []string{"go"}},
+			want:    []string{"/project/main.go", "/project/util/strings.go"},
+		},
+		{
+			name:    "languages intersect with patterns",
+			options: DocumentationOptions{FilePatterns: []string{"main.*", "*.py"}, Languages: []string{"go"}},
+			want:    []string{"/project/main.go"},
+		},
+		{
+			name:    "several languages, case-insensitive",
+			options: DocumentationOptions{Languages: []string{"Python", "typescript"}},
+			want:    []string{"/project/scripts/gen.py", "/project/web/app.ts"},
+		},
+		{
+			name:    "no languages keeps everything",
+			options: DocumentationOptions{},
+			want:    []string{"/project/README.md", "/project/main.go", "/project/scripts/gen.py", "/project/util/strings.go", "/project/web/app.ts"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, mockSession, mockWorkflow, _ := createTestOrchestrator(t)
+			o.todoManager = todolist.NewManager()
+			require.NoError(t, o.serviceRegistry.RegisterFileSystem(fs))
+
+			sess := createMockSession("550e8400-e29b-41d4-a716-446655440510", "workspace-123", project)
+			mockSession.On("Create", "workspace-123", project, "", tt.want).Return(sess, nil)
+			mockWorkflow.On("Initialize", mock.Anything, sess.GetID(), workflow.WorkflowStateIdle).Return(nil)
+			mockWorkflow.On("Trigger", mock.Anything, sess.GetID(), workflow.EventStart).Return(nil)
+
+			_, err := o.StartDocumentation(context.Background(), DocumentationRequest{
+				ProjectPath: project,
+				WorkspaceID: "workspace-123",
+				Options:     tt.options,
+			})
+			require.NoError(t, err)
+
+			mockSession.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMatchesFilePatternsCaseInsensitive(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		options DocumentationOptions
+		want    bool
+	}{
+		{
+			name:    "include is case-sensitive by default",
+			path:    "/project/main.go",
+			options: DocumentationOptions{FilePatterns: []string{"*.GO"}},
+			want:    false,
+		},
+		{
+			name:    "include ignores case when enabled",
+			path:    "/project/main.go",
+			options: DocumentationOptions{FilePatterns: []string{"*.GO"}, CaseInsensitive: true},
+			want:    true,
+		},
+		{
+			name:    "upper-case path matches lower-case pattern when enabled",
+			path:    "/project/Cmd/MAIN.GO",
+			options: DocumentationOptions{FilePatterns: []string{"cmd/*.go"}, CaseInsensitive: true},
+			want:    true,
+		},
+		{
+			name:    "exclude is case-sensitive by default",
+			path:    "/project/main.go",
+			options: DocumentationOptions{ExcludePatterns: []string{"*.GO"}},
+			want:    true,
+		},
+		{
+			name:    "exclude ignores case when enabled",
+			path:    "/project/main.go",
+			options: DocumentationOptions{ExcludePatterns: []string{"*.GO"}, CaseInsensitive: true},
+			want:    false,
+		},
+		{
+			name:    "excluded directory ignores case when enabled",
+			path:    "/project/Vendor/lib.go",
+			options: DocumentationOptions{ExcludePatterns: []string{"vendor"}, CaseInsensitive: true},
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, matchesFilePatterns("/project", tt.path, tt.options))
+		})
+	}
+}
+
+// listCountingFileSystem records how often discovery lists the project.
+type listCountingFileSystem struct {
+	*fakeFileSystem
+	listCalls int
+}
+
+func (f *listCountingFileSystem) ListFiles(ctx context.Context, req services.ListFilesRequest) ([]services.FileInfo, error) {
+	f.listCalls++
+	return f.fakeFileSystem.ListFiles(ctx, req)
+}
+
+func TestStartDocumentationWithExplicitFiles(t *testing.T) {
+	const project = "/project"
+	newFS := func() *listCountingFileSystem {
+		return &listCountingFileSystem{fakeFileSystem: &fakeFileSystem{files: map[string][]byte{
+			"/project/main.go":    []byte("package main"),
+			"/project/util/io.go": []byte("package util"),
+			"/project/README.md":  []byte("# readme"),
+		}}}
+	}
+
+	t.Run("queue is seeded from the list without discovery", func(t *testing.T) {
+		o, mockSession, mockWorkflow, _ := createTestOrchestrator(t)
+		o.todoManager = todolist.NewManager()
+		fs := newFS()
+		require.NoError(t, o.serviceRegistry.RegisterFileSystem(fs))
+
+		want := []string{"/project/util/io.go", "/project/main.go"}
+		sess := createMockSession("550e8400-e29b-41d4-a716-446655440520", "workspace-123", project)
+		mockSession.On("Create", "workspace-123", project, "", want).Return(sess, nil)
+		mockWorkflow.On("Initialize", mock.Anything, sess.GetID(), workflow.WorkflowStateIdle).Return(nil)
+		mockWorkflow.On("Trigger", mock.Anything, sess.GetID(), workflow.EventStart).Return(nil)
+
+		docSess, err := o.StartDocumentation(context.Background(), DocumentationRequest{
+			ProjectPath: project,
+			WorkspaceID: "workspace-123",
+			Files:       []string{"util/io.go", "/project/main.go", "util/io.go"},
+		})
+		require.NoError(t, err)
+		assert.Zero(t, fs.listCalls)
+
+		progress, err := o.todoManager.GetProgress(context.Background(), docSess.ID)
+		require.NoError(t, err)
+		assert.Equal(t, len(want), progress.Pending)
+		mockSession.AssertExpectations(t)
+	})
+
+	t.Run("invalid paths are rejected before the session is created", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		require.NoError(t, o.serviceRegistry.RegisterFileSystem(newFS()))
+
+		_, err := o.StartDocumentation(context.Background(), DocumentationRequest{
+			ProjectPath: project,
+			WorkspaceID: "workspace-123",
+			Files:       []string{"main.go", "../secrets.env", "/project/../etc/passwd"},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid file paths")
+		assert.Contains(t, err.Error(), "secrets.env")
+		assert.Contains(t, err.Error(), "passwd")
+		mockSession.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestStartDocumentationDefaultMaxDepth(t *testing.T) {
+	const project = "/project"
+
+	// MaxDepth counts the directories below the project root
+	memFS, err := services.NewMemFileSystem(project, map[string][]byte{
+		"a.go":                         []byte("package a"),
+		"b/b.go":                       []byte("package b"),
+		"b/c/c.go":                     []byte("package c"),
+		"b/c/d/d.go":                   []byte("package d"),
+		"1/2/3/4/5/6/7/8/9/10/ten.go":  []byte("package ten"),
+		"1/2/3/4/5/6/7/8/9/10/11/x.go": []byte("package x"),
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		defaultDepth int
+		maxDepth     int
+		want         []string
+	}{
+		{
+			name: "unset default walks ten levels",
+			want: []string{"/project/1/2/3/4/5/6/7/8/9/10/ten.go", "/project/a.go", "/project/b/b.go", "/project/b/c/c.go", "/project/b/c/d/d.go"},
+		},
+		{
+			name:         "configured default applies when the request sets none",
+			defaultDepth: 2,
+			want:         []string{"/project/a.go", "/project/b/b.go", "/project/b/c/c.go"},
+		},
+		{
+			name:         "request depth overrides the configured default",
+			defaultDepth: 2,
+			maxDepth:     3,
+			want:         []string{"/project/a.go", "/project/b/b.go", "/project/b/c/c.go", "/project/b/c/d/d.go"},
+		},
+		{
+			name:         "unlimited default walks the whole tree",
+			defaultDepth: UnlimitedDepth,
+			want:         []string{"/project/1/2/3/4/5/6/7/8/9/10/11/x.go", "/project/1/2/3/4/5/6/7/8/9/10/ten.go", "/project/a.go", "/project/b/b.go", "/project/b/c/c.go", "/project/b/c/d/d.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, mockSession, mockWorkflow, _ := createTestOrchestrator(t)
+			o.todoManager = todolist.NewManager()
+			o.config.Session.DefaultMaxDepth = tt.defaultDepth
+			require.NoError(t, o.serviceRegistry.RegisterFileSystem(memFS))
+
+			sess := createMockSession("550e8400-e29b-41d4-a716-446655440511", "workspace-123", project)
+			mockSession.On("Create", "workspace-123", project, "", tt.want).Return(sess, nil)
+			mockWorkflow.On("Initialize", mock.Anything, sess.GetID(), workflow.WorkflowStateIdle).Return(nil)
+			mockWorkflow.On("Trigger", mock.Anything, sess.GetID(), workflow.EventStart).Return(nil)
+
+			_, err := o.StartDocumentation(context.Background(), DocumentationRequest{
+				ProjectPath: project,
+				WorkspaceID: "workspace-123",
+				Options:     DocumentationOptions{MaxDepth: tt.maxDepth},
+			})
+			require.NoError(t, err)
+
+			mockSession.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMemFileSystemPipeline(t *testing.T) {
+	ctx := context.Background()
+	const project = "/project"
+
+	memFS, err := services.NewMemFileSystem(project, map[string][]byte{
+		"main.go":              []byte("package main"),
+		"util/strings.go":      []byte("package util"),
+		"util/strings_test.go": []byte("package util"),
+		"README.md":            []byte("# project"),
+	})
+	require.NoError(t, err)
+
+	o, mockSession, mockWorkflow, _ := createTestOrchestrator(t)
+	o.todoManager = todolist.NewManager()
+	require.NoError(t, o.serviceRegistry.RegisterFileSystem(memFS))
+
+	var mu sync.Mutex
+	analyzed := make(map[string]string)
+	require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &stubAIService{
+		analyzeFunc: func(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error) {
+			mu.Lock()
+			analyzed[req.FilePath] = req.Content
+			mu.Unlock()
+			return &services.FileAnalysisResponse{Summary: req.FilePath}, nil
+		},
+	}))
+
+	// Discovery lists the project from memory
+	want := []string{"/project/main.go", "/project/util/strings.go"}
+	sess := createMockSession("550e8400-e29b-41d4-a716-446655440520", "workspace-123", project)
+	mockSession.On("Create", "workspace-123", project, "", want).Return(sess, nil)
+	mockWorkflow.On("Initialize", mock.Anything, sess.GetID(), workflow.WorkflowStateIdle).Return(nil)
+	mockWorkflow.On("Trigger", mock.Anything, sess.GetID(), workflow.EventStart).Return(nil)
+
+	docSess, err := o.StartDocumentation(ctx, DocumentationRequest{
+		ProjectPath: project,
+		WorkspaceID: "workspace-123",
+		Options: DocumentationOptions{
+			FilePatterns:    []string{"*.go"},
+			ExcludePatterns: []string{"*_test.go"},
+		},
+	})
+	require.NoError(t, err)
+	mockSession.AssertExpectations(t)
+
+	// Processing reads every discovered file from memory too
+	engine, err := workflow.NewEngine(workflow.WorkflowConfig{})
+	require.NoError(t, err)
+	require.NoError(t, engine.Initialize(ctx, docSess.ID, workflow.WorkflowStateProcessing))
+	o.workflowEngine = engine
+	sess.Status = session.StatusInProgress
+	mockSession.On("Get", sess.ID).Return(sess, nil)
+	mockSession.On("Update", sess.ID, mock.AnythingOfType("session.SessionUpdate")).Return(nil)
+
+	results, err := o.ProcessFiles(ctx, docSess.ID, 2)
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, map[string]string{
+		"/project/main.go":         "package main",
+		"/project/util/strings.go": "package util",
+	}, analyzed)
+
+	progress, err := o.todoManager.GetProgress(ctx, docSess.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, progress.Complete)
+}
+
+// cancelAfterContext is cancelled by the after-th call to its Err method, so
+// a test can cancel a walk part way through.
+type cancelAfterContext struct {
+	context.Context
+	cancel context.CancelFunc
+	calls  atomic.Int32
+	after  int32
+}
+
+func (c *cancelAfterContext) Err() error {
+	if c.calls.Add(1) == c.after {
+		c.cancel()
+	}
+	return c.Context.Err()
+}
+
+func TestStartDocumentationDiscoveryCancelled(t *testing.T) {
+	root := t.TempDir()
+	for d := 0; d < 100; d++ {
+		dir := filepath.Join(root, fmt.Sprintf("pkg%03d", d))
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+		for f := 0; f < 20; f++ {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%02d.go", f)), []byte("package pkg"), 0o644))
+		}
+	}
+
+	o, mockSession, _, _ := createTestOrchestrator(t)
+	lfs, err := services.NewLocalFileSystem(root, 0)
+	require.NoError(t, err)
+	require.NoError(t, o.serviceRegistry.RegisterFileSystem(lfs))
+
+	parent, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx := &cancelAfterContext{Context: parent, cancel: cancel, after: 50}
+
+	started := time.Now()
+	_, err = o.StartDocumentation(ctx, DocumentationRequest{
+		ProjectPath: root,
+		WorkspaceID: "workspace-123",
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(started), 5*time.Second)
+	assert.Less(t, ctx.calls.Load(), int32(100), "the walk stopped soon after the cancellation")
+	mockSession.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRediscover(t *testing.T) {
+	ctx := context.Background()
+	o, mockSession, _, _ := createTestOrchestrator(t)
+	sessionID := "550e8400-e29b-41d4-a716-446655440950"
+	sess := newStatefulSession(t, o, mockSession, sessionID)
+	o.setSessionOptions(sessionID, DocumentationOptions{FilePatterns: []string{"*.go"}})
+
+	fs := &fakeFileSystem{files: map[string][]byte{
+		"/project/main.go":      []byte("package main"),
+		"/project/util/util.go": []byte("package util"),
+	}}
+	require.NoError(t, o.serviceRegistry.RegisterFileSystem(fs))
+	require.NoError(t, o.todoManager.AddItem(ctx, sessionID, todolist.TodoItem{FilePath: "/project/main.go", Priority: 5}))
+	require.NoError(t, o.todoManager.AddItem(ctx, sessionID, todolist.TodoItem{FilePath: "/project/util/util.go"}))
+	sess.Progress.TotalFiles = 2
+
+	// One file is finished before the repository grows
+	_, err := o.ProcessNextFile(ctx, sessionID)
+	require.NoError(t, err)
+	fs.files["/project/new.go"] = []byte("package main")
+	fs.files["/project/util/more.go"] = []byte("package util")
+	fs.files["/project/README.md"] = []byte("# project")
+
+	added, err := o.Rediscover(ctx, sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, added)
+	assert.Equal(t, 4, sess.Progress.TotalFiles)
+
+	items, err := o.todoManager.ListItems(ctx, sessionID)
+	require.NoError(t, err)
+	statuses := make(map[string]todolist.ItemStatus)
+	for _, item := range items {
+		statuses[item.FilePath] = item.Status
+	}
+	assert.Equal(t, map[string]todolist.ItemStatus{
+		"/project/main.go":      todolist.ItemStatusComplete,
+		"/project/new.go":       todolist.ItemStatusPending,
+		"/project/util/more.go": todolist.ItemStatusPending,
+		"/project/util/util.go": todolist.ItemStatusPending,
+	}, statuses)
+
+	// Nothing is new the second time
+	added, err = o.Rediscover(ctx, sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, added)
+	assert.Equal(t, 4, sess.Progress.TotalFiles)
+}
+
+func TestRediscoverExplicitFiles(t *testing.T) {
+	ctx := context.Background()
+	o, mockSession, _, _ := createTestOrchestrator(t)
+	sessionID := "550e8400-e29b-41d4-a716-446655440951"
+	sess := newStatefulSession(t, o, mockSession, sessionID)
+	o.setSessionFiles(sessionID, []string{"main.go"}, false)
+
+	fs := &fakeFileSystem{files: map[string][]byte{
+		"/project/main.go":      []byte("package main"),
+		"/project/util/util.go": []byte("package util"),
+	}}
+	require.NoError(t, o.serviceRegistry.RegisterFileSystem(fs))
+	require.NoError(t, o.todoManager.AddItem(ctx, sessionID, todolist.TodoItem{FilePath: "/project/main.go"}))
+	sess.Progress.TotalFiles = 1
+
+	// The project is not walked for a session that listed its files
+	fs.files["/project/new.go"] = []byte("package main")
+	added, err := o.Rediscover(ctx, sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, added)
+	assert.Equal(t, 1, sess.Progress.TotalFiles)
+
+	items, err := o.todoManager.ListItems(ctx, sessionID)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "/project/main.go", items[0].FilePath)
+
+	// With dependencies included, only newly declared ones are added
+	o.setSessionFiles(sessionID, []string{"main.go"}, true)
+	resolver := &mapDependencyResolver{deps: map[string][]string{
+		"/project/main.go": {"util/util.go"},
+	}}
+	require.NoError(t, o.container.Register(DependencyResolverName, resolver))
+
+	added, err = o.Rediscover(ctx, sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, added)
+	assert.Equal(t, 2, sess.Progress.TotalFiles)
+}
@@ -0,0 +1,107 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// bufferingAnalysisStore holds saved analyses until Flush writes them to
+// the underlying store.
+type bufferingAnalysisStore struct {
+	*memoryAnalysisStore
+	mu       sync.Mutex
+	pending  map[string][]*FileAnalysis
+	flushErr error
+}
+
+func newBufferingAnalysisStore() *bufferingAnalysisStore {
+	return &bufferingAnalysisStore{
+		memoryAnalysisStore: newMemoryAnalysisStore(),
+		pending:             make(map[string][]*FileAnalysis),
+	}
+}
+
+func (s *bufferingAnalysisStore) SaveAnalysis(ctx context.Context, projectPath string, analysis *FileAnalysis) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending[projectPath] = append(s.pending[projectPath], analysis)
+	return nil
+}
+
+func (s *bufferingAnalysisStore) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.flushErr != nil {
+		return s.flushErr
+	}
+	for projectPath, analyses := range s.pending {
+		for _, analysis := range analyses {
+			if err := s.memoryAnalysisStore.SaveAnalysis(ctx, projectPath, analysis); err != nil {
+				return err
+			}
+		}
+	}
+	s.pending = make(map[string][]*FileAnalysis)
+	return nil
+}
+
+func TestCompleteSessionDrainsAnalyses(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("buffered analyses are persisted before completion", func(t *testing.T) {
+		o, mockSession, mockWorkflow, _ := createTestOrchestrator(t)
+		sessionID := "550e8400-e29b-41d4-a716-446655440730"
+		setupBatchSession(t, o, mockSession, sessionID, 3)
+		store := newBufferingAnalysisStore()
+		require.NoError(t, o.container.Register(AnalysisStoreName, store))
+		mockWorkflow.On("Transition", mock.Anything, sessionID, workflow.WorkflowStateComplete).Return(nil)
+
+		_, err := o.ProcessFiles(ctx, sessionID, 1)
+		require.NoError(t, err)
+		stored, err := store.GetAnalysis(ctx, "/project", "/project/file0.go")
+		require.NoError(t, err)
+		assert.Nil(t, stored, "analysis should still be buffered")
+
+		require.NoError(t, o.CompleteSession(ctx, sessionID, CompleteOptions{}))
+
+		for _, file := range []string{"/project/file0.go", "/project/file1.go", "/project/file2.go"} {
+			stored, err := store.GetAnalysis(ctx, "/project", file)
+			require.NoError(t, err)
+			assert.NotNil(t, stored, "%s should be persisted", file)
+		}
+		mockWorkflow.AssertExpectations(t)
+	})
+
+	t.Run("flush error blocks completion", func(t *testing.T) {
+		o, mockSession, mockWorkflow, _ := createTestOrchestrator(t)
+		sessionID := "550e8400-e29b-41d4-a716-446655440731"
+		setupBatchSession(t, o, mockSession, sessionID, 1)
+		store := newBufferingAnalysisStore()
+		store.flushErr = errors.New("disk full")
+		require.NoError(t, o.container.Register(AnalysisStoreName, store))
+
+		_, err := o.ProcessFiles(ctx, sessionID, 1)
+		require.NoError(t, err)
+
+		err = o.CompleteSession(ctx, sessionID, CompleteOptions{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to flush analyses: disk full")
+		mockWorkflow.AssertNotCalled(t, "Transition", mock.Anything, sessionID, workflow.WorkflowStateComplete)
+	})
+
+	t.Run("drain without a buffering store is a no-op", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		sessionID := "550e8400-e29b-41d4-a716-446655440732"
+		setupBatchSession(t, o, mockSession, sessionID, 0)
+		require.NoError(t, o.container.Register(AnalysisStoreName, newMemoryAnalysisStore()))
+
+		assert.NoError(t, o.Drain(ctx, sessionID))
+	})
+}
@@ -2,9 +2,14 @@ package errors
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/services"
 	"github.com/rs/zerolog/log"
 )
 
@@ -38,21 +43,138 @@ func NewExponentialBackoffStrategy() *ExponentialBackoffStrategy {
 
 // CanRecover checks if the error is recoverable.
 func (s *ExponentialBackoffStrategy) CanRecover(err error) bool {
+	return isRecoverable(err)
+}
+
+// Recover attempts to recover from the error.
+func (s *ExponentialBackoffStrategy) Recover(ctx context.Context, err error) error {
+	return attemptRecovery(ctx, err)
+}
+
+// GetBackoffDuration calculates the backoff duration for an attempt.
+func (s *ExponentialBackoffStrategy) GetBackoffDuration(attempt int) time.Duration {
+	if attempt <= 0 {
+		return s.BaseDelay
+	}
+
+	// Calculate exponential backoff: base * 2^(attempt-1)
+	delay := s.BaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay > s.MaxDelay {
+			return s.MaxDelay
+		}
+	}
+
+	return delay
+}
+
+// maxAttempts returns the retry limit for the strategy.
+func (s *ExponentialBackoffStrategy) maxAttempts() int {
+	return s.MaxAttempts
+}
+
+// LinearBackoffStrategy waits BaseDelay longer on each attempt.
+type LinearBackoffStrategy struct {
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	MaxAttempts int
+}
+
+// CanRecover checks if the error is recoverable.
+func (s *LinearBackoffStrategy) CanRecover(err error) bool {
+	return isRecoverable(err)
+}
+
+// Recover attempts to recover from the error.
+func (s *LinearBackoffStrategy) Recover(ctx context.Context, err error) error {
+	return attemptRecovery(ctx, err)
+}
+
+// GetBackoffDuration calculates the backoff duration for an attempt.
+func (s *LinearBackoffStrategy) GetBackoffDuration(attempt int) time.Duration {
+	if attempt <= 0 {
+		return s.BaseDelay
+	}
+
+	// Calculate linear backoff: base * attempt, guarding against overflow
+	delay := s.BaseDelay * time.Duration(attempt)
+	if delay > s.MaxDelay || delay/time.Duration(attempt) != s.BaseDelay {
+		return s.MaxDelay
+	}
+	return delay
+}
+
+func (s *LinearBackoffStrategy) maxAttempts() int {
+	return s.MaxAttempts
+}
+
+// FixedDelayStrategy waits the same delay before every attempt.
+type FixedDelayStrategy struct {
+	Delay       time.Duration
+	MaxAttempts int
+}
+
+// CanRecover checks if the error is recoverable.
+func (s *FixedDelayStrategy) CanRecover(err error) bool {
+	return isRecoverable(err)
+}
+
+// Recover attempts to recover from the error.
+func (s *FixedDelayStrategy) Recover(ctx context.Context, err error) error {
+	return attemptRecovery(ctx, err)
+}
+
+// GetBackoffDuration returns the fixed delay regardless of the attempt.
+func (s *FixedDelayStrategy) GetBackoffDuration(attempt int) time.Duration {
+	return s.Delay
+}
+
+func (s *FixedDelayStrategy) maxAttempts() int {
+	return s.MaxAttempts
+}
+
+// attemptLimited is implemented by strategies that bound the number of retries.
+type attemptLimited interface {
+	maxAttempts() int
+}
+
+// isRecoverable reports whether an error is worth retrying.
+func isRecoverable(err error) bool {
 	// Don't recover from validation errors
 	if IsValidationError(err) {
 		return false
 	}
 
-	// Service errors are usually recoverable
-	if e, ok := err.(*OrchestratorError); ok {
+	// A caller that gave up does not want the operation retried, even
+	// when a service error wraps the cancellation
+	if stderrors.Is(err, context.Canceled) {
+		return false
+	}
+
+	// Timeouts are usually transient slowness. Callers that can't use
+	// WrapTimeout, such as the orchestrator, pass the context error on
+	if stderrors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	// Rate limits clear once the provider's window passes
+	var rateLimited *services.RateLimitError
+	if stderrors.As(err, &rateLimited) {
+		return true
+	}
+
+	// Service errors are usually recoverable, even when wrapped
+	var e *OrchestratorError
+	if stderrors.As(err, &e) {
 		return e.Type == ErrorTypeService || e.Type == ErrorTypeInternal
 	}
 
 	return false
 }
 
-// Recover attempts to recover from the error.
-func (s *ExponentialBackoffStrategy) Recover(ctx context.Context, err error) error {
+// attemptRecovery performs the recovery steps shared by all strategies.
+func attemptRecovery(ctx context.Context, err error) error {
 	// Log the recovery attempt
 	log.Warn().
 		Err(err).
@@ -67,73 +189,113 @@ func (s *ExponentialBackoffStrategy) Recover(ctx context.Context, err error) err
 	return nil
 }
 
-// GetBackoffDuration calculates the backoff duration for an attempt.
-func (s *ExponentialBackoffStrategy) GetBackoffDuration(attempt int) time.Duration {
-	if attempt <= 0 {
-		return s.BaseDelay
-	}
+// NewBackoffStrategy builds the recovery strategy selected by the workflow
+// configuration. Zero values fall back to exponential backoff starting at one
+// second, capped at 30 seconds, with 5 attempts.
+func NewBackoffStrategy(cfg orchestrator.WorkflowConfig) (RecoveryStrategy, error) {
+	defaults := NewExponentialBackoffStrategy()
 
-	// Calculate exponential backoff: base * 2^(attempt-1)
-	delay := s.BaseDelay
-	for i := 1; i < attempt; i++ {
-		delay *= 2
-		if delay > s.MaxDelay {
-			return s.MaxDelay
-		}
+	base := cfg.BackoffBaseDelay
+	if base == 0 {
+		base = defaults.BaseDelay
+	}
+	maxDelay := cfg.BackoffMaxDelay
+	if maxDelay == 0 {
+		maxDelay = defaults.MaxDelay
+	}
+	attempts := cfg.MaxRetries
+	if attempts == 0 {
+		attempts = defaults.MaxAttempts
 	}
 
-	return delay
+	switch cfg.BackoffStrategy {
+	case orchestrator.BackoffExponential, "":
+		return &ExponentialBackoffStrategy{BaseDelay: base, MaxDelay: maxDelay, MaxAttempts: attempts}, nil
+	case orchestrator.BackoffLinear:
+		return &LinearBackoffStrategy{BaseDelay: base, MaxDelay: maxDelay, MaxAttempts: attempts}, nil
+	case orchestrator.BackoffFixed:
+		return &FixedDelayStrategy{Delay: base, MaxAttempts: attempts}, nil
+	default:
+		return nil, fmt.Errorf("unknown backoff strategy: %s", cfg.BackoffStrategy)
+	}
 }
 
 // RecoveryManager coordinates error recovery strategies.
 type RecoveryManager struct {
 	strategies []RecoveryStrategy
 	attempts   map[string]int
+	mu         sync.Mutex
+
+	// maxRetryAfter caps the Retry-After a rate limit may ask to wait; 0
+	// does not cap it
+	maxRetryAfter time.Duration
+
+	// wait sleeps for the backoff before a retry; nil waits in real time
+	wait func(ctx context.Context, d time.Duration) error
 }
 
-// NewRecoveryManager creates a new recovery manager.
-func NewRecoveryManager() *RecoveryManager {
-	return &RecoveryManager{
-		strategies: []RecoveryStrategy{
-			NewExponentialBackoffStrategy(),
-		},
-		attempts: make(map[string]int),
+// RecoveryManager can be registered with the orchestrator to retry failed
+// file analyses.
+var _ orchestrator.RecoveryManager = (*RecoveryManager)(nil)
+
+// NewRecoveryManager creates a new recovery manager using the backoff
+// strategy selected in the workflow configuration. A zero MaxRetryAfter
+// falls back to orchestrator.DefaultMaxRetryAfter.
+func NewRecoveryManager(cfg orchestrator.WorkflowConfig) (*RecoveryManager, error) {
+	strategy, err := NewBackoffStrategy(cfg)
+	if err != nil {
+		return nil, err
 	}
+	maxRetryAfter := cfg.MaxRetryAfter
+	if maxRetryAfter == 0 {
+		maxRetryAfter = orchestrator.DefaultMaxRetryAfter
+	}
+
+	return &RecoveryManager{
+		strategies:    []RecoveryStrategy{strategy},
+		attempts:      make(map[string]int),
+		maxRetryAfter: maxRetryAfter,
+	}, nil
 }
 
 // HandleError attempts to recover from an error.
 func (m *RecoveryManager) HandleError(ctx context.Context, err error, operationID string) error {
 	// Track attempts
+	m.mu.Lock()
 	m.attempts[operationID]++
+	attempt := m.attempts[operationID]
+	m.mu.Unlock()
 
 	// Find a suitable recovery strategy
 	for _, strategy := range m.strategies {
 		if strategy.CanRecover(err) {
 			// Check if we've exceeded max attempts
-			if backoff, ok := strategy.(*ExponentialBackoffStrategy); ok {
-				if m.attempts[operationID] > backoff.MaxAttempts {
-					return fmt.Errorf("max recovery attempts exceeded: %w", err)
+			if limited, ok := strategy.(attemptLimited); ok {
+				if attempt > limited.maxAttempts() {
+					return NewRecoveryExhaustedError(operationID, attempt, err)
 				}
 			}
 
-			// Wait before recovery
-			backoffDuration := strategy.GetBackoffDuration(m.attempts[operationID])
+			// Wait before recovery, unless the provider asks for longer
+			// than is allowed
+			backoffDuration, backoffErr := m.backoffFor(strategy, attempt, err)
+			if backoffErr != nil {
+				return backoffErr
+			}
 			log.Info().
 				Dur("backoff", backoffDuration).
-				Int("attempt", m.attempts[operationID]).
+				Int("attempt", attempt).
 				Msg("Waiting before recovery attempt")
 
-			select {
-			case <-time.After(backoffDuration):
-				// Attempt recovery
-				if recoveryErr := strategy.Recover(ctx, err); recoveryErr != nil {
-					return fmt.Errorf("recovery failed: %w", recoveryErr)
-				}
-				return nil
+			if waitErr := m.waitFor(ctx, backoffDuration); waitErr != nil {
+				return waitErr
+			}
 
-			case <-ctx.Done():
-				return ctx.Err()
+			// Attempt recovery
+			if recoveryErr := strategy.Recover(ctx, err); recoveryErr != nil {
+				return fmt.Errorf("recovery failed: %w", recoveryErr)
 			}
+			return nil
 		}
 	}
 
@@ -141,11 +303,63 @@ func (m *RecoveryManager) HandleError(ctx context.Context, err error, operationI
 	return err
 }
 
+// backoffFor returns how long to wait before retrying after err: the
+// strategy's backoff, or the provider's Retry-After when err is a rate
+// limit asking for longer. A Retry-After over the manager's maximum fails
+// the attempt instead, wrapping err.
+func (m *RecoveryManager) backoffFor(strategy RecoveryStrategy, attempt int, err error) (time.Duration, error) {
+	backoff := strategy.GetBackoffDuration(attempt)
+
+	var rateLimited *services.RateLimitError
+	if !stderrors.As(err, &rateLimited) || rateLimited.RetryAfter <= backoff {
+		return backoff, nil
+	}
+	if m.maxRetryAfter > 0 && rateLimited.RetryAfter > m.maxRetryAfter {
+		return 0, fmt.Errorf("%s asked to retry after %s, more than the maximum of %s: %w",
+			rateLimited.Provider, rateLimited.RetryAfter, m.maxRetryAfter, err)
+	}
+	return rateLimited.RetryAfter, nil
+}
+
+// waitFor sleeps for d, returning early with the context's error if it is
+// done first.
+func (m *RecoveryManager) waitFor(ctx context.Context, d time.Duration) error {
+	if m.wait != nil {
+		return m.wait(ctx, d)
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // ResetAttempts clears the attempt counter for an operation.
 func (m *RecoveryManager) ResetAttempts(operationID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	delete(m.attempts, operationID)
 }
 
+// AttemptsWithPrefix returns the attempt counts of all operations whose ID
+// starts with prefix.
+func (m *RecoveryManager) AttemptsWithPrefix(prefix string) map[string]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	attempts := make(map[string]int)
+	for operationID, count := range m.attempts {
+		if strings.HasPrefix(operationID, prefix) {
+			attempts[operationID] = count
+		}
+	}
+	return attempts
+}
+
 // GetRecoveryHint provides user-friendly recovery suggestions.
 func GetRecoveryHint(err error) string {
 	if e, ok := err.(*OrchestratorError); ok {
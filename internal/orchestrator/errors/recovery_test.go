@@ -3,9 +3,14 @@ package errors
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/services"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -55,6 +60,31 @@ func TestExponentialBackoffStrategy_CanRecover(t *testing.T) {
 			err:      NewInvalidStateError("idle", "complete"),
 			expected: false,
 		},
+		{
+			name:     "wrapped service error - recoverable",
+			err:      fmt.Errorf("failed to analyze file: %w", NewServiceError("ai", nil)),
+			expected: true,
+		},
+		{
+			name:     "deadline exceeded - recoverable",
+			err:      fmt.Errorf("failed to analyze file: %w", context.DeadlineExceeded),
+			expected: true,
+		},
+		{
+			name:     "wrapped timeout - recoverable",
+			err:      WrapTimeout("ai", context.DeadlineExceeded),
+			expected: true,
+		},
+		{
+			name:     "cancellation - not recoverable",
+			err:      fmt.Errorf("failed to analyze file: %w", context.Canceled),
+			expected: false,
+		},
+		{
+			name:     "service error wrapping cancellation - not recoverable",
+			err:      NewServiceError("database", context.Canceled),
+			expected: false,
+		},
 		{
 			name:     "regular error - not recoverable",
 			err:      errors.New("regular error"),
@@ -191,7 +221,8 @@ func TestExponentialBackoffStrategy_CustomValues(t *testing.T) {
 }
 
 func TestNewRecoveryManager(t *testing.T) {
-	manager := NewRecoveryManager()
+	manager, err := NewRecoveryManager(orchestrator.WorkflowConfig{})
+	assert.NoError(t, err)
 	assert.NotNil(t, manager)
 	assert.NotNil(t, manager.strategies)
 	assert.NotNil(t, manager.attempts)
@@ -203,6 +234,182 @@ func TestNewRecoveryManager(t *testing.T) {
 	assert.NotNil(t, strategy)
 }
 
+func TestNewRecoveryManager_BackoffStrategies(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      orchestrator.WorkflowConfig
+		wantType RecoveryStrategy
+		want     []time.Duration // backoff for attempts 1..n
+	}{
+		{
+			name: "exponential",
+			cfg: orchestrator.WorkflowConfig{
+				BackoffStrategy:  orchestrator.BackoffExponential,
+				BackoffBaseDelay: 100 * time.Millisecond,
+				BackoffMaxDelay:  500 * time.Millisecond,
+			},
+			wantType: &ExponentialBackoffStrategy{},
+			want: []time.Duration{
+				100 * time.Millisecond,
+				200 * time.Millisecond,
+				400 * time.Millisecond,
+				500 * time.Millisecond,
+				500 * time.Millisecond,
+			},
+		},
+		{
+			name: "linear",
+			cfg: orchestrator.WorkflowConfig{
+				BackoffStrategy:  orchestrator.BackoffLinear,
+				BackoffBaseDelay: 100 * time.Millisecond,
+				BackoffMaxDelay:  350 * time.Millisecond,
+			},
+			wantType: &LinearBackoffStrategy{},
+			want: []time.Duration{
+				100 * time.Millisecond,
+				200 * time.Millisecond,
+				300 * time.Millisecond,
+				350 * time.Millisecond,
+				350 * time.Millisecond,
+			},
+		},
+		{
+			name: "fixed",
+			cfg: orchestrator.WorkflowConfig{
+				BackoffStrategy:  orchestrator.BackoffFixed,
+				BackoffBaseDelay: 250 * time.Millisecond,
+			},
+			wantType: &FixedDelayStrategy{},
+			want: []time.Duration{
+				250 * time.Millisecond,
+				250 * time.Millisecond,
+				250 * time.Millisecond,
+			},
+		},
+		{
+			name:     "empty defaults to exponential",
+			cfg:      orchestrator.WorkflowConfig{},
+			wantType: &ExponentialBackoffStrategy{},
+			want:     []time.Duration{1 * time.Second, 2 * time.Second, 4 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager, err := NewRecoveryManager(tt.cfg)
+			assert.NoError(t, err)
+			assert.Len(t, manager.strategies, 1)
+
+			strategy := manager.strategies[0]
+			assert.IsType(t, tt.wantType, strategy)
+			for i, want := range tt.want {
+				assert.Equal(t, want, strategy.GetBackoffDuration(i+1), "attempt %d", i+1)
+			}
+		})
+	}
+
+	t.Run("max retries bound attempts", func(t *testing.T) {
+		strategy, err := NewBackoffStrategy(orchestrator.WorkflowConfig{
+			BackoffStrategy: orchestrator.BackoffLinear,
+			MaxRetries:      2,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, strategy.(*LinearBackoffStrategy).MaxAttempts)
+	})
+
+	t.Run("unknown strategy is rejected", func(t *testing.T) {
+		manager, err := NewRecoveryManager(orchestrator.WorkflowConfig{BackoffStrategy: "random"})
+		assert.Error(t, err)
+		assert.Nil(t, manager)
+		assert.Contains(t, err.Error(), "unknown backoff strategy: random")
+	})
+}
+
+func TestLinearBackoffStrategy_GetBackoffDuration(t *testing.T) {
+	strategy := &LinearBackoffStrategy{BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+
+	assert.Equal(t, time.Second, strategy.GetBackoffDuration(0))
+	assert.Equal(t, time.Second, strategy.GetBackoffDuration(-3))
+	assert.Equal(t, 5*time.Second, strategy.GetBackoffDuration(5))
+	assert.Equal(t, 10*time.Second, strategy.GetBackoffDuration(1<<40)) // Overflow is capped
+}
+
+func TestRecoveryManager_HandleErrorFixedDelayLimit(t *testing.T) {
+	manager := &RecoveryManager{
+		strategies: []RecoveryStrategy{&FixedDelayStrategy{Delay: time.Millisecond, MaxAttempts: 1}},
+		attempts:   make(map[string]int),
+	}
+	err := NewServiceError("ai", errors.New("unavailable"))
+
+	assert.NoError(t, manager.HandleError(context.Background(), err, "op"))
+
+	secondErr := manager.HandleError(context.Background(), err, "op")
+	assert.Error(t, secondErr)
+	assert.Contains(t, secondErr.Error(), "max recovery attempts exceeded")
+}
+
+func TestRecoveryManager_HandleErrorExhaustedKeepsCause(t *testing.T) {
+	newManager := func() *RecoveryManager {
+		return &RecoveryManager{
+			strategies: []RecoveryStrategy{&FixedDelayStrategy{MaxAttempts: 2}},
+			attempts:   make(map[string]int),
+		}
+	}
+	exhaust := func(manager *RecoveryManager, err error) error {
+		for i := 0; i < 2; i++ {
+			assert.NoError(t, manager.HandleError(context.Background(), err, "analyze:main.go"))
+		}
+		return manager.HandleError(context.Background(), err, "analyze:main.go")
+	}
+
+	t.Run("service error", func(t *testing.T) {
+		cause := errors.New("connection refused")
+		original := NewServiceError("ai", cause)
+
+		result := exhaust(newManager(), fmt.Errorf("analysis failed: %w", original))
+
+		var oerr *OrchestratorError
+		assert.True(t, errors.As(result, &oerr))
+		assert.Equal(t, ErrorTypeService, oerr.Type)
+		assert.Equal(t, 3, oerr.Details["attempts"])
+		assert.Equal(t, "analyze:main.go", oerr.Details["operation_id"])
+		assert.Equal(t, "ai", oerr.Details["service"])
+		assert.True(t, IsServiceError(result))
+		assert.ErrorIs(t, result, original)
+		assert.ErrorIs(t, result, cause)
+		assert.Contains(t, result.Error(), "max recovery attempts exceeded")
+
+		// The original error's details are copied, not shared
+		assert.NotContains(t, original.Details, "attempts")
+	})
+
+	t.Run("internal error keeps its type", func(t *testing.T) {
+		result := exhaust(newManager(), NewInternalError("cache corrupted", nil))
+
+		var oerr *OrchestratorError
+		assert.True(t, errors.As(result, &oerr))
+		assert.Equal(t, ErrorTypeInternal, oerr.Type)
+		assert.Equal(t, 3, oerr.Details["attempts"])
+	})
+
+	t.Run("other errors become service errors", func(t *testing.T) {
+		rateLimited := &services.RateLimitError{Provider: "openai"}
+		manager := newManager()
+		manager.wait = func(ctx context.Context, d time.Duration) error { return nil }
+
+		result := exhaust(manager, rateLimited)
+
+		var oerr *OrchestratorError
+		assert.True(t, errors.As(result, &oerr))
+		assert.Equal(t, ErrorTypeService, oerr.Type)
+		assert.Equal(t, 3, oerr.Details["attempts"])
+
+		var got *services.RateLimitError
+		assert.True(t, errors.As(result, &got))
+		assert.Same(t, rateLimited, got)
+	})
+}
+
 func TestRecoveryManager_HandleError(t *testing.T) {
 	// Create a custom strategy with much shorter delays for testing
 	testStrategy := &ExponentialBackoffStrategy{
@@ -529,8 +736,137 @@ func TestRecoveryManagerWithMockStrategy(t *testing.T) {
 
 // Helper function to check if error is service error
 func IsServiceError(err error) bool {
-	if e, ok := err.(*OrchestratorError); ok {
+	var e *OrchestratorError
+	if errors.As(err, &e) {
 		return e.Type == ErrorTypeService
 	}
 	return false
 }
+
+func TestRecoveryManager_AttemptsWithPrefix(t *testing.T) {
+	manager := &RecoveryManager{
+		strategies: []RecoveryStrategy{&FixedDelayStrategy{MaxAttempts: 5}},
+		attempts:   make(map[string]int),
+	}
+	ctx := context.Background()
+	err := NewServiceError("ai", nil)
+
+	assert.NoError(t, manager.HandleError(ctx, err, "session-1:/a.go"))
+	assert.NoError(t, manager.HandleError(ctx, err, "session-1:/a.go"))
+	assert.NoError(t, manager.HandleError(ctx, err, "session-1:/b.go"))
+	assert.NoError(t, manager.HandleError(ctx, err, "session-2:/a.go"))
+
+	assert.Equal(t, map[string]int{
+		"session-1:/a.go": 2,
+		"session-1:/b.go": 1,
+	}, manager.AttemptsWithPrefix("session-1:"))
+
+	manager.ResetAttempts("session-1:/a.go")
+	assert.Equal(t, map[string]int{"session-1:/b.go": 1}, manager.AttemptsWithPrefix("session-1:"))
+}
+
+func TestRecoveryManager_HandleErrorHonorsRetryAfter(t *testing.T) {
+	// A provider stub that rate limits every request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "5")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	rateLimited := func(t *testing.T) error {
+		t.Helper()
+		resp, err := http.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		return fmt.Errorf("failed to analyze file: %w", services.NewRateLimitError("openai", resp))
+	}
+
+	tests := []struct {
+		name     string
+		strategy RecoveryStrategy
+		want     time.Duration
+	}{
+		{
+			name:     "retry-after longer than backoff",
+			strategy: &ExponentialBackoffStrategy{BaseDelay: 10 * time.Millisecond, MaxDelay: time.Second, MaxAttempts: 3},
+			want:     5 * time.Second,
+		},
+		{
+			name:     "backoff longer than retry-after",
+			strategy: &FixedDelayStrategy{Delay: 8 * time.Second, MaxAttempts: 3},
+			want:     8 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var waited []time.Duration
+			manager := &RecoveryManager{
+				strategies: []RecoveryStrategy{tt.strategy},
+				attempts:   make(map[string]int),
+				wait: func(ctx context.Context, d time.Duration) error {
+					waited = append(waited, d)
+					return nil
+				},
+			}
+
+			assert.NoError(t, manager.HandleError(context.Background(), rateLimited(t), "op"))
+			assert.Equal(t, []time.Duration{tt.want}, waited)
+		})
+	}
+}
+
+func TestRecoveryManager_HandleErrorRetryAfterWaits(t *testing.T) {
+	manager := &RecoveryManager{
+		strategies: []RecoveryStrategy{&FixedDelayStrategy{Delay: time.Millisecond, MaxAttempts: 3}},
+		attempts:   make(map[string]int),
+	}
+	err := &services.RateLimitError{Provider: "openai", RetryAfter: 50 * time.Millisecond}
+
+	start := time.Now()
+	assert.NoError(t, manager.HandleError(context.Background(), err, "op"))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	// The wait still gives up when the context ends
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	err.RetryAfter = time.Minute
+	assert.ErrorIs(t, manager.HandleError(ctx, err, "op"), context.DeadlineExceeded)
+}
+
+func TestRecoveryManager_HandleErrorRetryAfterOverMaximum(t *testing.T) {
+	var waited []time.Duration
+	manager := &RecoveryManager{
+		strategies:    []RecoveryStrategy{&FixedDelayStrategy{Delay: time.Millisecond, MaxAttempts: 3}},
+		attempts:      make(map[string]int),
+		maxRetryAfter: time.Minute,
+		wait: func(ctx context.Context, d time.Duration) error {
+			waited = append(waited, d)
+			return nil
+		},
+	}
+
+	// A Retry-After within the maximum is honored
+	err := &services.RateLimitError{Provider: "openai", RetryAfter: time.Minute}
+	assert.NoError(t, manager.HandleError(context.Background(), err, "op"))
+	assert.Equal(t, []time.Duration{time.Minute}, waited)
+
+	// A longer one fails the attempt without waiting and keeps the cause
+	err = &services.RateLimitError{Provider: "openai", RetryAfter: 2 * time.Hour}
+	handleErr := manager.HandleError(context.Background(), err, "op")
+	assert.ErrorIs(t, handleErr, err)
+	assert.Contains(t, handleErr.Error(), "more than the maximum of 1m0s")
+	assert.Len(t, waited, 1)
+}
+
+func TestNewRecoveryManager_MaxRetryAfter(t *testing.T) {
+	manager, err := NewRecoveryManager(orchestrator.WorkflowConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, orchestrator.DefaultMaxRetryAfter, manager.maxRetryAfter)
+
+	manager, err = NewRecoveryManager(orchestrator.WorkflowConfig{MaxRetryAfter: time.Second})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, manager.maxRetryAfter)
+}
@@ -3,6 +3,8 @@
 package errors
 
 import (
+	"context"
+	stderrors "errors"
 	"fmt"
 	"time"
 
@@ -81,6 +83,13 @@ func NewValidationError(message string, cause error) *OrchestratorError {
 	}
 }
 
+// NewRequestValidationError converts the problems collected while
+// validating a request into a validation error, listing them under
+// Details["errors"].
+func NewRequestValidationError(verr *orchestrator.ValidationError) *OrchestratorError {
+	return NewValidationError(verr.Message, nil).WithDetails("errors", verr.Errors)
+}
+
 // NewNotFoundError creates a not found error.
 func NewNotFoundError(message string, cause error) *OrchestratorError {
 	return &OrchestratorError{
@@ -119,6 +128,14 @@ func NewSessionExpiredError(sessionID string) *OrchestratorError {
 	}
 }
 
+// NewSessionExpiredErrorFrom converts the orchestrator's expiry error into
+// a session expired error, recording when the session expired.
+func NewSessionExpiredErrorFrom(serr *orchestrator.SessionExpiredError) *OrchestratorError {
+	err := NewSessionExpiredError(serr.SessionID).WithDetails("expires_at", serr.ExpiresAt)
+	err.Cause = serr
+	return err
+}
+
 // NewServiceError creates an external service error.
 func NewServiceError(service string, cause error) *OrchestratorError {
 	return &OrchestratorError{
@@ -133,6 +150,45 @@ func NewServiceError(service string, cause error) *OrchestratorError {
 	}
 }
 
+// WrapTimeout converts the error of a service or database call that ran
+// out of time into a service error, which recovery retries. Cancellation by
+// the caller is returned unchanged and is not retried, as are all other
+// errors.
+func WrapTimeout(service string, err error) error {
+	if !stderrors.Is(err, context.DeadlineExceeded) || stderrors.Is(err, context.Canceled) {
+		return err
+	}
+	return NewServiceError(service, err).
+		WithDetails("timeout", true).
+		WithHint(fmt.Sprintf("The call to %s timed out; retry the operation", service))
+}
+
+// NewRecoveryExhaustedError creates the error returned once recovery of an
+// operation has been attempted too many times. It keeps the type, details and
+// cause of the original error, defaulting to a service error for errors of
+// other types, and records the attempt count under Details["attempts"].
+func NewRecoveryExhaustedError(operationID string, attempts int, cause error) *OrchestratorError {
+	err := &OrchestratorError{
+		Type:    ErrorTypeService,
+		Message: "max recovery attempts exceeded",
+		Details: map[string]interface{}{},
+		Cause:   cause,
+		Time:    time.Now(),
+		Hint:    "Recovery gave up; check the failing service before retrying the operation",
+	}
+
+	var original *OrchestratorError
+	if stderrors.As(cause, &original) {
+		err.Type = original.Type
+		for key, value := range original.Details {
+			err.Details[key] = value
+		}
+	}
+	err.Details["operation_id"] = operationID
+	err.Details["attempts"] = attempts
+	return err
+}
+
 // NewInternalError creates an internal system error.
 func NewInternalError(message string, cause error) *OrchestratorError {
 	return &OrchestratorError{
@@ -144,17 +200,20 @@ func NewInternalError(message string, cause error) *OrchestratorError {
 	}
 }
 
-// IsValidationError checks if an error is a validation error.
+// IsValidationError checks if an error, or one it wraps, is a validation
+// error.
 func IsValidationError(err error) bool {
-	if e, ok := err.(*OrchestratorError); ok {
+	var e *OrchestratorError
+	if stderrors.As(err, &e) {
 		return e.Type == ErrorTypeValidation
 	}
 	return false
 }
 
-// IsNotFoundError checks if an error is a not found error.
+// IsNotFoundError checks if an error, or one it wraps, is a not found error.
 func IsNotFoundError(err error) bool {
-	if e, ok := err.(*OrchestratorError); ok {
+	var e *OrchestratorError
+	if stderrors.As(err, &e) {
 		return e.Type == ErrorTypeNotFound
 	}
 	return false
@@ -162,7 +221,12 @@ func IsNotFoundError(err error) bool {
 
 // IsSessionExpiredError checks if an error is a session expired error.
 func IsSessionExpiredError(err error) bool {
-	if e, ok := err.(*OrchestratorError); ok {
+	var serr *orchestrator.SessionExpiredError
+	if stderrors.As(err, &serr) {
+		return true
+	}
+	var e *OrchestratorError
+	if stderrors.As(err, &e) {
 		return e.Type == ErrorTypeSession && e.Details["session_id"] != nil
 	}
 	return false
@@ -1,6 +1,7 @@
 package errors
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestErrorType(t *testing.T) {
@@ -114,6 +116,19 @@ func TestNewValidationError(t *testing.T) {
 	})
 }
 
+func TestNewRequestValidationError(t *testing.T) {
+	problems := []string{"workspace_id is required", "max_depth cannot be negative"}
+	err := NewRequestValidationError(&orchestrator.ValidationError{
+		Message: "invalid documentation request",
+		Errors:  problems,
+	})
+
+	assert.True(t, IsValidationError(err))
+	assert.Equal(t, "invalid documentation request", err.Message)
+	assert.Equal(t, problems, err.Details["errors"])
+	assert.Equal(t, "validation: invalid documentation request", err.Error())
+}
+
 func TestNewNotFoundError(t *testing.T) {
 	t.Run("without cause", func(t *testing.T) {
 		err := NewNotFoundError("resource not found", nil)
@@ -156,6 +171,17 @@ func TestNewSessionExpiredError(t *testing.T) {
 		assert.Equal(t, "Start a new documentation session", err.Hint)
 		assert.WithinDuration(t, time.Now(), err.Time, time.Second)
 	})
+
+	t.Run("from orchestrator error", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Minute)
+		serr := &orchestrator.SessionExpiredError{SessionID: "session-123", ExpiresAt: expiresAt}
+		err := NewSessionExpiredErrorFrom(serr)
+		assert.Equal(t, ErrorTypeSession, err.Type)
+		assert.Equal(t, "session session-123 has expired", err.Message)
+		assert.Equal(t, "session-123", err.Details["session_id"])
+		assert.Equal(t, expiresAt, err.Details["expires_at"])
+		assert.Equal(t, serr, err.Cause)
+	})
 }
 
 func TestNewServiceError(t *testing.T) {
@@ -180,6 +206,35 @@ func TestNewServiceError(t *testing.T) {
 	})
 }
 
+func TestWrapTimeout(t *testing.T) {
+	t.Run("deadline exceeded becomes a service error", func(t *testing.T) {
+		cause := fmt.Errorf("query sessions: %w", context.DeadlineExceeded)
+		err := WrapTimeout("database", cause)
+
+		var oerr *OrchestratorError
+		require.True(t, errors.As(err, &oerr))
+		assert.Equal(t, ErrorTypeService, oerr.Type)
+		assert.Equal(t, "database", oerr.Details["service"])
+		assert.Equal(t, true, oerr.Details["timeout"])
+		assert.Equal(t, "The call to database timed out; retry the operation", oerr.Hint)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.True(t, NewExponentialBackoffStrategy().CanRecover(err))
+	})
+
+	t.Run("cancellation is returned unchanged", func(t *testing.T) {
+		cause := fmt.Errorf("query sessions: %w", context.Canceled)
+		err := WrapTimeout("database", cause)
+		assert.Same(t, cause, err)
+		assert.False(t, NewExponentialBackoffStrategy().CanRecover(err))
+	})
+
+	t.Run("other errors are returned unchanged", func(t *testing.T) {
+		cause := errors.New("connection refused")
+		assert.Same(t, cause, WrapTimeout("ai", cause))
+		assert.NoError(t, WrapTimeout("ai", nil))
+	})
+}
+
 func TestNewInternalError(t *testing.T) {
 	t.Run("without cause", func(t *testing.T) {
 		err := NewInternalError("unexpected error", nil)
@@ -226,6 +281,11 @@ func TestIsValidationError(t *testing.T) {
 			err:      nil,
 			expected: false,
 		},
+		{
+			name:     "wrapped validation error",
+			err:      fmt.Errorf("request rejected: %w", NewValidationError("test", nil)),
+			expected: true,
+		},
 		{
 			name: "orchestrator error with validation type",
 			err: &OrchestratorError{
@@ -313,6 +373,11 @@ func TestIsSessionExpiredError(t *testing.T) {
 			err:      NewSessionExpiredError("session-123"),
 			expected: true,
 		},
+		{
+			name:     "orchestrator session expired error",
+			err:      fmt.Errorf("wrapped: %w", &orchestrator.SessionExpiredError{SessionID: "session-123"}),
+			expected: true,
+		},
 		{
 			name:     "validation error",
 			err:      NewValidationError("test", nil),
@@ -0,0 +1,138 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// Subscription is a consumer's view of the broker.
+// Events are read from its buffer with Pop or TryPop.
+type Subscription struct {
+	*RingBuffer
+
+	// ID uniquely identifies the subscription
+	ID string
+}
+
+// Broker fans events out to subscribers, each with its own ring buffer.
+type Broker struct {
+	mu            sync.RWMutex
+	config        BufferConfig
+	subscriptions map[string]*Subscription
+	onDrop        func(subscriptionID string, event Event)
+}
+
+// NewBroker creates a broker whose subscriptions use the given buffer
+// configuration.
+func NewBroker(config BufferConfig) (*Broker, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid buffer config: %w", err)
+	}
+
+	return &Broker{
+		config:        config,
+		subscriptions: make(map[string]*Subscription),
+	}, nil
+}
+
+// Subscribe registers a new subscriber.
+func (b *Broker) Subscribe() (*Subscription, error) {
+	buffer, err := NewRingBuffer(b.config)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &Subscription{RingBuffer: buffer, ID: uuid.New().String()}
+
+	b.mu.Lock()
+	b.subscriptions[sub.ID] = sub
+	b.mu.Unlock()
+
+	return sub, nil
+}
+
+// Unsubscribe removes a subscriber and closes its buffer.
+func (b *Broker) Unsubscribe(id string) {
+	b.mu.Lock()
+	sub, exists := b.subscriptions[id]
+	delete(b.subscriptions, id)
+	b.mu.Unlock()
+
+	if exists {
+		sub.Close()
+	}
+}
+
+// OnDrop sets a function called with every event a subscriber's overflow
+// policy drops, such as a metrics counter. It is called from Publish, so
+// it should return quickly.
+func (b *Broker) OnDrop(fn func(subscriptionID string, event Event)) {
+	b.mu.Lock()
+	b.onDrop = fn
+	b.mu.Unlock()
+}
+
+// Publish delivers an event to every subscriber. A full subscriber buffer
+// only affects that subscriber; drops are recorded in its metrics and
+// reported to the OnDrop function.
+func (b *Broker) Publish(event Event) {
+	b.mu.RLock()
+	subs := make([]*Subscription, 0, len(b.subscriptions))
+	for _, sub := range b.subscriptions {
+		subs = append(subs, sub)
+	}
+	onDrop := b.onDrop
+	b.mu.RUnlock()
+
+	for _, sub := range subs {
+		dropped, ok, err := sub.push(event)
+		if errors.Is(err, ErrBufferFull) {
+			log.Warn().
+				Str("subscription_id", sub.ID).
+				Str("event_type", event.Type).
+				Msg("Subscriber buffer full, event dropped")
+		}
+		if ok && onDrop != nil {
+			onDrop(sub.ID, dropped)
+		}
+	}
+}
+
+// Metrics returns the buffer metrics of every subscriber keyed by
+// subscription ID.
+func (b *Broker) Metrics() map[string]Metrics {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	metrics := make(map[string]Metrics, len(b.subscriptions))
+	for id, sub := range b.subscriptions {
+		metrics[id] = sub.Metrics()
+	}
+	return metrics
+}
+
+// DroppedTotal returns the number of events dropped across all current
+// subscribers.
+func (b *Broker) DroppedTotal() uint64 {
+	var total uint64
+	for _, m := range b.Metrics() {
+		total += m.Dropped
+	}
+	return total
+}
+
+// Close unsubscribes every subscriber.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	subs := b.subscriptions
+	b.subscriptions = make(map[string]*Subscription)
+	b.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.Close()
+	}
+}
@@ -0,0 +1,122 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroker_SlowSubscriberIsIsolated(t *testing.T) {
+	broker, err := NewBroker(BufferConfig{Capacity: 3, Policy: OverflowDropOldest})
+	require.NoError(t, err)
+	defer broker.Close()
+
+	fast, err := broker.Subscribe()
+	require.NoError(t, err)
+	slow, err := broker.Subscribe()
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		broker.Publish(newEvent(i))
+		_, ok := fast.TryPop()
+		require.True(t, ok)
+	}
+
+	metrics := broker.Metrics()
+	require.Len(t, metrics, 2)
+	assert.Equal(t, uint64(0), metrics[fast.ID].Dropped)
+	assert.Equal(t, uint64(10), metrics[fast.ID].Delivered)
+	assert.Equal(t, uint64(7), metrics[slow.ID].Dropped)
+	assert.Equal(t, 3, metrics[slow.ID].Buffered)
+	assert.Equal(t, uint64(7), broker.DroppedTotal())
+}
+
+func TestBroker_BlockingSubscriberDoesNotDeadlock(t *testing.T) {
+	broker, err := NewBroker(BufferConfig{
+		Capacity:     1,
+		Policy:       OverflowBlockWithTimeout,
+		BlockTimeout: 5 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	defer broker.Close()
+
+	stuck, err := broker.Subscribe()
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 5; i++ {
+			broker.Publish(newEvent(i))
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publisher deadlocked on a stuck subscriber")
+	}
+
+	assert.Equal(t, uint64(4), stuck.Metrics().Dropped)
+}
+
+func TestBroker_OnDrop(t *testing.T) {
+	tests := []struct {
+		name   string
+		config BufferConfig
+		want   []string
+	}{
+		{
+			name:   "drop oldest reports the evicted events",
+			config: BufferConfig{Capacity: 2, Policy: OverflowDropOldest},
+			want:   []string{"event-0", "event-1"},
+		},
+		{
+			name:   "block with timeout reports the rejected events",
+			config: BufferConfig{Capacity: 2, Policy: OverflowBlockWithTimeout, BlockTimeout: time.Millisecond},
+			want:   []string{"event-2", "event-3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broker, err := NewBroker(tt.config)
+			require.NoError(t, err)
+			defer broker.Close()
+			sub, err := broker.Subscribe()
+			require.NoError(t, err)
+
+			var dropped []string
+			broker.OnDrop(func(subscriptionID string, event Event) {
+				assert.Equal(t, sub.ID, subscriptionID)
+				dropped = append(dropped, event.Type)
+			})
+			for i := 0; i < 4; i++ {
+				broker.Publish(newEvent(i))
+			}
+
+			assert.Equal(t, tt.want, dropped)
+			assert.Equal(t, uint64(len(tt.want)), broker.DroppedTotal())
+		})
+	}
+}
+
+func TestBroker_Unsubscribe(t *testing.T) {
+	_, err := NewBroker(BufferConfig{})
+	assert.Error(t, err)
+
+	broker, err := NewBroker(DefaultBufferConfig())
+	require.NoError(t, err)
+
+	sub, err := broker.Subscribe()
+	require.NoError(t, err)
+
+	broker.Unsubscribe(sub.ID)
+	broker.Unsubscribe(sub.ID) // unknown IDs are ignored
+	broker.Publish(newEvent(0))
+
+	assert.Empty(t, broker.Metrics())
+	assert.ErrorIs(t, sub.Push(newEvent(1)), ErrBufferClosed)
+}
@@ -0,0 +1,209 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RingBuffer is a bounded FIFO of events for a single consumer.
+// When it is full, the configured overflow policy decides whether the
+// oldest event is discarded or the producer waits for space.
+type RingBuffer struct {
+	mu     sync.Mutex
+	config BufferConfig
+	events []Event
+	head   int
+	count  int
+	closed bool
+
+	// ready and space wake consumers and blocked producers
+	ready chan struct{}
+	space chan struct{}
+
+	published uint64
+	delivered uint64
+	dropped   uint64
+}
+
+// NewRingBuffer creates a ring buffer with the given configuration.
+func NewRingBuffer(config BufferConfig) (*RingBuffer, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid buffer config: %w", err)
+	}
+
+	return &RingBuffer{
+		config: config,
+		events: make([]Event, config.Capacity),
+		ready:  make(chan struct{}, 1),
+		space:  make(chan struct{}, 1),
+	}, nil
+}
+
+// Push adds an event to the buffer according to the overflow policy.
+// Under block_with_timeout it returns ErrBufferFull if no space became
+// available in time; the event is then counted as dropped.
+func (b *RingBuffer) Push(event Event) error {
+	_, _, err := b.push(event)
+	return err
+}
+
+// push adds an event like Push and also returns the event the overflow
+// policy dropped, if any: the oldest one under drop_oldest, or event
+// itself when a blocked push times out.
+func (b *RingBuffer) push(event Event) (Event, bool, error) {
+	var timer *time.Timer
+
+	for {
+		b.mu.Lock()
+		if b.closed {
+			b.mu.Unlock()
+			return Event{}, false, ErrBufferClosed
+		}
+
+		if b.count < len(b.events) {
+			b.enqueueLocked(event)
+			b.mu.Unlock()
+			if timer != nil {
+				timer.Stop()
+			}
+			return Event{}, false, nil
+		}
+
+		if b.config.Policy == OverflowDropOldest {
+			oldest := b.events[b.head]
+			b.head = (b.head + 1) % len(b.events)
+			b.count--
+			b.dropped++
+			b.enqueueLocked(event)
+			b.mu.Unlock()
+			return oldest, true, nil
+		}
+		b.mu.Unlock()
+
+		if timer == nil {
+			timer = time.NewTimer(b.config.BlockTimeout)
+		}
+
+		select {
+		case <-b.space:
+		case <-timer.C:
+			b.mu.Lock()
+			b.dropped++
+			b.mu.Unlock()
+			return event, true, ErrBufferFull
+		}
+	}
+}
+
+// Pop removes and returns the oldest event, waiting until one is available.
+// It returns ErrBufferClosed once the buffer is closed and drained, or the
+// context error if ctx is done first.
+func (b *RingBuffer) Pop(ctx context.Context) (Event, error) {
+	for {
+		if event, ok, closed := b.tryPop(); ok {
+			return event, nil
+		} else if closed {
+			return Event{}, ErrBufferClosed
+		}
+
+		select {
+		case <-b.ready:
+		case <-ctx.Done():
+			return Event{}, ctx.Err()
+		}
+	}
+}
+
+// TryPop removes and returns the oldest event without waiting.
+// The boolean is false if the buffer is empty.
+func (b *RingBuffer) TryPop() (Event, bool) {
+	event, ok, _ := b.tryPop()
+	return event, ok
+}
+
+// Len returns the number of buffered events.
+func (b *RingBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.count
+}
+
+// Metrics returns a snapshot of the buffer counters.
+func (b *RingBuffer) Metrics() Metrics {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return Metrics{
+		Capacity:  len(b.events),
+		Buffered:  b.count,
+		Published: b.published,
+		Delivered: b.delivered,
+		Dropped:   b.dropped,
+	}
+}
+
+// Close stops the buffer from accepting events. Buffered events can still
+// be consumed, and blocked producers and consumers are released.
+func (b *RingBuffer) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	close(b.ready)
+	close(b.space)
+}
+
+// tryPop removes the oldest event if there is one. It also reports whether
+// the buffer is closed so Pop can stop waiting.
+func (b *RingBuffer) tryPop() (Event, bool, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.count == 0 {
+		return Event{}, false, b.closed
+	}
+
+	event := b.events[b.head]
+	b.events[b.head] = Event{}
+	b.head = (b.head + 1) % len(b.events)
+	b.count--
+	b.delivered++
+
+	if !b.closed {
+		notify(b.space)
+		if b.count > 0 {
+			// Pass the wake-up on so another waiting consumer is not stranded
+			notify(b.ready)
+		}
+	}
+
+	return event, true, b.closed
+}
+
+// enqueueLocked appends an event to the tail. The caller must hold b.mu and
+// ensure there is room.
+func (b *RingBuffer) enqueueLocked(event Event) {
+	tail := (b.head + b.count) % len(b.events)
+	b.events[tail] = event
+	b.count++
+	b.published++
+
+	notify(b.ready)
+	if b.count < len(b.events) {
+		// Pass the wake-up on so another blocked producer is not stranded
+		notify(b.space)
+	}
+}
+
+// notify signals ch without blocking if a signal is already pending.
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
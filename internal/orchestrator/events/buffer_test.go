@@ -0,0 +1,235 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEvent(i int) Event {
+	return Event{Type: fmt.Sprintf("event-%d", i), SessionID: "session-123"}
+}
+
+func TestBufferConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  BufferConfig
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name:   "default config",
+			config: DefaultBufferConfig(),
+		},
+		{
+			name:    "zero capacity",
+			config:  BufferConfig{Capacity: 0, Policy: OverflowDropOldest},
+			wantErr: true,
+			errMsg:  "capacity must be positive",
+		},
+		{
+			name:    "block without timeout",
+			config:  BufferConfig{Capacity: 1, Policy: OverflowBlockWithTimeout},
+			wantErr: true,
+			errMsg:  "block_timeout must be positive",
+		},
+		{
+			name:    "unknown policy",
+			config:  BufferConfig{Capacity: 1, Policy: "drop_newest"},
+			wantErr: true,
+			errMsg:  "unknown overflow policy: drop_newest",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_FIFO(t *testing.T) {
+	buffer, err := NewRingBuffer(BufferConfig{Capacity: 3, Policy: OverflowDropOldest})
+	require.NoError(t, err)
+
+	// Wrap around the backing array a few times
+	for i := 0; i < 7; i++ {
+		require.NoError(t, buffer.Push(newEvent(i)))
+		event, ok := buffer.TryPop()
+		require.True(t, ok)
+		assert.Equal(t, newEvent(i), event)
+	}
+
+	_, ok := buffer.TryPop()
+	assert.False(t, ok)
+}
+
+func TestRingBuffer_DropOldest(t *testing.T) {
+	buffer, err := NewRingBuffer(BufferConfig{Capacity: 4, Policy: OverflowDropOldest})
+	require.NoError(t, err)
+
+	// Produce far faster than anything drains
+	for i := 0; i < 10; i++ {
+		require.NoError(t, buffer.Push(newEvent(i)))
+	}
+
+	metrics := buffer.Metrics()
+	assert.Equal(t, 4, metrics.Capacity)
+	assert.Equal(t, 4, metrics.Buffered)
+	assert.Equal(t, uint64(6), metrics.Dropped)
+
+	// Only the newest events survive, in order
+	for i := 6; i < 10; i++ {
+		event, ok := buffer.TryPop()
+		require.True(t, ok)
+		assert.Equal(t, newEvent(i), event)
+	}
+	assert.Equal(t, uint64(4), buffer.Metrics().Delivered)
+}
+
+func TestRingBuffer_BlockWithTimeout(t *testing.T) {
+	t.Run("drops after timeout", func(t *testing.T) {
+		buffer, err := NewRingBuffer(BufferConfig{
+			Capacity:     2,
+			Policy:       OverflowBlockWithTimeout,
+			BlockTimeout: 20 * time.Millisecond,
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, buffer.Push(newEvent(0)))
+		require.NoError(t, buffer.Push(newEvent(1)))
+
+		start := time.Now()
+		err = buffer.Push(newEvent(2))
+		assert.ErrorIs(t, err, ErrBufferFull)
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+		// The buffered events are untouched
+		metrics := buffer.Metrics()
+		assert.Equal(t, 2, metrics.Buffered)
+		assert.Equal(t, uint64(1), metrics.Dropped)
+		event, _ := buffer.TryPop()
+		assert.Equal(t, newEvent(0), event)
+	})
+
+	t.Run("slow consumer loses nothing", func(t *testing.T) {
+		buffer, err := NewRingBuffer(BufferConfig{
+			Capacity:     2,
+			Policy:       OverflowBlockWithTimeout,
+			BlockTimeout: time.Second,
+		})
+		require.NoError(t, err)
+
+		const total = 20
+		received := make([]Event, 0, total)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for len(received) < total {
+				event, err := buffer.Pop(context.Background())
+				if err != nil {
+					return
+				}
+				received = append(received, event)
+				time.Sleep(time.Millisecond)
+			}
+		}()
+
+		for i := 0; i < total; i++ {
+			require.NoError(t, buffer.Push(newEvent(i)))
+		}
+		<-done
+
+		require.Len(t, received, total)
+		for i, event := range received {
+			assert.Equal(t, newEvent(i), event)
+		}
+		assert.Equal(t, uint64(0), buffer.Metrics().Dropped)
+	})
+
+	t.Run("concurrent producers", func(t *testing.T) {
+		buffer, err := NewRingBuffer(BufferConfig{
+			Capacity:     1,
+			Policy:       OverflowBlockWithTimeout,
+			BlockTimeout: time.Second,
+		})
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				assert.NoError(t, buffer.Push(newEvent(i)))
+			}(i)
+		}
+
+		for i := 0; i < 5; i++ {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			_, err := buffer.Pop(ctx)
+			cancel()
+			require.NoError(t, err)
+		}
+		wg.Wait()
+
+		assert.Equal(t, uint64(5), buffer.Metrics().Delivered)
+	})
+}
+
+func TestRingBuffer_PopContext(t *testing.T) {
+	buffer, err := NewRingBuffer(DefaultBufferConfig())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = buffer.Pop(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRingBuffer_Close(t *testing.T) {
+	buffer, err := NewRingBuffer(BufferConfig{
+		Capacity:     1,
+		Policy:       OverflowBlockWithTimeout,
+		BlockTimeout: time.Minute,
+	})
+	require.NoError(t, err)
+	require.NoError(t, buffer.Push(newEvent(0)))
+
+	// A producer blocked on a full buffer is released by Close
+	blocked := make(chan error, 1)
+	go func() {
+		blocked <- buffer.Push(newEvent(1))
+	}()
+	time.Sleep(10 * time.Millisecond)
+	buffer.Close()
+
+	select {
+	case err := <-blocked:
+		assert.ErrorIs(t, err, ErrBufferClosed)
+	case <-time.After(time.Second):
+		t.Fatal("producer was not released by Close")
+	}
+
+	// Buffered events are still delivered, then the buffer reports closed
+	event, err := buffer.Pop(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, newEvent(0), event)
+
+	_, err = buffer.Pop(context.Background())
+	assert.ErrorIs(t, err, ErrBufferClosed)
+	assert.ErrorIs(t, buffer.Push(newEvent(2)), ErrBufferClosed)
+
+	buffer.Close() // idempotent
+}
@@ -0,0 +1,281 @@
+package events
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Codec serializes the Data of an Event for storage. Data must be JSON-like:
+// codecs may decode numbers as float64 and slices as []interface{}.
+type Codec interface {
+	// Name identifies the codec in configuration
+	Name() string
+
+	// Encode serializes event data
+	Encode(data map[string]interface{}) ([]byte, error)
+
+	// Decode restores event data serialized by Encode
+	Decode(raw []byte) (map[string]interface{}, error)
+}
+
+const (
+	// CodecJSON is the name of the JSON codec
+	CodecJSON = "json"
+
+	// CodecCompact is the name of the compact binary codec
+	CodecCompact = "compact"
+)
+
+// DefaultCodec is the codec used when none is configured.
+var DefaultCodec Codec = JSONCodec{}
+
+// CodecByName returns the codec registered under name. An empty name returns
+// DefaultCodec.
+func CodecByName(name string) (Codec, error) {
+	switch name {
+	case "":
+		return DefaultCodec, nil
+	case CodecJSON:
+		return JSONCodec{}, nil
+	case CodecCompact:
+		return CompactCodec{}, nil
+	default:
+		return nil, fmt.Errorf("unknown event codec: %s", name)
+	}
+}
+
+// JSONCodec encodes event data as JSON, which the session_events table
+// stores natively.
+type JSONCodec struct{}
+
+// Name implements Codec.
+func (JSONCodec) Name() string { return CodecJSON }
+
+// Encode implements Codec.
+func (JSONCodec) Encode(data map[string]interface{}) ([]byte, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event data: %w", err)
+	}
+	return raw, nil
+}
+
+// Decode implements Codec.
+func (JSONCodec) Decode(raw []byte) (map[string]interface{}, error) {
+	var data map[string]interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("failed to decode event data: %w", err)
+	}
+	return data, nil
+}
+
+// CompactCodec encodes event data in a tagged binary form: lengths and
+// integral numbers are varints and no punctuation is stored, so it is
+// smaller than JSON for high-volume recording. Values that are not
+// JSON-like are normalized through JSON first, and numbers decode as
+// float64 as they do from JSON.
+type CompactCodec struct{}
+
+// Value tags of the compact encoding.
+const (
+	compactNull byte = iota
+	compactFalse
+	compactTrue
+	compactInt
+	compactFloat
+	compactString
+	compactList
+	compactMap
+)
+
+// Name implements Codec.
+func (CompactCodec) Name() string { return CodecCompact }
+
+// Encode implements Codec.
+func (CompactCodec) Encode(data map[string]interface{}) ([]byte, error) {
+	if data == nil {
+		return []byte{compactNull}, nil
+	}
+
+	buf, err := appendCompact(nil, data)
+	if err != nil {
+		// Normalize values such as typed slices or structs through JSON
+		raw, jsonErr := JSONCodec{}.Encode(data)
+		if jsonErr != nil {
+			return nil, jsonErr
+		}
+		normalized, jsonErr := JSONCodec{}.Decode(raw)
+		if jsonErr != nil {
+			return nil, jsonErr
+		}
+		if buf, err = appendCompact(nil, normalized); err != nil {
+			return nil, fmt.Errorf("failed to encode event data: %w", err)
+		}
+	}
+	return buf, nil
+}
+
+// Decode implements Codec.
+func (CompactCodec) Decode(raw []byte) (map[string]interface{}, error) {
+	value, rest, err := readCompact(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode event data: %w", err)
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("failed to decode event data: %d trailing bytes", len(rest))
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("failed to decode event data: not an object")
+	}
+}
+
+// maxCompactInt bounds the numbers stored as varints, keeping them exactly
+// representable as float64.
+const maxCompactInt = 1 << 53
+
+func appendCompact(buf []byte, value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return append(buf, compactNull), nil
+	case bool:
+		if v {
+			return append(buf, compactTrue), nil
+		}
+		return append(buf, compactFalse), nil
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) <= maxCompactInt && !(v == 0 && math.Signbit(v)) {
+			buf = append(buf, compactInt)
+			return binary.AppendVarint(buf, int64(v)), nil
+		}
+		buf = append(buf, compactFloat)
+		return binary.BigEndian.AppendUint64(buf, math.Float64bits(v)), nil
+	case string:
+		buf = append(buf, compactString)
+		buf = binary.AppendUvarint(buf, uint64(len(v)))
+		return append(buf, v...), nil
+	case []interface{}:
+		buf = append(buf, compactList)
+		buf = binary.AppendUvarint(buf, uint64(len(v)))
+		for _, item := range v {
+			var err error
+			if buf, err = appendCompact(buf, item); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case map[string]interface{}:
+		// Sort keys so equal data always encodes to equal bytes
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		buf = append(buf, compactMap)
+		buf = binary.AppendUvarint(buf, uint64(len(v)))
+		for _, key := range keys {
+			buf = binary.AppendUvarint(buf, uint64(len(key)))
+			buf = append(buf, key...)
+			var err error
+			if buf, err = appendCompact(buf, v[key]); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", value)
+	}
+}
+
+func readCompact(raw []byte) (interface{}, []byte, error) {
+	if len(raw) == 0 {
+		return nil, nil, fmt.Errorf("unexpected end of data")
+	}
+
+	tag, raw := raw[0], raw[1:]
+	switch tag {
+	case compactNull:
+		return nil, raw, nil
+	case compactFalse:
+		return false, raw, nil
+	case compactTrue:
+		return true, raw, nil
+	case compactInt:
+		n, size := binary.Varint(raw)
+		if size <= 0 {
+			return nil, nil, fmt.Errorf("invalid number")
+		}
+		return float64(n), raw[size:], nil
+	case compactFloat:
+		if len(raw) < 8 {
+			return nil, nil, fmt.Errorf("unexpected end of data")
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(raw)), raw[8:], nil
+	case compactString:
+		return readCompactString(raw)
+	case compactList:
+		n, raw, err := readCompactLength(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		list := make([]interface{}, 0, n)
+		for i := 0; i < n; i++ {
+			var item interface{}
+			if item, raw, err = readCompact(raw); err != nil {
+				return nil, nil, err
+			}
+			list = append(list, item)
+		}
+		return list, raw, nil
+	case compactMap:
+		n, raw, err := readCompactLength(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		m := make(map[string]interface{}, n)
+		for i := 0; i < n; i++ {
+			var key, value interface{}
+			if key, raw, err = readCompactString(raw); err != nil {
+				return nil, nil, err
+			}
+			if value, raw, err = readCompact(raw); err != nil {
+				return nil, nil, err
+			}
+			m[key.(string)] = value
+		}
+		return m, raw, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown value tag %d", tag)
+	}
+}
+
+// readCompactLength reads a length prefix, rejecting lengths longer than
+// the remaining data so corrupt input cannot force a huge allocation.
+func readCompactLength(raw []byte) (int, []byte, error) {
+	n, size := binary.Uvarint(raw)
+	if size <= 0 {
+		return 0, nil, fmt.Errorf("invalid length")
+	}
+	raw = raw[size:]
+	if n > uint64(len(raw)) {
+		return 0, nil, fmt.Errorf("unexpected end of data")
+	}
+	return int(n), raw, nil
+}
+
+func readCompactString(raw []byte) (interface{}, []byte, error) {
+	n, raw, err := readCompactLength(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	return string(raw[:n]), raw[n:], nil
+}
@@ -0,0 +1,111 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func complexEventData() map[string]interface{} {
+	return map[string]interface{}{
+		"file":      "pkg/util.go",
+		"processed": float64(42),
+		"ratio":     0.75,
+		"retrying":  false,
+		"error":     nil,
+		"languages": []interface{}{"go", "python"},
+		"progress": map[string]interface{}{
+			"total":  float64(10),
+			"failed": []interface{}{"a.go", map[string]interface{}{"path": "b.go", "attempts": float64(3)}},
+		},
+	}
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	codecs := []Codec{JSONCodec{}, CompactCodec{}}
+
+	for _, codec := range codecs {
+		t.Run(codec.Name(), func(t *testing.T) {
+			raw, err := codec.Encode(complexEventData())
+			require.NoError(t, err)
+
+			data, err := codec.Decode(raw)
+			require.NoError(t, err)
+			assert.Equal(t, complexEventData(), data)
+
+			raw, err = codec.Encode(nil)
+			require.NoError(t, err)
+			data, err = codec.Decode(raw)
+			require.NoError(t, err)
+			assert.Nil(t, data)
+		})
+	}
+}
+
+func TestCodecNormalizesGoValues(t *testing.T) {
+	// Typed values come back in their JSON form from every codec
+	data := map[string]interface{}{
+		"count": 3,
+		"files": []string{"a.go", "b.go"},
+		"item":  struct{ Path string }{Path: "c.go"},
+	}
+	want := map[string]interface{}{
+		"count": float64(3),
+		"files": []interface{}{"a.go", "b.go"},
+		"item":  map[string]interface{}{"Path": "c.go"},
+	}
+
+	for _, codec := range []Codec{JSONCodec{}, CompactCodec{}} {
+		t.Run(codec.Name(), func(t *testing.T) {
+			raw, err := codec.Encode(data)
+			require.NoError(t, err)
+
+			decoded, err := codec.Decode(raw)
+			require.NoError(t, err)
+			assert.Equal(t, want, decoded)
+		})
+	}
+}
+
+func TestCompactCodecIsSmaller(t *testing.T) {
+	jsonRaw, err := JSONCodec{}.Encode(complexEventData())
+	require.NoError(t, err)
+	protoRaw, err := CompactCodec{}.Encode(complexEventData())
+	require.NoError(t, err)
+
+	assert.Less(t, len(protoRaw), len(jsonRaw))
+}
+
+func TestCodecDecodeInvalid(t *testing.T) {
+	_, err := JSONCodec{}.Decode([]byte("{"))
+	assert.Error(t, err)
+
+	_, err = CompactCodec{}.Decode([]byte{0xff, 0xff})
+	assert.Error(t, err)
+}
+
+func TestCodecByName(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "", want: CodecJSON},
+		{name: CodecJSON, want: CodecJSON},
+		{name: CodecCompact, want: CodecCompact},
+		{name: "gob", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec, err := CodecByName(tt.name)
+			if tt.wantErr {
+				assert.EqualError(t, err, "unknown event codec: gob")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, codec.Name())
+		})
+	}
+}
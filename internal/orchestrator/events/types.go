@@ -0,0 +1,114 @@
+// Package events delivers orchestrator events to subscribers.
+// Each subscriber owns a bounded buffer so that a slow consumer cannot grow
+// memory without limit or stall producers indefinitely.
+package events
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Event is a notification emitted by the orchestrator.
+type Event struct {
+	// Type identifies the kind of event
+	Type string `json:"type"`
+
+	// SessionID is the session the event belongs to
+	SessionID string `json:"session_id"`
+
+	// Timestamp is when the event was produced
+	Timestamp time.Time `json:"timestamp"`
+
+	// Data contains event specific fields
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+// OverflowPolicy controls what a buffer does when it is full.
+type OverflowPolicy string
+
+const (
+	// OverflowDropOldest discards the oldest buffered event to make room
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+
+	// OverflowBlockWithTimeout blocks the producer until space frees up or
+	// the timeout elapses, then drops the new event
+	OverflowBlockWithTimeout OverflowPolicy = "block_with_timeout"
+)
+
+const (
+	// DefaultCapacity is the buffer capacity used when none is configured
+	DefaultCapacity = 256
+
+	// DefaultBlockTimeout is the producer timeout used when none is configured
+	DefaultBlockTimeout = 100 * time.Millisecond
+)
+
+var (
+	// ErrBufferFull is returned when an event is dropped because the
+	// buffer stayed full for the whole block timeout.
+	ErrBufferFull = errors.New("event buffer full")
+
+	// ErrBufferClosed is returned when pushing to or popping from a closed
+	// buffer.
+	ErrBufferClosed = errors.New("event buffer closed")
+)
+
+// BufferConfig contains the settings of a subscriber buffer.
+type BufferConfig struct {
+	// Capacity is the maximum number of buffered events
+	Capacity int `json:"capacity"`
+
+	// Policy is the overflow policy applied when the buffer is full
+	Policy OverflowPolicy `json:"policy"`
+
+	// BlockTimeout is how long a producer waits for space under the
+	// block_with_timeout policy
+	BlockTimeout time.Duration `json:"block_timeout"`
+}
+
+// DefaultBufferConfig returns the default buffer configuration.
+func DefaultBufferConfig() BufferConfig {
+	return BufferConfig{
+		Capacity:     DefaultCapacity,
+		Policy:       OverflowDropOldest,
+		BlockTimeout: DefaultBlockTimeout,
+	}
+}
+
+// Validate checks the buffer configuration.
+func (c BufferConfig) Validate() error {
+	if c.Capacity <= 0 {
+		return fmt.Errorf("capacity must be positive")
+	}
+
+	switch c.Policy {
+	case OverflowDropOldest:
+	case OverflowBlockWithTimeout:
+		if c.BlockTimeout <= 0 {
+			return fmt.Errorf("block_timeout must be positive for policy %s", c.Policy)
+		}
+	default:
+		return fmt.Errorf("unknown overflow policy: %s", c.Policy)
+	}
+
+	return nil
+}
+
+// Metrics is a snapshot of buffer counters.
+type Metrics struct {
+	// Capacity is the maximum number of buffered events
+	Capacity int `json:"capacity"`
+
+	// Buffered is the number of events waiting to be consumed
+	Buffered int `json:"buffered"`
+
+	// Published is the number of events accepted into the buffer
+	Published uint64 `json:"published"`
+
+	// Delivered is the number of events handed to the consumer
+	Delivered uint64 `json:"delivered"`
+
+	// Dropped is the number of events lost to the overflow policy
+	Dropped uint64 `json:"dropped"`
+}
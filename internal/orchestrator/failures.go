@@ -0,0 +1,84 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/session"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/todolist"
+)
+
+// ErrConsecutiveFailures is returned by ProcessNextFile when a file failure
+// reaches Workflow.MaxConsecutiveFailures failures in a row. The session has
+// been moved to the failed state; the error also wraps the
+// FileProcessingError.
+var ErrConsecutiveFailures = errors.New("session failed after consecutive file failures")
+
+// failureRun is a session's current run of consecutive file failures.
+type failureRun struct {
+	count   int
+	started time.Time
+}
+
+// recordFileFailure counts a file failure of a session and returns the
+// length of its current run of failures and whether that reaches the
+// configured threshold. A failure outside the configured window of the
+// run's first failure starts a new run.
+func (o *OrchestratorImpl) recordFileFailure(sessionID string) (int, bool) {
+	var threshold int
+	var window time.Duration
+	if o.config != nil {
+		threshold = o.config.Workflow.MaxConsecutiveFailures
+		window = o.config.Workflow.ConsecutiveFailureWindow
+	}
+	if threshold <= 0 {
+		return 0, false
+	}
+
+	o.failuresMu.Lock()
+	defer o.failuresMu.Unlock()
+
+	if o.failureRuns == nil {
+		o.failureRuns = make(map[string]failureRun)
+	}
+	now := time.Now()
+	run := o.failureRuns[sessionID]
+	if run.count == 0 || window > 0 && now.Sub(run.started) > window {
+		run = failureRun{started: now}
+	}
+	run.count++
+	o.failureRuns[sessionID] = run
+	return run.count, run.count >= threshold
+}
+
+// resetFileFailures ends a session's run of consecutive file failures.
+func (o *OrchestratorImpl) resetFileFailures(sessionID string) {
+	o.failuresMu.Lock()
+	defer o.failuresMu.Unlock()
+	delete(o.failureRuns, sessionID)
+}
+
+// failConsecutive fails a session whose latest file failure, fileErr, made
+// failures files fail in a row.
+func (o *OrchestratorImpl) failConsecutive(ctx context.Context, sess *DocumentationSession, fileErr *FileProcessingError, failures int) error {
+	LoggerFromContext(ctx).Error().
+		Err(fileErr.Err).
+		Str("file", fileErr.FilePath).
+		Int("consecutive_failures", failures).
+		Msg("Too many consecutive file failures, failing session")
+
+	o.resetFileFailures(sess.ID)
+	note := session.SessionNote{
+		FilePath: fileErr.FilePath,
+		Status:   string(todolist.ItemStatusFailed),
+		Severity: session.NoteSeverityError,
+		Source:   NoteSourceProcessor,
+		Message:  fmt.Sprintf("%d consecutive files failed", failures),
+	}
+	if err := o.failSession(ctx, sess, note); err != nil {
+		return errors.Join(fileErr, err)
+	}
+	return fmt.Errorf("%w: %w", ErrConsecutiveFailures, fileErr)
+}
@@ -0,0 +1,118 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/services"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/session"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/todolist"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsecutiveFailures(t *testing.T) {
+	ctx := context.Background()
+
+	// setup queues one file per outcome, in order; "bad" files fail
+	setup := func(t *testing.T, outcomes ...string) (*OrchestratorImpl, *session.Session, string) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		o.config.Workflow.MaxConsecutiveFailures = 3
+		sessionID := "550e8400-e29b-41d4-a716-446655440910"
+		sess := newStatefulSession(t, o, mockSession, sessionID)
+
+		for i, outcome := range outcomes {
+			require.NoError(t, o.todoManager.AddItem(ctx, sessionID, todolist.TodoItem{
+				FilePath: fmt.Sprintf("/project/%02d_%s.go", i, outcome),
+				Priority: len(outcomes) - i,
+			}))
+		}
+		require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &stubAIService{
+			analyzeFunc: func(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error) {
+				if strings.HasSuffix(req.FilePath, "_bad.go") {
+					return nil, errors.New("model refused")
+				}
+				return &services.FileAnalysisResponse{Summary: "ok"}, nil
+			},
+		}))
+		return o, sess, sessionID
+	}
+
+	t.Run("scattered failures keep the session processing", func(t *testing.T) {
+		o, sess, sessionID := setup(t, "bad", "bad", "ok", "bad", "bad", "ok", "bad", "bad")
+
+		results, err := o.ProcessFiles(ctx, sessionID, 1)
+		var fileErr *FileProcessingError
+		require.ErrorAs(t, err, &fileErr)
+		assert.NotErrorIs(t, err, ErrConsecutiveFailures)
+		assert.Len(t, results, 2)
+
+		assert.Equal(t, session.StatusInProgress, sess.Status)
+		state, err := o.workflowEngine.GetState(ctx, sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, workflow.WorkflowStateProcessing, state)
+		progress, err := o.todoManager.GetProgress(ctx, sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, 6, progress.Failed)
+		assert.Equal(t, 2, progress.Complete)
+	})
+
+	t.Run("a run of failures past the threshold fails the session", func(t *testing.T) {
+		o, sess, sessionID := setup(t, "ok", "bad", "bad", "bad", "ok")
+
+		results, err := o.ProcessFiles(ctx, sessionID, 1)
+		require.ErrorIs(t, err, ErrConsecutiveFailures)
+		assert.Len(t, results, 1)
+
+		assert.Equal(t, session.StatusFailed, sess.Status)
+		state, err := o.workflowEngine.GetState(ctx, sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, workflow.WorkflowStateFailed, state)
+
+		// The last note explains why the session failed
+		require.NotEmpty(t, sess.Notes)
+		last := sess.Notes[len(sess.Notes)-1]
+		assert.Equal(t, session.NoteSeverityError, last.Severity)
+		assert.Equal(t, "/project/03_bad.go", last.FilePath)
+		assert.Equal(t, "3 consecutive files failed", last.Message)
+
+		progress, err := o.todoManager.GetProgress(ctx, sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, progress.Pending, "files after the run are not processed")
+	})
+
+	t.Run("failures outside the window start a new run", func(t *testing.T) {
+		o, sess, sessionID := setup(t, "bad", "bad", "bad")
+		o.config.Workflow.ConsecutiveFailureWindow = time.Minute
+
+		for i := 0; i < 2; i++ {
+			_, err := o.ProcessNextFile(ctx, sessionID)
+			require.Error(t, err)
+		}
+		// Move the run's first failure out of the window
+		o.failuresMu.Lock()
+		run := o.failureRuns[sessionID]
+		run.started = run.started.Add(-2 * time.Minute)
+		o.failureRuns[sessionID] = run
+		o.failuresMu.Unlock()
+
+		_, err := o.ProcessNextFile(ctx, sessionID)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrConsecutiveFailures)
+		assert.Equal(t, session.StatusInProgress, sess.Status)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		o, sess, sessionID := setup(t, "bad", "bad", "bad", "bad")
+		o.config.Workflow.MaxConsecutiveFailures = 0
+
+		_, err := o.ProcessFiles(ctx, sessionID, 1)
+		assert.NotErrorIs(t, err, ErrConsecutiveFailures)
+		assert.Equal(t, session.StatusInProgress, sess.Status)
+	})
+}
@@ -0,0 +1,101 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/services"
+)
+
+// Prober reports whether a process is alive and ready for traffic, as
+// Kubernetes liveness and readiness probes expect.
+type Prober interface {
+	// Liveness fails only if the process itself is broken and should be
+	// restarted
+	Liveness() error
+
+	// Readiness fails while dependencies are unreachable and the process
+	// should not receive requests
+	Readiness(ctx context.Context) error
+}
+
+// Liveness reports whether the orchestrator process is alive. It does no
+// I/O, so an unreachable database or AI provider never fails it; those are
+// reported by Readiness instead.
+func (o *OrchestratorImpl) Liveness() error {
+	return nil
+}
+
+// Readiness reports whether the orchestrator can serve requests: it is not
+// shutting down, the database answers a ping and the configured AI provider
+// is registered. Registered services implementing services.Pinger are
+// pinged too. Every failed check is included in the returned error.
+func (o *OrchestratorImpl) Readiness(ctx context.Context) error {
+	o.lifecycleMu.RLock()
+	shuttingDown := o.shuttingDown
+	o.lifecycleMu.RUnlock()
+	if shuttingDown {
+		return ErrShuttingDown
+	}
+
+	var errs []error
+	if o.db == nil {
+		errs = append(errs, fmt.Errorf("database is not connected"))
+	} else if err := o.db.PingContext(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("database is unreachable: %w", err))
+	}
+
+	provider := o.aiProviderName()
+	if ai, err := o.serviceRegistry.GetAIService(provider); err != nil {
+		errs = append(errs, fmt.Errorf("AI provider %s is not available: %w", provider, err))
+	} else {
+		errs = append(errs, ping(ctx, "AI provider "+provider, ai))
+	}
+	if fs, err := o.serviceRegistry.GetFileSystem(); err == nil {
+		errs = append(errs, ping(ctx, "file system", fs))
+	}
+	if memory, err := o.serviceRegistry.GetMemoryService(); err == nil {
+		errs = append(errs, ping(ctx, "memory service", memory))
+	}
+
+	return errors.Join(errs...)
+}
+
+// ping pings service if it implements services.Pinger.
+func ping(ctx context.Context, name string, service interface{}) error {
+	pinger, ok := service.(services.Pinger)
+	if !ok {
+		return nil
+	}
+	if err := pinger.Ping(ctx); err != nil {
+		return fmt.Errorf("%s is unreachable: %w", name, err)
+	}
+	return nil
+}
+
+// ProbeHandler serves the liveness probe at /healthz and the readiness probe
+// at /readyz. Each responds 200 when its check passes and 503 with the error
+// otherwise.
+func ProbeHandler(p Prober) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeProbe(w, p.Liveness())
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		writeProbe(w, p.Readiness(r.Context()))
+	})
+	return mux
+}
+
+// writeProbe writes a probe result as a plain text response.
+func writeProbe(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(w, err)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
@@ -0,0 +1,138 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pingingAIService is an AI service whose backend can be made unreachable.
+type pingingAIService struct {
+	stubAIService
+	pingErr error
+}
+
+func (s *pingingAIService) Ping(ctx context.Context) error {
+	return s.pingErr
+}
+
+// probeOrchestrator returns an orchestrator with a mock database whose pings
+// are expected to fail with pingErr.
+func probeOrchestrator(t *testing.T, pingErr error) (*OrchestratorImpl, sqlmock.Sqlmock) {
+	t.Helper()
+	o, _, _, _ := createTestOrchestrator(t)
+
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	mock.ExpectPing().WillReturnError(pingErr)
+	o.db = db
+	return o, mock
+}
+
+// unreachableDatabaseConfig returns a default config whose database refuses
+// connections, for building an orchestrator through NewOrchestrator.
+func unreachableDatabaseConfig(t *testing.T) *Config {
+	t.Helper()
+
+	// Reserve a port, then free it so connections to it are refused
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+
+	config := DefaultConfig()
+	config.Database.Host = "127.0.0.1"
+	config.Database.Port = port
+	config.Database.Password = "secret"
+	return config
+}
+
+// probe requests path from the orchestrator's probe handler.
+func probe(o *OrchestratorImpl, path string) (int, string) {
+	rec := httptest.NewRecorder()
+	ProbeHandler(o).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec.Code, rec.Body.String()
+}
+
+func TestLivenessAndReadiness(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("both succeed when healthy", func(t *testing.T) {
+		o, mock := probeOrchestrator(t, nil)
+		require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &pingingAIService{}))
+
+		assert.NoError(t, o.Liveness())
+		assert.NoError(t, o.Readiness(ctx))
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		mock.ExpectPing()
+		code, body := probe(o, "/healthz")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "ok\n", body)
+		code, body = probe(o, "/readyz")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "ok\n", body)
+	})
+
+	t.Run("database down fails readiness only", func(t *testing.T) {
+		o, mock := probeOrchestrator(t, errors.New("connection refused"))
+		require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &pingingAIService{}))
+
+		assert.NoError(t, o.Liveness())
+		err := o.Readiness(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "database is unreachable: connection refused")
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+		code, _ := probe(o, "/healthz")
+		assert.Equal(t, http.StatusOK, code)
+		code, body := probe(o, "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Contains(t, body, "database is unreachable")
+	})
+
+	t.Run("database down at boot fails readiness only", func(t *testing.T) {
+		o, err := NewOrchestrator(unreachableDatabaseConfig(t))
+		require.NoError(t, err)
+		t.Cleanup(func() { o.Shutdown(context.Background()) })
+		require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &pingingAIService{}))
+
+		assert.NoError(t, o.Liveness())
+		err = o.Readiness(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "database is unreachable")
+	})
+
+	t.Run("unavailable services fail readiness", func(t *testing.T) {
+		o, _ := probeOrchestrator(t, nil)
+
+		err := o.Readiness(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "AI provider openai is not available")
+
+		o, _ = probeOrchestrator(t, nil)
+		require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &pingingAIService{pingErr: errors.New("401 unauthorized")}))
+		err = o.Readiness(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "AI provider openai is unreachable: 401 unauthorized")
+		assert.NoError(t, o.Liveness())
+	})
+
+	t.Run("shutting down is not ready", func(t *testing.T) {
+		o, _ := probeOrchestrator(t, nil)
+		require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &pingingAIService{}))
+		o.shuttingDown = true
+
+		assert.ErrorIs(t, o.Readiness(ctx), ErrShuttingDown)
+		assert.NoError(t, o.Liveness())
+	})
+}
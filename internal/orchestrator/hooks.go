@@ -0,0 +1,83 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+)
+
+// ContentProcessor transforms a file's content after it is read and before
+// it is sent for analysis, for example to redact secrets.
+type ContentProcessor func(ctx context.Context, filePath string, content []byte) ([]byte, error)
+
+// FileProcessor transforms a file's analysis before it is stored, for
+// example to post-process the generated documentation.
+type FileProcessor func(ctx context.Context, analysis *FileAnalysis) (*FileAnalysis, error)
+
+// ProcessorError reports that a registered processor rejected a file. The
+// file is marked failed without being retried.
+type ProcessorError struct {
+	FilePath string
+	Err      error
+}
+
+func (e *ProcessorError) Error() string {
+	return fmt.Sprintf("processor failed for %s: %v", e.FilePath, e.Err)
+}
+
+func (e *ProcessorError) Unwrap() error {
+	return e.Err
+}
+
+// RegisterContentProcessor adds a processor run on each file's content
+// before analysis. Processors run in registration order, each receiving the
+// previous one's output.
+func (o *OrchestratorImpl) RegisterContentProcessor(p ContentProcessor) {
+	o.hooksMu.Lock()
+	defer o.hooksMu.Unlock()
+	o.contentProcessors = append(o.contentProcessors, p)
+}
+
+// RegisterFileProcessor adds a processor run on each file's analysis.
+// Processors run in registration order, each receiving the previous one's
+// output.
+func (o *OrchestratorImpl) RegisterFileProcessor(p FileProcessor) {
+	o.hooksMu.Lock()
+	defer o.hooksMu.Unlock()
+	o.fileProcessors = append(o.fileProcessors, p)
+}
+
+// processContent runs the registered content processors over content.
+func (o *OrchestratorImpl) processContent(ctx context.Context, filePath string, content []byte) ([]byte, error) {
+	o.hooksMu.RLock()
+	processors := o.contentProcessors
+	o.hooksMu.RUnlock()
+
+	for _, process := range processors {
+		var err error
+		content, err = process(ctx, filePath, content)
+		if err != nil {
+			return nil, &ProcessorError{FilePath: filePath, Err: err}
+		}
+	}
+	return content, nil
+}
+
+// processAnalysis runs the registered file processors over analysis.
+func (o *OrchestratorImpl) processAnalysis(ctx context.Context, analysis *FileAnalysis) (*FileAnalysis, error) {
+	o.hooksMu.RLock()
+	processors := o.fileProcessors
+	o.hooksMu.RUnlock()
+
+	filePath := analysis.FilePath
+	for _, process := range processors {
+		var err error
+		analysis, err = process(ctx, analysis)
+		if err != nil {
+			return nil, &ProcessorError{FilePath: filePath, Err: err}
+		}
+		if analysis == nil {
+			return nil, &ProcessorError{FilePath: filePath, Err: fmt.Errorf("file processor returned no analysis")}
+		}
+	}
+	return analysis, nil
+}
@@ -0,0 +1,108 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/services"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/todolist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileProcessors(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (*OrchestratorImpl, string, map[string]string) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		sessionID := "550e8400-e29b-41d4-a716-446655440910"
+		newStatefulSession(t, o, mockSession, sessionID)
+
+		require.NoError(t, o.serviceRegistry.RegisterFileSystem(&fakeFileSystem{files: map[string][]byte{
+			"/project/config.go": []byte(`const apiKey = "SECRET"`),
+			"/project/bad.go":    []byte("package bad"),
+			"/project/main.go":   []byte("package main"),
+		}}))
+		for _, item := range []todolist.TodoItem{
+			{FilePath: "/project/config.go", Priority: 3},
+			{FilePath: "/project/bad.go", Priority: 2},
+			{FilePath: "/project/main.go", Priority: 1},
+		} {
+			require.NoError(t, o.todoManager.AddItem(ctx, sessionID, item))
+		}
+
+		sent := make(map[string]string)
+		require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &stubAIService{
+			analyzeFunc: func(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error) {
+				sent[req.FilePath] = req.Content
+				return &services.FileAnalysisResponse{Summary: "summary of " + req.FilePath}, nil
+			},
+		}))
+		return o, sessionID, sent
+	}
+
+	t.Run("processors run in registration order", func(t *testing.T) {
+		o, sessionID, sent := setup(t)
+		o.RegisterContentProcessor(func(ctx context.Context, filePath string, content []byte) ([]byte, error) {
+			return bytes.ReplaceAll(content, []byte("SECRET"), []byte("[redacted]")), nil
+		})
+		o.RegisterContentProcessor(func(ctx context.Context, filePath string, content []byte) ([]byte, error) {
+			return append([]byte("// checked\n"), content...), nil
+		})
+		var order []string
+		o.RegisterFileProcessor(func(ctx context.Context, analysis *FileAnalysis) (*FileAnalysis, error) {
+			order = append(order, "first")
+			analysis.Content += "\n\nReviewed."
+			return analysis, nil
+		})
+		o.RegisterFileProcessor(func(ctx context.Context, analysis *FileAnalysis) (*FileAnalysis, error) {
+			order = append(order, "second")
+			assert.Contains(t, analysis.Content, "Reviewed.")
+			return analysis, nil
+		})
+
+		analysis, err := o.ProcessNextFile(ctx, sessionID)
+		require.NoError(t, err)
+
+		assert.Equal(t, "// checked\nconst apiKey = \"[redacted]\"", sent["/project/config.go"])
+		assert.Equal(t, "summary of /project/config.go\n\nReviewed.", analysis.Content)
+		assert.Equal(t, []string{"first", "second"}, order)
+		// Change detection sees the file as stored
+		assert.Equal(t, contentHash([]byte(`const apiKey = "SECRET"`)), analysis.ContentHash)
+	})
+
+	t.Run("a failing processor fails just that file", func(t *testing.T) {
+		o, sessionID, sent := setup(t)
+		rejected := errors.New("forbidden package")
+		o.RegisterContentProcessor(func(ctx context.Context, filePath string, content []byte) ([]byte, error) {
+			if filePath == "/project/bad.go" {
+				return nil, rejected
+			}
+			return content, nil
+		})
+		o.RegisterFileProcessor(func(ctx context.Context, analysis *FileAnalysis) (*FileAnalysis, error) {
+			if analysis.FilePath == "/project/main.go" {
+				return nil, rejected
+			}
+			return analysis, nil
+		})
+
+		results, err := o.ProcessFiles(ctx, sessionID, 1)
+		var processorErr *ProcessorError
+		require.ErrorAs(t, err, &processorErr)
+		assert.ErrorIs(t, err, rejected)
+		require.Len(t, results, 1)
+		assert.Equal(t, "/project/config.go", results[0].FilePath)
+
+		// The rejected content never reached the AI service
+		assert.NotContains(t, sent, "/project/bad.go")
+		assert.Contains(t, sent, "/project/main.go")
+
+		progress, err := o.todoManager.GetProgress(ctx, sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, progress.Complete)
+		assert.Equal(t, 2, progress.Failed)
+	})
+}
@@ -0,0 +1,178 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/session"
+)
+
+// ErrSessionNotFound is returned when a session lookup has no match.
+var ErrSessionNotFound = errors.New("session not found")
+
+// idempotencyEntry records the session started for an idempotency key.
+// expiresAt is the session's last known expiry. Sliding expiry can extend
+// a session past it, so it only tells the sweep which sessions to load
+// again; whether a session is live is always decided by loading it.
+type idempotencyEntry struct {
+	sessionID string
+	expiresAt time.Time
+}
+
+// keyLock serializes the requests for one idempotency key. refs counts the
+// holders and waiters, so the lock is dropped once none remain.
+type keyLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// minKeySweep is the number of keys below which registerKey doesn't sweep.
+const minKeySweep = 64
+
+// GetSessionByKey retrieves the session started with an idempotency key.
+func (o *OrchestratorImpl) GetSessionByKey(ctx context.Context, key string) (*DocumentationSession, error) {
+	if key == "" {
+		return nil, fmt.Errorf("idempotency key is required")
+	}
+
+	unlock := o.lockKey(key)
+	defer unlock()
+
+	return o.lookupKey(ctx, key)
+}
+
+// lockKey locks key, leaving other keys free, and returns the function that
+// unlocks it. keysMu is held only to find the key's lock, never while
+// waiting on it.
+func (o *OrchestratorImpl) lockKey(key string) func() {
+	o.keysMu.Lock()
+	if o.keyLocks == nil {
+		o.keyLocks = make(map[string]*keyLock)
+	}
+	lock, ok := o.keyLocks[key]
+	if !ok {
+		lock = &keyLock{}
+		o.keyLocks[key] = lock
+	}
+	lock.refs++
+	o.keysMu.Unlock()
+
+	lock.mu.Lock()
+	return func() {
+		lock.mu.Unlock()
+
+		o.keysMu.Lock()
+		defer o.keysMu.Unlock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(o.keyLocks, key)
+		}
+	}
+}
+
+// lookupKey resolves a key to its live session. The mapping is dropped once
+// the session has expired or no longer exists; any other failure to load it
+// is returned as is and keeps the mapping. The caller must hold the key's
+// lock.
+func (o *OrchestratorImpl) lookupKey(ctx context.Context, key string) (*DocumentationSession, error) {
+	o.keysMu.Lock()
+	entry, ok := o.sessionKeys[key]
+	o.keysMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: no session for idempotency key %q", ErrSessionNotFound, key)
+	}
+
+	sess, err := o.GetSession(ctx, entry.sessionID)
+	if err != nil {
+		if sessionGone(err) {
+			o.forgetKey(key, entry.sessionID)
+			return nil, fmt.Errorf("%w: session for idempotency key %q: %w", ErrSessionNotFound, key, err)
+		}
+		return nil, fmt.Errorf("failed to load session for idempotency key %q: %w", key, err)
+	}
+
+	o.refreshKey(key, entry.sessionID, sess.ExpiresAt)
+	return sess, nil
+}
+
+// registerKey maps a key to a newly started session. Once the map has
+// doubled in size since the last sweep, it is swept so keys that are never
+// looked up again don't accumulate. The caller must hold the key's lock.
+func (o *OrchestratorImpl) registerKey(key, sessionID string, expiresAt time.Time) {
+	o.keysMu.Lock()
+	if o.sessionKeys == nil {
+		o.sessionKeys = make(map[string]idempotencyEntry)
+	}
+	o.sessionKeys[key] = idempotencyEntry{sessionID: sessionID, expiresAt: expiresAt}
+	sweep := len(o.sessionKeys) > max(o.nextKeySweep, minKeySweep)
+	if sweep {
+		// Keep concurrent registrations from starting sweeps of their own
+		o.nextKeySweep = 2 * len(o.sessionKeys)
+	}
+	o.keysMu.Unlock()
+
+	if sweep {
+		o.sweepKeys()
+	}
+}
+
+// sweepKeys reloads the sessions of keys past their last known expiry,
+// dropping the keys of sessions that have expired or no longer exist and
+// refreshing the expiry of those sliding expiry extended. Sessions that
+// fail to load for another reason keep their keys. keysMu is not held
+// while sessions load.
+func (o *OrchestratorImpl) sweepKeys() {
+	now := time.Now()
+	o.keysMu.Lock()
+	stale := make(map[string]idempotencyEntry)
+	for key, entry := range o.sessionKeys {
+		if now.After(entry.expiresAt) {
+			stale[key] = entry
+		}
+	}
+	o.keysMu.Unlock()
+
+	for key, entry := range stale {
+		sess, err := o.loadSession(entry.sessionID)
+		switch {
+		case err == nil:
+			o.refreshKey(key, entry.sessionID, sess.ExpiresAt)
+		case sessionGone(err):
+			o.forgetKey(key, entry.sessionID)
+		}
+	}
+
+	o.keysMu.Lock()
+	o.nextKeySweep = 2 * len(o.sessionKeys)
+	o.keysMu.Unlock()
+}
+
+// sessionGone reports whether err from loading a session means it has
+// expired or no longer exists, so its idempotency key can be dropped.
+func sessionGone(err error) bool {
+	var expired *SessionExpiredError
+	return errors.Is(err, session.ErrNotFound) || errors.As(err, &expired)
+}
+
+// refreshKey records the current expiry of the session a key maps to, if
+// the key still maps to sessionID.
+func (o *OrchestratorImpl) refreshKey(key, sessionID string, expiresAt time.Time) {
+	o.keysMu.Lock()
+	defer o.keysMu.Unlock()
+	if entry, ok := o.sessionKeys[key]; ok && entry.sessionID == sessionID {
+		entry.expiresAt = expiresAt
+		o.sessionKeys[key] = entry
+	}
+}
+
+// forgetKey drops the mapping of a key, if it still maps to sessionID.
+func (o *OrchestratorImpl) forgetKey(key, sessionID string) {
+	o.keysMu.Lock()
+	defer o.keysMu.Unlock()
+	if entry, ok := o.sessionKeys[key]; ok && entry.sessionID == sessionID {
+		delete(o.sessionKeys, key)
+	}
+}
@@ -0,0 +1,205 @@
+package orchestrator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/session"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotentStartAndGetSessionByKey(t *testing.T) {
+	ctx := context.Background()
+	o, mockSession, mockWorkflow, mockTodo := createTestOrchestrator(t)
+
+	sess := createMockSession("550e8400-e29b-41d4-a716-446655440800", "workspace-123", "/path/to/project")
+	mockSession.On("Create", "workspace-123", "/path/to/project", "", []string{}).Return(sess, nil).Once()
+	mockSession.On("Get", sess.ID).Return(sess, nil)
+	mockWorkflow.On("Initialize", mock.Anything, sess.GetID(), workflow.WorkflowStateIdle).Return(nil).Once()
+	mockWorkflow.On("Trigger", mock.Anything, sess.GetID(), workflow.EventStart).Return(nil).Once()
+	mockTodo.On("CreateList", mock.Anything, sess.GetID()).Return(nil).Once()
+
+	req := DocumentationRequest{
+		WorkspaceID:    "workspace-123",
+		ProjectPath:    "/path/to/project",
+		IdempotencyKey: "client-key-1",
+	}
+
+	first, err := o.StartDocumentation(ctx, req)
+	require.NoError(t, err)
+
+	// A retry with the same key returns the original session
+	retry, err := o.StartDocumentation(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, retry.ID)
+
+	fetched, err := o.GetSessionByKey(ctx, "client-key-1")
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, fetched.ID)
+
+	mockSession.AssertNumberOfCalls(t, "Create", 1)
+	mockWorkflow.AssertExpectations(t)
+	mockTodo.AssertExpectations(t)
+}
+
+func TestGetSessionByKeyNotFound(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("unknown key", func(t *testing.T) {
+		o, _, _, _ := createTestOrchestrator(t)
+
+		_, err := o.GetSessionByKey(ctx, "never-used")
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+	})
+
+	t.Run("expired key", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		sess := createMockSession("550e8400-e29b-41d4-a716-446655440801", "workspace-123", "/path/to/project")
+		sess.ExpiresAt = time.Now().Add(-time.Minute)
+		mockSession.On("Get", sess.ID).Return(sess, nil)
+		o.registerKey("old-key", sess.GetID(), sess.ExpiresAt)
+
+		_, err := o.GetSessionByKey(ctx, "old-key")
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+		assert.NotContains(t, o.sessionKeys, "old-key")
+	})
+
+	t.Run("session extended past its initial expiry", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		sess := createMockSession("550e8400-e29b-41d4-a716-446655440805", "workspace-123", "/path/to/project")
+		sess.Status = session.StatusInProgress
+		o.registerKey("extended-key", sess.GetID(), time.Now().Add(-time.Minute))
+
+		// Sliding expiry has since moved the session's expiry forward
+		sess.ExpiresAt = time.Now().Add(time.Hour)
+		mockSession.On("Get", sess.ID).Return(sess, nil)
+
+		fetched, err := o.GetSessionByKey(ctx, "extended-key")
+		require.NoError(t, err)
+		assert.Equal(t, sess.GetID(), fetched.ID)
+		assert.Equal(t, sess.ExpiresAt, o.sessionKeys["extended-key"].expiresAt)
+
+		// A retried start returns the session instead of creating another
+		retry, err := o.StartDocumentation(ctx, DocumentationRequest{
+			WorkspaceID:    "workspace-123",
+			ProjectPath:    "/path/to/project",
+			IdempotencyKey: "extended-key",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, sess.GetID(), retry.ID)
+		mockSession.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("empty key", func(t *testing.T) {
+		o, _, _, _ := createTestOrchestrator(t)
+
+		_, err := o.GetSessionByKey(ctx, "")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "idempotency key is required")
+	})
+}
+
+func TestLookupKeyErrors(t *testing.T) {
+	ctx := context.Background()
+	sessionID := "550e8400-e29b-41d4-a716-446655440802"
+	id := uuid.MustParse(sessionID)
+
+	t.Run("missing session drops the key", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		mockSession.On("Get", id).Return(nil, fmt.Errorf("session %s %w", id, session.ErrNotFound))
+		o.registerKey("gone-key", sessionID, time.Now().Add(time.Hour))
+
+		_, err := o.GetSessionByKey(ctx, "gone-key")
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+		assert.ErrorIs(t, err, session.ErrNotFound)
+		assert.NotContains(t, o.sessionKeys, "gone-key")
+	})
+
+	t.Run("transient error keeps the key", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		mockSession.On("Get", id).Return(nil, sql.ErrConnDone)
+		o.registerKey("busy-key", sessionID, time.Now().Add(time.Hour))
+
+		_, err := o.GetSessionByKey(ctx, "busy-key")
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		assert.NotErrorIs(t, err, ErrSessionNotFound)
+		assert.Contains(t, o.sessionKeys, "busy-key")
+
+		// A retried start fails rather than starting a second session
+		_, err = o.StartDocumentation(ctx, DocumentationRequest{
+			WorkspaceID:    "workspace-123",
+			ProjectPath:    "/path/to/project",
+			IdempotencyKey: "busy-key",
+		})
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		mockSession.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestRegisterKeySweepsExpiredKeys(t *testing.T) {
+	o, mockSession, _, _ := createTestOrchestrator(t)
+	gone := uuid.MustParse("550e8400-e29b-41d4-a716-446655440803")
+	mockSession.On("Get", gone).Return(nil, fmt.Errorf("session %s %w", gone, session.ErrNotFound))
+	extended := createMockSession("550e8400-e29b-41d4-a716-446655440806", "workspace-123", "/path/to/project")
+	extended.ExpiresAt = time.Now().Add(time.Hour)
+	mockSession.On("Get", extended.ID).Return(extended, nil)
+
+	for i := range minKeySweep - 1 {
+		o.registerKey(fmt.Sprintf("expired-%d", i), gone.String(), time.Now().Add(-time.Minute))
+	}
+	o.registerKey("extended", extended.GetID(), time.Now().Add(-time.Minute))
+	o.registerKey("live", "550e8400-e29b-41d4-a716-446655440804", time.Now().Add(time.Hour))
+
+	// Past their last known expiry, the purged sessions' keys are dropped
+	// while the extended session keeps its key with its new expiry; the
+	// live key's session isn't loaded at all
+	assert.Len(t, o.sessionKeys, 2)
+	assert.Contains(t, o.sessionKeys, "live")
+	assert.Equal(t, extended.ExpiresAt, o.sessionKeys["extended"].expiresAt)
+	mockSession.AssertNumberOfCalls(t, "Get", minKeySweep)
+}
+
+func TestLockKeyIsPerKey(t *testing.T) {
+	o, _, _, _ := createTestOrchestrator(t)
+
+	unlockA := o.lockKey("a")
+	locked := make(chan struct{})
+	go func() {
+		unlockB := o.lockKey("b")
+		unlockB()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("a held key blocked another key")
+	}
+
+	// The same key waits for its holder
+	secondA := make(chan struct{})
+	go func() {
+		unlock := o.lockKey("a")
+		close(secondA)
+		unlock()
+	}()
+	select {
+	case <-secondA:
+		t.Fatal("a key was locked twice")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlockA()
+	<-secondA
+
+	assert.Eventually(t, func() bool {
+		o.keysMu.Lock()
+		defer o.keysMu.Unlock()
+		return len(o.keyLocks) == 0
+	}, time.Second, 10*time.Millisecond, "unused key locks are dropped")
+}
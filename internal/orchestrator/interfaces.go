@@ -6,6 +6,10 @@ package orchestrator
 import (
 	"context"
 	"time"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/session"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/todolist"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/workflow"
 )
 
 // Orchestrator is the main interface for the documentation orchestration system.
@@ -21,14 +25,116 @@ type Orchestrator interface {
 	// Returns an error if the session doesn't exist or has expired.
 	GetSession(ctx context.Context, sessionID string) (*DocumentationSession, error)
 
+	// CanFireEvent reports the state a session would move to if event were
+	// triggered now, and whether the transition is allowed. Nothing is changed.
+	CanFireEvent(ctx context.Context, sessionID string, event workflow.WorkflowEvent) (workflow.WorkflowState, bool, error)
+
+	// GetSessionByKey retrieves the session started with the given
+	// idempotency key. Returns ErrSessionNotFound if the key is unknown or
+	// its session has expired.
+	GetSessionByKey(ctx context.Context, key string) (*DocumentationSession, error)
+
+	// SearchSessions finds sessions in a workspace whose project path or
+	// notes contain the query, case-insensitively, most recent first.
+	SearchSessions(ctx context.Context, workspaceID, query string) ([]*DocumentationSession, error)
+
 	// ProcessNextFile processes the next file in the TODO queue for a session.
 	// It coordinates with the file system service, MCP handler, and AI services
-	// to analyze and document the file.
+	// to analyze and document the file. When the queue is drained it returns
+	// ErrNoMoreFiles, signalling that the session may be completed.
 	ProcessNextFile(ctx context.Context, sessionID string) (*FileAnalysis, error)
 
+	// AddFiles appends files to the TODO queue of a session that has not
+	// yet finished. Every path is validated before any file is enqueued.
+	AddFiles(ctx context.Context, sessionID string, files []string, priority int) error
+
+	// Rediscover runs file discovery again for a session's project and
+	// enqueues the files its TODO list doesn't have yet, such as files
+	// created since the session started. It returns how many were added.
+	Rediscover(ctx context.Context, sessionID string) (int, error)
+
+	// ProcessFiles processes the remaining files of a session with a pool of
+	// workers until the queue is empty. A concurrency of 0 uses the session's
+	// configured MaxConcurrency. Per-file failures are collected and returned
+	// together with the successful analyses.
+	ProcessFiles(ctx context.Context, sessionID string, concurrency int) ([]*FileAnalysis, error)
+
+	// RunToCompletion processes every remaining file of a session and then
+	// completes it with opts, reporting the session's file counts. A
+	// cancelled ctx pauses the session instead of losing queued work, and a
+	// later run resumes it.
+	RunToCompletion(ctx context.Context, sessionID string, concurrency int, opts CompleteOptions) (*BatchResult, error)
+
+	// GetProgressBatch returns the TODO queue progress of several sessions
+	// in one call, keyed by session ID. Sessions without a TODO list are
+	// omitted rather than reported as errors.
+	GetProgressBatch(ctx context.Context, sessionIDs []string) (map[string]*todolist.Progress, error)
+
+	// GetRecoveryStats returns how many recovery attempts each file of a
+	// session has consumed, keyed by file path.
+	GetRecoveryStats(sessionID string) (map[string]int, error)
+
+	// GetRelatedFiles returns the files a file's analysis memory links to,
+	// such as its dependencies. It requires a registered MemoryService.
+	GetRelatedFiles(ctx context.Context, sessionID, filePath string) ([]string, error)
+
+	// GenerateModuleDocumentation combines file analyses of a session into
+	// module documentation, rendered with the session's template.
+	// opts.Progress is notified as each file is folded in.
+	GenerateModuleDocumentation(ctx context.Context, sessionID string, analyses []*FileAnalysis, opts ModuleDocOptions) (*ModuleDocumentation, error)
+
+	// Drain flushes analyses buffered by the registered AnalysisStore,
+	// returning once they are durably written.
+	Drain(ctx context.Context, sessionID string) error
+
 	// CompleteSession marks a documentation session as complete, finalizing
 	// all pending operations and cleaning up resources.
-	CompleteSession(ctx context.Context, sessionID string) error
+	// Unless opts.SkipRemaining is set, it fails while files are still
+	// pending or in progress. Buffered analyses are drained first and a
+	// failed flush blocks completion. Completing an already-completed
+	// session succeeds without doing anything.
+	CompleteSession(ctx context.Context, sessionID string, opts CompleteOptions) error
+
+	// CancelSession stops a session that has not finished, leaving its
+	// remaining files unprocessed. Cancelling an already-cancelled session
+	// succeeds without doing anything.
+	CancelSession(ctx context.Context, sessionID string) error
+
+	// PauseWorkspace pauses every processing session of a workspace and
+	// returns how many were paused. Sessions in other states are skipped
+	PauseWorkspace(ctx context.Context, workspaceID string) (int, error)
+
+	// ResumeWorkspace resumes every paused session of a workspace and
+	// returns how many were resumed. Sessions in other states are skipped
+	ResumeWorkspace(ctx context.Context, workspaceID string) (int, error)
+
+	// GetSessionDetail returns a session together with its merged progress,
+	// TODO items, workflow history and most recent notes
+	GetSessionDetail(ctx context.Context, sessionID string) (*SessionDetail, error)
+
+	// GetNotes returns the notes recorded for a session whose severity is
+	// at least minSeverity, oldest first. An empty minSeverity returns all
+	// notes.
+	GetNotes(ctx context.Context, sessionID string, minSeverity session.NoteSeverity) ([]session.SessionNote, error)
+
+	// ReplayProgressLog applies file completions recorded in the registered
+	// ProgressLog whose progress update never happened, such as after a
+	// crash. It is meant to run at startup and counts each file once.
+	ReplayProgressLog(ctx context.Context) (int, error)
+
+	// Liveness reports whether the process is alive; it never depends on
+	// the database or other services
+	Liveness() error
+
+	// Readiness reports whether the database and required services are
+	// reachable, so requests can be routed to this process
+	Readiness(ctx context.Context) error
+
+	// Shutdown stops accepting new work, waits for in-flight operations to
+	// finish, and releases all resources. If ctx expires first, the
+	// remaining operations are cancelled, their files are returned to the
+	// queue and an error wrapping ErrShutdownTimedOut is returned.
+	Shutdown(ctx context.Context) error
 }
 
 // Container manages dependencies for the orchestrator using dependency injection.
@@ -37,10 +143,11 @@ type Orchestrator interface {
 type Container interface {
 	// Register registers a service with the container under the given name.
 	// If a service with the same name already exists, it will be overwritten.
-	Register(name string, service interface{})
+	// Returns an error if the service is nil.
+	Register(name string, service interface{}) error
 
 	// Get retrieves a service from the container by name.
-	// Returns an error if the service is not registered.
+	// Returns an error if the service is not registered or is nil.
 	Get(name string) (interface{}, error)
 
 	// MustGet retrieves a service from the container by name.
@@ -54,11 +161,41 @@ type DocumentationRequest struct {
 	// ProjectPath is the root directory of the codebase to document
 	ProjectPath string `json:"project_path"`
 
+	// ModuleName names the module being documented when the session covers
+	// a single module rather than the whole project
+	ModuleName string `json:"module_name,omitempty"`
+
 	// WorkspaceID identifies the workspace for isolation and security
 	WorkspaceID string `json:"workspace_id"`
 
 	// Options contains configuration for the documentation process
 	Options DocumentationOptions `json:"options"`
+
+	// Files seeds the TODO list with an explicit list of files instead of
+	// discovering them. Relative paths are resolved against ProjectPath.
+	// It cannot be combined with the discovery options: MaxDepth,
+	// FilePatterns, ExcludePatterns and Languages.
+	Files []string `json:"files,omitempty"`
+
+	// IncludeDependencies also enqueues the direct dependencies of Files,
+	// as reported by the registered DependencyResolver, so that documenting
+	// a change set covers what the changed files touch. It requires Files
+	IncludeDependencies bool `json:"include_dependencies,omitempty"`
+
+	// IdempotencyKey makes retried starts return the session created by the
+	// first request with the same key instead of starting a new one
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+
+	// SkipAutoInitialize leaves the new session idle instead of moving it
+	// to initialized, for callers that drive the workflow manually
+	SkipAutoInitialize bool `json:"skip_auto_initialize,omitempty"`
+}
+
+// CompleteOptions controls how a session is completed.
+type CompleteOptions struct {
+	// SkipRemaining marks pending and in-progress files as skipped instead
+	// of rejecting the completion
+	SkipRemaining bool `json:"skip_remaining,omitempty"`
 }
 
 // DocumentationOptions configures how documentation should be generated.
@@ -67,6 +204,7 @@ type DocumentationOptions struct {
 	IncludePrivate bool `json:"include_private"`
 
 	// MaxDepth limits how deep to traverse the directory structure
+	// (0 uses Session.DefaultMaxDepth)
 	MaxDepth int `json:"max_depth"`
 
 	// FilePatterns specifies which files to include (e.g., ["*.go", "*.py"])
@@ -74,6 +212,71 @@ type DocumentationOptions struct {
 
 	// ExcludePatterns specifies which files/directories to exclude
 	ExcludePatterns []string `json:"exclude_patterns"`
+
+	// MaxConcurrency is the number of workers ProcessFiles uses for this
+	// session, clamped to the global workflow limit (0 uses the limit)
+	MaxConcurrency int `json:"max_concurrency,omitempty"`
+
+	// Languages restricts discovery to files detected as one of these
+	// languages, in addition to the pattern filters
+	Languages []string `json:"languages,omitempty"`
+
+	// ReprocessPolicy controls whether files with a stored analysis are
+	// enqueued again (all, changed, missing). Defaults to all.
+	ReprocessPolicy ReprocessPolicy `json:"reprocess_policy,omitempty"`
+
+	// Template selects the documentation style (reference, tutorial).
+	// Defaults to DefaultTemplate.
+	Template string `json:"template,omitempty"`
+
+	// CaseInsensitive matches FilePatterns and ExcludePatterns ignoring
+	// case, for case-insensitive file systems such as Windows and macOS
+	CaseInsensitive bool `json:"case_insensitive,omitempty"`
+
+	// FailFast fails the whole session on the first file that can't be
+	// analyzed instead of marking the file failed and continuing
+	FailFast bool `json:"fail_fast,omitempty"`
+
+	// Model selects the AI model that analyzes the session's files, unless
+	// the file's language pins one in its prompt template config. Empty
+	// leaves the choice to the AI service.
+	Model string `json:"model,omitempty"`
+}
+
+// ReprocessPolicy determines which discovered files are enqueued when an
+// AnalysisStore already holds analyses for the project.
+type ReprocessPolicy string
+
+const (
+	// ReprocessAll enqueues every discovered file
+	ReprocessAll ReprocessPolicy = "all"
+
+	// ReprocessChanged enqueues files whose content differs from the stored analysis
+	ReprocessChanged ReprocessPolicy = "changed"
+
+	// ReprocessMissing enqueues only files without a stored analysis
+	ReprocessMissing ReprocessPolicy = "missing"
+)
+
+// AnalysisStore persists file analyses so they can be reused across sessions.
+// When registered in the container under AnalysisStoreName, the orchestrator
+// saves completed analyses and consults it during file discovery.
+type AnalysisStore interface {
+	// GetAnalysis returns the stored analysis for a file in a project,
+	// or nil if the file has not been analyzed
+	GetAnalysis(ctx context.Context, projectPath, filePath string) (*FileAnalysis, error)
+
+	// SaveAnalysis stores the analysis of a file in a project
+	SaveAnalysis(ctx context.Context, projectPath string, analysis *FileAnalysis) error
+}
+
+// FlushingAnalysisStore is an AnalysisStore that buffers writes. Sessions
+// are only completed once Flush has durably written every saved analysis.
+type FlushingAnalysisStore interface {
+	AnalysisStore
+
+	// Flush writes all buffered analyses and returns once they are durable
+	Flush(ctx context.Context) error
 }
 
 // DocumentationSession represents an active documentation generation session.
@@ -87,6 +290,9 @@ type DocumentationSession struct {
 	// ProjectPath is the root directory being documented
 	ProjectPath string `json:"project_path"`
 
+	// ModuleName is the module being documented, empty for a whole project
+	ModuleName string `json:"module_name,omitempty"`
+
 	// State represents the current workflow state
 	State WorkflowState `json:"state"`
 
@@ -108,6 +314,62 @@ func (s *DocumentationSession) GetID() string {
 	return s.ID
 }
 
+// Clone returns a deep copy of the session, so a copy handed to a caller
+// does not change when the original is updated. Clone of nil is nil.
+func (s *DocumentationSession) Clone() *DocumentationSession {
+	if s == nil {
+		return nil
+	}
+	// Every field, Progress included, is a value, so a copy is deep
+	c := *s
+	return &c
+}
+
+// Elapsed returns how long the session has run. Terminal sessions are
+// measured up to their last update; active sessions up to now.
+func (s *DocumentationSession) Elapsed() time.Duration {
+	return s.elapsedAt(time.Now())
+}
+
+func (s *DocumentationSession) elapsedAt(now time.Time) time.Duration {
+	end := now
+	if s.IsTerminal() && !s.UpdatedAt.IsZero() {
+		end = s.UpdatedAt
+	}
+	if end.Before(s.CreatedAt) {
+		return 0
+	}
+	return end.Sub(s.CreatedAt)
+}
+
+// IsActive reports whether the session can still make progress.
+func (s *DocumentationSession) IsActive() bool {
+	switch s.State {
+	case WorkflowStateIdle, WorkflowStateInitialized, WorkflowStateProcessing, WorkflowStatePaused:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsTerminal reports whether the session has finished, successfully or not.
+func (s *DocumentationSession) IsTerminal() bool {
+	switch s.State {
+	case WorkflowStateComplete, WorkflowStateFailed, WorkflowStateCancelled, WorkflowStateExpired:
+		return true
+	default:
+		return false
+	}
+}
+
+// PercentComplete returns the share of files already handled, from 0 to 100.
+// Failed and skipped files count as handled, as they do for the stored
+// Progress.Percent. A session with no files reports 0.
+func (s *DocumentationSession) PercentComplete() float64 {
+	done := s.Progress.ProcessedFiles + s.Progress.FailedFiles + s.Progress.SkippedFiles
+	return session.Percent(done, s.Progress.TotalFiles)
+}
+
 // WorkflowState represents the current state of a documentation workflow.
 type WorkflowState string
 
@@ -115,16 +377,50 @@ const (
 	// WorkflowStateIdle indicates the session is created but not started
 	WorkflowStateIdle WorkflowState = "idle"
 
+	// WorkflowStateInitialized indicates the session is set up and ready to process
+	WorkflowStateInitialized WorkflowState = "initialized"
+
 	// WorkflowStateProcessing indicates active documentation generation
 	WorkflowStateProcessing WorkflowState = "processing"
 
+	// WorkflowStatePaused indicates processing is suspended and can be resumed
+	WorkflowStatePaused WorkflowState = "paused"
+
 	// WorkflowStateComplete indicates successful completion
 	WorkflowStateComplete WorkflowState = "complete"
 
 	// WorkflowStateFailed indicates the workflow encountered an error
 	WorkflowStateFailed WorkflowState = "failed"
+
+	// WorkflowStateCancelled indicates the workflow was cancelled
+	WorkflowStateCancelled WorkflowState = "cancelled"
+
+	// WorkflowStateExpired indicates the session passed its expiry before
+	// finishing. The workflow engine has no such state; it is only reported
+	// for sessions whose status is expired or whose expiry has passed
+	WorkflowStateExpired WorkflowState = "expired"
 )
 
+// SessionDetail is the full view of a session returned by GetSessionDetail.
+type SessionDetail struct {
+	// Session is the session itself; its Progress merges the session's
+	// recorded progress with the counts of its TODO list
+	Session *DocumentationSession `json:"session"`
+
+	// Queue is the progress of the session's TODO list, nil once the list
+	// has been removed
+	Queue *todolist.Progress `json:"queue,omitempty"`
+
+	// Items are the session's TODO items with their current status
+	Items []todolist.TodoItem `json:"items"`
+
+	// History is the session's workflow state transitions, oldest first
+	History []workflow.StateTransition `json:"history"`
+
+	// Notes are the session's most recent notes, oldest first
+	Notes []session.SessionNote `json:"notes"`
+}
+
 // SessionProgress tracks the progress of documentation generation.
 type SessionProgress struct {
 	// TotalFiles is the total number of files to process
@@ -136,8 +432,18 @@ type SessionProgress struct {
 	// FailedFiles is the number of files that failed processing
 	FailedFiles int `json:"failed_files"`
 
-	// CurrentFile is the file currently being processed
+	// SkippedFiles is the number of files skipped when the session was
+	// completed early
+	SkippedFiles int `json:"skipped_files,omitempty"`
+
+	// CurrentFile is the file currently being processed. It holds one file,
+	// so it is only reliable when the session processes one file at a time;
+	// with concurrent workers it names the latest file started that is still
+	// in flight, or none once that one finishes
 	CurrentFile string `json:"current_file,omitempty"`
+
+	// Percent is the share of files finished, from 0 to 100
+	Percent float64 `json:"percent"`
 }
 
 // FileAnalysis represents the result of analyzing a single file.
@@ -154,6 +460,9 @@ type FileAnalysis struct {
 	// TokenCount is the number of tokens used in the analysis
 	TokenCount int `json:"token_count"`
 
+	// ContentHash is the SHA-256 of the analyzed content, if it was read
+	ContentHash string `json:"content_hash,omitempty"`
+
 	// ProcessedAt is when the file was analyzed
 	ProcessedAt time.Time `json:"processed_at"`
 }
@@ -222,6 +531,9 @@ type DatabaseConfig struct {
 
 	// ConnMaxLifetime is the maximum connection lifetime
 	ConnMaxLifetime time.Duration `json:"conn_max_lifetime"`
+
+	// ConnMaxIdleTime is how long a connection may sit idle before it is closed
+	ConnMaxIdleTime time.Duration `json:"conn_max_idle_time"`
 }
 
 // ServicesConfig contains external service configurations.
@@ -234,8 +546,32 @@ type ServicesConfig struct {
 
 	// GeminiKey is the API key for Google Gemini
 	GeminiKey string `json:"gemini_key"`
+
+	// AIProvider is the name of the registered AI service used for analysis
+	AIProvider string `json:"ai_provider"`
+
+	// PromptTemplates holds analysis prompt templates keyed by language;
+	// the DefaultPromptKey entry is used for every other language
+	PromptTemplates map[string]PromptTemplateConfig `json:"prompt_templates,omitempty"`
+
+	// MaxContextTokens is the most tokens a file may have before
+	// OversizePolicy applies (0 disables the pre-flight count)
+	MaxContextTokens int `json:"max_context_tokens"`
+
+	// OversizePolicy is what happens to a file over MaxContextTokens: skip
+	// or chunk
+	OversizePolicy string `json:"oversize_policy"`
 }
 
+// Supported values for ServicesConfig.OversizePolicy.
+const (
+	// OversizeSkip skips oversized files, recording a note on the session
+	OversizeSkip = "skip"
+
+	// OversizeChunk analyzes oversized files in pieces and merges the results
+	OversizeChunk = "chunk"
+)
+
 // SessionConfig contains session management settings.
 type SessionConfig struct {
 	// Timeout is how long a session remains valid
@@ -246,8 +582,60 @@ type SessionConfig struct {
 
 	// CleanupInterval is how often to clean expired sessions
 	CleanupInterval time.Duration `json:"cleanup_interval"`
+
+	// TerminalRetention is how long finished sessions are kept before
+	// cleanup deletes them (0 keeps them forever)
+	TerminalRetention time.Duration `json:"terminal_retention"`
+
+	// MaxQueueWait is how long a session's oldest pending file may wait
+	// before StarvationAction is taken (0 disables the check)
+	MaxQueueWait time.Duration `json:"max_queue_wait"`
+
+	// StarvationAction is what happens to a starved session: boost or fail
+	StarvationAction string `json:"starvation_action"`
+
+	// SlidingExpiry extends a session's expiry by Timeout whenever its
+	// progress is updated, so active sessions don't expire mid-run
+	SlidingExpiry bool `json:"sliding_expiry"`
+
+	// MaxLifetime caps sliding expiry, measured from session creation
+	// (0 leaves it uncapped)
+	MaxLifetime time.Duration `json:"max_lifetime"`
+
+	// MaxNotes caps how many notes a session keeps, dropping the oldest
+	// and keeping error notes first (0 keeps every note)
+	MaxNotes int `json:"max_notes"`
+
+	// TodoMaxIdle is how long a session's TODO list may go unused before
+	// it is deleted, even if the session has not expired (0 keeps lists
+	// until the session is cleaned up)
+	TodoMaxIdle time.Duration `json:"todo_max_idle"`
+
+	// IDPrefix is prepended to session IDs, joined by an underscore (for
+	// example "doc_<uuid>"), to make them easier to spot in logs. Plain
+	// UUIDs are still accepted
+	IDPrefix string `json:"id_prefix"`
+
+	// ProjectBaseDir is the directory relative project paths are resolved
+	// against; when set, project paths must lie inside it. Empty requires
+	// absolute project paths
+	ProjectBaseDir string `json:"project_base_dir"`
+
+	// DefaultMaxDepth is the directory depth discovery walks when a request
+	// sets no MaxDepth (0 uses DefaultMaxDepth, UnlimitedDepth walks the
+	// whole tree)
+	DefaultMaxDepth int `json:"default_max_depth"`
 }
 
+// Supported values for SessionConfig.StarvationAction.
+const (
+	// StarvationBoost moves the starved files ahead of the session's other files
+	StarvationBoost = "boost"
+
+	// StarvationFail fails the starved session
+	StarvationFail = "fail"
+)
+
 // WorkflowConfig contains workflow state machine settings.
 type WorkflowConfig struct {
 	// MaxRetries is the maximum number of retries for failed operations
@@ -258,8 +646,56 @@ type WorkflowConfig struct {
 
 	// TransitionTimeout is the maximum time for state transitions
 	TransitionTimeout time.Duration `json:"transition_timeout"`
+
+	// MaxConcurrency caps the number of workers processing a session's files
+	MaxConcurrency int `json:"max_concurrency"`
+
+	// BackoffStrategy selects the recovery backoff: exponential, linear or fixed
+	BackoffStrategy string `json:"backoff_strategy"`
+
+	// BackoffBaseDelay is the first backoff delay, and the step for linear backoff
+	BackoffBaseDelay time.Duration `json:"backoff_base_delay"`
+
+	// BackoffMaxDelay caps the backoff delay
+	BackoffMaxDelay time.Duration `json:"backoff_max_delay"`
+
+	// MaxRetryAfter is the longest Retry-After a rate-limited provider may
+	// ask for; a retry that would have to wait longer is not attempted
+	MaxRetryAfter time.Duration `json:"max_retry_after"`
+
+	// InitialState is the workflow state new sessions are created in: idle
+	// (moved on to initialized unless the request sets SkipAutoInitialize)
+	// or initialized
+	InitialState WorkflowState `json:"initial_state"`
+
+	// MaxConsecutiveFailures fails a session once this many of its files
+	// fail in a row; any processed file resets the count (0 never fails a
+	// session for file failures)
+	MaxConsecutiveFailures int `json:"max_consecutive_failures"`
+
+	// ConsecutiveFailureWindow limits how far apart the first and last of
+	// the consecutive failures may be; a failure after the window starts a
+	// new run (0 does not limit the span)
+	ConsecutiveFailureWindow time.Duration `json:"consecutive_failure_window"`
+
+	// DiscoveryConcurrency is how many directories StartDocumentation's
+	// file discovery reads at once, for file system services that walk in
+	// parallel (0 uses the service's own setting)
+	DiscoveryConcurrency int `json:"discovery_concurrency"`
+
+	// ShutdownTimeout bounds how long Shutdown waits for in-flight work
+	// when its context has no deadline (0 waits as long as the context
+	// allows)
+	ShutdownTimeout time.Duration `json:"shutdown_timeout"`
 }
 
+// Supported values for WorkflowConfig.BackoffStrategy.
+const (
+	BackoffExponential = "exponential"
+	BackoffLinear      = "linear"
+	BackoffFixed       = "fixed"
+)
+
 // LoggingConfig contains logging configuration.
 type LoggingConfig struct {
 	// Level is the minimum log level (debug, info, warn, error)
@@ -0,0 +1,142 @@
+package orchestrator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocumentationSession_StateHelpers(t *testing.T) {
+	tests := []struct {
+		state        WorkflowState
+		wantActive   bool
+		wantTerminal bool
+	}{
+		{WorkflowStateIdle, true, false},
+		{WorkflowStateInitialized, true, false},
+		{WorkflowStateProcessing, true, false},
+		{WorkflowStatePaused, true, false},
+		{WorkflowStateComplete, false, true},
+		{WorkflowStateFailed, false, true},
+		{WorkflowStateCancelled, false, true},
+		{WorkflowState("unknown"), false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.state), func(t *testing.T) {
+			s := &DocumentationSession{State: tt.state}
+			assert.Equal(t, tt.wantActive, s.IsActive())
+			assert.Equal(t, tt.wantTerminal, s.IsTerminal())
+		})
+	}
+}
+
+func TestDocumentationSession_Elapsed(t *testing.T) {
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	updated := created.Add(5 * time.Minute)
+	now := created.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		session DocumentationSession
+		want    time.Duration
+	}{
+		{
+			name:    "active session measures to now",
+			session: DocumentationSession{State: WorkflowStateProcessing, CreatedAt: created, UpdatedAt: updated},
+			want:    time.Hour,
+		},
+		{
+			name:    "terminal session measures to last update",
+			session: DocumentationSession{State: WorkflowStateComplete, CreatedAt: created, UpdatedAt: updated},
+			want:    5 * time.Minute,
+		},
+		{
+			name:    "terminal session without update measures to now",
+			session: DocumentationSession{State: WorkflowStateFailed, CreatedAt: created},
+			want:    time.Hour,
+		},
+		{
+			name:    "created in the future is zero",
+			session: DocumentationSession{State: WorkflowStateIdle, CreatedAt: now.Add(time.Minute)},
+			want:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.session.elapsedAt(now))
+		})
+	}
+
+	s := &DocumentationSession{State: WorkflowStateProcessing, CreatedAt: time.Now().Add(-time.Minute)}
+	assert.GreaterOrEqual(t, s.Elapsed(), time.Minute)
+}
+
+func TestDocumentationSession_PercentComplete(t *testing.T) {
+	tests := []struct {
+		name     string
+		progress SessionProgress
+		want     float64
+	}{
+		{"zero total", SessionProgress{}, 0},
+		{"zero total with processed files", SessionProgress{ProcessedFiles: 3}, 0},
+		{"none processed", SessionProgress{TotalFiles: 4}, 0},
+		{"quarter", SessionProgress{TotalFiles: 4, ProcessedFiles: 1}, 25},
+		{"failed files count as handled", SessionProgress{TotalFiles: 4, ProcessedFiles: 1, FailedFiles: 1}, 50},
+		{"skipped files count as handled", SessionProgress{TotalFiles: 4, ProcessedFiles: 1, FailedFiles: 1, SkippedFiles: 1}, 75},
+		{"all processed", SessionProgress{TotalFiles: 3, ProcessedFiles: 3}, 100},
+		{"clamped at 100", SessionProgress{TotalFiles: 2, ProcessedFiles: 3}, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &DocumentationSession{Progress: tt.progress}
+			assert.InDelta(t, tt.want, s.PercentComplete(), 0.0001)
+		})
+	}
+}
+
+func TestDocumentationSession_PercentCompleteMatchesStored(t *testing.T) {
+	// Thirds don't divide evenly, so both paths must round the same way
+	stored := session.Progress{
+		TotalFiles:     7,
+		ProcessedFiles: 3,
+		FailedFiles:    []string{"/project/a.go", "/project/b.go"},
+		SkippedFiles:   []string{"/project/c.go"},
+	}
+	stored.Percent = stored.ComputePercent()
+
+	s := &DocumentationSession{Progress: SessionProgress{
+		TotalFiles:     stored.TotalFiles,
+		ProcessedFiles: stored.ProcessedFiles,
+		FailedFiles:    len(stored.FailedFiles),
+		SkippedFiles:   len(stored.SkippedFiles),
+		Percent:        stored.Percent,
+	}}
+	assert.Equal(t, stored.Percent, s.PercentComplete())
+	assert.Equal(t, s.Progress.Percent, s.PercentComplete())
+}
+
+func TestDocumentationSession_Clone(t *testing.T) {
+	original := &DocumentationSession{
+		ID:       "550e8400-e29b-41d4-a716-446655440000",
+		State:    WorkflowStateProcessing,
+		Progress: SessionProgress{TotalFiles: 10, ProcessedFiles: 4, CurrentFile: "/project/a.go"},
+	}
+
+	clone := original.Clone()
+	assert.Equal(t, original, clone)
+	assert.NotSame(t, original, clone)
+
+	clone.State = WorkflowStateFailed
+	clone.Progress.ProcessedFiles = 9
+	clone.Progress.CurrentFile = "/project/b.go"
+	assert.Equal(t, WorkflowStateProcessing, original.State)
+	assert.Equal(t, 4, original.Progress.ProcessedFiles)
+	assert.Equal(t, "/project/a.go", original.Progress.CurrentFile)
+
+	assert.Nil(t, (*DocumentationSession)(nil).Clone())
+}
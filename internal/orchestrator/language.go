@@ -0,0 +1,42 @@
+package orchestrator
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// languageByExtension maps lowercase file extensions to language names.
+var languageByExtension = map[string]string{
+	".go":    "go",
+	".py":    "python",
+	".js":    "javascript",
+	".jsx":   "javascript",
+	".mjs":   "javascript",
+	".ts":    "typescript",
+	".tsx":   "typescript",
+	".java":  "java",
+	".kt":    "kotlin",
+	".rs":    "rust",
+	".rb":    "ruby",
+	".php":   "php",
+	".c":     "c",
+	".h":     "c",
+	".cc":    "cpp",
+	".cpp":   "cpp",
+	".hpp":   "cpp",
+	".cs":    "csharp",
+	".swift": "swift",
+	".scala": "scala",
+	".sh":    "shell",
+	".sql":   "sql",
+	".md":    "markdown",
+	".yaml":  "yaml",
+	".yml":   "yaml",
+	".json":  "json",
+}
+
+// DetectLanguage returns the language of a file based on its extension,
+// or an empty string if the language is not recognized.
+func DetectLanguage(path string) string {
+	return languageByExtension[strings.ToLower(filepath.Ext(path))]
+}
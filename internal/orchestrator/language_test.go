@@ -0,0 +1,28 @@
+package orchestrator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/project/main.go", "go"},
+		{"/project/script.py", "python"},
+		{"/project/web/App.TSX", "typescript"},
+		{"/project/lib/index.js", "javascript"},
+		{"/project/README.md", "markdown"},
+		{"/project/Makefile", ""},
+		{"/project/archive.tar.gz", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, DetectLanguage(tt.path))
+		})
+	}
+}
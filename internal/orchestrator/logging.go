@@ -0,0 +1,39 @@
+package orchestrator
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// sessionLoggerKey marks a context whose logger is bound to a session.
+type sessionLoggerKey struct{}
+
+// ContextWithSessionLogger returns a context carrying a logger with
+// session_id and a fresh correlation_id bound, derived from the context's
+// logger. A context already bound to the same session is returned
+// unchanged, so nested operations share one correlation ID.
+func ContextWithSessionLogger(ctx context.Context, sessionID string) context.Context {
+	if bound, ok := ctx.Value(sessionLoggerKey{}).(string); ok && bound == sessionID {
+		return ctx
+	}
+
+	logger := LoggerFromContext(ctx).With().
+		Str("session_id", sessionID).
+		Str("correlation_id", uuid.NewString()).
+		Logger()
+
+	ctx = context.WithValue(ctx, sessionLoggerKey{}, sessionID)
+	return logger.WithContext(ctx)
+}
+
+// LoggerFromContext returns the logger stored in ctx, or the global logger
+// if there is none.
+func LoggerFromContext(ctx context.Context) *zerolog.Logger {
+	if logger := zerolog.Ctx(ctx); logger.GetLevel() != zerolog.Disabled {
+		return logger
+	}
+	return &log.Logger
+}
@@ -0,0 +1,83 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logLines decodes the JSON log lines written to buf.
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func TestContextWithSessionLogger(t *testing.T) {
+	var buf bytes.Buffer
+	base := zerolog.New(&buf).WithContext(context.Background())
+
+	ctx := ContextWithSessionLogger(base, "session-1")
+	LoggerFromContext(ctx).Info().Msg("first")
+
+	// Rebinding the same session keeps the correlation ID
+	LoggerFromContext(ContextWithSessionLogger(ctx, "session-1")).Info().Msg("second")
+
+	// A different session gets its own fields
+	LoggerFromContext(ContextWithSessionLogger(base, "session-2")).Info().Msg("third")
+
+	lines := logLines(t, &buf)
+	require.Len(t, lines, 3)
+	assert.Equal(t, "session-1", lines[0]["session_id"])
+	assert.NotEmpty(t, lines[0]["correlation_id"])
+	assert.Equal(t, lines[0]["correlation_id"], lines[1]["correlation_id"])
+	assert.Equal(t, "session-2", lines[2]["session_id"])
+	assert.NotEqual(t, lines[0]["correlation_id"], lines[2]["correlation_id"])
+}
+
+func TestLoggerFromContextFallsBackToGlobal(t *testing.T) {
+	assert.NotNil(t, LoggerFromContext(context.Background()))
+}
+
+func TestProcessFilesLogsSessionFields(t *testing.T) {
+	o, mockSession, _, _ := createTestOrchestrator(t)
+	sessionID := "550e8400-e29b-41d4-a716-446655440720"
+	setupBatchSession(t, o, mockSession, sessionID, 3)
+
+	var buf bytes.Buffer
+	ctx := zerolog.New(&buf).WithContext(context.Background())
+
+	results, err := o.ProcessFiles(ctx, sessionID, 2)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	var processed int
+	var correlationID interface{}
+	for _, line := range logLines(t, &buf) {
+		assert.Equal(t, sessionID, line["session_id"], "line %v", line)
+		if correlationID == nil {
+			correlationID = line["correlation_id"]
+		}
+		assert.Equal(t, correlationID, line["correlation_id"], "line %v", line)
+		if line["message"] == "File processed" {
+			processed++
+		}
+	}
+	assert.NotEmpty(t, correlationID)
+	assert.Equal(t, 3, processed)
+}
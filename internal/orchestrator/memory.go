@@ -0,0 +1,91 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/services"
+)
+
+// memoryFilePathKey is the memory metadata key holding the analyzed file.
+const memoryFilePathKey = "file_path"
+
+// fileMemoryID returns the ID of the memory recording a file's analysis.
+// IDs are scoped to the session so links stay within one knowledge graph.
+func fileMemoryID(sessionID, filePath string) string {
+	return sessionID + ":" + filePath
+}
+
+// storeAnalysisMemory records an analysis as a Zettelkasten memory linked to
+// the memories of the files it depends on. It does nothing when no
+// MemoryService is registered.
+func (o *OrchestratorImpl) storeAnalysisMemory(ctx context.Context, sessionID string, analysis *FileAnalysis) error {
+	memories, err := o.serviceRegistry.GetMemoryService()
+	if err != nil {
+		return nil
+	}
+
+	tags := make([]string, 0, len(analysis.Metadata.Classes)+1)
+	if analysis.Metadata.Language != "" {
+		tags = append(tags, analysis.Metadata.Language)
+	}
+	tags = append(tags, analysis.Metadata.Classes...)
+
+	links := make([]string, 0, len(analysis.Metadata.Dependencies))
+	for _, dep := range analysis.Metadata.Dependencies {
+		links = append(links, fileMemoryID(sessionID, dep))
+	}
+
+	processedAt := analysis.ProcessedAt.Unix()
+	return memories.StoreMemory(ctx, services.Memory{
+		ID:        fileMemoryID(sessionID, analysis.FilePath),
+		Title:     analysis.FilePath,
+		Content:   analysis.Content,
+		Tags:      tags,
+		Links:     links,
+		Metadata:  map[string]string{memoryFilePathKey: analysis.FilePath, "session_id": sessionID},
+		CreatedAt: processedAt,
+		UpdatedAt: processedAt,
+	})
+}
+
+// GetRelatedFiles returns the files linked to filePath in the session's
+// memory graph, sorted by path.
+func (o *OrchestratorImpl) GetRelatedFiles(ctx context.Context, sessionID, filePath string) ([]string, error) {
+	if _, err := parseSessionID(sessionID); err != nil {
+		return nil, fmt.Errorf("invalid session ID: %w", err)
+	}
+
+	memories, err := o.serviceRegistry.GetMemoryService()
+	if err != nil {
+		return nil, fmt.Errorf("memory service unavailable: %w", err)
+	}
+
+	memory, err := memories.RetrieveMemory(ctx, fileMemoryID(sessionID, filePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve memory for %s: %w", filePath, err)
+	}
+
+	prefix := fileMemoryID(sessionID, "")
+	seen := make(map[string]bool)
+	related := []string{}
+	for _, link := range memory.Links {
+		path := strings.TrimPrefix(link, prefix)
+
+		// Prefer the path recorded on the linked memory when it exists
+		if linked, err := memories.RetrieveMemory(ctx, link); err == nil && linked.Metadata[memoryFilePathKey] != "" {
+			path = linked.Metadata[memoryFilePathKey]
+		}
+
+		if path == filePath || seen[path] {
+			continue
+		}
+		seen[path] = true
+		related = append(related, path)
+	}
+
+	sort.Strings(related)
+	return related, nil
+}
@@ -0,0 +1,121 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryService is an in-memory MemoryService keyed by memory ID.
+type memoryService struct {
+	mu       sync.Mutex
+	memories map[string]services.Memory
+}
+
+func newMemoryService() *memoryService {
+	return &memoryService{memories: make(map[string]services.Memory)}
+}
+
+func (m *memoryService) StoreMemory(ctx context.Context, memory services.Memory) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.memories[memory.ID] = memory
+	return nil
+}
+
+func (m *memoryService) RetrieveMemory(ctx context.Context, id string) (*services.Memory, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	memory, ok := m.memories[id]
+	if !ok {
+		return nil, fmt.Errorf("memory %s not found", id)
+	}
+	return &memory, nil
+}
+
+func (m *memoryService) SearchMemories(ctx context.Context, query string) (*services.MemorySearchResult, error) {
+	return &services.MemorySearchResult{}, nil
+}
+
+func (m *memoryService) EvolveMemories(ctx context.Context) error {
+	return nil
+}
+
+func TestAnalysisMemories(t *testing.T) {
+	ctx := context.Background()
+	deps := map[string][]string{
+		"/project/file0.go": {"/project/file1.go", "/project/file2.go", "/project/missing.go"},
+		"/project/file1.go": {"/project/file2.go"},
+	}
+	ai := &stubAIService{
+		analyzeFunc: func(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error) {
+			return &services.FileAnalysisResponse{
+				Summary:      "summary of " + req.FilePath,
+				Classes:      []string{"Parser"},
+				Dependencies: deps[req.FilePath],
+			}, nil
+		},
+	}
+
+	t.Run("analyses become linked memories", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		sessionID := "550e8400-e29b-41d4-a716-446655440730"
+		setupBatchSession(t, o, mockSession, sessionID, 3)
+		require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, ai))
+		memories := newMemoryService()
+		require.NoError(t, o.serviceRegistry.RegisterMemoryService(memories))
+
+		for i := 0; i < 3; i++ {
+			_, err := o.ProcessNextFile(ctx, sessionID)
+			require.NoError(t, err)
+		}
+		require.Len(t, memories.memories, 3)
+
+		memory, err := memories.RetrieveMemory(ctx, fileMemoryID(sessionID, "/project/file0.go"))
+		require.NoError(t, err)
+		assert.Equal(t, "summary of /project/file0.go", memory.Content)
+		assert.Equal(t, []string{"go", "Parser"}, memory.Tags)
+		assert.Equal(t, []string{
+			fileMemoryID(sessionID, "/project/file1.go"),
+			fileMemoryID(sessionID, "/project/file2.go"),
+			fileMemoryID(sessionID, "/project/missing.go"),
+		}, memory.Links)
+		assert.Equal(t, "/project/file0.go", memory.Metadata[memoryFilePathKey])
+
+		// Links are followed, including to files that were never analyzed
+		related, err := o.GetRelatedFiles(ctx, sessionID, "/project/file0.go")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/project/file1.go", "/project/file2.go", "/project/missing.go"}, related)
+
+		related, err = o.GetRelatedFiles(ctx, sessionID, "/project/file2.go")
+		require.NoError(t, err)
+		assert.Empty(t, related)
+
+		_, err = o.GetRelatedFiles(ctx, sessionID, "/project/unknown.go")
+		assert.Error(t, err)
+	})
+
+	t.Run("no memory service", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		sessionID := "550e8400-e29b-41d4-a716-446655440731"
+		setupBatchSession(t, o, mockSession, sessionID, 1)
+		require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, ai))
+
+		_, err := o.ProcessNextFile(ctx, sessionID)
+		require.NoError(t, err)
+
+		_, err = o.GetRelatedFiles(ctx, sessionID, "/project/file0.go")
+		assert.ErrorContains(t, err, "memory service unavailable")
+	})
+
+	t.Run("invalid session ID", func(t *testing.T) {
+		o, _, _, _ := createTestOrchestrator(t)
+		_, err := o.GetRelatedFiles(ctx, "not-a-uuid", "/project/file0.go")
+		assert.ErrorContains(t, err, "invalid session ID")
+	})
+}
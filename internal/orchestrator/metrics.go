@@ -0,0 +1,82 @@
+package orchestrator
+
+import (
+	"context"
+	"time"
+)
+
+// MetricsName is the container name under which a Metrics implementation
+// is registered. Without one, the orchestrator records nothing.
+const MetricsName = "metrics"
+
+// File processing outcomes recorded by Metrics.FileProcessed.
+const (
+	FileStatusProcessed = "processed"
+	FileStatusFailed    = "failed"
+	FileStatusSkipped   = "skipped"
+)
+
+// Metrics records operational metrics for the orchestrator.
+type Metrics interface {
+	// SessionStarted records a new documentation session
+	SessionStarted(workspaceID string)
+
+	// FileProcessed records the outcome and duration of a file analysis
+	FileProcessed(status string, duration time.Duration)
+
+	// StateTransition records a workflow state change
+	StateTransition(from, to WorkflowState)
+
+	// FileLatency records, by language, how long a file waited in the queue
+	// before it was handed out and how long it then took to finish
+	FileLatency(language string, queueWait, processing time.Duration)
+
+	// EventDropped records, by event type, an event a subscriber lost to
+	// its buffer's overflow policy
+	EventDropped(eventType string)
+}
+
+// WithMetrics registers m under MetricsName, so the orchestrator records
+// its metrics into it.
+func WithMetrics(m Metrics) Option {
+	return Option{name: MetricsName, service: m}
+}
+
+// noopMetrics discards all metrics.
+type noopMetrics struct{}
+
+func (noopMetrics) SessionStarted(string)                            {}
+func (noopMetrics) FileProcessed(string, time.Duration)              {}
+func (noopMetrics) StateTransition(WorkflowState, WorkflowState)     {}
+func (noopMetrics) FileLatency(string, time.Duration, time.Duration) {}
+func (noopMetrics) EventDropped(string)                              {}
+
+// metrics returns the registered Metrics implementation, or a no-op one.
+func (o *OrchestratorImpl) metrics() Metrics {
+	if o.container == nil {
+		return noopMetrics{}
+	}
+	service, err := o.container.Get(MetricsName)
+	if err != nil {
+		return noopMetrics{}
+	}
+	if m, ok := service.(Metrics); ok {
+		return m
+	}
+	return noopMetrics{}
+}
+
+// recordFileLatency reports the queue wait and processing time of a
+// finished file from its TODO item timestamps.
+func (o *OrchestratorImpl) recordFileLatency(ctx context.Context, sessionID, filePath string) {
+	m := o.metrics()
+	if _, ok := m.(noopMetrics); ok {
+		// Nothing records latencies, so skip the lookup
+		return
+	}
+	item, err := o.todoManager.GetItem(ctx, sessionID, filePath)
+	if err != nil || item.StartedAt.IsZero() || item.CompletedAt.IsZero() {
+		return
+	}
+	m.FileLatency(DetectLanguage(filePath), item.StartedAt.Sub(item.EnqueuedAt), item.CompletedAt.Sub(item.StartedAt))
+}
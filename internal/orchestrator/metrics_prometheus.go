@@ -0,0 +1,99 @@
+package orchestrator
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// PrometheusMetrics implements Metrics with Prometheus collectors.
+// Each instance owns its registry so several can coexist, e.g. in tests.
+type PrometheusMetrics struct {
+	registry        *prometheus.Registry
+	sessionsStarted *prometheus.CounterVec
+	filesProcessed  *prometheus.CounterVec
+	transitions     *prometheus.CounterVec
+	fileDuration    *prometheus.HistogramVec
+	queueWait       *prometheus.HistogramVec
+	fileLatency     *prometheus.HistogramVec
+	eventsDropped   *prometheus.CounterVec
+}
+
+var _ Metrics = (*PrometheusMetrics)(nil)
+
+// NewPrometheusMetrics creates the orchestrator collectors and registers
+// them with a new registry.
+func NewPrometheusMetrics() *PrometheusMetrics {
+	m := &PrometheusMetrics{
+		registry: prometheus.NewRegistry(),
+		sessionsStarted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "codedoc_sessions_started_total",
+			Help: "Number of documentation sessions started.",
+		}, []string{"workspace_id"}),
+		filesProcessed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "codedoc_files_processed_total",
+			Help: "Number of files processed, by outcome.",
+		}, []string{"status"}),
+		transitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "codedoc_transitions_total",
+			Help: "Number of workflow state transitions.",
+		}, []string{"from", "to"}),
+		fileDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "codedoc_file_processing_seconds",
+			Help:    "Time spent analyzing a single file.",
+			Buckets: prometheus.ExponentialBuckets(0.05, 2, 12),
+		}, []string{"status"}),
+		queueWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "codedoc_file_queue_wait_seconds",
+			Help:    "Time a file waited in the TODO queue before processing, by language.",
+			Buckets: prometheus.ExponentialBuckets(0.1, 2, 16),
+		}, []string{"language"}),
+		fileLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "codedoc_file_latency_seconds",
+			Help:    "Time from a file leaving the TODO queue to its final status, by language.",
+			Buckets: prometheus.ExponentialBuckets(0.05, 2, 12),
+		}, []string{"language"}),
+		eventsDropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "codedoc_events_dropped_total",
+			Help: "Number of events subscribers lost to buffer overflow, by event type.",
+		}, []string{"type"}),
+	}
+
+	m.registry.MustRegister(m.sessionsStarted, m.filesProcessed, m.transitions, m.fileDuration, m.queueWait, m.fileLatency, m.eventsDropped)
+	return m
+}
+
+// SessionStarted records a new documentation session.
+func (m *PrometheusMetrics) SessionStarted(workspaceID string) {
+	m.sessionsStarted.WithLabelValues(workspaceID).Inc()
+}
+
+// FileProcessed records the outcome and duration of a file analysis.
+func (m *PrometheusMetrics) FileProcessed(status string, duration time.Duration) {
+	m.filesProcessed.WithLabelValues(status).Inc()
+	m.fileDuration.WithLabelValues(status).Observe(duration.Seconds())
+}
+
+// StateTransition records a workflow state change.
+func (m *PrometheusMetrics) StateTransition(from, to WorkflowState) {
+	m.transitions.WithLabelValues(string(from), string(to)).Inc()
+}
+
+// FileLatency records a file's queue wait and processing time.
+func (m *PrometheusMetrics) FileLatency(language string, queueWait, processing time.Duration) {
+	m.queueWait.WithLabelValues(language).Observe(queueWait.Seconds())
+	m.fileLatency.WithLabelValues(language).Observe(processing.Seconds())
+}
+
+// EventDropped records an event lost to a subscriber's buffer overflow.
+func (m *PrometheusMetrics) EventDropped(eventType string) {
+	m.eventsDropped.WithLabelValues(eventType).Inc()
+}
+
+// Handler returns an http.Handler that serves the metrics in the
+// Prometheus exposition format.
+func (m *PrometheusMetrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,156 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/services"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/todolist"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// scrapeMetrics fetches the exposition text served by the handler.
+func scrapeMetrics(t *testing.T, m *PrometheusMetrics) string {
+	t.Helper()
+
+	server := httptest.NewServer(m.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestPrometheusMetrics_Handler(t *testing.T) {
+	m := NewPrometheusMetrics()
+
+	m.SessionStarted("workspace-123")
+	m.SessionStarted("workspace-123")
+	m.FileProcessed(FileStatusProcessed, 120*time.Millisecond)
+	m.FileProcessed(FileStatusFailed, 3*time.Second)
+	m.StateTransition(WorkflowStateIdle, WorkflowStateInitialized)
+	m.FileLatency("go", 90*time.Second, 2*time.Second)
+	m.EventDropped("file_failed")
+
+	body := scrapeMetrics(t, m)
+
+	expected := []string{
+		`codedoc_sessions_started_total{workspace_id="workspace-123"} 2`,
+		`codedoc_files_processed_total{status="processed"} 1`,
+		`codedoc_files_processed_total{status="failed"} 1`,
+		`codedoc_transitions_total{from="idle",to="initialized"} 1`,
+		`codedoc_file_processing_seconds_bucket{status="processed",le="0.2"} 1`,
+		`codedoc_file_processing_seconds_count{status="failed"} 1`,
+		`codedoc_file_processing_seconds_sum{status="failed"} 3`,
+		`codedoc_file_queue_wait_seconds_sum{language="go"} 90`,
+		`codedoc_file_latency_seconds_sum{language="go"} 2`,
+		`codedoc_events_dropped_total{type="file_failed"} 1`,
+	}
+	for _, series := range expected {
+		assert.Contains(t, body, series)
+	}
+}
+
+func TestNewOrchestratorWithMetrics(t *testing.T) {
+	m := NewPrometheusMetrics()
+
+	o, err := NewOrchestrator(unreachableDatabaseConfig(t), WithMetrics(m))
+	require.NoError(t, err)
+	t.Cleanup(func() { o.Shutdown(context.Background()) })
+
+	// What the orchestrator records is what the server exposes
+	o.metrics().FileProcessed(FileStatusSkipped, time.Second)
+	assert.Contains(t, scrapeMetrics(t, m), `codedoc_files_processed_total{status="skipped"} 1`)
+}
+
+func TestStartDocumentationRecordsMetrics(t *testing.T) {
+	o, mockSession, mockWorkflow, mockTodo := createTestOrchestrator(t)
+	m := NewPrometheusMetrics()
+	require.NoError(t, o.container.Register(MetricsName, m))
+
+	sess := createMockSession("550e8400-e29b-41d4-a716-446655440900", "workspace-123", "/path/to/project")
+	mockSession.On("Create", "workspace-123", "/path/to/project", "", []string{}).Return(sess, nil)
+	mockWorkflow.On("Initialize", mock.Anything, sess.GetID(), workflow.WorkflowStateIdle).Return(nil)
+	mockWorkflow.On("Trigger", mock.Anything, sess.GetID(), workflow.EventStart).Return(nil)
+	mockTodo.On("CreateList", mock.Anything, sess.GetID()).Return(nil)
+
+	_, err := o.StartDocumentation(context.Background(), DocumentationRequest{
+		WorkspaceID: "workspace-123",
+		ProjectPath: "/path/to/project",
+	})
+	require.NoError(t, err)
+
+	body := scrapeMetrics(t, m)
+	assert.Contains(t, body, `codedoc_sessions_started_total{workspace_id="workspace-123"} 1`)
+	assert.Contains(t, body, `codedoc_transitions_total{from="idle",to="initialized"} 1`)
+}
+
+// latency is one FileLatency observation.
+type latency struct {
+	language   string
+	queueWait  time.Duration
+	processing time.Duration
+}
+
+// recordingMetrics keeps the file latencies it is given and discards the
+// other metrics.
+type recordingMetrics struct {
+	noopMetrics
+	latencies []latency
+}
+
+func (m *recordingMetrics) FileLatency(language string, queueWait, processing time.Duration) {
+	m.latencies = append(m.latencies, latency{language, queueWait, processing})
+}
+
+func TestProcessNextFileRecordsLatency(t *testing.T) {
+	ctx := context.Background()
+	o, mockSession, _, _ := createTestOrchestrator(t)
+	sessionID := "550e8400-e29b-41d4-a716-446655440920"
+	newStatefulSession(t, o, mockSession, sessionID)
+	m := &recordingMetrics{}
+	require.NoError(t, o.container.Register(MetricsName, m))
+
+	clock := &queueClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	o.todoManager = todolist.NewManagerWithClock(clock)
+	require.NoError(t, o.todoManager.CreateList(ctx, sessionID))
+	require.NoError(t, o.todoManager.AddItem(ctx, sessionID, todolist.TodoItem{FilePath: "/project/main.go", Priority: 2}))
+	clock.Advance(30 * time.Second)
+	require.NoError(t, o.todoManager.AddItem(ctx, sessionID, todolist.TodoItem{FilePath: "/project/app.py", Priority: 1}))
+	clock.Advance(time.Minute)
+
+	// Each analysis takes a simulated amount of time; app.py fails
+	analysisTime := map[string]time.Duration{"/project/main.go": 5 * time.Second, "/project/app.py": 12 * time.Second}
+	require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &stubAIService{
+		analyzeFunc: func(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error) {
+			clock.Advance(analysisTime[req.FilePath])
+			if req.FilePath == "/project/app.py" {
+				return nil, errors.New("model refused")
+			}
+			return &services.FileAnalysisResponse{Summary: "ok"}, nil
+		},
+	}))
+
+	_, err := o.ProcessNextFile(ctx, sessionID)
+	require.NoError(t, err)
+	_, err = o.ProcessNextFile(ctx, sessionID)
+	require.Error(t, err)
+
+	assert.Equal(t, []latency{
+		{language: "go", queueWait: 90 * time.Second, processing: 5 * time.Second},
+		{language: "python", queueWait: 65 * time.Second, processing: 12 * time.Second},
+	}, m.latencies)
+}
@@ -0,0 +1,55 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/services"
+)
+
+// ErrUnsupportedModel reports that a requested AI model is not one the
+// configured AI provider declares it serves.
+var ErrUnsupportedModel = errors.New("unsupported model")
+
+// analysisModel returns the model that analyzes a session's file: the model
+// pinned for the file's language, else the session's model, else the model
+// pinned on the default prompt. It returns "" when none is chosen.
+func (o *OrchestratorImpl) analysisModel(sessionID, language string) string {
+	prompts := o.promptRegistry()
+	if language != "" {
+		if model := prompts.Model(language); model != "" {
+			return model
+		}
+	}
+	if model := o.getSessionOptions(sessionID).Model; model != "" {
+		return model
+	}
+	return prompts.Model(DefaultPromptKey)
+}
+
+// checkModel returns ErrUnsupportedModel if the configured AI provider
+// declares its models and model is not among them. Any model passes when
+// no provider is registered or it doesn't declare its models.
+func (o *OrchestratorImpl) checkModel(model string) error {
+	if model == "" {
+		return nil
+	}
+
+	provider := o.aiProviderName()
+	ai, err := o.serviceRegistry.GetAIService(provider)
+	if err != nil {
+		return nil
+	}
+	restricted, ok := ai.(services.ModelRestricted)
+	if !ok {
+		return nil
+	}
+
+	supported := restricted.SupportedModels()
+	if slices.Contains(supported, model) {
+		return nil
+	}
+	return fmt.Errorf("%w %q for AI provider %s (supported: %s)", ErrUnsupportedModel, model, provider, strings.Join(supported, ", "))
+}
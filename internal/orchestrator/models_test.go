@@ -0,0 +1,113 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// modelAIService is a stubAIService that declares the models it serves.
+type modelAIService struct {
+	stubAIService
+	models []string
+}
+
+func (s *modelAIService) SupportedModels() []string {
+	return s.models
+}
+
+func TestAnalyzeFileModel(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T, pins map[string]PromptTemplateConfig) (*OrchestratorImpl, map[string]string) {
+		o, _, _, _ := createTestOrchestrator(t)
+		registry, err := LoadPromptRegistry(pins)
+		require.NoError(t, err)
+		o.prompts = registry
+
+		models := make(map[string]string)
+		require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &modelAIService{
+			stubAIService: stubAIService{
+				analyzeFunc: func(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error) {
+					models[req.FilePath] = req.Model
+					return &services.FileAnalysisResponse{Summary: "ok"}, nil
+				},
+			},
+			models: []string{"small", "large"},
+		}))
+		return o, models
+	}
+
+	tests := []struct {
+		name    string
+		pins    map[string]PromptTemplateConfig
+		session string
+		want    map[string]string
+	}{
+		{
+			name: "no model chosen",
+			want: map[string]string{"/project/main.go": "", "/project/notes.txt": ""},
+		},
+		{
+			name:    "session model",
+			session: "small",
+			want:    map[string]string{"/project/main.go": "small", "/project/notes.txt": "small"},
+		},
+		{
+			name:    "language pin overrides the session model",
+			pins:    map[string]PromptTemplateConfig{"go": {Model: "large"}},
+			session: "small",
+			want:    map[string]string{"/project/main.go": "large", "/project/notes.txt": "small"},
+		},
+		{
+			name: "default pin applies when the session chooses none",
+			pins: map[string]PromptTemplateConfig{
+				"go":             {Inline: "go prompt", Model: "large"},
+				DefaultPromptKey: {Model: "small"},
+			},
+			want: map[string]string{"/project/main.go": "large", "/project/notes.txt": "small"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, models := setup(t, tt.pins)
+			sessionID := "550e8400-e29b-41d4-a716-446655440930"
+			o.setSessionOptions(sessionID, DocumentationOptions{Model: tt.session})
+
+			for path := range tt.want {
+				_, err := o.analyzeFile(ctx, sessionID, path)
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, models)
+		})
+	}
+
+	t.Run("unsupported pinned model fails without calling the provider", func(t *testing.T) {
+		o, models := setup(t, map[string]PromptTemplateConfig{"go": {Model: "huge"}})
+
+		_, err := o.analyzeFile(ctx, "", "/project/main.go")
+		assert.ErrorIs(t, err, ErrUnsupportedModel)
+		assert.Empty(t, models)
+	})
+}
+
+func TestStartDocumentationRejectsUnsupportedModel(t *testing.T) {
+	o, _, _, _ := createTestOrchestrator(t)
+	require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &modelAIService{models: []string{"small", "large"}}))
+
+	_, err := o.StartDocumentation(context.Background(), DocumentationRequest{
+		ProjectPath: "/project",
+		WorkspaceID: "workspace-123",
+		Options:     DocumentationOptions{Model: "huge"},
+	})
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	require.Len(t, validationErr.Errors, 1)
+	assert.Contains(t, validationErr.Errors[0], `unsupported model "huge"`)
+	assert.Contains(t, validationErr.Errors[0], "small, large")
+}
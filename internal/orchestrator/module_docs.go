@@ -0,0 +1,144 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/services"
+	"github.com/rs/zerolog"
+)
+
+// ModuleDocumentation is the documentation generated for a session's module.
+type ModuleDocumentation struct {
+	// SessionID is the session the documentation belongs to
+	SessionID string `json:"session_id"`
+
+	// Template is the documentation template that was applied
+	Template string `json:"template"`
+
+	// Content is the generated documentation
+	Content string `json:"content"`
+
+	// TokenCount is the number of tokens in the generated documentation
+	TokenCount int `json:"token_count"`
+
+	// GeneratedAt is when the documentation was produced
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// ModuleProgressFunc is told each time a file's analysis has been folded
+// into the module documentation; done counts the files folded so far.
+type ModuleProgressFunc func(filePath string, done, total int)
+
+// ModuleDocOptions controls module documentation generation.
+type ModuleDocOptions struct {
+	// Progress, if set, is called once per file as its analysis is folded in
+	Progress ModuleProgressFunc
+}
+
+// GenerateModuleDocumentation folds the given file analyses into a single
+// module analysis and asks the AI service to document it using the
+// session's template.
+func (o *OrchestratorImpl) GenerateModuleDocumentation(ctx context.Context, sessionID string, analyses []*FileAnalysis, opts ModuleDocOptions) (*ModuleDocumentation, error) {
+	if err := o.beginOperation(); err != nil {
+		return nil, err
+	}
+	defer o.endOperation()
+
+	if _, err := o.GetSession(ctx, sessionID); err != nil {
+		return nil, err
+	}
+	if len(analyses) == 0 {
+		return nil, fmt.Errorf("no file analyses to document")
+	}
+
+	ai, err := o.aiService(o.aiProviderName())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AI service: %w", err)
+	}
+
+	template := resolveTemplate(o.getSessionOptions(sessionID).Template)
+
+	logger := LoggerFromContext(ContextWithSessionLogger(ctx, sessionID))
+
+	var module services.FileAnalysisResponse
+	for i, analysis := range analyses {
+		foldAnalysis(&module, analysis)
+		reportModuleProgress(logger, opts.Progress, analysis.FilePath, i+1, len(analyses))
+	}
+
+	resp, err := ai.GenerateDocumentation(ctx, services.DocumentationRequest{
+		Analysis: module,
+		Template: template,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate documentation: %w", err)
+	}
+
+	logger.Info().
+		Str("template", template).
+		Int("files", len(analyses)).
+		Msg("Module documentation generated")
+
+	return &ModuleDocumentation{
+		SessionID:   sessionID,
+		Template:    template,
+		Content:     resp.Content,
+		TokenCount:  resp.TokenCount,
+		GeneratedAt: time.Now(),
+	}, nil
+}
+
+// reportModuleProgress calls progress, if set, logging rather than
+// propagating a panic so a faulty callback can't abort generation.
+func reportModuleProgress(logger *zerolog.Logger, progress ModuleProgressFunc, filePath string, done, total int) {
+	if progress == nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Warn().
+				Interface("panic", r).
+				Str("file", filePath).
+				Msg("Module documentation progress callback panicked")
+		}
+	}()
+	progress(filePath, done, total)
+}
+
+// foldAnalysis merges a file analysis into the module analysis, appending
+// its summary and collecting symbols and dependencies without duplicates.
+func foldAnalysis(module *services.FileAnalysisResponse, analysis *FileAnalysis) {
+	if analysis.Content != "" {
+		summary := fmt.Sprintf("%s: %s", analysis.FilePath, analysis.Content)
+		if module.Summary == "" {
+			module.Summary = summary
+		} else {
+			module.Summary = strings.Join([]string{module.Summary, summary}, "\n")
+		}
+	}
+
+	module.Functions = appendUnique(module.Functions, analysis.Metadata.Functions...)
+	module.Classes = appendUnique(module.Classes, analysis.Metadata.Classes...)
+	module.Dependencies = appendUnique(module.Dependencies, analysis.Metadata.Dependencies...)
+	module.TokenCount += analysis.TokenCount
+}
+
+// appendUnique appends the values not already present in list.
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, existing := range list {
+			if existing == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
+}
@@ -0,0 +1,170 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateModuleDocumentation(t *testing.T) {
+	const sessionID = "550e8400-e29b-41d4-a716-446655440a00"
+
+	analyses := []*FileAnalysis{
+		{
+			FilePath:   "/project/a.go",
+			Content:    "parses input",
+			Metadata:   FileMetadata{Functions: []string{"Parse"}, Dependencies: []string{"fmt"}},
+			TokenCount: 10,
+		},
+		{
+			FilePath:   "/project/b.go",
+			Content:    "renders output",
+			Metadata:   FileMetadata{Functions: []string{"Render"}, Classes: []string{"Renderer"}, Dependencies: []string{"fmt", "io"}},
+			TokenCount: 5,
+		},
+	}
+
+	tests := []struct {
+		name         string
+		options      *DocumentationOptions
+		generateErr  error
+		wantTemplate string
+		wantErr      bool
+		errMsg       string
+	}{
+		{
+			name:         "selected template is passed to the AI service",
+			options:      &DocumentationOptions{Template: TemplateTutorial},
+			wantTemplate: TemplateTutorial,
+		},
+		{
+			name:         "default template when none selected",
+			wantTemplate: DefaultTemplate,
+		},
+		{
+			name:        "generation failure",
+			generateErr: errors.New("model overloaded"),
+			wantErr:     true,
+			errMsg:      "failed to generate documentation",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, mockSession, _, _ := createTestOrchestrator(t)
+			sess := createMockSession(sessionID, "workspace-123", "/project")
+			mockSession.On("Get", sess.ID).Return(sess, nil)
+			if tt.options != nil {
+				o.setSessionOptions(sessionID, *tt.options)
+			}
+
+			var got services.DocumentationRequest
+			require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &stubAIService{
+				generateFunc: func(ctx context.Context, req services.DocumentationRequest) (*services.DocumentationResponse, error) {
+					got = req
+					if tt.generateErr != nil {
+						return nil, tt.generateErr
+					}
+					return &services.DocumentationResponse{Content: "# Module", TokenCount: 42}, nil
+				},
+			}))
+
+			doc, err := o.GenerateModuleDocumentation(context.Background(), sessionID, analyses, ModuleDocOptions{})
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+				assert.Nil(t, doc)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTemplate, got.Template)
+			assert.Equal(t, tt.wantTemplate, doc.Template)
+			assert.Equal(t, "# Module", doc.Content)
+			assert.Equal(t, 42, doc.TokenCount)
+
+			// The module analysis combines every file
+			assert.Equal(t, "/project/a.go: parses input\n/project/b.go: renders output", got.Analysis.Summary)
+			assert.Equal(t, []string{"Parse", "Render"}, got.Analysis.Functions)
+			assert.Equal(t, []string{"Renderer"}, got.Analysis.Classes)
+			assert.Equal(t, []string{"fmt", "io"}, got.Analysis.Dependencies)
+			assert.Equal(t, 15, got.Analysis.TokenCount)
+		})
+	}
+
+	t.Run("requires analyses and an AI service", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		sess := createMockSession(sessionID, "workspace-123", "/project")
+		mockSession.On("Get", sess.ID).Return(sess, nil)
+
+		_, err := o.GenerateModuleDocumentation(context.Background(), sessionID, nil, ModuleDocOptions{})
+		assert.EqualError(t, err, "no file analyses to document")
+
+		_, err = o.GenerateModuleDocumentation(context.Background(), sessionID, analyses, ModuleDocOptions{})
+		assert.ErrorContains(t, err, "failed to get AI service")
+	})
+}
+
+func TestGenerateModuleDocumentationProgress(t *testing.T) {
+	const sessionID = "550e8400-e29b-41d4-a716-446655440a01"
+
+	newOrchestrator := func(t *testing.T) *OrchestratorImpl {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		sess := createMockSession(sessionID, "workspace-123", "/project")
+		mockSession.On("Get", sess.ID).Return(sess, nil)
+		require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &stubAIService{
+			generateFunc: func(ctx context.Context, req services.DocumentationRequest) (*services.DocumentationResponse, error) {
+				return &services.DocumentationResponse{Content: "# Module"}, nil
+			},
+		}))
+		return o
+	}
+
+	var analyses []*FileAnalysis
+	for _, path := range []string{"/project/a.go", "/project/b.go", "/project/c.go", "/project/d.go"} {
+		analyses = append(analyses, &FileAnalysis{FilePath: path, Content: "summary"})
+	}
+
+	t.Run("fires once per file in order", func(t *testing.T) {
+		o := newOrchestrator(t)
+
+		type call struct {
+			path        string
+			done, total int
+		}
+		var calls []call
+		_, err := o.GenerateModuleDocumentation(context.Background(), sessionID, analyses, ModuleDocOptions{
+			Progress: func(filePath string, done, total int) {
+				calls = append(calls, call{filePath, done, total})
+			},
+		})
+		require.NoError(t, err)
+
+		require.Len(t, calls, len(analyses))
+		for i, c := range calls {
+			assert.Equal(t, analyses[i].FilePath, c.path)
+			assert.Equal(t, i+1, c.done)
+			assert.Equal(t, len(analyses), c.total)
+		}
+	})
+
+	t.Run("panicking callback does not abort generation", func(t *testing.T) {
+		o := newOrchestrator(t)
+
+		calls := 0
+		doc, err := o.GenerateModuleDocumentation(context.Background(), sessionID, analyses, ModuleDocOptions{
+			Progress: func(filePath string, done, total int) {
+				calls++
+				panic("progress bar broke")
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "# Module", doc.Content)
+		assert.Equal(t, len(analyses), calls)
+	})
+}
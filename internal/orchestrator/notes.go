@@ -0,0 +1,68 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/session"
+)
+
+// Sources of the notes the orchestrator records on sessions.
+const (
+	// NoteSourceProcessor marks notes about analyzing individual files
+	NoteSourceProcessor = "processor"
+
+	// NoteSourceScheduler marks notes about queue starvation handling
+	NoteSourceScheduler = "scheduler"
+
+	// NoteSourceCompletion marks notes recorded when a session completes
+	NoteSourceCompletion = "completion"
+)
+
+// addSessionNote appends a note to a session. Notes are an audit trail, so a
+// failure to record one is logged rather than returned.
+func (o *OrchestratorImpl) addSessionNote(ctx context.Context, sessionID string, note session.SessionNote) {
+	sessionUUID, err := parseSessionID(sessionID)
+	if err != nil {
+		return
+	}
+
+	o.progressMu.Lock()
+	err = o.sessionManager.Update(sessionUUID, session.NewSessionUpdate().WithNote(note).Build())
+	o.progressMu.Unlock()
+	if err != nil {
+		LoggerFromContext(ctx).Warn().
+			Err(err).
+			Str("source", note.Source).
+			Str("note", note.Message).
+			Msg("Failed to record session note")
+	}
+}
+
+// GetNotes returns the notes recorded for a session whose severity is at
+// least minSeverity, oldest first. An empty minSeverity returns all notes.
+func (o *OrchestratorImpl) GetNotes(ctx context.Context, sessionID string, minSeverity session.NoteSeverity) ([]session.SessionNote, error) {
+	sessionUUID, err := parseSessionID(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid session ID: %w", err)
+	}
+	if minSeverity == "" {
+		minSeverity = session.NoteSeverityInfo
+	}
+	if !minSeverity.IsValid() {
+		return nil, fmt.Errorf("invalid minimum severity %q", minSeverity)
+	}
+
+	sess, err := o.sessionManager.Get(sessionUUID)
+	if err != nil {
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+
+	notes := []session.SessionNote{}
+	for _, note := range sess.Notes {
+		if note.Severity.AtLeast(minSeverity) {
+			notes = append(notes, note)
+		}
+	}
+	return notes, nil
+}
@@ -0,0 +1,109 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/services"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/session"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/todolist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionNotesDuringFailingRun(t *testing.T) {
+	ctx := context.Background()
+	o, mockSession, _, _ := createTestOrchestrator(t)
+	sessionID := "550e8400-e29b-41d4-a716-446655440780"
+	newStatefulSession(t, o, mockSession, sessionID)
+
+	for _, item := range []todolist.TodoItem{
+		{FilePath: "/project/bad.go", Priority: 3},
+		{FilePath: "/project/good.go", Priority: 2},
+		{FilePath: "/project/later.go", Priority: 1},
+	} {
+		require.NoError(t, o.todoManager.AddItem(ctx, sessionID, item))
+	}
+	require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &stubAIService{
+		analyzeFunc: func(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error) {
+			if req.FilePath == "/project/bad.go" {
+				return nil, errors.New("model refused")
+			}
+			return &services.FileAnalysisResponse{Summary: "ok"}, nil
+		},
+	}))
+
+	_, err := o.ProcessNextFile(ctx, sessionID)
+	var fileErr *FileProcessingError
+	require.ErrorAs(t, err, &fileErr)
+	_, err = o.ProcessNextFile(ctx, sessionID)
+	require.NoError(t, err)
+	require.NoError(t, o.CompleteSession(ctx, sessionID, CompleteOptions{SkipRemaining: true}))
+
+	notes, err := o.GetNotes(ctx, sessionID, "")
+	require.NoError(t, err)
+	require.Len(t, notes, 2)
+
+	assert.Equal(t, session.NoteSeverityError, notes[0].Severity)
+	assert.Equal(t, NoteSourceProcessor, notes[0].Source)
+	assert.Equal(t, "/project/bad.go", notes[0].FilePath)
+	assert.Contains(t, notes[0].Message, "model refused")
+
+	assert.Equal(t, session.NoteSeverityWarn, notes[1].Severity)
+	assert.Equal(t, NoteSourceCompletion, notes[1].Source)
+	assert.Equal(t, "skipped 1 unprocessed files on completion", notes[1].Message)
+}
+
+func TestGetNotes(t *testing.T) {
+	ctx := context.Background()
+	o, mockSession, _, _ := createTestOrchestrator(t)
+	sessionID := "550e8400-e29b-41d4-a716-446655440781"
+
+	sess := createMockSession(sessionID, "workspace-123", "/project")
+	sess.Notes = []session.SessionNote{
+		{Severity: session.NoteSeverityInfo, Message: "info"},
+		{Message: "legacy note without severity"},
+		{Severity: session.NoteSeverityWarn, Message: "warn"},
+		{Severity: session.NoteSeverityError, Message: "error"},
+	}
+	mockSession.On("Get", uuid.MustParse(sessionID)).Return(sess, nil)
+
+	messages := func(notes []session.SessionNote) []string {
+		out := make([]string, 0, len(notes))
+		for _, note := range notes {
+			out = append(out, note.Message)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name        string
+		minSeverity session.NoteSeverity
+		want        []string
+	}{
+		{"all notes", "", []string{"info", "legacy note without severity", "warn", "error"}},
+		{"info and above", session.NoteSeverityInfo, []string{"info", "legacy note without severity", "warn", "error"}},
+		{"warn and above", session.NoteSeverityWarn, []string{"warn", "error"}},
+		{"errors only", session.NoteSeverityError, []string{"error"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notes, err := o.GetNotes(ctx, sessionID, tt.minSeverity)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, messages(notes))
+		})
+	}
+
+	t.Run("invalid severity", func(t *testing.T) {
+		_, err := o.GetNotes(ctx, sessionID, "fatal")
+		assert.EqualError(t, err, `invalid minimum severity "fatal"`)
+	})
+
+	t.Run("invalid session ID", func(t *testing.T) {
+		_, err := o.GetNotes(ctx, "not-a-uuid", "")
+		assert.ErrorContains(t, err, "invalid session ID")
+	})
+}
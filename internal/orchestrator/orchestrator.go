@@ -5,10 +5,13 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	_ "github.com/lib/pq" // PostgreSQL driver
-	"github.com/google/uuid"
 	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/services"
 	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/session"
 	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/todolist"
@@ -27,33 +30,59 @@ type OrchestratorImpl struct {
 	todoManager     todolist.Manager
 	serviceRegistry services.Registry
 	config          *Config
+	prompts         *PromptRegistry
+
+	// Lifecycle management for graceful shutdown
+	lifecycleMu   sync.RWMutex
+	shuttingDown  bool
+	inFlight      sync.WaitGroup
+	workerCtx     context.Context
+	cancelWorkers context.CancelFunc
+
+	// Files returned to the queue by a forced shutdown
+	shutdownRequeued atomic.Int64
+
+	// Per-session processing options and serialized progress updates
+	optionsMu      sync.RWMutex
+	sessionOptions map[string]sessionRequest
+	progressMu     sync.Mutex
+
+	// Idempotency key to session mapping, and a lock per key in use
+	keysMu       sync.Mutex
+	sessionKeys  map[string]idempotencyEntry
+	keyLocks     map[string]*keyLock
+	nextKeySweep int
+
+	// Registered processors, run in registration order
+	hooksMu           sync.RWMutex
+	contentProcessors []ContentProcessor
+	fileProcessors    []FileProcessor
+
+	// In-flight request slots of concurrency-limited AI services, by name
+	aiLimitsMu sync.Mutex
+	aiLimits   map[string]chan struct{}
+
+	// Runs of consecutive file failures, by session
+	failuresMu  sync.Mutex
+	failureRuns map[string]failureRun
 }
 
 // NewOrchestrator creates a new orchestrator instance with all required dependencies.
-// It initializes the core components and registers them with the dependency container.
-func NewOrchestrator(config *Config) (*OrchestratorImpl, error) {
+// It initializes the core components and registers them with the dependency container,
+// along with any optional components supplied as options.
+func NewOrchestrator(config *Config, opts ...Option) (*OrchestratorImpl, error) {
 	// Validate and set defaults
 	if err := LoadConfig(config); err != nil {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
-	// Initialize database connection
-	db, err := InitDatabase(&config.Database)
+	prompts, err := LoadPromptRegistry(config.Services.PromptTemplates)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize database: %w", err)
+		return nil, fmt.Errorf("failed to load prompt templates: %w", err)
 	}
 
-	// Create dependency container
-	container := NewContainer()
-	container.Register("db", db)
-
-	// Initialize core components
-	sessionManager := session.NewManager(db, session.SessionConfig{
-		DefaultTTL:      config.Session.Timeout,
-		MaxSessions:     config.Session.MaxConcurrent,
-		CleanupInterval: config.Session.CleanupInterval,
-	})
-
+	// Build the workflow engine first: it validates its configuration, and
+	// nothing has to be released if that fails
 	workflowEngine, err := workflow.NewEngine(workflow.WorkflowConfig{
 		MaxRetries:        config.Workflow.MaxRetries,
 		RetryDelay:        config.Workflow.RetryDelay,
@@ -63,20 +92,61 @@ func NewOrchestrator(config *Config) (*OrchestratorImpl, error) {
 		return nil, fmt.Errorf("failed to create workflow engine: %w", err)
 	}
 
-	todoManager := todolist.NewManager()
+	// Open the database without requiring it to be up: an outage at boot is
+	// reported by Readiness rather than preventing the process from starting
+	db, err := OpenDatabase(&config.Database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	if err := pingDatabase(db); err != nil {
+		log.Warn().
+			Err(err).
+			Str("host", config.Database.Host).
+			Int("port", config.Database.Port).
+			Msg("Database is unreachable; readiness will fail until it recovers")
+	}
+
+	// Initialize core components
+	sessionManager := session.NewManager(db, session.SessionConfig{
+		DefaultTTL:        config.Session.Timeout,
+		MaxSessions:       config.Session.MaxConcurrent,
+		CleanupInterval:   config.Session.CleanupInterval,
+		TerminalRetention: config.Session.TerminalRetention,
+		SlidingExpiry:     config.Session.SlidingExpiry,
+		MaxLifetime:       config.Session.MaxLifetime,
+		MaxNotes:          config.Session.MaxNotes,
+	})
+
+	todoManager := todolist.NewManagerWithConfig(todolist.ManagerConfig{
+		MaxIdle: config.Session.TodoMaxIdle,
+	})
 	serviceRegistry := services.NewRegistry()
 
 	// Register services in container
-	container.Register("session", sessionManager)
-	container.Register("workflow", workflowEngine)
-	container.Register("todo", todoManager)
-	container.Register("services", serviceRegistry)
-	container.Register("config", config)
+	container := NewContainer()
+	registrations := []Option{
+		{"db", db},
+		{"session", sessionManager},
+		{"workflow", workflowEngine},
+		{"todo", todoManager},
+		{"services", serviceRegistry},
+		{"config", config},
+	}
+	registrations = append(registrations, opts...)
+	for _, r := range registrations {
+		if err := container.Register(r.name, r.service); err != nil {
+			sessionManager.Shutdown()
+			db.Close()
+			return nil, fmt.Errorf("failed to register services: %w", err)
+		}
+	}
 
 	log.Info().
 		Str("component", "orchestrator").
 		Msg("Orchestrator initialized successfully")
 
+	workerCtx, cancelWorkers := context.WithCancel(context.Background())
+
 	return &OrchestratorImpl{
 		container:       container,
 		db:              db,
@@ -85,68 +155,174 @@ func NewOrchestrator(config *Config) (*OrchestratorImpl, error) {
 		todoManager:     todoManager,
 		serviceRegistry: serviceRegistry,
 		config:          config,
+		prompts:         prompts,
+		workerCtx:       workerCtx,
+		cancelWorkers:   cancelWorkers,
+		sessionOptions:  make(map[string]sessionRequest),
+		sessionKeys:     make(map[string]idempotencyEntry),
 	}, nil
 }
 
 // StartDocumentation initiates a new documentation session for a codebase.
 // It creates a session, initializes the workflow, and prepares the TODO list.
 func (o *OrchestratorImpl) StartDocumentation(ctx context.Context, req DocumentationRequest) (*DocumentationSession, error) {
-	// Validate request
-	if err := validateDocumentationRequest(req); err != nil {
-		return nil, fmt.Errorf("invalid documentation request: %w", err)
+	if err := o.beginOperation(); err != nil {
+		return nil, err
 	}
+	defer o.endOperation()
 
-	// Apply defaults without modifying the input
-	maxDepth := req.Options.MaxDepth
-	if maxDepth == 0 {
-		maxDepth = 10 // Default max depth
+	// Validate request and normalize its project path
+	if err := validateDocumentationRequest(&req, o.projectBaseDir()); err != nil {
+		return nil, err
+	}
+	if err := o.checkModel(req.Options.Model); err != nil {
+		return nil, &ValidationError{Message: "invalid documentation request", Errors: []string{err.Error()}}
 	}
 
-	// Create new session using the session manager
-	sess, err := o.sessionManager.Create(req.WorkspaceID, req.ProjectPath, []string{})
+	// Serialize keyed starts so a retry can't race the original request
+	if req.IdempotencyKey != "" {
+		unlock := o.lockKey(req.IdempotencyKey)
+		defer unlock()
+
+		existing, err := o.lookupKey(ctx, req.IdempotencyKey)
+		if err != nil && !errors.Is(err, ErrSessionNotFound) {
+			return nil, err
+		}
+		if err == nil {
+			log.Info().
+				Str("session_id", existing.ID).
+				Str("idempotency_key", req.IdempotencyKey).
+				Msg("Returning existing session for idempotency key")
+			return existing, nil
+		}
+	}
+
+	// Discover the files to document before creating any state
+	files, err := o.discoverFiles(ctx, req, o.discoveryDepth(req.Options))
 	if err != nil {
+		return nil, fmt.Errorf("failed to discover files: %w", err)
+	}
+
+	// Create the session row together with its workflow and TODO list: if
+	// any step fails the row is rolled back and the rest is discarded
+	var docSess *DocumentationSession
+	var setupErr error
+	discard := func() {}
+	_, err = o.sessionManager.CreateAtomic(req.WorkspaceID, req.ProjectPath, req.ModuleName, files, func(_ *sql.Tx, sess *session.Session) error {
+		docSess = &DocumentationSession{
+			ID:          o.formatSessionID(sess.ID),
+			WorkspaceID: sess.WorkspaceID,
+			ProjectPath: sess.ProjectPath,
+			ModuleName:  sess.ModuleName,
+			State:       o.initialState(),
+			Progress: SessionProgress{
+				TotalFiles:     sess.Progress.TotalFiles,
+				ProcessedFiles: sess.Progress.ProcessedFiles,
+				FailedFiles:    len(sess.Progress.FailedFiles),
+			},
+			CreatedAt: sess.CreatedAt,
+			UpdatedAt: sess.UpdatedAt,
+			ExpiresAt: sess.ExpiresAt,
+		}
+		discard, setupErr = o.setUpSession(ctx, docSess, files, req.SkipAutoInitialize)
+		return setupErr
+	})
+	if err != nil {
+		discard()
+		if setupErr != nil {
+			return nil, setupErr
+		}
 		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
 
-	// Convert to DocumentationSession
-	docSess := &DocumentationSession{
-		ID:          sess.GetID(),
-		WorkspaceID: sess.WorkspaceID,
-		ProjectPath: sess.ModuleName, // Using ModuleName as ProjectPath
-		State:       WorkflowStateIdle,
-		Progress: SessionProgress{
-			TotalFiles:     sess.Progress.TotalFiles,
-			ProcessedFiles: sess.Progress.ProcessedFiles,
-			FailedFiles:    len(sess.Progress.FailedFiles),
-		},
-		CreatedAt: sess.CreatedAt,
-		UpdatedAt: sess.UpdatedAt,
-		ExpiresAt: sess.ExpiresAt,
+	if docSess.State != o.initialState() {
+		o.metrics().StateTransition(o.initialState(), docSess.State)
+	}
+	o.setSessionOptions(docSess.ID, req.Options)
+	o.setSessionFiles(docSess.ID, req.Files, req.IncludeDependencies)
+	if req.IdempotencyKey != "" {
+		o.registerKey(req.IdempotencyKey, docSess.ID, docSess.ExpiresAt)
+	}
+
+	LoggerFromContext(ContextWithSessionLogger(ctx, docSess.ID)).Info().
+		Str("workspace_id", req.WorkspaceID).
+		Str("project_path", req.ProjectPath).
+		Msg("Documentation session started")
+
+	o.metrics().SessionStarted(req.WorkspaceID)
+	return docSess, nil
+}
+
+// setUpSession initializes the workflow and TODO list of a new session and
+// seeds the list with files. The returned function discards whatever was
+// set up, for when the session is not created after all.
+func (o *OrchestratorImpl) setUpSession(ctx context.Context, docSess *DocumentationSession, files []string, skipAutoInitialize bool) (func(), error) {
+	logger := LoggerFromContext(ContextWithSessionLogger(ctx, docSess.ID))
+	var undo []func() error
+	discard := func() {
+		for i := len(undo) - 1; i >= 0; i-- {
+			if err := undo[i](); err != nil {
+				logger.Warn().
+					Err(err).
+					Msg("Failed to discard state of a session that was not created")
+			}
+		}
 	}
 
 	// Initialize workflow
-	if err := o.workflowEngine.Initialize(ctx, docSess.ID, workflow.WorkflowStateIdle); err != nil {
-		return nil, fmt.Errorf("failed to initialize workflow: %w", err)
+	if err := o.workflowEngine.Initialize(ctx, docSess.ID, workflow.WorkflowState(docSess.State)); err != nil {
+		return discard, fmt.Errorf("failed to initialize workflow: %w", err)
 	}
+	undo = append(undo, func() error { return o.workflowEngine.Remove(ctx, docSess.ID) })
 
 	// Create TODO list for the session
 	if err := o.todoManager.CreateList(ctx, docSess.ID); err != nil {
-		return nil, fmt.Errorf("failed to create TODO list: %w", err)
+		return discard, fmt.Errorf("failed to create TODO list: %w", err)
 	}
+	undo = append(undo, func() error { return o.todoManager.DeleteList(ctx, docSess.ID) })
 
-	log.Info().
-		Str("session_id", docSess.ID).
-		Str("workspace_id", req.WorkspaceID).
-		Str("project_path", req.ProjectPath).
-		Msg("Documentation session started")
+	// Move the workflow to initialized so the session is ready to process
+	if docSess.State == WorkflowStateIdle && !skipAutoInitialize {
+		if err := o.workflowEngine.Trigger(ctx, docSess.ID, workflow.EventStart); err != nil {
+			return discard, fmt.Errorf("failed to start workflow: %w", err)
+		}
+		docSess.State = WorkflowStateInitialized
+	}
 
-	return docSess, nil
+	// Seed the TODO list with the discovered files
+	for _, file := range files {
+		if err := o.todoManager.AddItem(ctx, docSess.ID, todolist.TodoItem{FilePath: file}); err != nil {
+			return discard, fmt.Errorf("failed to enqueue %s: %w", file, err)
+		}
+	}
+
+	return discard, nil
+}
+
+// initialState returns the configured workflow state for new sessions,
+// defaulting to idle.
+func (o *OrchestratorImpl) initialState() WorkflowState {
+	if o.config == nil || o.config.Workflow.InitialState == "" {
+		return WorkflowStateIdle
+	}
+	return o.config.Workflow.InitialState
 }
 
 // GetSession retrieves an existing documentation session by ID.
 func (o *OrchestratorImpl) GetSession(ctx context.Context, sessionID string) (*DocumentationSession, error) {
+	sess, err := o.loadSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.toDocumentationSession(sess), nil
+}
+
+// loadSession fetches a session by ID, rejecting invalid IDs and expired
+// sessions.
+func (o *OrchestratorImpl) loadSession(sessionID string) (*session.Session, error) {
 	// Parse UUID
-	id, err := uuid.Parse(sessionID)
+	id, err := parseSessionID(sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid session ID: %w", err)
 	}
@@ -156,67 +332,173 @@ func (o *OrchestratorImpl) GetSession(ctx context.Context, sessionID string) (*D
 		return nil, fmt.Errorf("session not found: %w", err)
 	}
 
-	// Check if session has expired
-	if time.Now().After(sess.ExpiresAt) {
-		return nil, fmt.Errorf("session %s has expired", sessionID)
-	}
-
-	// Convert to DocumentationSession
-	// Map session status to workflow state
-	var state WorkflowState
-	switch sess.Status {
-	case session.StatusPending:
-		state = WorkflowStateIdle
-	case session.StatusInProgress:
-		state = WorkflowStateProcessing
-	case session.StatusCompleted:
-		state = WorkflowStateComplete
-	case session.StatusFailed:
-		state = WorkflowStateFailed
-	case session.StatusExpired:
-		state = WorkflowStateFailed // Map expired to failed
-	default:
-		state = WorkflowStateIdle
+	// Check if session has expired, either by the sweeper or by its clock
+	if sess.Status == session.StatusExpired || time.Now().After(sess.ExpiresAt) {
+		return nil, &SessionExpiredError{SessionID: sessionID, ExpiresAt: sess.ExpiresAt}
+	}
+
+	return sess, nil
+}
+
+// CanFireEvent is a dry run of workflowEngine.Trigger: it loads the session's
+// current workflow state and reports where event would take it, without
+// performing the transition.
+func (o *OrchestratorImpl) CanFireEvent(ctx context.Context, sessionID string, event workflow.WorkflowEvent) (workflow.WorkflowState, bool, error) {
+	if _, err := parseSessionID(sessionID); err != nil {
+		return "", false, fmt.Errorf("invalid session ID: %w", err)
+	}
+
+	current, err := o.workflowEngine.GetState(ctx, sessionID)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get workflow state: %w", err)
+	}
+
+	target, ok := o.workflowEngine.CanTransition(current, event)
+	return target, ok, nil
+}
+
+// GetProgressBatch returns the TODO queue progress of several sessions,
+// reading every list under one lock. Sessions without a list are omitted.
+func (o *OrchestratorImpl) GetProgressBatch(ctx context.Context, sessionIDs []string) (map[string]*todolist.Progress, error) {
+	for _, sessionID := range sessionIDs {
+		if _, err := parseSessionID(sessionID); err != nil {
+			return nil, fmt.Errorf("invalid session ID %q: %w", sessionID, err)
+		}
+	}
+
+	progress, err := o.todoManager.GetProgressBatch(ctx, sessionIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get TODO progress: %w", err)
+	}
+	return progress, nil
+}
+
+// SearchSessions returns the sessions of a workspace whose project path or
+// notes contain the query, ignoring case. Results are ordered by most
+// recently updated first.
+func (o *OrchestratorImpl) SearchSessions(ctx context.Context, workspaceID, query string) ([]*DocumentationSession, error) {
+	if workspaceID == "" {
+		return nil, fmt.Errorf("workspace_id is required")
+	}
+	if query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+
+	sessions, err := o.sessionManager.Search(workspaceID, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search sessions: %w", err)
+	}
+
+	results := make([]*DocumentationSession, 0, len(sessions))
+	for _, sess := range sessions {
+		results = append(results, o.toDocumentationSession(sess))
+	}
+	return results, nil
+}
+
+// toDocumentationSession converts a stored session to its orchestrator view.
+// The view is built afresh on every call and shares nothing with the stored
+// session, so callers may modify what GetSession and SearchSessions return.
+func (o *OrchestratorImpl) toDocumentationSession(sess *session.Session) *DocumentationSession {
+	// Map session status to workflow state; unknown statuses read as idle.
+	// The engine has no expired state, so expiry is reported here, for
+	// active sessions as soon as their expiry passes rather than only once
+	// the sweeper marks them
+	state := WorkflowStateIdle
+	if mapped, err := session.StatusToWorkflowState(sess.Status); err == nil {
+		state = WorkflowState(mapped)
+	}
+	if sess.Status == session.StatusExpired || (!sess.Status.IsTerminal() && time.Now().After(sess.ExpiresAt)) {
+		state = WorkflowStateExpired
 	}
 
 	docSess := &DocumentationSession{
-		ID:          sess.GetID(),
+		ID:          o.formatSessionID(sess.ID),
 		WorkspaceID: sess.WorkspaceID,
-		ProjectPath: sess.ModuleName, // Using ModuleName as ProjectPath
+		ProjectPath: sess.ProjectPath,
+		ModuleName:  sess.ModuleName,
 		State:       state,
 		Progress: SessionProgress{
 			TotalFiles:     sess.Progress.TotalFiles,
 			ProcessedFiles: sess.Progress.ProcessedFiles,
 			FailedFiles:    len(sess.Progress.FailedFiles),
+			SkippedFiles:   len(sess.Progress.SkippedFiles),
 			CurrentFile:    sess.Progress.CurrentFile,
+			Percent:        sess.Progress.Percent,
 		},
 		CreatedAt: sess.CreatedAt,
 		UpdatedAt: sess.UpdatedAt,
 		ExpiresAt: sess.ExpiresAt,
 	}
 
-	return docSess, nil
+	return docSess
 }
 
+// enterProcessing moves a session that has not started processing into the
+// processing state. A pending session may be idle or already initialized in
+// the workflow engine, and an in-progress one may be paused, so the engine's
+// state is consulted for them.
+func (o *OrchestratorImpl) enterProcessing(ctx context.Context, sess *DocumentationSession) error {
+	if sess.State == WorkflowStateIdle || sess.State == WorkflowStateProcessing {
+		if state, err := o.workflowEngine.GetState(ctx, sess.ID); err == nil {
+			sess.State = WorkflowState(state)
+		}
+	}
+
+	switch sess.State {
+	case WorkflowStateProcessing:
+		return nil
+	case WorkflowStatePaused:
+		return fmt.Errorf("%w: %s", ErrSessionPaused, sess.ID)
+	case WorkflowStateIdle:
+		if err := o.workflowEngine.Transition(ctx, sess.ID, workflow.WorkflowStateProcessing); err != nil {
+			return fmt.Errorf("failed to transition to processing state: %w", err)
+		}
+	case WorkflowStateInitialized:
+		if err := o.workflowEngine.Trigger(ctx, sess.ID, workflow.EventProcess); err != nil {
+			return fmt.Errorf("failed to transition to processing state: %w", err)
+		}
+	default:
+		return fmt.Errorf("cannot transition from %s to %s", sess.State, WorkflowStateProcessing)
+	}
+
+	o.metrics().StateTransition(sess.State, WorkflowStateProcessing)
+	sess.State = WorkflowStateProcessing
+	return nil
+}
+
+// ErrNoMoreFiles is returned by ProcessNextFile once a session's TODO queue
+// is drained. Earlier versions returned a nil analysis and nil error instead.
+var ErrNoMoreFiles = errors.New("no more files to process")
+
 // ProcessNextFile processes the next file in the TODO queue for a session.
+// It returns ErrNoMoreFiles when every file has been handed out.
 func (o *OrchestratorImpl) ProcessNextFile(ctx context.Context, sessionID string) (*FileAnalysis, error) {
+	if err := o.beginOperation(); err != nil {
+		return nil, err
+	}
+	defer o.endOperation()
+
+	ctx = ContextWithSessionLogger(ctx, sessionID)
+	logger := LoggerFromContext(ctx)
+
 	// Get session
 	sess, err := o.GetSession(ctx, sessionID)
 	if err != nil {
 		return nil, err
 	}
+	if err := ensureActive(sess); err != nil {
+		return nil, err
+	}
 
 	// Check workflow state
-	if sess.State != WorkflowStateProcessing {
-		// Transition to processing state if idle
-		if sess.State == WorkflowStateIdle {
-			if err := o.workflowEngine.Transition(ctx, sessionID, workflow.WorkflowStateProcessing); err != nil {
-				return nil, fmt.Errorf("failed to transition to processing state: %w", err)
-			}
-			sess.State = WorkflowStateProcessing
-		} else {
-			return nil, fmt.Errorf("cannot transition from %s to %s", sess.State, WorkflowStateProcessing)
-		}
+	if err := o.enterProcessing(ctx, sess); err != nil {
+		return nil, err
+	}
+
+	// Deal with files that have waited too long before handing one out
+	if err := o.enforceQueueWait(ctx, sess); err != nil {
+		return nil, err
 	}
 
 	// Get next file from TODO list
@@ -225,96 +507,470 @@ func (o *OrchestratorImpl) ProcessNextFile(ctx context.Context, sessionID string
 		// Check if it's a "no more todos" error
 		var noMoreTodos *todolist.NoMoreTodosError
 		if errors.As(err, &noMoreTodos) {
-			// No more files to process
-			return nil, nil
+			return nil, ErrNoMoreFiles
 		}
 		return nil, fmt.Errorf("failed to get next file: %w", err)
 	}
 
-	// Update session progress
+	// Record the file before the slow analysis, so that after a crash the
+	// stored session still shows which file was in flight
+	if err := o.persistCurrentFile(sessionID, nextFile); err != nil {
+		if rollbackErr := o.todoManager.UpdateProgress(ctx, sessionID, nextFile, todolist.ItemStatusPending); rollbackErr != nil {
+			logger.Error().
+				Err(rollbackErr).
+				Str("file", nextFile).
+				Msg("Failed to return unrecorded file to the queue")
+		}
+		return nil, err
+	}
 	sess.Progress.CurrentFile = nextFile
 	sess.UpdatedAt = time.Now()
 
-	// Create placeholder file analysis
-	// (Actual implementation would call file system and AI services)
-	analysis := &FileAnalysis{
-		FilePath: nextFile,
-		Content:  "// TODO: Implement actual file analysis",
-		Metadata: FileMetadata{
-			Language:     "go",
-			Functions:    []string{},
-			Classes:      []string{},
-			Dependencies: []string{},
-			Complexity:   0,
-		},
-		TokenCount:  0,
-		ProcessedAt: time.Now(),
+	// Analyze the file, aborting if the orchestrator shuts down mid-flight
+	analysisCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(o.workerCtx, cancel)
+	defer stop()
+
+	started := time.Now()
+	analysis, err := o.analyzeWithRecovery(analysisCtx, sessionID, nextFile)
+	if err == nil {
+		analysis, err = o.processAnalysis(analysisCtx, analysis)
 	}
+	if err != nil {
+		if analysisCtx.Err() != nil {
+			// Interrupted rather than failed: put the file back so it isn't lost
+			if rollbackErr := o.todoManager.UpdateProgress(ctx, sessionID, nextFile, todolist.ItemStatusPending); rollbackErr != nil {
+				logger.Error().
+					Err(rollbackErr).
+					Str("file", nextFile).
+					Msg("Failed to return interrupted file to the queue")
+			} else if o.workerCtx.Err() != nil {
+				o.shutdownRequeued.Add(1)
+			}
+			o.clearCurrentFile(ctx, sessionID, nextFile)
+			return nil, fmt.Errorf("processing of %s interrupted: %w", nextFile, err)
+		}
 
-	// Update progress in session manager
-	sessionUUID, _ := uuid.Parse(sessionID)
-	progress := session.Progress{
-		TotalFiles:     sess.Progress.TotalFiles,
-		ProcessedFiles: sess.Progress.ProcessedFiles + 1,
-		CurrentFile:    "",
-		FailedFiles:    []string{},
+		var tooLarge *FileTooLargeError
+		if errors.As(err, &tooLarge) {
+			o.metrics().FileProcessed(FileStatusSkipped, time.Since(started))
+			return nil, o.skipFile(ctx, sessionID, nextFile, err)
+		}
+
+		o.metrics().FileProcessed(FileStatusFailed, time.Since(started))
+		if updateErr := o.todoManager.UpdateProgress(ctx, sessionID, nextFile, todolist.ItemStatusFailed); updateErr != nil {
+			logger.Error().
+				Err(updateErr).
+				Str("file", nextFile).
+				Msg("Failed to mark file as failed")
+		}
+		o.recordFileLatency(ctx, sessionID, nextFile)
+		o.publishProgress(sessionID, nextFile, ProgressFailed)
+		note := session.SessionNote{
+			FilePath: nextFile,
+			Status:   string(todolist.ItemStatusFailed),
+			Severity: session.NoteSeverityError,
+			Source:   NoteSourceProcessor,
+			Message:  fmt.Sprintf("analysis failed: %v", err),
+		}
+		fileErr := &FileProcessingError{FilePath: nextFile, Err: err}
+		if o.getSessionOptions(sessionID).FailFast {
+			o.recordFailedFile(ctx, sessionID, nextFile, nil)
+			return nil, o.failFast(ctx, sess, fileErr, note)
+		}
+		o.recordFailedFile(ctx, sessionID, nextFile, &note)
+		if failures, exceeded := o.recordFileFailure(sessionID); exceeded {
+			return nil, o.failConsecutive(ctx, sess, fileErr, failures)
+		}
+		return nil, fileErr
 	}
-	
-	if err := o.sessionManager.Update(sessionUUID, session.SessionUpdate{
-		Progress: &progress,
-	}); err != nil {
+	o.metrics().FileProcessed(FileStatusProcessed, time.Since(started))
+
+	if err := o.todoManager.UpdateProgress(ctx, sessionID, nextFile, todolist.ItemStatusComplete); err != nil {
+		return nil, fmt.Errorf("failed to update TODO progress: %w", err)
+	}
+	o.recordFileLatency(ctx, sessionID, nextFile)
+	o.resetFileFailures(sessionID)
+	o.publishProgress(sessionID, nextFile, ProgressProcessed)
+
+	// Persist the analysis so later sessions can skip unchanged files
+	if store, ok := o.analysisStore(); ok {
+		if err := store.SaveAnalysis(ctx, sess.ProjectPath, analysis); err != nil {
+			logger.Warn().
+				Err(err).
+				Str("file", nextFile).
+				Msg("Failed to store file analysis")
+		}
+	}
+
+	// Link the analysis into the memory graph when a MemoryService is set
+	if err := o.storeAnalysisMemory(ctx, sessionID, analysis); err != nil {
+		logger.Warn().
+			Err(err).
+			Str("file", nextFile).
+			Msg("Failed to store analysis memory")
+	}
+
+	// Update progress in session manager. Workers may finish files of the
+	// same session concurrently, so re-read the progress under the lock.
+	sessionUUID, _ := parseSessionID(sessionID)
+	o.progressMu.Lock()
+	current, err := o.sessionManager.Get(sessionUUID)
+	if err != nil {
+		o.progressMu.Unlock()
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+	progress := current.Progress
+	progress.ProcessedFiles++
+	if progress.CurrentFile == nextFile {
+		progress.CurrentFile = ""
+	}
+	if progress.FailedFiles == nil {
+		progress.FailedFiles = []string{}
+	}
+	// Log the completion first so a crash before the update can be replayed
+	sequence, logged := o.appendProgressLog(ctx, sessionID, nextFile, progress.ProcessedFiles)
+	err = o.sessionManager.Update(sessionUUID, session.NewSessionUpdate().WithProgress(progress).Build())
+	o.progressMu.Unlock()
+	if err != nil {
 		return nil, fmt.Errorf("failed to update session progress: %w", err)
 	}
+	if logged {
+		o.acknowledgeProgressLog(ctx, sequence)
+	}
 
-	log.Info().
-		Str("session_id", sessionID).
+	logger.Info().
 		Str("file", nextFile).
-		Int("processed", sess.Progress.ProcessedFiles).
-		Int("total", sess.Progress.TotalFiles).
+		Int("processed", progress.ProcessedFiles).
+		Int("total", progress.TotalFiles).
 		Msg("File processed")
 
 	return analysis, nil
 }
 
-// CompleteSession marks a documentation session as complete.
-func (o *OrchestratorImpl) CompleteSession(ctx context.Context, sessionID string) error {
+// recordFailedFile adds a failed file to the session's stored progress and
+// clears it if it is the current file, recording note with it when given. Like
+// skipped files, failed files must be stored on the session because its
+// TODO list is deleted once it completes.
+func (o *OrchestratorImpl) recordFailedFile(ctx context.Context, sessionID, filePath string, note *session.SessionNote) {
+	sessionUUID, _ := parseSessionID(sessionID)
+	o.progressMu.Lock()
+	defer o.progressMu.Unlock()
+
+	current, err := o.sessionManager.Get(sessionUUID)
+	if err == nil {
+		progress := current.Progress
+		if progress.CurrentFile == filePath {
+			progress.CurrentFile = ""
+		}
+		progress.FailedFiles = append(append([]string{}, progress.FailedFiles...), filePath)
+		update := session.NewSessionUpdate().WithProgress(progress)
+		if note != nil {
+			update = update.WithNote(*note)
+		}
+		err = o.sessionManager.Update(sessionUUID, update.Build())
+	}
+	if err != nil {
+		LoggerFromContext(ctx).Error().
+			Err(err).
+			Str("file", filePath).
+			Msg("Failed to record failed file on the session")
+	}
+}
+
+// clearCurrentFile clears filePath as the file a session is processing,
+// unless another worker has since recorded its own file.
+func (o *OrchestratorImpl) clearCurrentFile(ctx context.Context, sessionID, filePath string) {
+	sessionUUID, _ := parseSessionID(sessionID)
+	o.progressMu.Lock()
+	defer o.progressMu.Unlock()
+
+	current, err := o.sessionManager.Get(sessionUUID)
+	if err == nil && current.Progress.CurrentFile == filePath {
+		err = o.sessionManager.Update(sessionUUID, session.NewSessionUpdate().WithCurrentFile("").Build())
+	}
+	if err != nil {
+		LoggerFromContext(ctx).Error().
+			Err(err).
+			Str("file", filePath).
+			Msg("Failed to clear the current file")
+	}
+}
+
+// persistCurrentFile stores filePath as the file a session is processing.
+func (o *OrchestratorImpl) persistCurrentFile(sessionID, filePath string) error {
+	sessionUUID, err := parseSessionID(sessionID)
+	if err != nil {
+		return fmt.Errorf("invalid session ID: %w", err)
+	}
+
+	o.progressMu.Lock()
+	err = o.sessionManager.Update(sessionUUID, session.NewSessionUpdate().WithCurrentFile(filePath).Build())
+	o.progressMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to record current file %s: %w", filePath, err)
+	}
+	return nil
+}
+
+// AddFiles appends files to a running session's TODO queue.
+func (o *OrchestratorImpl) AddFiles(ctx context.Context, sessionID string, files []string, priority int) error {
+	if err := o.beginOperation(); err != nil {
+		return err
+	}
+	defer o.endOperation()
+
+	docSess, err := o.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if err := ensureActive(docSess); err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	// Validate every path before touching the queue
+	if fs, err := o.serviceRegistry.GetFileSystem(); err == nil {
+		var invalid []error
+		for _, file := range files {
+			if err := fs.ValidatePath(ctx, file); err != nil {
+				invalid = append(invalid, fmt.Errorf("%s: %w", file, err))
+			}
+		}
+		if len(invalid) > 0 {
+			return fmt.Errorf("invalid file paths: %w", errors.Join(invalid...))
+		}
+	}
+
+	// Files already in the list are reprioritized rather than added, so
+	// only count the items actually inserted
+	added := 0
+	var enqueueErr error
+	for _, file := range files {
+		inserted, err := o.todoManager.UpsertItem(ctx, sessionID, todolist.TodoItem{FilePath: file, Priority: priority})
+		if err != nil {
+			enqueueErr = fmt.Errorf("failed to enqueue files: %s: %w", file, err)
+			break
+		}
+		if inserted {
+			added++
+		}
+	}
+	if added == 0 {
+		return enqueueErr
+	}
+
+	// Grow the session total to include the new files, even when a later
+	// file failed to enqueue
+	sessionUUID, _ := parseSessionID(sessionID)
+	o.progressMu.Lock()
+	sess, err := o.sessionManager.Get(sessionUUID)
+	if err != nil {
+		o.progressMu.Unlock()
+		return fmt.Errorf("session not found: %w", err)
+	}
+	progress := sess.Progress
+	progress.TotalFiles += added
+	err = o.sessionManager.Update(sessionUUID, session.NewSessionUpdate().WithProgress(progress).Build())
+	o.progressMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to update session progress: %w", err)
+	}
+
+	LoggerFromContext(ContextWithSessionLogger(ctx, sessionID)).Info().
+		Int("added", added).
+		Int("total", progress.TotalFiles).
+		Msg("Files added to session")
+
+	return enqueueErr
+}
+
+// Drain flushes analyses buffered by the registered AnalysisStore. It is a
+// no-op when no store is registered or the store writes through.
+func (o *OrchestratorImpl) Drain(ctx context.Context, sessionID string) error {
+	if _, err := o.GetSession(ctx, sessionID); err != nil {
+		return err
+	}
+	return o.flushAnalyses(ctx)
+}
+
+// flushAnalyses flushes the analysis store if it buffers writes.
+func (o *OrchestratorImpl) flushAnalyses(ctx context.Context) error {
+	store, ok := o.analysisStore()
+	if !ok {
+		return nil
+	}
+	flusher, ok := store.(FlushingAnalysisStore)
+	if !ok {
+		return nil
+	}
+
+	if err := flusher.Flush(ctx); err != nil {
+		return fmt.Errorf("failed to flush analyses: %w", err)
+	}
+	return nil
+}
+
+// CompleteSession marks a documentation session as complete. Files that
+// are still queued either block completion or, with opts.SkipRemaining, are
+// marked skipped.
+func (o *OrchestratorImpl) CompleteSession(ctx context.Context, sessionID string, opts CompleteOptions) error {
+	ctx = ContextWithSessionLogger(ctx, sessionID)
+	logger := LoggerFromContext(ctx)
+
 	// Get session
 	sess, err := o.GetSession(ctx, sessionID)
 	if err != nil {
 		return err
 	}
 
+	// A retried completion succeeds without repeating any side effects
+	if sess.State == WorkflowStateComplete {
+		logger.Debug().Msg("Session already completed")
+		return nil
+	}
+	if err := ensureActive(sess); err != nil {
+		return err
+	}
+
+	// Never report complete while analyses could still be lost
+	if err := o.flushAnalyses(ctx); err != nil {
+		return err
+	}
+
+	// Guard against completing with unprocessed files
+	todoProgress, err := o.todoManager.GetProgress(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get TODO progress: %w", err)
+	}
+	var skipped []string
+	if remaining := todoProgress.Pending + todoProgress.InProgress; remaining > 0 {
+		if !opts.SkipRemaining {
+			return fmt.Errorf("session %s has %d unprocessed files", sessionID, remaining)
+		}
+		skipped, err = o.todoManager.SkipRemaining(ctx, sessionID)
+		if err != nil {
+			return fmt.Errorf("failed to skip remaining files: %w", err)
+		}
+	}
+
 	// Transition to complete state
 	if err := o.workflowEngine.Transition(ctx, sessionID, workflow.WorkflowStateComplete); err != nil {
 		return fmt.Errorf("failed to transition to complete state: %w", err)
 	}
+	o.metrics().StateTransition(sess.State, WorkflowStateComplete)
 
-	// Update session status to completed
-	sessionUUID, _ := uuid.Parse(sessionID)
-	completedStatus := session.StatusCompleted
-	if err := o.sessionManager.Update(sessionUUID, session.SessionUpdate{
-		Status: &completedStatus,
-	}); err != nil {
+	// Update session status to completed, recording any skipped files
+	sessionUUID, _ := parseSessionID(sessionID)
+	update := session.NewSessionUpdate().WithStatus(session.StatusCompleted)
+
+	o.progressMu.Lock()
+	if len(skipped) > 0 {
+		current, err := o.sessionManager.Get(sessionUUID)
+		if err != nil {
+			o.progressMu.Unlock()
+			return fmt.Errorf("session not found: %w", err)
+		}
+		progress := current.Progress
+		progress.CurrentFile = ""
+		progress.SkippedFiles = append(append([]string{}, progress.SkippedFiles...), skipped...)
+		update.WithProgress(progress).WithNote(session.SessionNote{
+			Status:   string(todolist.ItemStatusSkipped),
+			Severity: session.NoteSeverityWarn,
+			Source:   NoteSourceCompletion,
+			Message:  fmt.Sprintf("skipped %d unprocessed files on completion", len(skipped)),
+		})
+	}
+	err = o.sessionManager.Update(sessionUUID, update.Build())
+	o.progressMu.Unlock()
+	if err != nil {
 		return fmt.Errorf("failed to update session: %w", err)
 	}
+	for _, filePath := range skipped {
+		o.publishProgress(sessionID, filePath, ProgressSkipped)
+	}
 
-	// Clean up TODO list
+	// Clean up per-session state and the TODO list
+	o.clearSessionOptions(sessionID)
+	o.resetRecoveryStats(sessionID)
+	o.resetFileFailures(sessionID)
 	if err := o.todoManager.DeleteList(ctx, sessionID); err != nil {
-		log.Warn().
+		logger.Warn().
 			Err(err).
-			Str("session_id", sessionID).
 			Msg("Failed to delete TODO list")
 	}
 
-	log.Info().
-		Str("session_id", sessionID).
+	logger.Info().
 		Int("processed", sess.Progress.ProcessedFiles).
 		Int("failed", sess.Progress.FailedFiles).
+		Int("skipped", len(skipped)).
 		Msg("Documentation session completed")
 
 	return nil
 }
 
+// CancelSession stops a documentation session without completing it, leaving
+// any queued files unprocessed. Cancelling an already-cancelled session is a
+// no-op.
+func (o *OrchestratorImpl) CancelSession(ctx context.Context, sessionID string) error {
+	ctx = ContextWithSessionLogger(ctx, sessionID)
+	logger := LoggerFromContext(ctx)
+
+	sess, err := o.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	// Cancelled sessions are persisted as failed, so ask the engine
+	state, err := o.workflowEngine.GetState(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get workflow state: %w", err)
+	}
+	if state == workflow.WorkflowStateCancelled {
+		logger.Debug().Msg("Session already cancelled")
+		return nil
+	}
+	if err := ensureActive(sess); err != nil {
+		return err
+	}
+
+	if err := o.workflowEngine.Transition(ctx, sessionID, workflow.WorkflowStateCancelled); err != nil {
+		return fmt.Errorf("failed to transition to cancelled state: %w", err)
+	}
+	o.metrics().StateTransition(WorkflowState(state), WorkflowStateCancelled)
+
+	status, err := session.WorkflowStateToStatus(workflow.WorkflowStateCancelled)
+	if err != nil {
+		return err
+	}
+	sessionUUID, _ := parseSessionID(sessionID)
+	o.progressMu.Lock()
+	err = o.sessionManager.Update(sessionUUID, session.NewSessionUpdate().WithStatus(status).Build())
+	o.progressMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+
+	// Clean up per-session state and the TODO list
+	o.clearSessionOptions(sessionID)
+	o.resetRecoveryStats(sessionID)
+	o.resetFileFailures(sessionID)
+	if err := o.todoManager.DeleteList(ctx, sessionID); err != nil {
+		logger.Warn().
+			Err(err).
+			Msg("Failed to delete TODO list")
+	}
+
+	logger.Info().
+		Int("processed", sess.Progress.ProcessedFiles).
+		Msg("Documentation session cancelled")
+
+	return nil
+}
+
 // Container returns the dependency injection container.
 // This allows external code to register additional services.
 func (o *OrchestratorImpl) Container() Container {
@@ -322,45 +978,151 @@ func (o *OrchestratorImpl) Container() Container {
 }
 
 // validateDocumentationRequest ensures the request has all required fields.
-func validateDocumentationRequest(req DocumentationRequest) error {
+// Every problem is collected into a single ValidationError so callers can
+// fix them all at once.
+func validateDocumentationRequest(req *DocumentationRequest, baseDir string) error {
+	var problems []string
+
 	if req.ProjectPath == "" {
-		return fmt.Errorf("project_path is required")
+		problems = append(problems, "project_path is required")
+	} else if path, err := normalizeProjectPath(req.ProjectPath, baseDir); err != nil {
+		problems = append(problems, err.Error())
+	} else {
+		req.ProjectPath = path
 	}
 	if req.WorkspaceID == "" {
-		return fmt.Errorf("workspace_id is required")
+		problems = append(problems, "workspace_id is required")
 	}
 
 	// Validate options
 	if req.Options.MaxDepth < 0 {
-		return fmt.Errorf("max_depth cannot be negative")
+		problems = append(problems, "max_depth cannot be negative")
 	}
 
+	if req.Options.MaxConcurrency < 0 {
+		problems = append(problems, "max_concurrency cannot be negative")
+	}
+
+	switch req.Options.ReprocessPolicy {
+	case "", ReprocessAll, ReprocessChanged, ReprocessMissing:
+	default:
+		problems = append(problems, fmt.Sprintf("invalid reprocess_policy: %s", req.Options.ReprocessPolicy))
+	}
+
+	if req.Options.Template != "" && !IsKnownTemplate(req.Options.Template) {
+		problems = append(problems, fmt.Sprintf("unknown template: %s (known: %s)", req.Options.Template, strings.Join(KnownTemplates(), ", ")))
+	}
+
+	problems = append(problems, validatePatterns("file_patterns", req.Options.FilePatterns)...)
+	problems = append(problems, validatePatterns("exclude_patterns", req.Options.ExcludePatterns)...)
+
+	if req.IncludeDependencies && len(req.Files) == 0 {
+		problems = append(problems, "include_dependencies requires files")
+	}
+
+	// An explicit file list replaces discovery, so its options would be ignored
+	if len(req.Files) > 0 {
+		if set := discoveryOptionsSet(req.Options); len(set) > 0 {
+			problems = append(problems, fmt.Sprintf("files cannot be combined with discovery options (%s); use either an explicit file list or discovery", strings.Join(set, ", ")))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Message: "invalid documentation request", Errors: problems}
+	}
 	return nil
 }
 
+// discoveryOptionsSet returns the names of the options that only apply when
+// files are discovered by scanning the project.
+func discoveryOptionsSet(opts DocumentationOptions) []string {
+	var set []string
+	if len(opts.FilePatterns) > 0 {
+		set = append(set, "file_patterns")
+	}
+	if len(opts.ExcludePatterns) > 0 {
+		set = append(set, "exclude_patterns")
+	}
+	if opts.MaxDepth > 0 {
+		set = append(set, "max_depth")
+	}
+	if len(opts.Languages) > 0 {
+		set = append(set, "languages")
+	}
+	return set
+}
+
+// validatePatterns reports every pattern that is not a well-formed glob.
+func validatePatterns(field string, patterns []string) []string {
+	var problems []string
+	for _, pattern := range patterns {
+		if _, err := filepath.Match(pattern, "probe"); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid pattern %q in %s: %v", pattern, field, err))
+		}
+	}
+	return problems
+}
+
+// normalizeProjectPath cleans path and makes it absolute. Relative paths are
+// resolved against baseDir, and when baseDir is set the result must stay
+// inside it. Without a baseDir only absolute paths are accepted.
+func normalizeProjectPath(path, baseDir string) (string, error) {
+	if strings.ContainsRune(path, 0) {
+		return "", fmt.Errorf("project_path contains a NUL byte")
+	}
+
+	if !filepath.IsAbs(path) {
+		if baseDir == "" {
+			return "", fmt.Errorf("project_path must be absolute: %s", path)
+		}
+		path = filepath.Join(baseDir, path)
+	}
+	path = filepath.Clean(path)
+
+	if baseDir != "" {
+		rel, err := filepath.Rel(filepath.Clean(baseDir), path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", fmt.Errorf("project_path escapes the project base directory: %s", path)
+		}
+	}
+	return path, nil
+}
+
+// projectBaseDir returns the directory relative project paths resolve
+// against, or "" when none is configured.
+func (o *OrchestratorImpl) projectBaseDir() string {
+	if o.config == nil {
+		return ""
+	}
+	return o.config.Session.ProjectBaseDir
+}
+
+// connPool is the subset of *sql.DB used to configure pooling.
+type connPool interface {
+	SetMaxOpenConns(n int)
+	SetMaxIdleConns(n int)
+	SetConnMaxLifetime(d time.Duration)
+	SetConnMaxIdleTime(d time.Duration)
+}
+
+// configurePool applies the connection pool settings from cfg.
+func configurePool(pool connPool, cfg *DatabaseConfig) {
+	pool.SetMaxOpenConns(cfg.MaxOpenConns)
+	pool.SetMaxIdleConns(cfg.MaxIdleConns)
+	pool.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	pool.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+}
+
 // InitDatabase initializes a database connection pool with the provided configuration.
 // It sets up connection pooling parameters and verifies connectivity.
 func InitDatabase(cfg *DatabaseConfig) (*sql.DB, error) {
-	// Build connection string
-	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Database, cfg.SSLMode)
-
-	// Open database connection
-	db, err := sql.Open("postgres", dsn)
+	db, err := OpenDatabase(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database connection: %w", err)
+		return nil, err
 	}
 
-	// Configure connection pool
-	db.SetMaxOpenConns(cfg.MaxOpenConns)
-	db.SetMaxIdleConns(cfg.MaxIdleConns)
-	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
-
 	// Verify connectivity
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	
-	if err := db.PingContext(ctx); err != nil {
+	if err := pingDatabase(db); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
@@ -373,3 +1135,31 @@ func InitDatabase(cfg *DatabaseConfig) (*sql.DB, error) {
 
 	return db, nil
 }
+
+// OpenDatabase opens a database connection pool with the provided
+// configuration without verifying connectivity, so it succeeds while the
+// database is down.
+func OpenDatabase(cfg *DatabaseConfig) (*sql.DB, error) {
+	// Build connection string
+	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Database, cfg.SSLMode)
+
+	// Open database connection
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database connection: %w", err)
+	}
+
+	// Configure connection pool
+	configurePool(db, cfg)
+
+	return db, nil
+}
+
+// pingDatabase verifies that db answers a ping within five seconds.
+func pingDatabase(db *sql.DB) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	
+	return db.PingContext(ctx)
+}
@@ -1,11 +1,17 @@
 package orchestrator
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"io"
 	"net/url"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -17,6 +23,7 @@ import (
 	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/workflow"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -27,14 +34,27 @@ type mockSessionManager struct {
 	mock.Mock
 }
 
-func (m *mockSessionManager) Create(workspaceID, moduleName string, filePaths []string) (*session.Session, error) {
-	args := m.Called(workspaceID, moduleName, filePaths)
+func (m *mockSessionManager) Create(workspaceID, projectPath, moduleName string, filePaths []string) (*session.Session, error) {
+	args := m.Called(workspaceID, projectPath, moduleName, filePaths)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*session.Session), args.Error(1)
 }
 
+// CreateAtomic answers from the Create expectation and runs init without a
+// transaction.
+func (m *mockSessionManager) CreateAtomic(workspaceID, projectPath, moduleName string, filePaths []string, init func(*sql.Tx, *session.Session) error) (*session.Session, error) {
+	sess, err := m.Create(workspaceID, projectPath, moduleName, filePaths)
+	if err != nil {
+		return nil, err
+	}
+	if err := init(nil, sess); err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
 func (m *mockSessionManager) Get(id uuid.UUID) (*session.Session, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
@@ -61,6 +81,14 @@ func (m *mockSessionManager) List(filter session.SessionFilter) ([]*session.Sess
 	return args.Get(0).([]*session.Session), args.Error(1)
 }
 
+func (m *mockSessionManager) Search(workspaceID, query string) ([]*session.Session, error) {
+	args := m.Called(workspaceID, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*session.Session), args.Error(1)
+}
+
 func (m *mockSessionManager) ExpireSessions() error {
 	args := m.Called()
 	return args.Error(0)
@@ -100,11 +128,47 @@ func (m *mockWorkflowEngine) GetHistory(ctx context.Context, sessionID string) (
 	return args.Get(0).([]workflow.StateTransition), args.Error(1)
 }
 
+func (m *mockWorkflowEngine) ForceState(ctx context.Context, sessionID string, state workflow.WorkflowState, reason string) error {
+	args := m.Called(ctx, sessionID, state, reason)
+	return args.Error(0)
+}
+
 func (m *mockWorkflowEngine) Trigger(ctx context.Context, sessionID string, event workflow.WorkflowEvent) error {
 	args := m.Called(ctx, sessionID, event)
 	return args.Error(0)
 }
 
+func (m *mockWorkflowEngine) CompactHistory(sessionID string) error {
+	args := m.Called(sessionID)
+	return args.Error(0)
+}
+
+func (m *mockWorkflowEngine) StateVisitCounts(sessionID string) (map[workflow.WorkflowState]int, error) {
+	args := m.Called(sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[workflow.WorkflowState]int), args.Error(1)
+}
+
+func (m *mockWorkflowEngine) GetHistoryFiltered(ctx context.Context, sessionID string, filter workflow.HistoryFilter) ([]workflow.StateTransition, error) {
+	args := m.Called(ctx, sessionID, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]workflow.StateTransition), args.Error(1)
+}
+
+func (m *mockWorkflowEngine) Remove(ctx context.Context, sessionID string) error {
+	args := m.Called(ctx, sessionID)
+	return args.Error(0)
+}
+
+func (m *mockWorkflowEngine) HistoryToDOT(sessionID string) (string, error) {
+	args := m.Called(sessionID)
+	return args.String(0), args.Error(1)
+}
+
 func (m *mockWorkflowEngine) CanTransition(from workflow.WorkflowState, event workflow.WorkflowEvent) (workflow.WorkflowState, bool) {
 	args := m.Called(from, event)
 	return args.Get(0).(workflow.WorkflowState), args.Bool(1)
@@ -114,16 +178,57 @@ type mockTodoManager struct {
 	mock.Mock
 }
 
+func (m *mockTodoManager) Shutdown() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *mockTodoManager) GetProgressBatch(ctx context.Context, sessionIDs []string) (map[string]*todolist.Progress, error) {
+	args := m.Called(ctx, sessionIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]*todolist.Progress), args.Error(1)
+}
+
+func (m *mockTodoManager) ListItems(ctx context.Context, sessionID string) ([]todolist.TodoItem, error) {
+	args := m.Called(ctx, sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]todolist.TodoItem), args.Error(1)
+}
+
+func (m *mockTodoManager) GetItem(ctx context.Context, sessionID string, filePath string) (todolist.TodoItem, error) {
+	args := m.Called(ctx, sessionID, filePath)
+	return args.Get(0).(todolist.TodoItem), args.Error(1)
+}
+
 func (m *mockTodoManager) CreateList(ctx context.Context, sessionID string) error {
 	args := m.Called(ctx, sessionID)
 	return args.Error(0)
 }
 
+func (m *mockTodoManager) CreateListWithOptions(ctx context.Context, sessionID string, opts todolist.ListOptions) error {
+	args := m.Called(ctx, sessionID, opts)
+	return args.Error(0)
+}
+
 func (m *mockTodoManager) AddItem(ctx context.Context, sessionID string, item todolist.TodoItem) error {
 	args := m.Called(ctx, sessionID, item)
 	return args.Error(0)
 }
 
+func (m *mockTodoManager) UpsertItem(ctx context.Context, sessionID string, item todolist.TodoItem) (bool, error) {
+	args := m.Called(ctx, sessionID, item)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockTodoManager) AddItems(ctx context.Context, sessionID string, items []todolist.TodoItem) error {
+	args := m.Called(ctx, sessionID, items)
+	return args.Error(0)
+}
+
 func (m *mockTodoManager) GetNext(ctx context.Context, sessionID string) (string, error) {
 	args := m.Called(ctx, sessionID)
 	return args.String(0), args.Error(1)
@@ -142,17 +247,149 @@ func (m *mockTodoManager) GetProgress(ctx context.Context, sessionID string) (*t
 	return args.Get(0).(*todolist.Progress), args.Error(1)
 }
 
+func (m *mockTodoManager) GetNextAcrossSessions(ctx context.Context, sessionIDs []string) (string, todolist.TodoItem, error) {
+	args := m.Called(ctx, sessionIDs)
+	return args.String(0), args.Get(1).(todolist.TodoItem), args.Error(2)
+}
+
+func (m *mockTodoManager) SkipRemaining(ctx context.Context, sessionID string) ([]string, error) {
+	args := m.Called(ctx, sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
 func (m *mockTodoManager) DeleteList(ctx context.Context, sessionID string) error {
 	args := m.Called(ctx, sessionID)
 	return args.Error(0)
 }
 
+func (m *mockTodoManager) QueueStats(ctx context.Context, sessionID string) (*todolist.QueueStats, error) {
+	args := m.Called(ctx, sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*todolist.QueueStats), args.Error(1)
+}
+
+func (m *mockTodoManager) PromoteStarved(ctx context.Context, sessionID string, maxWait time.Duration) ([]string, error) {
+	args := m.Called(ctx, sessionID, maxWait)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// stubAIService is a configurable AIService for exercising analysis paths.
+type stubAIService struct {
+	analyzeFunc     func(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error)
+	generateFunc    func(ctx context.Context, req services.DocumentationRequest) (*services.DocumentationResponse, error)
+	countTokensFunc func(ctx context.Context, text string) (int, error)
+}
+
+func (s *stubAIService) AnalyzeFile(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error) {
+	if s.analyzeFunc != nil {
+		return s.analyzeFunc(ctx, req)
+	}
+	return &services.FileAnalysisResponse{Summary: "analysis of " + req.FilePath}, nil
+}
+
+func (s *stubAIService) GenerateDocumentation(ctx context.Context, req services.DocumentationRequest) (*services.DocumentationResponse, error) {
+	if s.generateFunc != nil {
+		return s.generateFunc(ctx, req)
+	}
+	return &services.DocumentationResponse{Content: "documentation"}, nil
+}
+
+func (s *stubAIService) CountTokens(ctx context.Context, text string) (int, error) {
+	if s.countTokensFunc != nil {
+		return s.countTokensFunc(ctx, text)
+	}
+	return len(text), nil
+}
+
+// fakeFileSystem is an in-memory FileSystemService keyed by absolute path.
+type fakeFileSystem struct {
+	files map[string][]byte
+}
+
+func (f *fakeFileSystem) ListFiles(ctx context.Context, req services.ListFilesRequest) ([]services.FileInfo, error) {
+	var infos []services.FileInfo
+	for path, content := range f.files {
+		if strings.HasPrefix(path, req.RootPath) {
+			infos = append(infos, services.FileInfo{Path: path, Size: int64(len(content))})
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Path < infos[j].Path })
+	return infos, nil
+}
+
+func (f *fakeFileSystem) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	content, ok := f.files[path]
+	if !ok {
+		return nil, fmt.Errorf("file not found: %s", path)
+	}
+	return content, nil
+}
+
+func (f *fakeFileSystem) OpenFile(ctx context.Context, path string) (io.ReadCloser, error) {
+	content, ok := f.files[path]
+	if !ok {
+		return nil, fmt.Errorf("file not found: %s", path)
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (f *fakeFileSystem) WriteFile(ctx context.Context, path string, content []byte) error {
+	f.files[path] = content
+	return nil
+}
+
+func (f *fakeFileSystem) GetFileInfo(ctx context.Context, path string) (*services.FileInfo, error) {
+	content, ok := f.files[path]
+	if !ok {
+		return nil, fmt.Errorf("file not found: %s", path)
+	}
+	return &services.FileInfo{Path: path, Size: int64(len(content))}, nil
+}
+
+func (f *fakeFileSystem) ValidatePath(ctx context.Context, path string) error {
+	if strings.Contains(path, "..") {
+		return fmt.Errorf("path escapes project root: %s", path)
+	}
+	return nil
+}
+
+// memoryAnalysisStore is an in-memory AnalysisStore.
+type memoryAnalysisStore struct {
+	mu       sync.Mutex
+	analyses map[string]*FileAnalysis
+}
+
+func newMemoryAnalysisStore() *memoryAnalysisStore {
+	return &memoryAnalysisStore{analyses: make(map[string]*FileAnalysis)}
+}
+
+func (s *memoryAnalysisStore) GetAnalysis(ctx context.Context, projectPath, filePath string) (*FileAnalysis, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.analyses[projectPath+"|"+filePath], nil
+}
+
+func (s *memoryAnalysisStore) SaveAnalysis(ctx context.Context, projectPath string, analysis *FileAnalysis) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.analyses[projectPath+"|"+analysis.FilePath] = analysis
+	return nil
+}
+
 // Test helper functions
-func createMockSession(id, workspaceID, moduleName string) *session.Session {
+func createMockSession(id, workspaceID, projectPath string) *session.Session {
 	return &session.Session{
 		ID:          uuid.MustParse(id),
 		WorkspaceID: workspaceID,
-		ModuleName:  moduleName,
+		ProjectPath: projectPath,
 		Status:      session.StatusPending,
 		FilePaths:   []string{},
 		Progress:    session.Progress{},
@@ -209,10 +446,12 @@ func setupTestDatabaseWithSchema(ctx context.Context, t *testing.T) (*sql.DB, fu
 		CREATE TABLE IF NOT EXISTS documentation_sessions (
 			id UUID PRIMARY KEY,
 			workspace_id VARCHAR(255) NOT NULL,
+			project_path TEXT NOT NULL DEFAULT '',
 			module_name VARCHAR(255) NOT NULL DEFAULT '',
 			status VARCHAR(50) NOT NULL,
 			file_paths TEXT[] NOT NULL DEFAULT '{}',
 			progress JSONB NOT NULL DEFAULT '{"total_files": 0, "processed_files": 0, "failed_files": []}'::jsonb,
+			notes JSONB NOT NULL DEFAULT '[]'::jsonb,
 			version INTEGER NOT NULL DEFAULT 1,
 			created_at TIMESTAMP WITH TIME ZONE NOT NULL,
 			updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
@@ -332,6 +571,9 @@ func createTestOrchestrator(t *testing.T) (*OrchestratorImpl, *mockSessionManage
 	container.Register("services", mockServices)
 	container.Register("config", config)
 
+	workerCtx, cancelWorkers := context.WithCancel(context.Background())
+	t.Cleanup(cancelWorkers)
+
 	o := &OrchestratorImpl{
 		container:       container,
 		sessionManager:  mockSession,
@@ -339,6 +581,8 @@ func createTestOrchestrator(t *testing.T) (*OrchestratorImpl, *mockSessionManage
 		todoManager:     mockTodo,
 		serviceRegistry: mockServices,
 		config:          config,
+		workerCtx:       workerCtx,
+		cancelWorkers:   cancelWorkers,
 	}
 
 	return o, mockSession, mockWorkflow, mockTodo
@@ -446,7 +690,7 @@ func TestStartDocumentation(t *testing.T) {
 				mockSess := &session.Session{
 					ID:          uuid.New(),
 					WorkspaceID: "workspace-123",
-					ModuleName:  "/path/to/project",
+					ProjectPath: "/path/to/project",
 					Status:      session.StatusPending,
 					FilePaths:   []string{},
 					Progress:    session.Progress{},
@@ -454,21 +698,43 @@ func TestStartDocumentation(t *testing.T) {
 					UpdatedAt:   time.Now(),
 					ExpiresAt:   time.Now().Add(24 * time.Hour),
 				}
-				sm.On("Create", "workspace-123", "/path/to/project", []string{}).Return(mockSess, nil)
+				sm.On("Create", "workspace-123", "/path/to/project", "", []string{}).Return(mockSess, nil)
 				we.On("Initialize", mock.Anything, mockSess.GetID(), workflow.WorkflowStateIdle).Return(nil)
 				tm.On("CreateList", mock.Anything, mockSess.GetID()).Return(nil)
+				we.On("Trigger", mock.Anything, mockSess.GetID(), workflow.EventStart).Return(nil)
 			},
 			wantErr: false,
 			verifyResult: func(t *testing.T, sess *DocumentationSession) {
 				assert.NotEmpty(t, sess.ID)
 				assert.Equal(t, "workspace-123", sess.WorkspaceID)
 				assert.Equal(t, "/path/to/project", sess.ProjectPath)
-				assert.Equal(t, WorkflowStateIdle, sess.State)
+				assert.Equal(t, WorkflowStateInitialized, sess.State)
 				assert.Equal(t, 0, sess.Progress.TotalFiles)
 				assert.Equal(t, 0, sess.Progress.ProcessedFiles)
 				assert.Equal(t, 0, sess.Progress.FailedFiles)
 			},
 		},
+		{
+			name: "module session keeps project path and module name",
+			req: DocumentationRequest{
+				WorkspaceID: "workspace-123",
+				ProjectPath: "/path/to/project",
+				ModuleName:  "billing",
+			},
+			setupMocks: func(sm *mockSessionManager, we *mockWorkflowEngine, tm *mockTodoManager) {
+				mockSess := createMockSession("550e8400-e29b-41d4-a716-446655440029", "workspace-123", "/path/to/project")
+				mockSess.ModuleName = "billing"
+				sm.On("Create", "workspace-123", "/path/to/project", "billing", []string{}).Return(mockSess, nil)
+				we.On("Initialize", mock.Anything, mockSess.GetID(), workflow.WorkflowStateIdle).Return(nil)
+				tm.On("CreateList", mock.Anything, mockSess.GetID()).Return(nil)
+				we.On("Trigger", mock.Anything, mockSess.GetID(), workflow.EventStart).Return(nil)
+			},
+			wantErr: false,
+			verifyResult: func(t *testing.T, sess *DocumentationSession) {
+				assert.Equal(t, "/path/to/project", sess.ProjectPath)
+				assert.Equal(t, "billing", sess.ModuleName)
+			},
+		},
 		{
 			name: "missing workspace ID",
 			req: DocumentationRequest{
@@ -498,7 +764,7 @@ func TestStartDocumentation(t *testing.T) {
 				ProjectPath: "/path/to/project",
 			},
 			setupMocks: func(sm *mockSessionManager, we *mockWorkflowEngine, tm *mockTodoManager) {
-				sm.On("Create", "workspace-123", "/path/to/project", []string{}).
+				sm.On("Create", "workspace-123", "/path/to/project", "", []string{}).
 					Return(nil, errors.New("database error"))
 			},
 			wantErr: true,
@@ -514,7 +780,7 @@ func TestStartDocumentation(t *testing.T) {
 				mockSess := &session.Session{
 					ID:          uuid.New(),
 					WorkspaceID: "workspace-123",
-					ModuleName:  "/path/to/project",
+					ProjectPath: "/path/to/project",
 					Status:      session.StatusPending,
 					FilePaths:   []string{},
 					Progress:    session.Progress{},
@@ -522,7 +788,7 @@ func TestStartDocumentation(t *testing.T) {
 					UpdatedAt:   time.Now(),
 					ExpiresAt:   time.Now().Add(24 * time.Hour),
 				}
-				sm.On("Create", "workspace-123", "/path/to/project", []string{}).Return(mockSess, nil)
+				sm.On("Create", "workspace-123", "/path/to/project", "", []string{}).Return(mockSess, nil)
 				we.On("Initialize", mock.Anything, mockSess.GetID(), workflow.WorkflowStateIdle).
 					Return(errors.New("workflow error"))
 			},
@@ -539,7 +805,7 @@ func TestStartDocumentation(t *testing.T) {
 				mockSess := &session.Session{
 					ID:          uuid.New(),
 					WorkspaceID: "workspace-123",
-					ModuleName:  "/path/to/project",
+					ProjectPath: "/path/to/project",
 					Status:      session.StatusPending,
 					FilePaths:   []string{},
 					Progress:    session.Progress{},
@@ -547,14 +813,53 @@ func TestStartDocumentation(t *testing.T) {
 					UpdatedAt:   time.Now(),
 					ExpiresAt:   time.Now().Add(24 * time.Hour),
 				}
-				sm.On("Create", "workspace-123", "/path/to/project", []string{}).Return(mockSess, nil)
+				sm.On("Create", "workspace-123", "/path/to/project", "", []string{}).Return(mockSess, nil)
 				we.On("Initialize", mock.Anything, mockSess.GetID(), workflow.WorkflowStateIdle).Return(nil)
 				tm.On("CreateList", mock.Anything, mockSess.GetID()).
 					Return(errors.New("todo error"))
+				// The workflow initialized for the uncreated session is discarded
+				we.On("Remove", mock.Anything, mockSess.GetID()).Return(nil)
 			},
 			wantErr: true,
 			errMsg:  "failed to create TODO list",
 		},
+		{
+			name: "workflow start fails",
+			req: DocumentationRequest{
+				WorkspaceID: "workspace-123",
+				ProjectPath: "/path/to/project",
+			},
+			setupMocks: func(sm *mockSessionManager, we *mockWorkflowEngine, tm *mockTodoManager) {
+				mockSess := createMockSession("550e8400-e29b-41d4-a716-446655440020", "workspace-123", "/path/to/project")
+				sm.On("Create", "workspace-123", "/path/to/project", "", []string{}).Return(mockSess, nil)
+				we.On("Initialize", mock.Anything, mockSess.GetID(), workflow.WorkflowStateIdle).Return(nil)
+				tm.On("CreateList", mock.Anything, mockSess.GetID()).Return(nil)
+				we.On("Trigger", mock.Anything, mockSess.GetID(), workflow.EventStart).
+					Return(errors.New("invalid transition"))
+				tm.On("DeleteList", mock.Anything, mockSess.GetID()).Return(nil)
+				we.On("Remove", mock.Anything, mockSess.GetID()).Return(nil)
+			},
+			wantErr: true,
+			errMsg:  "failed to start workflow",
+		},
+		{
+			name: "skip auto initialization",
+			req: DocumentationRequest{
+				WorkspaceID:        "workspace-123",
+				ProjectPath:        "/path/to/project",
+				SkipAutoInitialize: true,
+			},
+			setupMocks: func(sm *mockSessionManager, we *mockWorkflowEngine, tm *mockTodoManager) {
+				mockSess := createMockSession("550e8400-e29b-41d4-a716-446655440021", "workspace-123", "/path/to/project")
+				sm.On("Create", "workspace-123", "/path/to/project", "", []string{}).Return(mockSess, nil)
+				we.On("Initialize", mock.Anything, mockSess.GetID(), workflow.WorkflowStateIdle).Return(nil)
+				tm.On("CreateList", mock.Anything, mockSess.GetID()).Return(nil)
+			},
+			wantErr: false,
+			verifyResult: func(t *testing.T, sess *DocumentationSession) {
+				assert.Equal(t, WorkflowStateIdle, sess.State)
+			},
+		},
 		{
 			name: "default max depth applied",
 			req: DocumentationRequest{
@@ -568,7 +873,7 @@ func TestStartDocumentation(t *testing.T) {
 				mockSess := &session.Session{
 					ID:          uuid.New(),
 					WorkspaceID: "workspace-123",
-					ModuleName:  "/path/to/project",
+					ProjectPath: "/path/to/project",
 					Status:      session.StatusPending,
 					FilePaths:   []string{},
 					Progress:    session.Progress{},
@@ -576,9 +881,10 @@ func TestStartDocumentation(t *testing.T) {
 					UpdatedAt:   time.Now(),
 					ExpiresAt:   time.Now().Add(24 * time.Hour),
 				}
-				sm.On("Create", "workspace-123", "/path/to/project", []string{}).Return(mockSess, nil)
+				sm.On("Create", "workspace-123", "/path/to/project", "", []string{}).Return(mockSess, nil)
 				we.On("Initialize", mock.Anything, mockSess.GetID(), workflow.WorkflowStateIdle).Return(nil)
 				tm.On("CreateList", mock.Anything, mockSess.GetID()).Return(nil)
+				we.On("Trigger", mock.Anything, mockSess.GetID(), workflow.EventStart).Return(nil)
 			},
 			wantErr: false,
 			verifyResult: func(t *testing.T, sess *DocumentationSession) {
@@ -602,6 +908,21 @@ func TestStartDocumentation(t *testing.T) {
 			wantErr: true,
 			errMsg:  "max_depth cannot be negative",
 		},
+		{
+			name: "malformed file pattern",
+			req: DocumentationRequest{
+				WorkspaceID: "workspace-123",
+				ProjectPath: "/path/to/project",
+				Options: DocumentationOptions{
+					FilePatterns: []string{"[abc"},
+				},
+			},
+			setupMocks: func(sm *mockSessionManager, we *mockWorkflowEngine, tm *mockTodoManager) {
+				// No mocks needed - validation fails before a session is created
+			},
+			wantErr: true,
+			errMsg:  `invalid pattern "[abc"`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -635,6 +956,65 @@ func TestStartDocumentation(t *testing.T) {
 	}
 }
 
+func TestStartDocumentationInitialState(t *testing.T) {
+	for _, skip := range []bool{false, true} {
+		t.Run(fmt.Sprintf("initialized with skip auto initialize %v", skip), func(t *testing.T) {
+			o, mockSession, mockWorkflow, mockTodo := createTestOrchestrator(t)
+			o.config.Workflow.InitialState = WorkflowStateInitialized
+
+			mockSess := createMockSession("550e8400-e29b-41d4-a716-446655440030", "workspace-123", "/path/to/project")
+			mockSession.On("Create", "workspace-123", "/path/to/project", "", []string{}).Return(mockSess, nil)
+			mockWorkflow.On("Initialize", mock.Anything, mockSess.GetID(), workflow.WorkflowStateInitialized).Return(nil)
+			mockTodo.On("CreateList", mock.Anything, mockSess.GetID()).Return(nil)
+
+			sess, err := o.StartDocumentation(context.Background(), DocumentationRequest{
+				WorkspaceID:        "workspace-123",
+				ProjectPath:        "/path/to/project",
+				SkipAutoInitialize: skip,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, WorkflowStateInitialized, sess.State)
+
+			// The session starts initialized, so there is nothing to trigger
+			mockWorkflow.AssertExpectations(t)
+			mockWorkflow.AssertNotCalled(t, "Trigger", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestStartDocumentationDiscardsPartialSession(t *testing.T) {
+	ctx := context.Background()
+	o, mockSession, _, mockTodo := createTestOrchestrator(t)
+	engine, err := workflow.NewEngine(workflow.WorkflowConfig{})
+	require.NoError(t, err)
+	o.workflowEngine = engine
+	require.NoError(t, o.serviceRegistry.RegisterFileSystem(&fakeFileSystem{files: map[string][]byte{
+		"/path/to/project/main.go": []byte("package main"),
+	}}))
+
+	// Seeding the TODO list fails after the session row was inserted
+	mockSess := createMockSession("550e8400-e29b-41d4-a716-446655440031", "workspace-123", "/path/to/project")
+	mockSession.On("Create", "workspace-123", "/path/to/project", "", []string{"/path/to/project/main.go"}).Return(mockSess, nil)
+	mockTodo.On("CreateList", mock.Anything, mockSess.GetID()).Return(nil)
+	mockTodo.On("AddItem", mock.Anything, mockSess.GetID(), mock.Anything).Return(errors.New("queue full"))
+	mockTodo.On("DeleteList", mock.Anything, mockSess.GetID()).Return(nil)
+
+	_, err = o.StartDocumentation(ctx, DocumentationRequest{
+		WorkspaceID:    "workspace-123",
+		ProjectPath:    "/path/to/project",
+		Files:          []string{"main.go"},
+		IdempotencyKey: "start-1",
+	})
+	require.ErrorContains(t, err, "failed to enqueue /path/to/project/main.go: queue full")
+
+	// Nothing of the session is left behind
+	mockTodo.AssertCalled(t, "DeleteList", mock.Anything, mockSess.GetID())
+	_, err = engine.GetState(ctx, mockSess.GetID())
+	assert.Error(t, err)
+	_, err = o.GetSessionByKey(ctx, "start-1")
+	assert.Error(t, err)
+}
+
 // Test GetSession
 func TestGetSession(t *testing.T) {
 	tests := []struct {
@@ -725,6 +1105,245 @@ func TestGetSession(t *testing.T) {
 	}
 }
 
+func TestCanFireEvent(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		state      workflow.WorkflowState
+		event      workflow.WorkflowEvent
+		wantTarget workflow.WorkflowState
+		wantOK     bool
+	}{
+		{
+			name:       "pause while processing",
+			state:      workflow.WorkflowStateProcessing,
+			event:      workflow.EventPause,
+			wantTarget: workflow.WorkflowStatePaused,
+			wantOK:     true,
+		},
+		{
+			name:       "resume while paused",
+			state:      workflow.WorkflowStatePaused,
+			event:      workflow.EventResume,
+			wantTarget: workflow.WorkflowStateProcessing,
+			wantOK:     true,
+		},
+		{
+			name:   "pause while idle",
+			state:  workflow.WorkflowStateIdle,
+			event:  workflow.EventPause,
+			wantOK: false,
+		},
+		{
+			name:   "complete while initialized",
+			state:  workflow.WorkflowStateInitialized,
+			event:  workflow.EventComplete,
+			wantOK: false,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, _, _, _ := createTestOrchestrator(t)
+			engine, err := workflow.NewEngine(workflow.WorkflowConfig{})
+			require.NoError(t, err)
+			o.workflowEngine = engine
+
+			sessionID := fmt.Sprintf("550e8400-e29b-41d4-a716-4466554409%02d", i)
+			require.NoError(t, engine.Initialize(ctx, sessionID, tt.state))
+
+			target, ok, err := o.CanFireEvent(ctx, sessionID, tt.event)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantTarget, target)
+
+			// The dry run never changes the state or records history
+			state, err := engine.GetState(ctx, sessionID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.state, state)
+			history, err := engine.GetHistory(ctx, sessionID)
+			require.NoError(t, err)
+			assert.Len(t, history, 1) // just the initialization
+		})
+	}
+
+	t.Run("invalid session ID", func(t *testing.T) {
+		o, _, _, _ := createTestOrchestrator(t)
+		_, ok, err := o.CanFireEvent(ctx, "not-a-uuid", workflow.EventPause)
+		assert.Error(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("unknown workflow", func(t *testing.T) {
+		o, _, mockWorkflow, _ := createTestOrchestrator(t)
+		sessionID := "550e8400-e29b-41d4-a716-446655440999"
+		mockWorkflow.On("GetState", mock.Anything, sessionID).
+			Return(workflow.WorkflowState(""), errors.New("no workflow found"))
+
+		_, ok, err := o.CanFireEvent(ctx, sessionID, workflow.EventPause)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to get workflow state")
+		assert.False(t, ok)
+		mockWorkflow.AssertNotCalled(t, "CanTransition", mock.Anything, mock.Anything)
+	})
+}
+
+func TestGetProgressBatch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("progress of several sessions", func(t *testing.T) {
+		o, _, _, _ := createTestOrchestrator(t)
+		o.todoManager = todolist.NewManager()
+
+		first := "550e8400-e29b-41d4-a716-446655440750"
+		second := "550e8400-e29b-41d4-a716-446655440751"
+		unknown := "550e8400-e29b-41d4-a716-446655440752"
+		require.NoError(t, o.todoManager.CreateList(ctx, first))
+		require.NoError(t, o.todoManager.AddItems(ctx, first, []todolist.TodoItem{
+			{FilePath: "/project/a.go", Priority: 1},
+			{FilePath: "/project/b.go", Priority: 1},
+		}))
+		require.NoError(t, o.todoManager.UpdateProgress(ctx, first, "/project/a.go", todolist.ItemStatusComplete))
+		require.NoError(t, o.todoManager.CreateList(ctx, second))
+		require.NoError(t, o.todoManager.AddItem(ctx, second, todolist.TodoItem{FilePath: "/project/c.go", Priority: 1}))
+
+		progress, err := o.GetProgressBatch(ctx, []string{first, second, unknown})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]*todolist.Progress{
+			first:  {Total: 2, Pending: 1, Complete: 1},
+			second: {Total: 1, Pending: 1},
+		}, progress)
+	})
+
+	t.Run("invalid session ID", func(t *testing.T) {
+		o, _, _, mockTodo := createTestOrchestrator(t)
+		_, err := o.GetProgressBatch(ctx, []string{"550e8400-e29b-41d4-a716-446655440750", "not-a-uuid"})
+		assert.ErrorContains(t, err, `invalid session ID "not-a-uuid"`)
+		mockTodo.AssertNotCalled(t, "GetProgressBatch", mock.Anything, mock.Anything)
+	})
+
+	t.Run("manager failure", func(t *testing.T) {
+		o, _, _, mockTodo := createTestOrchestrator(t)
+		sessionIDs := []string{"550e8400-e29b-41d4-a716-446655440750"}
+		mockTodo.On("GetProgressBatch", mock.Anything, sessionIDs).Return(nil, errors.New("boom"))
+
+		_, err := o.GetProgressBatch(ctx, sessionIDs)
+		assert.ErrorContains(t, err, "failed to get TODO progress: boom")
+	})
+}
+
+func TestProcessNextFileFromInitialized(t *testing.T) {
+	ctx := context.Background()
+	o, mockSession, _, _ := createTestOrchestrator(t)
+	engine, err := workflow.NewEngine(workflow.WorkflowConfig{})
+	require.NoError(t, err)
+	o.workflowEngine = engine
+
+	sessionID := "550e8400-e29b-41d4-a716-446655440204"
+	setupBatchSession(t, o, mockSession, sessionID, 1)
+	sess, err := mockSession.Get(uuid.MustParse(sessionID))
+	require.NoError(t, err)
+	sess.Status = session.StatusPending
+
+	// StartDocumentation leaves new sessions initialized
+	require.NoError(t, engine.Initialize(ctx, sessionID, workflow.WorkflowStateIdle))
+	require.NoError(t, engine.Trigger(ctx, sessionID, workflow.EventStart))
+
+	analysis, err := o.ProcessNextFile(ctx, sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, "/project/file0.go", analysis.FilePath)
+
+	state, err := engine.GetState(ctx, sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, workflow.WorkflowStateProcessing, state)
+}
+
+func TestSearchSessions(t *testing.T) {
+	tests := []struct {
+		name        string
+		workspaceID string
+		query       string
+		setupMocks  func(*mockSessionManager)
+		wantIDs     []string
+		wantErr     bool
+		errMsg      string
+	}{
+		{
+			name:        "matches are converted in order",
+			workspaceID: "workspace-123",
+			query:       "billing",
+			setupMocks: func(sm *mockSessionManager) {
+				sm.On("Search", "workspace-123", "billing").Return([]*session.Session{
+					createMockSession("550e8400-e29b-41d4-a716-446655440001", "workspace-123", "/src/gateway"),
+					createMockSession("550e8400-e29b-41d4-a716-446655440000", "workspace-123", "/src/billing"),
+				}, nil)
+			},
+			wantIDs: []string{
+				"550e8400-e29b-41d4-a716-446655440001",
+				"550e8400-e29b-41d4-a716-446655440000",
+			},
+		},
+		{
+			name:        "no matches",
+			workspaceID: "workspace-123",
+			query:       "payments",
+			setupMocks: func(sm *mockSessionManager) {
+				sm.On("Search", "workspace-123", "payments").Return([]*session.Session{}, nil)
+			},
+			wantIDs: []string{},
+		},
+		{
+			name:       "missing workspace",
+			query:      "billing",
+			setupMocks: func(sm *mockSessionManager) {},
+			wantErr:    true,
+			errMsg:     "workspace_id is required",
+		},
+		{
+			name:        "missing query",
+			workspaceID: "workspace-123",
+			setupMocks:  func(sm *mockSessionManager) {},
+			wantErr:     true,
+			errMsg:      "query is required",
+		},
+		{
+			name:        "search failure",
+			workspaceID: "workspace-123",
+			query:       "billing",
+			setupMocks: func(sm *mockSessionManager) {
+				sm.On("Search", "workspace-123", "billing").Return(nil, errors.New("database error"))
+			},
+			wantErr: true,
+			errMsg:  "failed to search sessions",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, mockSession, _, _ := createTestOrchestrator(t)
+			tt.setupMocks(mockSession)
+
+			sessions, err := o.SearchSessions(context.Background(), tt.workspaceID, tt.query)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+				assert.Nil(t, sessions)
+			} else {
+				require.NoError(t, err)
+				ids := make([]string, 0, len(sessions))
+				for _, sess := range sessions {
+					ids = append(ids, sess.ID)
+				}
+				assert.Equal(t, tt.wantIDs, ids)
+			}
+
+			mockSession.AssertExpectations(t)
+		})
+	}
+}
+
 // Test ProcessNextFile
 func TestProcessNextFile(t *testing.T) {
 	tests := []struct {
@@ -743,8 +1362,10 @@ func TestProcessNextFile(t *testing.T) {
 				sess := createMockSession("550e8400-e29b-41d4-a716-446655440100", "workspace-123", "/path/to/project")
 				sess.Status = session.StatusInProgress
 				sm.On("Get", id).Return(sess, nil)
+				we.On("GetState", mock.Anything, "550e8400-e29b-41d4-a716-446655440100").Return(workflow.WorkflowStateProcessing, nil)
 				
 				tm.On("GetNext", mock.Anything, "550e8400-e29b-41d4-a716-446655440100").Return("/path/to/file.go", nil)
+				tm.On("UpdateProgress", mock.Anything, "550e8400-e29b-41d4-a716-446655440100", "/path/to/file.go", todolist.ItemStatusComplete).Return(nil)
 				
 				// For the update call
 				sm.On("Update", id, mock.AnythingOfType("session.SessionUpdate")).Return(nil)
@@ -775,8 +1396,26 @@ func TestProcessNextFile(t *testing.T) {
 				sess := createMockSession("550e8400-e29b-41d4-a716-446655440201", "workspace-123", "test-module")
 				sess.Status = session.StatusPending // Set to Pending to trigger transition
 				sm.On("Get", id).Return(sess, nil)
+				we.On("GetState", mock.Anything, "550e8400-e29b-41d4-a716-446655440201").Return(workflow.WorkflowStateIdle, nil)
 				we.On("Transition", mock.Anything, "550e8400-e29b-41d4-a716-446655440201", workflow.WorkflowStateProcessing).Return(nil)
 				tm.On("GetNext", mock.Anything, "550e8400-e29b-41d4-a716-446655440201").Return("/path/to/file.go", nil)
+				tm.On("UpdateProgress", mock.Anything, "550e8400-e29b-41d4-a716-446655440201", "/path/to/file.go", todolist.ItemStatusComplete).Return(nil)
+				sm.On("Update", id, mock.AnythingOfType("session.SessionUpdate")).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:      "transition from initialized to processing",
+			sessionID: "550e8400-e29b-41d4-a716-446655440203",
+			setupMocks: func(sm *mockSessionManager, we *mockWorkflowEngine, tm *mockTodoManager) {
+				id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440203")
+				sess := createMockSession("550e8400-e29b-41d4-a716-446655440203", "workspace-123", "test-module")
+				sess.Status = session.StatusPending
+				sm.On("Get", id).Return(sess, nil)
+				we.On("GetState", mock.Anything, "550e8400-e29b-41d4-a716-446655440203").Return(workflow.WorkflowStateInitialized, nil)
+				we.On("Trigger", mock.Anything, "550e8400-e29b-41d4-a716-446655440203", workflow.EventProcess).Return(nil)
+				tm.On("GetNext", mock.Anything, "550e8400-e29b-41d4-a716-446655440203").Return("/path/to/file.go", nil)
+				tm.On("UpdateProgress", mock.Anything, "550e8400-e29b-41d4-a716-446655440203", "/path/to/file.go", todolist.ItemStatusComplete).Return(nil)
 				sm.On("Update", id, mock.AnythingOfType("session.SessionUpdate")).Return(nil)
 			},
 			wantErr: false,
@@ -791,23 +1430,35 @@ func TestProcessNextFile(t *testing.T) {
 				sm.On("Get", id).Return(sess, nil)
 			},
 			wantErr: true,
-			errMsg:  "cannot transition from",
+			errMsg:  "cannot be modified in state complete",
 		},
 		{
-			name:      "no more todos",
-			sessionID: "550e8400-e29b-41d4-a716-446655440203",
+			name:      "paused session",
+			sessionID: "550e8400-e29b-41d4-a716-446655440206",
+			setupMocks: func(sm *mockSessionManager, we *mockWorkflowEngine, tm *mockTodoManager) {
+				id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440206")
+				sess := createMockSession("550e8400-e29b-41d4-a716-446655440206", "workspace-123", "test-module")
+				sess.Status = session.StatusInProgress
+				sm.On("Get", id).Return(sess, nil)
+				we.On("GetState", mock.Anything, "550e8400-e29b-41d4-a716-446655440206").Return(workflow.WorkflowStatePaused, nil)
+			},
+			wantErr: true,
+			errMsg:  ErrSessionPaused.Error(),
+		},
+		{
+			name:      "no more todos",
+			sessionID: "550e8400-e29b-41d4-a716-446655440203",
 			setupMocks: func(sm *mockSessionManager, we *mockWorkflowEngine, tm *mockTodoManager) {
 				id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440203")
 				sess := createMockSession("550e8400-e29b-41d4-a716-446655440203", "workspace-123", "test-module")
 				sess.Status = session.StatusInProgress
 				sm.On("Get", id).Return(sess, nil)
+				we.On("GetState", mock.Anything, "550e8400-e29b-41d4-a716-446655440203").Return(workflow.WorkflowStateProcessing, nil)
 				tm.On("GetNext", mock.Anything, "550e8400-e29b-41d4-a716-446655440203").
 					Return("", &todolist.NoMoreTodosError{SessionID: "550e8400-e29b-41d4-a716-446655440203"})
 			},
-			wantErr: false,
-			verifyResult: func(t *testing.T, analysis *FileAnalysis) {
-				assert.Nil(t, analysis)
-			},
+			wantErr: true,
+			errMsg:  ErrNoMoreFiles.Error(),
 		},
 		{
 			name:      "todo manager error",
@@ -817,6 +1468,7 @@ func TestProcessNextFile(t *testing.T) {
 				sess := createMockSession("550e8400-e29b-41d4-a716-446655440204", "workspace-123", "test-module")
 				sess.Status = session.StatusInProgress
 				sm.On("Get", id).Return(sess, nil)
+				we.On("GetState", mock.Anything, "550e8400-e29b-41d4-a716-446655440204").Return(workflow.WorkflowStateProcessing, nil)
 				tm.On("GetNext", mock.Anything, "550e8400-e29b-41d4-a716-446655440204").
 					Return("", errors.New("database error"))
 			},
@@ -831,12 +1483,36 @@ func TestProcessNextFile(t *testing.T) {
 				sess := createMockSession("550e8400-e29b-41d4-a716-446655440205", "workspace-123", "test-module")
 				sess.Status = session.StatusInProgress
 				sm.On("Get", id).Return(sess, nil)
+				we.On("GetState", mock.Anything, "550e8400-e29b-41d4-a716-446655440205").Return(workflow.WorkflowStateProcessing, nil)
 				tm.On("GetNext", mock.Anything, "550e8400-e29b-41d4-a716-446655440205").Return("/path/to/file.go", nil)
+				tm.On("UpdateProgress", mock.Anything, "550e8400-e29b-41d4-a716-446655440205", "/path/to/file.go", todolist.ItemStatusComplete).Return(nil)
+				sm.On("Update", id, mock.MatchedBy(func(update session.SessionUpdate) bool {
+					return update.CurrentFile != nil
+				})).Return(nil)
+				sm.On("Update", id, mock.MatchedBy(func(update session.SessionUpdate) bool {
+					return update.Progress != nil
+				})).Return(errors.New("update failed"))
+			},
+			wantErr: true,
+			errMsg:  "failed to update session progress",
+		},
+		{
+			name:      "recording the current file fails",
+			sessionID: "550e8400-e29b-41d4-a716-446655440206",
+			setupMocks: func(sm *mockSessionManager, we *mockWorkflowEngine, tm *mockTodoManager) {
+				id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440206")
+				sess := createMockSession("550e8400-e29b-41d4-a716-446655440206", "workspace-123", "test-module")
+				sess.Status = session.StatusInProgress
+				sm.On("Get", id).Return(sess, nil)
+				we.On("GetState", mock.Anything, "550e8400-e29b-41d4-a716-446655440206").Return(workflow.WorkflowStateProcessing, nil)
+				tm.On("GetNext", mock.Anything, "550e8400-e29b-41d4-a716-446655440206").Return("/path/to/file.go", nil)
 				sm.On("Update", id, mock.AnythingOfType("session.SessionUpdate")).
 					Return(errors.New("update failed"))
+				// The file goes back to the queue without being analyzed
+				tm.On("UpdateProgress", mock.Anything, "550e8400-e29b-41d4-a716-446655440206", "/path/to/file.go", todolist.ItemStatusPending).Return(nil)
 			},
 			wantErr: true,
-			errMsg:  "failed to update session progress",
+			errMsg:  "failed to record current file",
 		},
 	}
 
@@ -871,6 +1547,7 @@ func TestCompleteSession(t *testing.T) {
 	tests := []struct {
 		name       string
 		sessionID  string
+		opts       CompleteOptions
 		setupMocks func(*mockSessionManager, *mockWorkflowEngine, *mockTodoManager)
 		wantErr    bool
 		errMsg     string
@@ -883,6 +1560,7 @@ func TestCompleteSession(t *testing.T) {
 				sess := createMockSession("550e8400-e29b-41d4-a716-446655440300", "workspace-123", "test-module")
 				sess.Status = session.StatusInProgress
 				sm.On("Get", id).Return(sess, nil)
+				tm.On("GetProgress", mock.Anything, sess.GetID()).Return(&todolist.Progress{Total: 2, Complete: 2}, nil)
 				we.On("Transition", mock.Anything, "550e8400-e29b-41d4-a716-446655440300", workflow.WorkflowStateComplete).Return(nil)
 				completedStatus := session.StatusCompleted
 				sm.On("Update", id, mock.MatchedBy(func(update session.SessionUpdate) bool {
@@ -910,6 +1588,7 @@ func TestCompleteSession(t *testing.T) {
 				sess := createMockSession("550e8400-e29b-41d4-a716-446655440302", "workspace-123", "test-module")
 				sess.Status = session.StatusInProgress
 				sm.On("Get", id).Return(sess, nil)
+				tm.On("GetProgress", mock.Anything, sess.GetID()).Return(&todolist.Progress{Total: 2, Complete: 2}, nil)
 				we.On("Transition", mock.Anything, "550e8400-e29b-41d4-a716-446655440302", workflow.WorkflowStateComplete).
 					Return(errors.New("invalid transition"))
 			},
@@ -924,6 +1603,7 @@ func TestCompleteSession(t *testing.T) {
 				sess := createMockSession("550e8400-e29b-41d4-a716-446655440303", "workspace-123", "test-module")
 				sess.Status = session.StatusInProgress
 				sm.On("Get", id).Return(sess, nil)
+				tm.On("GetProgress", mock.Anything, sess.GetID()).Return(&todolist.Progress{Total: 2, Complete: 2}, nil)
 				we.On("Transition", mock.Anything, "550e8400-e29b-41d4-a716-446655440303", workflow.WorkflowStateComplete).Return(nil)
 				sm.On("Update", id, mock.AnythingOfType("session.SessionUpdate")).
 					Return(errors.New("update failed"))
@@ -939,6 +1619,7 @@ func TestCompleteSession(t *testing.T) {
 				sess := createMockSession("550e8400-e29b-41d4-a716-446655440304", "workspace-123", "test-module")
 				sess.Status = session.StatusInProgress
 				sm.On("Get", id).Return(sess, nil)
+				tm.On("GetProgress", mock.Anything, sess.GetID()).Return(&todolist.Progress{Total: 2, Complete: 2}, nil)
 				we.On("Transition", mock.Anything, "550e8400-e29b-41d4-a716-446655440304", workflow.WorkflowStateComplete).Return(nil)
 				completedStatus := session.StatusCompleted
 				sm.On("Update", id, mock.MatchedBy(func(update session.SessionUpdate) bool {
@@ -949,6 +1630,56 @@ func TestCompleteSession(t *testing.T) {
 			},
 			wantErr: false, // TODO deletion failure is logged but doesn't fail the operation
 		},
+		{
+			name:      "unprocessed files block completion",
+			sessionID: "550e8400-e29b-41d4-a716-446655440305",
+			setupMocks: func(sm *mockSessionManager, we *mockWorkflowEngine, tm *mockTodoManager) {
+				id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440305")
+				sess := createMockSession("550e8400-e29b-41d4-a716-446655440305", "workspace-123", "test-module")
+				sess.Status = session.StatusInProgress
+				sm.On("Get", id).Return(sess, nil)
+				tm.On("GetProgress", mock.Anything, sess.GetID()).
+					Return(&todolist.Progress{Total: 3, Pending: 1, InProgress: 1, Complete: 1}, nil)
+			},
+			wantErr: true,
+			errMsg:  "session 550e8400-e29b-41d4-a716-446655440305 has 2 unprocessed files",
+		},
+		{
+			name:      "skip remaining completes with unprocessed files",
+			sessionID: "550e8400-e29b-41d4-a716-446655440306",
+			opts:      CompleteOptions{SkipRemaining: true},
+			setupMocks: func(sm *mockSessionManager, we *mockWorkflowEngine, tm *mockTodoManager) {
+				id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440306")
+				sess := createMockSession("550e8400-e29b-41d4-a716-446655440306", "workspace-123", "test-module")
+				sess.Status = session.StatusInProgress
+				sess.Progress = session.Progress{TotalFiles: 3, ProcessedFiles: 1, FailedFiles: []string{}}
+				sm.On("Get", id).Return(sess, nil)
+				tm.On("GetProgress", mock.Anything, sess.GetID()).
+					Return(&todolist.Progress{Total: 3, Pending: 1, InProgress: 1, Complete: 1}, nil)
+				tm.On("SkipRemaining", mock.Anything, sess.GetID()).Return([]string{"/b.go", "/c.go"}, nil)
+				we.On("Transition", mock.Anything, sess.GetID(), workflow.WorkflowStateComplete).Return(nil)
+				sm.On("Update", id, mock.MatchedBy(func(update session.SessionUpdate) bool {
+					return update.Status != nil && *update.Status == session.StatusCompleted &&
+						update.Progress != nil &&
+						update.Progress.ProcessedFiles == 1 &&
+						assert.ObjectsAreEqual([]string{"/b.go", "/c.go"}, update.Progress.SkippedFiles)
+				})).Return(nil)
+				tm.On("DeleteList", mock.Anything, sess.GetID()).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:      "todo progress unavailable",
+			sessionID: "550e8400-e29b-41d4-a716-446655440307",
+			setupMocks: func(sm *mockSessionManager, we *mockWorkflowEngine, tm *mockTodoManager) {
+				id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440307")
+				sess := createMockSession("550e8400-e29b-41d4-a716-446655440307", "workspace-123", "test-module")
+				sm.On("Get", id).Return(sess, nil)
+				tm.On("GetProgress", mock.Anything, sess.GetID()).Return(nil, errors.New("no TODO list"))
+			},
+			wantErr: true,
+			errMsg:  "failed to get TODO progress",
+		},
 	}
 
 	for _, tt := range tests {
@@ -957,7 +1688,7 @@ func TestCompleteSession(t *testing.T) {
 
 			tt.setupMocks(mockSession, mockWorkflow, mockTodo)
 
-			err := o.CompleteSession(context.Background(), tt.sessionID)
+			err := o.CompleteSession(context.Background(), tt.sessionID, tt.opts)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -973,6 +1704,297 @@ func TestCompleteSession(t *testing.T) {
 	}
 }
 
+// newStatefulSession registers a mock session whose status, progress and
+// notes follow the updates applied to it, backed by a real workflow engine
+// and TODO list.
+func newStatefulSession(t *testing.T, o *OrchestratorImpl, sm *mockSessionManager, sessionID string) *session.Session {
+	t.Helper()
+	ctx := context.Background()
+
+	engine, err := workflow.NewEngine(workflow.WorkflowConfig{})
+	require.NoError(t, err)
+	require.NoError(t, engine.Initialize(ctx, sessionID, workflow.WorkflowStateProcessing))
+	o.workflowEngine = engine
+	o.todoManager = todolist.NewManager()
+	require.NoError(t, o.todoManager.CreateList(ctx, sessionID))
+
+	id := uuid.MustParse(sessionID)
+	sess := createMockSession(sessionID, "workspace-123", "/project")
+	sess.Status = session.StatusInProgress
+	sm.On("Get", id).Return(sess, nil)
+	sm.On("Update", id, mock.AnythingOfType("session.SessionUpdate")).Run(func(args mock.Arguments) {
+		update := args.Get(1).(session.SessionUpdate)
+		if update.Status != nil {
+			sess.Status = *update.Status
+		}
+		if update.Progress != nil {
+			sess.Progress = *update.Progress
+		}
+		if update.CurrentFile != nil {
+			sess.Progress.CurrentFile = *update.CurrentFile
+		}
+		if update.Note != nil {
+			sess.Notes = append(sess.Notes, *update.Note)
+		}
+	}).Return(nil)
+	return sess
+}
+
+func TestCompleteSessionIdempotent(t *testing.T) {
+	ctx := context.Background()
+	o, mockSession, _, _ := createTestOrchestrator(t)
+	sessionID := "550e8400-e29b-41d4-a716-446655440760"
+	sess := newStatefulSession(t, o, mockSession, sessionID)
+
+	require.NoError(t, o.CompleteSession(ctx, sessionID, CompleteOptions{}))
+	assert.Equal(t, session.StatusCompleted, sess.Status)
+	history, err := o.workflowEngine.GetHistory(ctx, sessionID)
+	require.NoError(t, err)
+
+	// The retry succeeds and changes nothing
+	require.NoError(t, o.CompleteSession(ctx, sessionID, CompleteOptions{}))
+	mockSession.AssertNumberOfCalls(t, "Update", 1)
+	after, err := o.workflowEngine.GetHistory(ctx, sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, history, after)
+}
+
+func TestCancelSession(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("cancel is idempotent", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		sessionID := "550e8400-e29b-41d4-a716-446655440761"
+		sess := newStatefulSession(t, o, mockSession, sessionID)
+		require.NoError(t, o.todoManager.AddItem(ctx, sessionID, todolist.TodoItem{FilePath: "/project/a.go", Priority: 1}))
+
+		require.NoError(t, o.CancelSession(ctx, sessionID))
+		state, err := o.workflowEngine.GetState(ctx, sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, workflow.WorkflowStateCancelled, state)
+		assert.Equal(t, session.StatusFailed, sess.Status)
+		_, err = o.todoManager.GetProgress(ctx, sessionID)
+		assert.Error(t, err, "the TODO list is deleted")
+
+		require.NoError(t, o.CancelSession(ctx, sessionID))
+		mockSession.AssertNumberOfCalls(t, "Update", 1)
+	})
+
+	t.Run("completed session cannot be cancelled", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		sessionID := "550e8400-e29b-41d4-a716-446655440762"
+		newStatefulSession(t, o, mockSession, sessionID)
+		require.NoError(t, o.CompleteSession(ctx, sessionID, CompleteOptions{}))
+
+		err := o.CancelSession(ctx, sessionID)
+		assert.ErrorContains(t, err, "cannot be modified in state complete")
+	})
+
+	t.Run("cancelled session cannot be completed", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		sessionID := "550e8400-e29b-41d4-a716-446655440763"
+		newStatefulSession(t, o, mockSession, sessionID)
+		require.NoError(t, o.CancelSession(ctx, sessionID))
+
+		err := o.CompleteSession(ctx, sessionID, CompleteOptions{})
+		assert.ErrorContains(t, err, "cannot be modified in state failed")
+	})
+}
+
+// Test AddFiles
+func TestAddFiles(t *testing.T) {
+	tests := []struct {
+		name       string
+		sessionID  string
+		files      []string
+		setupMocks func(*mockSessionManager, *mockTodoManager)
+		wantErr    bool
+		errMsg     string
+	}{
+		{
+			name:      "files added to active session",
+			sessionID: "550e8400-e29b-41d4-a716-446655440600",
+			files:     []string{"/project/a.go", "/project/b.go"},
+			setupMocks: func(sm *mockSessionManager, tm *mockTodoManager) {
+				id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440600")
+				sess := createMockSession(id.String(), "workspace-123", "/project")
+				sess.Status = session.StatusInProgress
+				sess.Progress = session.Progress{TotalFiles: 3, ProcessedFiles: 1, FailedFiles: []string{"/project/bad.go"}}
+				sm.On("Get", id).Return(sess, nil)
+				tm.On("UpsertItem", mock.Anything, id.String(), todolist.TodoItem{FilePath: "/project/a.go", Priority: 7}).Return(true, nil)
+				tm.On("UpsertItem", mock.Anything, id.String(), todolist.TodoItem{FilePath: "/project/b.go", Priority: 7}).Return(true, nil)
+				sm.On("Update", id, mock.MatchedBy(func(update session.SessionUpdate) bool {
+					return update.Progress != nil &&
+						update.Progress.TotalFiles == 5 &&
+						update.Progress.ProcessedFiles == 1 &&
+						len(update.Progress.FailedFiles) == 1
+				})).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:      "files already queued are not counted again",
+			sessionID: "550e8400-e29b-41d4-a716-446655440604",
+			files:     []string{"/project/a.go"},
+			setupMocks: func(sm *mockSessionManager, tm *mockTodoManager) {
+				id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440604")
+				sess := createMockSession(id.String(), "workspace-123", "/project")
+				sess.Status = session.StatusInProgress
+				sess.Progress = session.Progress{TotalFiles: 3}
+				sm.On("Get", id).Return(sess, nil)
+				tm.On("UpsertItem", mock.Anything, id.String(), mock.Anything).Return(false, nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:      "only inserted files are counted",
+			sessionID: "550e8400-e29b-41d4-a716-446655440607",
+			files:     []string{"/project/a.go", "/project/b.go"},
+			setupMocks: func(sm *mockSessionManager, tm *mockTodoManager) {
+				id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440607")
+				sess := createMockSession(id.String(), "workspace-123", "/project")
+				sess.Status = session.StatusInProgress
+				sess.Progress = session.Progress{TotalFiles: 3}
+				sm.On("Get", id).Return(sess, nil)
+				tm.On("UpsertItem", mock.Anything, id.String(), todolist.TodoItem{FilePath: "/project/a.go", Priority: 7}).Return(false, nil)
+				tm.On("UpsertItem", mock.Anything, id.String(), todolist.TodoItem{FilePath: "/project/b.go", Priority: 7}).Return(true, nil)
+				sm.On("Update", id, mock.MatchedBy(func(update session.SessionUpdate) bool {
+					return update.Progress != nil && update.Progress.TotalFiles == 4
+				})).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:      "files enqueued before a failure are counted",
+			sessionID: "550e8400-e29b-41d4-a716-446655440608",
+			files:     []string{"/project/a.go", "/project/b.go"},
+			setupMocks: func(sm *mockSessionManager, tm *mockTodoManager) {
+				id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440608")
+				sess := createMockSession(id.String(), "workspace-123", "/project")
+				sess.Status = session.StatusInProgress
+				sess.Progress = session.Progress{TotalFiles: 3}
+				sm.On("Get", id).Return(sess, nil)
+				tm.On("UpsertItem", mock.Anything, id.String(), todolist.TodoItem{FilePath: "/project/a.go", Priority: 7}).Return(true, nil)
+				tm.On("UpsertItem", mock.Anything, id.String(), todolist.TodoItem{FilePath: "/project/b.go", Priority: 7}).Return(false, errors.New("list closed"))
+				sm.On("Update", id, mock.MatchedBy(func(update session.SessionUpdate) bool {
+					return update.Progress != nil && update.Progress.TotalFiles == 4
+				})).Return(nil)
+			},
+			wantErr: true,
+			errMsg:  "failed to enqueue files: /project/b.go: list closed",
+		},
+		{
+			name:      "rejected for completed session",
+			sessionID: "550e8400-e29b-41d4-a716-446655440601",
+			files:     []string{"/project/a.go"},
+			setupMocks: func(sm *mockSessionManager, tm *mockTodoManager) {
+				id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440601")
+				sess := createMockSession(id.String(), "workspace-123", "/project")
+				sess.Status = session.StatusCompleted
+				sm.On("Get", id).Return(sess, nil)
+			},
+			wantErr: true,
+			errMsg:  "in state complete",
+		},
+		{
+			name:      "path validation failures are reported",
+			sessionID: "550e8400-e29b-41d4-a716-446655440602",
+			files:     []string{"/project/a.go", "/project/../etc/passwd"},
+			setupMocks: func(sm *mockSessionManager, tm *mockTodoManager) {
+				id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440602")
+				sess := createMockSession(id.String(), "workspace-123", "/project")
+				sess.Status = session.StatusInProgress
+				sm.On("Get", id).Return(sess, nil)
+			},
+			wantErr: true,
+			errMsg:  "/project/../etc/passwd",
+		},
+		{
+			name:      "enqueue failure",
+			sessionID: "550e8400-e29b-41d4-a716-446655440603",
+			files:     []string{"/project/a.go"},
+			setupMocks: func(sm *mockSessionManager, tm *mockTodoManager) {
+				id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440603")
+				sess := createMockSession(id.String(), "workspace-123", "/project")
+				sm.On("Get", id).Return(sess, nil)
+				tm.On("UpsertItem", mock.Anything, id.String(), mock.Anything).Return(false, errors.New("no list"))
+			},
+			wantErr: true,
+			errMsg:  "failed to enqueue files",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, mockSession, _, mockTodo := createTestOrchestrator(t)
+			require.NoError(t, o.serviceRegistry.RegisterFileSystem(&fakeFileSystem{files: map[string][]byte{}}))
+
+			tt.setupMocks(mockSession, mockTodo)
+
+			err := o.AddFiles(context.Background(), tt.sessionID, tt.files, 7)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockSession.AssertExpectations(t)
+			mockTodo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAddFilesConcurrentCountsInsertsOnce(t *testing.T) {
+	ctx := context.Background()
+	o, mockSession, _, _ := createTestOrchestrator(t)
+	sessionID := "550e8400-e29b-41d4-a716-446655440606"
+	sess := newStatefulSession(t, o, mockSession, sessionID)
+
+	// Overlapping calls each count only the files they inserted
+	files := []string{"/project/a.go", "/project/b.go", "/project/c.go"}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, o.AddFiles(ctx, sessionID, files, 0))
+		}()
+	}
+	wg.Wait()
+
+	o.progressMu.Lock()
+	defer o.progressMu.Unlock()
+	assert.Equal(t, len(files), sess.Progress.TotalFiles)
+}
+
+func TestAddFilesHoldsProgressLock(t *testing.T) {
+	ctx := context.Background()
+	o, mockSession, _, _ := createTestOrchestrator(t)
+	sessionID := "550e8400-e29b-41d4-a716-446655440605"
+	sess := newStatefulSession(t, o, mockSession, sessionID)
+
+	// While a worker holds the progress lock, AddFiles queues the file but
+	// waits to update the session total
+	o.progressMu.Lock()
+	done := make(chan error, 1)
+	go func() {
+		done <- o.AddFiles(ctx, sessionID, []string{"/project/new.go"}, 0)
+	}()
+	select {
+	case err := <-done:
+		o.progressMu.Unlock()
+		t.Fatalf("AddFiles returned while the progress lock was held: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Equal(t, 0, sess.Progress.TotalFiles)
+	o.progressMu.Unlock()
+
+	require.NoError(t, <-done)
+	assert.Equal(t, 1, sess.Progress.TotalFiles)
+}
+
 // Test Container method
 func TestContainer(t *testing.T) {
 	o, _, _, _ := createTestOrchestrator(t)
@@ -1053,11 +2075,138 @@ func TestValidateDocumentationRequest(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "known template",
+			req: DocumentationRequest{
+				WorkspaceID: "workspace-123",
+				ProjectPath: "/path/to/project",
+				Options: DocumentationOptions{
+					Template: TemplateTutorial,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown template",
+			req: DocumentationRequest{
+				WorkspaceID: "workspace-123",
+				ProjectPath: "/path/to/project",
+				Options: DocumentationOptions{
+					Template: "poetry",
+				},
+			},
+			wantErr: true,
+			errMsg:  "unknown template: poetry (known: reference, tutorial)",
+		},
+		{
+			name: "negative max concurrency",
+			req: DocumentationRequest{
+				WorkspaceID: "workspace-123",
+				ProjectPath: "/path/to/project",
+				Options: DocumentationOptions{
+					MaxConcurrency: -2,
+				},
+			},
+			wantErr: true,
+			errMsg:  "max_concurrency cannot be negative",
+		},
+		{
+			name: "valid patterns",
+			req: DocumentationRequest{
+				WorkspaceID: "workspace-123",
+				ProjectPath: "/path/to/project",
+				Options: DocumentationOptions{
+					FilePatterns:    []string{"*.go", "cmd/*/main.go", "[a-z]*.md"},
+					ExcludePatterns: []string{"vendor", "*_test.go"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "malformed file pattern",
+			req: DocumentationRequest{
+				WorkspaceID: "workspace-123",
+				ProjectPath: "/path/to/project",
+				Options: DocumentationOptions{
+					FilePatterns: []string{"*.go", "[abc"},
+				},
+			},
+			wantErr: true,
+			errMsg:  `invalid pattern "[abc" in file_patterns`,
+		},
+		{
+			name: "malformed exclude pattern",
+			req: DocumentationRequest{
+				WorkspaceID: "workspace-123",
+				ProjectPath: "/path/to/project",
+				Options: DocumentationOptions{
+					ExcludePatterns: []string{"zz[\\"},
+				},
+			},
+			wantErr: true,
+			errMsg:  "in exclude_patterns",
+		},
+		{
+			name: "only file patterns",
+			req: DocumentationRequest{
+				WorkspaceID: "workspace-123",
+				ProjectPath: "/path/to/project",
+				Options: DocumentationOptions{
+					FilePatterns: []string{"*.go"},
+					MaxDepth:     3,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "only explicit files",
+			req: DocumentationRequest{
+				WorkspaceID: "workspace-123",
+				ProjectPath: "/path/to/project",
+				Files:       []string{"main.go", "util/io.go"},
+				Options: DocumentationOptions{
+					MaxConcurrency: 2,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "explicit files with discovery options",
+			req: DocumentationRequest{
+				WorkspaceID: "workspace-123",
+				ProjectPath: "/path/to/project",
+				Files:       []string{"main.go"},
+				Options: DocumentationOptions{
+					FilePatterns: []string{"*.go"},
+					MaxDepth:     2,
+				},
+			},
+			wantErr: true,
+			errMsg:  "files cannot be combined with discovery options (file_patterns, max_depth)",
+		},
+		{
+			name: "dependencies without files",
+			req: DocumentationRequest{
+				WorkspaceID:         "workspace-123",
+				ProjectPath:         "/path/to/project",
+				IncludeDependencies: true,
+			},
+			wantErr: true,
+			errMsg:  "include_dependencies requires files",
+		},
+		{
+			name: "neither files nor patterns",
+			req: DocumentationRequest{
+				WorkspaceID: "workspace-123",
+				ProjectPath: "/path/to/project",
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := validateDocumentationRequest(tt.req)
+			err := validateDocumentationRequest(&tt.req, "")
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -1068,3 +2217,199 @@ func TestValidateDocumentationRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateDocumentationRequestCollectsAllErrors(t *testing.T) {
+	err := validateDocumentationRequest(&DocumentationRequest{
+		Options: DocumentationOptions{
+			MaxDepth:        -1,
+			FilePatterns:    []string{"[abc"},
+			ExcludePatterns: []string{"zz[\\"},
+		},
+	}, "")
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, "invalid documentation request", verr.Message)
+	require.Len(t, verr.Errors, 5)
+	assert.Equal(t, "project_path is required", verr.Errors[0])
+	assert.Equal(t, "workspace_id is required", verr.Errors[1])
+	assert.Equal(t, "max_depth cannot be negative", verr.Errors[2])
+	assert.Contains(t, verr.Errors[3], `invalid pattern "[abc" in file_patterns`)
+	assert.Contains(t, verr.Errors[4], "in exclude_patterns")
+}
+
+func TestNormalizeProjectPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		baseDir string
+		want    string
+		errMsg  string
+	}{
+		{name: "absolute path", path: "/srv/projects/app", want: "/srv/projects/app"},
+		{name: "trailing slash", path: "/srv/projects/app/", want: "/srv/projects/app"},
+		{name: "redundant elements", path: "/srv/projects/./app//lib/..", want: "/srv/projects/app"},
+		{name: "relative without base", path: "app", errMsg: "project_path must be absolute: app"},
+		{name: "relative resolved against base", path: "app/", baseDir: "/srv/projects", want: "/srv/projects/app"},
+		{name: "absolute inside base", path: "/srv/projects/app", baseDir: "/srv/projects/", want: "/srv/projects/app"},
+		{name: "base itself", path: ".", baseDir: "/srv/projects", want: "/srv/projects"},
+		{name: "relative escape", path: "../etc", baseDir: "/srv/projects", errMsg: "project_path escapes the project base directory: /srv/etc"},
+		{name: "absolute escape", path: "/srv/projects/../etc", baseDir: "/srv/projects", errMsg: "project_path escapes the project base directory: /srv/etc"},
+		{name: "sibling with shared prefix", path: "/srv/projects-old", baseDir: "/srv/projects", errMsg: "escapes the project base directory"},
+		{name: "NUL byte", path: "/srv/app\x00", errMsg: "project_path contains a NUL byte"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeProjectPath(tt.path, tt.baseDir)
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidateDocumentationRequestNormalizesProjectPath(t *testing.T) {
+	req := DocumentationRequest{WorkspaceID: "workspace-123", ProjectPath: "app/"}
+	require.NoError(t, validateDocumentationRequest(&req, "/srv/projects"))
+	assert.Equal(t, "/srv/projects/app", req.ProjectPath)
+
+	req = DocumentationRequest{WorkspaceID: "workspace-123", ProjectPath: "../etc"}
+	err := validateDocumentationRequest(&req, "/srv/projects")
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, []string{"project_path escapes the project base directory: /srv/etc"}, verr.Errors)
+}
+
+func TestGetSessionProgressPercent(t *testing.T) {
+	ctx := context.Background()
+	o, mockSession, _, _ := createTestOrchestrator(t)
+	sessionID := "550e8400-e29b-41d4-a716-446655440810"
+
+	sess := createMockSession(sessionID, "workspace-123", "/project")
+	sess.Progress = session.Progress{TotalFiles: 4, ProcessedFiles: 1, Percent: 25}
+	mockSession.On("Get", sess.ID).Return(sess, nil)
+
+	docSess, err := o.GetSession(ctx, sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, 25.0, docSess.Progress.Percent)
+}
+
+func TestGetSessionReturnsCopies(t *testing.T) {
+	ctx := context.Background()
+	o, mockSession, _, _ := createTestOrchestrator(t)
+	sessionID := "550e8400-e29b-41d4-a716-446655440811"
+
+	sess := createMockSession(sessionID, "workspace-123", "/project")
+	sess.Progress = session.Progress{TotalFiles: 4, ProcessedFiles: 1, CurrentFile: "/project/a.go"}
+	mockSession.On("Get", sess.ID).Return(sess, nil)
+
+	first, err := o.GetSession(ctx, sessionID)
+	require.NoError(t, err)
+	clone := first.Clone()
+
+	// Mutating a returned session leaves the stored session alone
+	first.State = WorkflowStateFailed
+	first.Progress.ProcessedFiles = 4
+	first.Progress.CurrentFile = "/project/b.go"
+	assert.Equal(t, 1, sess.Progress.ProcessedFiles)
+	assert.Equal(t, "/project/a.go", sess.Progress.CurrentFile)
+
+	second, err := o.GetSession(ctx, sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, clone, second)
+	assert.NotSame(t, first, second)
+}
+
+func TestProcessNextFilePersistsCurrentFile(t *testing.T) {
+	ctx := context.Background()
+	o, mockSession, _, _ := createTestOrchestrator(t)
+	sessionID := "550e8400-e29b-41d4-a716-446655440920"
+	sess := newStatefulSession(t, o, mockSession, sessionID)
+	require.NoError(t, o.todoManager.AddItem(ctx, sessionID, todolist.TodoItem{FilePath: "/project/main.go"}))
+
+	var stored string
+	var status todolist.ItemStatus
+	require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &stubAIService{
+		analyzeFunc: func(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error) {
+			// What a crash at this point would leave behind
+			stored = sess.Progress.CurrentFile
+			item, err := o.todoManager.GetItem(ctx, sessionID, req.FilePath)
+			require.NoError(t, err)
+			status = item.Status
+			return &services.FileAnalysisResponse{Summary: "ok"}, nil
+		},
+	}))
+
+	_, err := o.ProcessNextFile(ctx, sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, "/project/main.go", stored)
+	assert.Equal(t, todolist.ItemStatusInProgress, status)
+	assert.Empty(t, sess.Progress.CurrentFile, "the current file is cleared once processed")
+}
+
+func TestProcessNextFileClearsCurrentFile(t *testing.T) {
+	setup := func(t *testing.T, sessionID string, analyze func(ctx context.Context, sess *session.Session) error) (*OrchestratorImpl, *session.Session) {
+		ctx := context.Background()
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		sess := newStatefulSession(t, o, mockSession, sessionID)
+		require.NoError(t, o.todoManager.AddItem(ctx, sessionID, todolist.TodoItem{FilePath: "/project/main.go"}))
+		require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &stubAIService{
+			analyzeFunc: func(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error) {
+				if err := analyze(ctx, sess); err != nil {
+					return nil, err
+				}
+				return &services.FileAnalysisResponse{Summary: "ok"}, nil
+			},
+		}))
+		return o, sess
+	}
+
+	t.Run("failed file", func(t *testing.T) {
+		sessionID := "550e8400-e29b-41d4-a716-446655440921"
+		o, sess := setup(t, sessionID, func(context.Context, *session.Session) error {
+			return errors.New("model overloaded")
+		})
+
+		_, err := o.ProcessNextFile(context.Background(), sessionID)
+		require.Error(t, err)
+		assert.Empty(t, sess.Progress.CurrentFile)
+		assert.Equal(t, []string{"/project/main.go"}, sess.Progress.FailedFiles)
+	})
+
+	t.Run("interrupted file", func(t *testing.T) {
+		sessionID := "550e8400-e29b-41d4-a716-446655440922"
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		o, sess := setup(t, sessionID, func(ctx context.Context, _ *session.Session) error {
+			cancel()
+			return ctx.Err()
+		})
+
+		_, err := o.ProcessNextFile(ctx, sessionID)
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Empty(t, sess.Progress.CurrentFile)
+
+		item, err := o.todoManager.GetItem(context.Background(), sessionID, "/project/main.go")
+		require.NoError(t, err)
+		assert.Equal(t, todolist.ItemStatusPending, item.Status)
+	})
+
+	t.Run("another worker's file is kept", func(t *testing.T) {
+		sessionID := "550e8400-e29b-41d4-a716-446655440923"
+		o, sess := setup(t, sessionID, func(_ context.Context, sess *session.Session) error {
+			// A second worker starts a file while this one is analyzed
+			sess.Progress.CurrentFile = "/project/other.go"
+			return nil
+		})
+
+		_, err := o.ProcessNextFile(context.Background(), sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, "/project/other.go", sess.Progress.CurrentFile)
+		assert.Equal(t, 1, sess.Progress.ProcessedFiles)
+	})
+}
@@ -0,0 +1,88 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/session"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/workflow"
+)
+
+// ErrSessionPaused is returned when a file is requested from a paused
+// session. Resume the session before processing more files.
+var ErrSessionPaused = errors.New("session is paused")
+
+// PauseWorkspace pauses every processing session of a workspace, for example
+// during an AI provider outage. Sessions that are not processing are
+// skipped. It returns how many sessions were paused; failures to pause
+// individual sessions are aggregated into the returned error.
+func (o *OrchestratorImpl) PauseWorkspace(ctx context.Context, workspaceID string) (int, error) {
+	return o.triggerWorkspace(ctx, workspaceID, workflow.WorkflowStateProcessing, workflow.EventPause)
+}
+
+// ResumeWorkspace resumes every paused session of a workspace. Sessions that
+// are not paused are skipped. It returns how many sessions were resumed;
+// failures to resume individual sessions are aggregated into the returned
+// error.
+func (o *OrchestratorImpl) ResumeWorkspace(ctx context.Context, workspaceID string) (int, error) {
+	return o.triggerWorkspace(ctx, workspaceID, workflow.WorkflowStatePaused, workflow.EventResume)
+}
+
+// triggerWorkspace fires event on every unexpired session of a workspace
+// whose workflow is in state from, returning how many moved.
+func (o *OrchestratorImpl) triggerWorkspace(ctx context.Context, workspaceID string, from workflow.WorkflowState, event workflow.WorkflowEvent) (int, error) {
+	if workspaceID == "" {
+		return 0, fmt.Errorf("workspace_id is required")
+	}
+	target, ok := o.workflowEngine.CanTransition(from, event)
+	if !ok {
+		return 0, fmt.Errorf("cannot %s sessions in state %s", event, from)
+	}
+
+	// Processing and paused sessions are both persisted as in progress, so
+	// the engine tells them apart
+	status := session.StatusInProgress
+	sessions, err := o.sessionManager.List(session.SessionFilter{WorkspaceID: &workspaceID, Status: &status})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	count := 0
+	var errs []error
+	for _, sess := range sessions {
+		if time.Now().After(sess.ExpiresAt) {
+			continue
+		}
+
+		sessionID := o.formatSessionID(sess.ID)
+		state, err := o.workflowEngine.GetState(ctx, sessionID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("session %s: failed to get workflow state: %w", sessionID, err))
+			continue
+		}
+		if state != from {
+			continue
+		}
+
+		if err := o.workflowEngine.Trigger(ctx, sessionID, event); err != nil {
+			errs = append(errs, fmt.Errorf("session %s: failed to %s: %w", sessionID, event, err))
+			continue
+		}
+		o.metrics().StateTransition(WorkflowState(from), WorkflowState(target))
+		count++
+	}
+
+	LoggerFromContext(ctx).Info().
+		Str("workspace_id", workspaceID).
+		Str("event", string(event)).
+		Int("sessions", count).
+		Int("failed", len(errs)).
+		Msg("Workspace sessions transitioned")
+
+	if err := errors.Join(errs...); err != nil {
+		return count, fmt.Errorf("failed to %s %d sessions in workspace %s: %w", event, len(errs), workspaceID, err)
+	}
+	return count, nil
+}
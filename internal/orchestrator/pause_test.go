@@ -0,0 +1,162 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/session"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// setupWorkspaceSessions registers in-progress sessions of workspace-123
+// with a real workflow engine, initializing each in the given state. A
+// state of "" leaves the session unknown to the engine.
+func setupWorkspaceSessions(t *testing.T, o *OrchestratorImpl, sm *mockSessionManager, states map[string]workflow.WorkflowState) workflow.Engine {
+	t.Helper()
+	ctx := context.Background()
+
+	engine, err := workflow.NewEngine(workflow.WorkflowConfig{})
+	require.NoError(t, err)
+	o.workflowEngine = engine
+
+	var sessions []*session.Session
+	for sessionID, state := range states {
+		sess := createMockSession(sessionID, "workspace-123", "/project")
+		sess.Status = session.StatusInProgress
+		sessions = append(sessions, sess)
+		if state != "" {
+			require.NoError(t, engine.Initialize(ctx, sessionID, state))
+		}
+	}
+
+	sm.On("List", mock.MatchedBy(func(filter session.SessionFilter) bool {
+		return filter.WorkspaceID != nil && *filter.WorkspaceID == "workspace-123" &&
+			filter.Status != nil && *filter.Status == session.StatusInProgress
+	})).Return(sessions, nil)
+	return engine
+}
+
+func TestPauseAndResumeWorkspace(t *testing.T) {
+	ctx := context.Background()
+	o, mockSession, _, _ := createTestOrchestrator(t)
+
+	const (
+		processingA = "550e8400-e29b-41d4-a716-446655440900"
+		processingB = "550e8400-e29b-41d4-a716-446655440901"
+		paused      = "550e8400-e29b-41d4-a716-446655440902"
+		initialized = "550e8400-e29b-41d4-a716-446655440903"
+	)
+	engine := setupWorkspaceSessions(t, o, mockSession, map[string]workflow.WorkflowState{
+		processingA: workflow.WorkflowStateProcessing,
+		processingB: workflow.WorkflowStateProcessing,
+		paused:      workflow.WorkflowStatePaused,
+		initialized: workflow.WorkflowStateInitialized,
+	})
+
+	stateOf := func(sessionID string) workflow.WorkflowState {
+		state, err := engine.GetState(ctx, sessionID)
+		require.NoError(t, err)
+		return state
+	}
+
+	count, err := o.PauseWorkspace(ctx, "workspace-123")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, workflow.WorkflowStatePaused, stateOf(processingA))
+	assert.Equal(t, workflow.WorkflowStatePaused, stateOf(processingB))
+	assert.Equal(t, workflow.WorkflowStatePaused, stateOf(paused))
+	assert.Equal(t, workflow.WorkflowStateInitialized, stateOf(initialized))
+
+	// Pausing again finds nothing left to pause
+	count, err = o.PauseWorkspace(ctx, "workspace-123")
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	count, err = o.ResumeWorkspace(ctx, "workspace-123")
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, workflow.WorkflowStateProcessing, stateOf(processingA))
+	assert.Equal(t, workflow.WorkflowStateProcessing, stateOf(processingB))
+	assert.Equal(t, workflow.WorkflowStateProcessing, stateOf(paused))
+	assert.Equal(t, workflow.WorkflowStateInitialized, stateOf(initialized))
+}
+
+func TestPauseWorkspaceAggregatesErrors(t *testing.T) {
+	ctx := context.Background()
+	o, mockSession, _, _ := createTestOrchestrator(t)
+
+	const (
+		processing = "550e8400-e29b-41d4-a716-446655440910"
+		unknown    = "550e8400-e29b-41d4-a716-446655440911"
+	)
+	setupWorkspaceSessions(t, o, mockSession, map[string]workflow.WorkflowState{
+		processing: workflow.WorkflowStateProcessing,
+		unknown:    "",
+	})
+
+	count, err := o.PauseWorkspace(ctx, "workspace-123")
+	assert.Equal(t, 1, count)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to pause 1 sessions in workspace workspace-123")
+	assert.Contains(t, err.Error(), unknown)
+}
+
+func TestPauseWorkspaceSkipsExpiredSessions(t *testing.T) {
+	ctx := context.Background()
+	o, mockSession, _, _ := createTestOrchestrator(t)
+
+	engine, err := workflow.NewEngine(workflow.WorkflowConfig{})
+	require.NoError(t, err)
+	o.workflowEngine = engine
+
+	sessionID := "550e8400-e29b-41d4-a716-446655440920"
+	require.NoError(t, engine.Initialize(ctx, sessionID, workflow.WorkflowStateProcessing))
+	sess := createMockSession(sessionID, "workspace-123", "/project")
+	sess.Status = session.StatusInProgress
+	sess.ExpiresAt = time.Now().Add(-time.Minute)
+	mockSession.On("List", mock.Anything).Return([]*session.Session{sess}, nil)
+
+	count, err := o.PauseWorkspace(ctx, "workspace-123")
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	state, err := engine.GetState(ctx, sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, workflow.WorkflowStateProcessing, state)
+}
+
+func TestPauseWorkspaceValidation(t *testing.T) {
+	ctx := context.Background()
+	o, mockSession, _, _ := createTestOrchestrator(t)
+	engine, err := workflow.NewEngine(workflow.WorkflowConfig{})
+	require.NoError(t, err)
+	o.workflowEngine = engine
+
+	_, err = o.PauseWorkspace(ctx, "")
+	assert.EqualError(t, err, "workspace_id is required")
+
+	mockSession.On("List", mock.Anything).Return(nil, errors.New("database error"))
+	_, err = o.ResumeWorkspace(ctx, "workspace-123")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to list sessions")
+}
+
+func TestProcessNextFilePausedSession(t *testing.T) {
+	ctx := context.Background()
+	o, mockSession, _, _ := createTestOrchestrator(t)
+	sessionID := "550e8400-e29b-41d4-a716-446655440930"
+	newStatefulSession(t, o, mockSession, sessionID)
+	require.NoError(t, o.workflowEngine.Trigger(ctx, sessionID, workflow.EventPause))
+
+	_, err := o.ProcessNextFile(ctx, sessionID)
+	assert.ErrorIs(t, err, ErrSessionPaused)
+
+	require.NoError(t, o.workflowEngine.Trigger(ctx, sessionID, workflow.EventResume))
+	_, err = o.ProcessNextFile(ctx, sessionID)
+	assert.ErrorIs(t, err, ErrNoMoreFiles)
+}
@@ -0,0 +1,115 @@
+package orchestrator
+
+import (
+	"time"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/events"
+)
+
+// ProgressPublisherName is the container name under which a
+// ProgressPublisher is registered.
+const ProgressPublisherName = "progress_publisher"
+
+// ProgressEventKind is the outcome of a file that a ProgressEvent reports.
+type ProgressEventKind string
+
+const (
+	// ProgressProcessed reports a file that was analyzed
+	ProgressProcessed ProgressEventKind = FileStatusProcessed
+
+	// ProgressFailed reports a file whose analysis failed
+	ProgressFailed ProgressEventKind = FileStatusFailed
+
+	// ProgressSkipped reports a file that was skipped without analysis
+	ProgressSkipped ProgressEventKind = FileStatusSkipped
+)
+
+// ProgressEvent reports the outcome of one file of a session.
+type ProgressEvent struct {
+	// SessionID identifies the session the file belongs to
+	SessionID string `json:"session_id"`
+
+	// FilePath is the file the outcome is for
+	FilePath string `json:"file_path"`
+
+	// Kind is the file's outcome
+	Kind ProgressEventKind `json:"kind"`
+
+	// At is when the outcome was recorded
+	At time.Time `json:"at"`
+}
+
+// Event converts the progress event to a broker event of type "file_"
+// followed by its kind, such as file_failed.
+func (e ProgressEvent) Event() events.Event {
+	return events.Event{
+		Type:      "file_" + string(e.Kind),
+		SessionID: e.SessionID,
+		Timestamp: e.At,
+		Data: map[string]interface{}{
+			"file_path": e.FilePath,
+			"kind":      string(e.Kind),
+		},
+	}
+}
+
+// ProgressPublisher receives a ProgressEvent for each file outcome. When
+// one is registered in the container under ProgressPublisherName, the
+// orchestrator publishes to it synchronously from the processing paths, so
+// a session's events arrive in the order its outcomes were recorded.
+// Publishers should return quickly, as processing waits for them.
+type ProgressPublisher interface {
+	PublishProgress(event ProgressEvent)
+}
+
+// ProgressPublisherFunc adapts a function to a ProgressPublisher.
+type ProgressPublisherFunc func(event ProgressEvent)
+
+// PublishProgress calls f(event).
+func (f ProgressPublisherFunc) PublishProgress(event ProgressEvent) {
+	f(event)
+}
+
+// BrokerProgressPublisher returns a ProgressPublisher that publishes each
+// event to broker's subscribers, converted with ProgressEvent.Event.
+func BrokerProgressPublisher(broker *events.Broker) ProgressPublisher {
+	return ProgressPublisherFunc(func(event ProgressEvent) {
+		broker.Publish(event.Event())
+	})
+}
+
+// CountDroppedEvents records every event broker's subscribers drop into
+// m, replacing any OnDrop function already set on broker.
+func CountDroppedEvents(broker *events.Broker, m Metrics) {
+	broker.OnDrop(func(_ string, event events.Event) {
+		m.EventDropped(event.Type)
+	})
+}
+
+// progressPublisher returns the registered ProgressPublisher, if any.
+func (o *OrchestratorImpl) progressPublisher() (ProgressPublisher, bool) {
+	if o.container == nil {
+		return nil, false
+	}
+	service, err := o.container.Get(ProgressPublisherName)
+	if err != nil {
+		return nil, false
+	}
+	publisher, ok := service.(ProgressPublisher)
+	return publisher, ok
+}
+
+// publishProgress publishes the outcome of a file to the registered
+// ProgressPublisher, if any.
+func (o *OrchestratorImpl) publishProgress(sessionID, filePath string, kind ProgressEventKind) {
+	publisher, ok := o.progressPublisher()
+	if !ok {
+		return
+	}
+	publisher.PublishProgress(ProgressEvent{
+		SessionID: sessionID,
+		FilePath:  filePath,
+		Kind:      kind,
+		At:        time.Now(),
+	})
+}
@@ -0,0 +1,106 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/events"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/services"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/todolist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressEvents(t *testing.T) {
+	ctx := context.Background()
+	o, mockSession, _, _ := createTestOrchestrator(t)
+	o.config.Services.MaxContextTokens = 100
+	sessionID := "550e8400-e29b-41d4-a716-446655440940"
+	newStatefulSession(t, o, mockSession, sessionID)
+
+	files := map[string][]byte{
+		"/project/a.go":     []byte("package a"),
+		"/project/bad.go":   []byte("package bad"),
+		"/project/huge.go":  []byte(strings.Repeat("x", 200)),
+		"/project/d.go":     []byte("package d"),
+		"/project/later.go": []byte("package later"),
+	}
+	for i, path := range []string{"/project/a.go", "/project/bad.go", "/project/huge.go", "/project/d.go"} {
+		require.NoError(t, o.todoManager.AddItem(ctx, sessionID, todolist.TodoItem{FilePath: path, Priority: 10 - i}))
+	}
+	require.NoError(t, o.serviceRegistry.RegisterFileSystem(&fakeFileSystem{files: files}))
+	require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &stubAIService{
+		analyzeFunc: func(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error) {
+			if req.FilePath == "/project/bad.go" {
+				return nil, errors.New("model refused")
+			}
+			return &services.FileAnalysisResponse{Summary: "ok"}, nil
+		},
+	}))
+
+	var published []ProgressEvent
+	require.NoError(t, o.container.Register(ProgressPublisherName, ProgressPublisherFunc(func(event ProgressEvent) {
+		published = append(published, event)
+	})))
+
+	_, err := o.ProcessFiles(ctx, sessionID, 1)
+	require.Error(t, err)
+
+	// A file left in the queue is skipped by the completion
+	require.NoError(t, o.todoManager.AddItem(ctx, sessionID, todolist.TodoItem{FilePath: "/project/later.go"}))
+	require.NoError(t, o.CompleteSession(ctx, sessionID, CompleteOptions{SkipRemaining: true}))
+
+	type outcome struct {
+		file string
+		kind ProgressEventKind
+	}
+	var got []outcome
+	for _, event := range published {
+		assert.Equal(t, sessionID, event.SessionID)
+		assert.False(t, event.At.IsZero())
+		got = append(got, outcome{event.FilePath, event.Kind})
+	}
+	assert.Equal(t, []outcome{
+		{"/project/a.go", ProgressProcessed},
+		{"/project/bad.go", ProgressFailed},
+		{"/project/huge.go", ProgressSkipped},
+		{"/project/d.go", ProgressProcessed},
+		{"/project/later.go", ProgressSkipped},
+	}, got)
+}
+
+func TestBrokerProgressPublisher(t *testing.T) {
+	broker, err := events.NewBroker(events.DefaultBufferConfig())
+	require.NoError(t, err)
+	defer broker.Close()
+	sub, err := broker.Subscribe()
+	require.NoError(t, err)
+
+	event := ProgressEvent{SessionID: "session-1", FilePath: "/project/bad.go", Kind: ProgressFailed}
+	BrokerProgressPublisher(broker).PublishProgress(event)
+
+	got, ok := sub.TryPop()
+	require.True(t, ok)
+	assert.Equal(t, "file_failed", got.Type)
+	assert.Equal(t, "session-1", got.SessionID)
+	assert.Equal(t, "/project/bad.go", got.Data["file_path"])
+	assert.Equal(t, "failed", got.Data["kind"])
+}
+
+func TestCountDroppedEvents(t *testing.T) {
+	broker, err := events.NewBroker(events.BufferConfig{Capacity: 1, Policy: events.OverflowDropOldest})
+	require.NoError(t, err)
+	defer broker.Close()
+	_, err = broker.Subscribe()
+	require.NoError(t, err)
+
+	m := NewPrometheusMetrics()
+	CountDroppedEvents(broker, m)
+	publisher := BrokerProgressPublisher(broker)
+	publisher.PublishProgress(ProgressEvent{SessionID: "session-1", FilePath: "/project/a.go", Kind: ProgressFailed})
+	publisher.PublishProgress(ProgressEvent{SessionID: "session-1", FilePath: "/project/b.go", Kind: ProgressFailed})
+
+	assert.Contains(t, scrapeMetrics(t, m), `codedoc_events_dropped_total{type="file_failed"} 1`)
+}
@@ -0,0 +1,466 @@
+package orchestrator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/session"
+)
+
+// ProgressLogName is the container name under which a ProgressLog is registered.
+const ProgressLogName = "progress_log"
+
+// ProgressLog is a write-ahead log of file completions. When one is
+// registered in the container under ProgressLogName, the orchestrator
+// records each completion before updating the session's aggregate progress
+// and acknowledges it afterwards, so ReplayProgressLog can finish updates
+// interrupted by a crash.
+type ProgressLog interface {
+	// Append durably records a completion and returns its sequence number
+	Append(ctx context.Context, entry ProgressLogEntry) (uint64, error)
+
+	// Acknowledge marks the entry with the given sequence number as applied
+	Acknowledge(ctx context.Context, sequence uint64) error
+
+	// Pending returns the unacknowledged entries in sequence order
+	Pending(ctx context.Context) ([]ProgressLogEntry, error)
+}
+
+// ProgressLogEntry records that a file of a session was completed.
+type ProgressLogEntry struct {
+	// Sequence orders the entries of a log; it is assigned by Append
+	Sequence uint64 `json:"sequence"`
+
+	// SessionID identifies the session the file belongs to
+	SessionID string `json:"session_id"`
+
+	// FilePath is the completed file
+	FilePath string `json:"file_path"`
+
+	// ProcessedFiles is the session's processed file count once this
+	// completion is applied; replay skips entries the count already covers
+	ProcessedFiles int `json:"processed_files"`
+
+	// RecordedAt is when the completion was logged
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// Operations written to a FileProgressLog.
+const (
+	progressLogAppend      = "append"
+	progressLogAcknowledge = "ack"
+)
+
+// progressLogRecord is one line of a FileProgressLog.
+type progressLogRecord struct {
+	Op    string            `json:"op"`
+	Entry *ProgressLogEntry `json:"entry,omitempty"`
+	Ack   uint64            `json:"ack,omitempty"`
+}
+
+// DefaultProgressLogCompactAfter is the number of acknowledged entries after
+// which a FileProgressLog rewrites its file with only the pending ones.
+const DefaultProgressLogCompactAfter = 1000
+
+// FileProgressLog is a ProgressLog stored as an append-only file of JSON
+// lines. Every write is synced before it returns. Acknowledged entries are
+// dropped from the file when it is opened and once
+// DefaultProgressLogCompactAfter of them have accumulated.
+type FileProgressLog struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	next    uint64
+	pending map[uint64]ProgressLogEntry
+
+	// Entries acknowledged since the file was last rewritten, and how many
+	// trigger a rewrite
+	acknowledged int
+	compactAfter int
+}
+
+var _ ProgressLog = (*FileProgressLog)(nil)
+
+// OpenFileProgressLog opens or creates the log at path and loads its
+// unacknowledged entries. A partially written last line, left by a crash
+// mid-write, is discarded.
+func OpenFileProgressLog(path string) (*FileProgressLog, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open progress log: %w", err)
+	}
+
+	l := &FileProgressLog{
+		path:         path,
+		file:         file,
+		next:         1,
+		pending:      make(map[uint64]ProgressLogEntry),
+		compactAfter: DefaultProgressLogCompactAfter,
+	}
+	if err := l.load(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if l.acknowledged > 0 {
+		if err := l.compactLocked(); err != nil {
+			l.file.Close()
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// load replays the file into memory and truncates a torn final line.
+func (l *FileProgressLog) load() error {
+	content, err := os.ReadFile(l.path)
+	if err != nil {
+		return fmt.Errorf("failed to read progress log: %w", err)
+	}
+
+	complete := bytes.LastIndexByte(content, '\n') + 1
+	if complete < len(content) {
+		if err := l.file.Truncate(int64(complete)); err != nil {
+			return fmt.Errorf("failed to truncate torn progress log entry: %w", err)
+		}
+		content = content[:complete]
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var record progressLogRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return fmt.Errorf("corrupt progress log at line %d: %w", line, err)
+		}
+		switch record.Op {
+		case progressLogAppend:
+			if record.Entry == nil {
+				return fmt.Errorf("corrupt progress log at line %d: append without entry", line)
+			}
+			l.pending[record.Entry.Sequence] = *record.Entry
+			if record.Entry.Sequence >= l.next {
+				l.next = record.Entry.Sequence + 1
+			}
+		case progressLogAcknowledge:
+			delete(l.pending, record.Ack)
+			l.acknowledged++
+		default:
+			return fmt.Errorf("corrupt progress log at line %d: unknown op %q", line, record.Op)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read progress log: %w", err)
+	}
+
+	if _, err := l.file.Seek(0, io.SeekEnd); err != nil {
+		return fmt.Errorf("failed to seek progress log: %w", err)
+	}
+	return nil
+}
+
+// Append durably records a completion and returns its sequence number.
+func (l *FileProgressLog) Append(ctx context.Context, entry ProgressLogEntry) (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry.Sequence = l.next
+	if err := l.write(progressLogRecord{Op: progressLogAppend, Entry: &entry}); err != nil {
+		return 0, err
+	}
+	l.next++
+	l.pending[entry.Sequence] = entry
+	return entry.Sequence, nil
+}
+
+// Acknowledge marks the entry with the given sequence number as applied.
+func (l *FileProgressLog) Acknowledge(ctx context.Context, sequence uint64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.pending[sequence]; !ok {
+		return nil
+	}
+	if err := l.write(progressLogRecord{Op: progressLogAcknowledge, Ack: sequence}); err != nil {
+		return err
+	}
+	delete(l.pending, sequence)
+
+	l.acknowledged++
+	if l.compactAfter > 0 && l.acknowledged >= l.compactAfter {
+		// The acknowledgement is already durable; a failed rewrite is
+		// retried on the next one
+		if err := l.compactLocked(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Compact rewrites the file with only the pending entries, dropping the
+// acknowledged ones. ReplayProgressLog calls it once replay is done.
+func (l *FileProgressLog) Compact(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.acknowledged == 0 {
+		return nil
+	}
+	return l.compactLocked()
+}
+
+// Pending returns the unacknowledged entries in sequence order.
+func (l *FileProgressLog) Pending(ctx context.Context) ([]ProgressLogEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.pendingLocked(), nil
+}
+
+// pendingLocked returns the unacknowledged entries in sequence order. The
+// caller must hold l.mu.
+func (l *FileProgressLog) pendingLocked() []ProgressLogEntry {
+	entries := make([]ProgressLogEntry, 0, len(l.pending))
+	for _, entry := range l.pending {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Sequence < entries[j].Sequence
+	})
+	return entries
+}
+
+// Close closes the underlying file.
+func (l *FileProgressLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// compactLocked replaces the file with one holding only the pending
+// entries. The new file is written and synced beside the old one and then
+// renamed over it, so a crash leaves one or the other whole. The caller
+// must hold l.mu.
+func (l *FileProgressLog) compactLocked() error {
+	var content bytes.Buffer
+	for _, entry := range l.pendingLocked() {
+		line, err := encodeProgressLogRecord(progressLogRecord{Op: progressLogAppend, Entry: &entry})
+		if err != nil {
+			return err
+		}
+		content.Write(line)
+	}
+
+	if err := writeFileSynced(l.path+".compact", content.Bytes()); err != nil {
+		return fmt.Errorf("failed to compact progress log: %w", err)
+	}
+	if err := os.Rename(l.path+".compact", l.path); err != nil {
+		return fmt.Errorf("failed to compact progress log: %w", err)
+	}
+	if dir, err := os.Open(filepath.Dir(l.path)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+
+	file, err := os.OpenFile(l.path, os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to reopen compacted progress log: %w", err)
+	}
+	l.file.Close()
+	l.file = file
+	l.acknowledged = 0
+	return nil
+}
+
+// writeFileSynced writes content to a new file at path and syncs it.
+func writeFileSynced(path string, content []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// write appends one record and syncs it to disk. The caller must hold l.mu.
+func (l *FileProgressLog) write(record progressLogRecord) error {
+	line, err := encodeProgressLogRecord(record)
+	if err != nil {
+		return err
+	}
+	if _, err := l.file.Write(line); err != nil {
+		return fmt.Errorf("failed to write progress log: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync progress log: %w", err)
+	}
+	return nil
+}
+
+// encodeProgressLogRecord encodes record as one line of a FileProgressLog.
+func encodeProgressLogRecord(record progressLogRecord) ([]byte, error) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode progress log record: %w", err)
+	}
+	return append(line, '\n'), nil
+}
+
+// progressLog returns the registered ProgressLog, if any.
+func (o *OrchestratorImpl) progressLog() (ProgressLog, bool) {
+	if o.container == nil {
+		return nil, false
+	}
+	service, err := o.container.Get(ProgressLogName)
+	if err != nil {
+		return nil, false
+	}
+	progressLog, ok := service.(ProgressLog)
+	return progressLog, ok
+}
+
+// appendProgressLog records a completion in the registered ProgressLog
+// before the session's progress is updated. It reports false when there is
+// no log or the write failed; failures are logged rather than returned, so
+// processing continues without crash protection.
+func (o *OrchestratorImpl) appendProgressLog(ctx context.Context, sessionID, filePath string, processedFiles int) (uint64, bool) {
+	progressLog, ok := o.progressLog()
+	if !ok {
+		return 0, false
+	}
+
+	sequence, err := progressLog.Append(ctx, ProgressLogEntry{
+		SessionID:      sessionID,
+		FilePath:       filePath,
+		ProcessedFiles: processedFiles,
+		RecordedAt:     time.Now(),
+	})
+	if err != nil {
+		LoggerFromContext(ctx).Warn().
+			Err(err).
+			Str("file", filePath).
+			Msg("Failed to record completion in progress log")
+		return 0, false
+	}
+	return sequence, true
+}
+
+// acknowledgeProgressLog marks a logged completion as applied. A failure
+// only leaves the entry for ReplayProgressLog, which skips applied entries.
+func (o *OrchestratorImpl) acknowledgeProgressLog(ctx context.Context, sequence uint64) {
+	progressLog, ok := o.progressLog()
+	if !ok {
+		return
+	}
+	if err := progressLog.Acknowledge(ctx, sequence); err != nil {
+		LoggerFromContext(ctx).Warn().
+			Err(err).
+			Uint64("sequence", sequence).
+			Msg("Failed to acknowledge progress log entry")
+	}
+}
+
+// ReplayProgressLog applies the completions left unacknowledged in the
+// registered ProgressLog, typically by a crash between processing a file and
+// persisting the session's progress. It should run at startup, before new
+// work is accepted. Entries whose completion the session's progress already
+// reflects are only acknowledged, so no file is counted twice, as are
+// entries whose session no longer exists. A log that can be compacted is
+// compacted afterwards. It returns how many completions were applied.
+func (o *OrchestratorImpl) ReplayProgressLog(ctx context.Context) (int, error) {
+	progressLog, ok := o.progressLog()
+	if !ok {
+		return 0, nil
+	}
+
+	entries, err := progressLog.Pending(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read progress log: %w", err)
+	}
+
+	applied := 0
+	var errs []error
+	for _, entry := range entries {
+		replayed, err := o.replayProgressEntry(ctx, progressLog, entry)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("entry %d for %s: %w", entry.Sequence, entry.FilePath, err))
+			continue
+		}
+		if replayed {
+			applied++
+		}
+	}
+
+	if compacter, ok := progressLog.(interface{ Compact(context.Context) error }); ok {
+		if err := compacter.Compact(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return applied, fmt.Errorf("failed to replay progress log: %w", err)
+	}
+	return applied, nil
+}
+
+// replayProgressEntry applies one logged completion unless the session's
+// progress already includes it, then acknowledges it.
+func (o *OrchestratorImpl) replayProgressEntry(ctx context.Context, progressLog ProgressLog, entry ProgressLogEntry) (bool, error) {
+	sessionUUID, err := parseSessionID(entry.SessionID)
+	if err != nil {
+		return false, fmt.Errorf("invalid session ID: %w", err)
+	}
+
+	o.progressMu.Lock()
+	current, err := o.sessionManager.Get(sessionUUID)
+	if errors.Is(err, session.ErrNotFound) {
+		// The session was purged, so there is nothing left to apply to
+		o.progressMu.Unlock()
+		LoggerFromContext(ctx).Info().
+			Str("session_id", entry.SessionID).
+			Str("file", entry.FilePath).
+			Msg("Dropping progress log entry of a purged session")
+		if err := progressLog.Acknowledge(ctx, entry.Sequence); err != nil {
+			return false, fmt.Errorf("failed to acknowledge: %w", err)
+		}
+		return false, nil
+	}
+	if err != nil {
+		o.progressMu.Unlock()
+		return false, fmt.Errorf("session not found: %w", err)
+	}
+
+	replayed := false
+	if current.Progress.ProcessedFiles < entry.ProcessedFiles {
+		progress := current.Progress
+		progress.ProcessedFiles++
+		progress.CurrentFile = ""
+		if err := o.sessionManager.Update(sessionUUID, session.NewSessionUpdate().WithProgress(progress).Build()); err != nil {
+			o.progressMu.Unlock()
+			return false, fmt.Errorf("failed to update session progress: %w", err)
+		}
+		replayed = true
+	}
+	o.progressMu.Unlock()
+
+	if err := progressLog.Acknowledge(ctx, entry.Sequence); err != nil {
+		return replayed, fmt.Errorf("failed to acknowledge: %w", err)
+	}
+	return replayed, nil
+}
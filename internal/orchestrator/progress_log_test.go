@@ -0,0 +1,260 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileProgressLog(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("pending entries survive reopening", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "progress.log")
+		log, err := OpenFileProgressLog(path)
+		require.NoError(t, err)
+
+		first, err := log.Append(ctx, ProgressLogEntry{SessionID: "s-1", FilePath: "/a.go", ProcessedFiles: 1})
+		require.NoError(t, err)
+		second, err := log.Append(ctx, ProgressLogEntry{SessionID: "s-1", FilePath: "/b.go", ProcessedFiles: 2})
+		require.NoError(t, err)
+		assert.Equal(t, first+1, second)
+		require.NoError(t, log.Acknowledge(ctx, first))
+		require.NoError(t, log.Close())
+
+		reopened, err := OpenFileProgressLog(path)
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		pending, err := reopened.Pending(ctx)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		assert.Equal(t, second, pending[0].Sequence)
+		assert.Equal(t, "/b.go", pending[0].FilePath)
+
+		// Sequence numbers keep increasing across reopens
+		third, err := reopened.Append(ctx, ProgressLogEntry{SessionID: "s-1", FilePath: "/c.go", ProcessedFiles: 3})
+		require.NoError(t, err)
+		assert.Equal(t, second+1, third)
+	})
+
+	t.Run("torn final line is discarded", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "progress.log")
+		log, err := OpenFileProgressLog(path)
+		require.NoError(t, err)
+		_, err = log.Append(ctx, ProgressLogEntry{SessionID: "s-1", FilePath: "/a.go", ProcessedFiles: 1})
+		require.NoError(t, err)
+		require.NoError(t, log.Close())
+
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+		require.NoError(t, err)
+		_, err = f.WriteString(`{"op":"append","entry":{"sequ`)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		reopened, err := OpenFileProgressLog(path)
+		require.NoError(t, err)
+		defer reopened.Close()
+		_, err = reopened.Append(ctx, ProgressLogEntry{SessionID: "s-1", FilePath: "/b.go", ProcessedFiles: 2})
+		require.NoError(t, err)
+
+		pending, err := reopened.Pending(ctx)
+		require.NoError(t, err)
+		assert.Len(t, pending, 2)
+	})
+
+	t.Run("acknowledged entries are compacted away", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "progress.log")
+		log, err := OpenFileProgressLog(path)
+		require.NoError(t, err)
+		log.compactAfter = 3
+
+		lines := func() int {
+			content, err := os.ReadFile(path)
+			require.NoError(t, err)
+			return strings.Count(string(content), "\n")
+		}
+
+		var sequences []uint64
+		for i := range 4 {
+			sequence, err := log.Append(ctx, ProgressLogEntry{SessionID: "s-1", FilePath: fmt.Sprintf("/%d.go", i), ProcessedFiles: i + 1})
+			require.NoError(t, err)
+			sequences = append(sequences, sequence)
+		}
+		require.NoError(t, log.Acknowledge(ctx, sequences[0]))
+		require.NoError(t, log.Acknowledge(ctx, sequences[1]))
+		assert.Equal(t, 6, lines(), "below the threshold the log only grows")
+
+		// The third acknowledgement leaves only the pending entry
+		require.NoError(t, log.Acknowledge(ctx, sequences[2]))
+		assert.Equal(t, 1, lines())
+
+		// Appends continue on the compacted file
+		next, err := log.Append(ctx, ProgressLogEntry{SessionID: "s-1", FilePath: "/4.go", ProcessedFiles: 5})
+		require.NoError(t, err)
+		assert.Greater(t, next, sequences[3])
+		require.NoError(t, log.Acknowledge(ctx, sequences[3]))
+		require.NoError(t, log.Close())
+
+		// Reopening drops what was acknowledged since
+		reopened, err := OpenFileProgressLog(path)
+		require.NoError(t, err)
+		defer reopened.Close()
+		assert.Equal(t, 1, lines())
+		pending, err := reopened.Pending(ctx)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		assert.Equal(t, "/4.go", pending[0].FilePath)
+	})
+
+	t.Run("corrupt entries are rejected", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "progress.log")
+		require.NoError(t, os.WriteFile(path, []byte("not json\n"), 0o644))
+
+		_, err := OpenFileProgressLog(path)
+		assert.ErrorContains(t, err, "corrupt progress log at line 1")
+	})
+}
+
+// progressSession registers a mock session whose progress follows the
+// updates applied to it.
+func progressSession(sm *mockSessionManager, sessionID string, processed int) *session.Session {
+	id := uuid.MustParse(sessionID)
+	sess := createMockSession(sessionID, "workspace-123", "/project")
+	sess.Status = session.StatusInProgress
+	sess.Progress = session.Progress{TotalFiles: 5, ProcessedFiles: processed, FailedFiles: []string{}}
+	sm.On("Get", id).Return(sess, nil)
+	sm.On("Update", id, mock.AnythingOfType("session.SessionUpdate")).Run(func(args mock.Arguments) {
+		if update := args.Get(1).(session.SessionUpdate); update.Progress != nil {
+			sess.Progress = *update.Progress
+		}
+	}).Return(nil)
+	return sess
+}
+
+func TestReplayProgressLog(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("crash before the aggregate update is replayed once", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		log, err := OpenFileProgressLog(filepath.Join(t.TempDir(), "progress.log"))
+		require.NoError(t, err)
+		defer log.Close()
+		require.NoError(t, o.container.Register(ProgressLogName, log))
+
+		sessionID := "550e8400-e29b-41d4-a716-446655440770"
+		sess := progressSession(mockSession, sessionID, 2)
+
+		// Two files were logged: the second reached the session, the third
+		// was logged just before the crash
+		_, err = log.Append(ctx, ProgressLogEntry{SessionID: sessionID, FilePath: "/project/b.go", ProcessedFiles: 2})
+		require.NoError(t, err)
+		_, err = log.Append(ctx, ProgressLogEntry{SessionID: sessionID, FilePath: "/project/c.go", ProcessedFiles: 3})
+		require.NoError(t, err)
+
+		applied, err := o.ReplayProgressLog(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, applied)
+		assert.Equal(t, 3, sess.Progress.ProcessedFiles)
+
+		pending, err := log.Pending(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+
+		// Replaying again changes nothing
+		applied, err = o.ReplayProgressLog(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, applied)
+		assert.Equal(t, 3, sess.Progress.ProcessedFiles)
+		mockSession.AssertNumberOfCalls(t, "Update", 1)
+	})
+
+	t.Run("sessions that fail to load stay pending", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		log, err := OpenFileProgressLog(filepath.Join(t.TempDir(), "progress.log"))
+		require.NoError(t, err)
+		defer log.Close()
+		require.NoError(t, o.container.Register(ProgressLogName, log))
+
+		sessionID := "550e8400-e29b-41d4-a716-446655440771"
+		mockSession.On("Get", uuid.MustParse(sessionID)).Return(nil, assert.AnError)
+		_, err = log.Append(ctx, ProgressLogEntry{SessionID: sessionID, FilePath: "/project/a.go", ProcessedFiles: 1})
+		require.NoError(t, err)
+
+		applied, err := o.ReplayProgressLog(ctx)
+		assert.ErrorContains(t, err, "session not found")
+		assert.Zero(t, applied)
+
+		pending, err := log.Pending(ctx)
+		require.NoError(t, err)
+		assert.Len(t, pending, 1)
+	})
+
+	t.Run("purged sessions are acknowledged", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		path := filepath.Join(t.TempDir(), "progress.log")
+		log, err := OpenFileProgressLog(path)
+		require.NoError(t, err)
+		defer log.Close()
+		require.NoError(t, o.container.Register(ProgressLogName, log))
+
+		sessionID := "550e8400-e29b-41d4-a716-446655440772"
+		id := uuid.MustParse(sessionID)
+		mockSession.On("Get", id).Return(nil, fmt.Errorf("session %s %w", id, session.ErrNotFound))
+		_, err = log.Append(ctx, ProgressLogEntry{SessionID: sessionID, FilePath: "/project/a.go", ProcessedFiles: 1})
+		require.NoError(t, err)
+
+		applied, err := o.ReplayProgressLog(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, applied)
+
+		pending, err := log.Pending(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+
+		// Replay compacts the log, so the next startup reads nothing
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Empty(t, content)
+	})
+
+	t.Run("no progress log", func(t *testing.T) {
+		o, _, _, _ := createTestOrchestrator(t)
+		applied, err := o.ReplayProgressLog(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, applied)
+	})
+}
+
+func TestProcessNextFileLogsProgress(t *testing.T) {
+	ctx := context.Background()
+	o, mockSession, _, _ := createTestOrchestrator(t)
+	sessionID := "550e8400-e29b-41d4-a716-446655440772"
+	setupBatchSession(t, o, mockSession, sessionID, 2)
+
+	log, err := OpenFileProgressLog(filepath.Join(t.TempDir(), "progress.log"))
+	require.NoError(t, err)
+	defer log.Close()
+	require.NoError(t, o.container.Register(ProgressLogName, log))
+
+	_, err = o.ProcessNextFile(ctx, sessionID)
+	require.NoError(t, err)
+
+	// The completion was logged and acknowledged once progress was saved
+	pending, err := log.Pending(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+	content, err := os.ReadFile(log.file.Name())
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `"op":"append"`)
+	assert.Contains(t, string(content), `"op":"ack"`)
+}
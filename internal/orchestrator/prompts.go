@@ -0,0 +1,150 @@
+package orchestrator
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// DefaultPromptKey is the PromptTemplates key holding the prompt used for
+// languages without a template of their own.
+const DefaultPromptKey = "default"
+
+// DefaultAnalysisPrompt is the built-in prompt used when no default prompt
+// template is configured.
+const DefaultAnalysisPrompt = `Analyze the {{if .Language}}{{.Language}} {{end}}file {{.FilePath}}.
+Summarize its purpose and list its functions, classes and dependencies.`
+
+// PromptTemplateConfig configures the analysis prompt for one language.
+// At most one of File and Inline may be set, and an entry without either
+// must pin a Model.
+type PromptTemplateConfig struct {
+	// File is the path of a file holding the template text
+	File string `json:"file,omitempty"`
+
+	// Inline is the template text itself
+	Inline string `json:"inline,omitempty"`
+
+	// Model pins the AI model that analyzes files of the language,
+	// overriding the session's model; on the DefaultPromptKey entry it is
+	// the model for sessions that don't choose one
+	Model string `json:"model,omitempty"`
+}
+
+// PromptData is the data analysis prompt templates are executed with.
+type PromptData struct {
+	// FilePath is the path of the file being analyzed
+	FilePath string
+
+	// Language is the detected language of the file, empty if unknown
+	Language string
+}
+
+// PromptRegistry holds the analysis prompt templates, keyed by language.
+// Templates are registered before the registry is shared; rendering is safe
+// for concurrent use.
+type PromptRegistry struct {
+	templates       map[string]*template.Template
+	defaultTemplate *template.Template
+	models          map[string]string
+}
+
+// NewPromptRegistry creates a registry whose default prompt is
+// DefaultAnalysisPrompt.
+func NewPromptRegistry() *PromptRegistry {
+	return &PromptRegistry{
+		templates:       make(map[string]*template.Template),
+		defaultTemplate: template.Must(template.New(DefaultPromptKey).Parse(DefaultAnalysisPrompt)),
+		models:          make(map[string]string),
+	}
+}
+
+// LoadPromptRegistry builds a registry from configured templates, reading
+// file-based templates from disk. The DefaultPromptKey entry replaces the
+// built-in default prompt.
+func LoadPromptRegistry(configs map[string]PromptTemplateConfig) (*PromptRegistry, error) {
+	registry := NewPromptRegistry()
+	for language, cfg := range configs {
+		text := cfg.Inline
+		if cfg.File != "" {
+			content, err := os.ReadFile(cfg.File)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read prompt template for %s: %w", language, err)
+			}
+			text = string(content)
+		}
+		if cfg.File != "" || cfg.Inline != "" {
+			if err := registry.Register(language, text); err != nil {
+				return nil, err
+			}
+		}
+		if cfg.Model != "" {
+			registry.PinModel(language, cfg.Model)
+		}
+	}
+	return registry, nil
+}
+
+// Register parses text as the prompt template for language, replacing any
+// existing one. Registering DefaultPromptKey replaces the default prompt.
+func (r *PromptRegistry) Register(language, text string) error {
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("prompt template for %s is empty", language)
+	}
+
+	tmpl, err := template.New(language).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid prompt template for %s: %w", language, err)
+	}
+
+	if language == DefaultPromptKey {
+		r.defaultTemplate = tmpl
+		return nil
+	}
+	r.templates[strings.ToLower(language)] = tmpl
+	return nil
+}
+
+// PinModel pins the AI model that analyzes files of language. Pinning
+// DefaultPromptKey sets the model used when nothing else chooses one.
+func (r *PromptRegistry) PinModel(language, model string) {
+	r.models[strings.ToLower(language)] = model
+}
+
+// Model returns the model pinned for language, or "" if none is.
+func (r *PromptRegistry) Model(language string) string {
+	return r.models[strings.ToLower(language)]
+}
+
+// Render returns the analysis prompt for a file, using the template of the
+// file's language or the default template when there is none.
+func (r *PromptRegistry) Render(data PromptData) (string, error) {
+	tmpl, ok := r.templates[data.Language]
+	if !ok {
+		tmpl = r.defaultTemplate
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template %s: %w", tmpl.Name(), err)
+	}
+	return b.String(), nil
+}
+
+// validatePromptTemplates checks that every configured template has exactly
+// one source, or pins a model instead.
+func validatePromptTemplates(configs map[string]PromptTemplateConfig) error {
+	for language, cfg := range configs {
+		if language == "" {
+			return fmt.Errorf("services.prompt_templates has an entry without a language")
+		}
+		if cfg.File == "" && cfg.Inline == "" && cfg.Model != "" {
+			continue
+		}
+		if (cfg.File == "") == (cfg.Inline == "") {
+			return fmt.Errorf("services.prompt_templates.%s must set exactly one of file or inline", language)
+		}
+	}
+	return nil
+}
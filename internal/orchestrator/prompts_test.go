@@ -0,0 +1,102 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromptRegistry(t *testing.T) {
+	goPrompt := filepath.Join(t.TempDir(), "go.tmpl")
+	require.NoError(t, os.WriteFile(goPrompt, []byte("Document the Go package in {{.FilePath}}."), 0o644))
+
+	registry, err := LoadPromptRegistry(map[string]PromptTemplateConfig{
+		"go":             {File: goPrompt},
+		"markdown":       {Inline: "Summarize the prose in {{.FilePath}}."},
+		DefaultPromptKey: {Inline: "Analyze {{.FilePath}} ({{.Language}})."},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"template from file", "/project/main.go", "Document the Go package in /project/main.go."},
+		{"inline template", "/project/README.md", "Summarize the prose in /project/README.md."},
+		{"known language without a template", "/project/app.py", "Analyze /project/app.py (python)."},
+		{"unknown language", "/project/Makefile", "Analyze /project/Makefile ()."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompt, err := registry.Render(PromptData{FilePath: tt.path, Language: DetectLanguage(tt.path)})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, prompt)
+		})
+	}
+
+	t.Run("built-in default", func(t *testing.T) {
+		prompt, err := NewPromptRegistry().Render(PromptData{FilePath: "/project/main.go", Language: "go"})
+		require.NoError(t, err)
+		assert.Contains(t, prompt, "Analyze the go file /project/main.go.")
+	})
+
+	t.Run("load errors", func(t *testing.T) {
+		_, err := LoadPromptRegistry(map[string]PromptTemplateConfig{"go": {File: filepath.Join(t.TempDir(), "missing.tmpl")}})
+		assert.ErrorContains(t, err, "failed to read prompt template for go")
+
+		_, err = LoadPromptRegistry(map[string]PromptTemplateConfig{"go": {Inline: "{{.FilePath"}})
+		assert.ErrorContains(t, err, "invalid prompt template for go")
+
+		_, err = LoadPromptRegistry(map[string]PromptTemplateConfig{"go": {Inline: "  "}})
+		assert.ErrorContains(t, err, "prompt template for go is empty")
+	})
+}
+
+func TestValidatePromptTemplates(t *testing.T) {
+	assert.NoError(t, validatePromptTemplates(nil))
+	assert.NoError(t, validatePromptTemplates(map[string]PromptTemplateConfig{"go": {Inline: "x"}, "rust": {File: "/prompts/rust.tmpl"}}))
+	assert.NoError(t, validatePromptTemplates(map[string]PromptTemplateConfig{"go": {Model: "large"}}))
+	assert.EqualError(t, validatePromptTemplates(map[string]PromptTemplateConfig{"go": {}}),
+		"services.prompt_templates.go must set exactly one of file or inline")
+	assert.EqualError(t, validatePromptTemplates(map[string]PromptTemplateConfig{"go": {Inline: "x", File: "/prompts/go.tmpl"}}),
+		"services.prompt_templates.go must set exactly one of file or inline")
+	assert.EqualError(t, validatePromptTemplates(map[string]PromptTemplateConfig{"": {Inline: "x"}}),
+		"services.prompt_templates has an entry without a language")
+}
+
+func TestAnalyzeFileUsesLanguagePrompt(t *testing.T) {
+	ctx := context.Background()
+	o, _, _, _ := createTestOrchestrator(t)
+
+	registry, err := LoadPromptRegistry(map[string]PromptTemplateConfig{
+		"go":             {Inline: "go prompt for {{.FilePath}}"},
+		DefaultPromptKey: {Inline: "default prompt for {{.FilePath}}"},
+	})
+	require.NoError(t, err)
+	o.prompts = registry
+
+	prompts := make(map[string]string)
+	require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &stubAIService{
+		analyzeFunc: func(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error) {
+			prompts[req.FilePath] = req.Prompt
+			return &services.FileAnalysisResponse{Summary: "ok"}, nil
+		},
+	}))
+
+	_, err = o.analyzeFile(ctx, "", "/project/main.go")
+	require.NoError(t, err)
+	_, err = o.analyzeFile(ctx, "", "/project/notes.txt")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"/project/main.go":   "go prompt for /project/main.go",
+		"/project/notes.txt": "default prompt for /project/notes.txt",
+	}, prompts)
+}
@@ -0,0 +1,113 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RecoveryManagerName is the container name under which a RecoveryManager
+// is registered. Without one, failed analyses are not retried.
+const RecoveryManagerName = "recovery"
+
+// DefaultMaxRetryAfter is the longest Retry-After a rate-limited provider
+// may ask a retry to wait when Workflow.MaxRetryAfter is not set.
+const DefaultMaxRetryAfter = 5 * time.Minute
+
+// RecoveryManager decides whether a failed operation should be retried and
+// tracks how many attempts each operation has consumed. It is implemented
+// by errors.RecoveryManager.
+type RecoveryManager interface {
+	// HandleError waits out the backoff for the operation and returns nil
+	// if it should be retried
+	HandleError(ctx context.Context, err error, operationID string) error
+
+	// ResetAttempts clears the attempt counter for an operation
+	ResetAttempts(operationID string)
+
+	// AttemptsWithPrefix returns the attempt counts of matching operations
+	AttemptsWithPrefix(prefix string) map[string]int
+}
+
+// WithRecoveryManager registers m under RecoveryManagerName, so failed
+// analyses are retried as it decides.
+func WithRecoveryManager(m RecoveryManager) Option {
+	return Option{name: RecoveryManagerName, service: m}
+}
+
+// GetRecoveryStats returns the number of recovery attempts consumed by each
+// file of a session, keyed by file path.
+func (o *OrchestratorImpl) GetRecoveryStats(sessionID string) (map[string]int, error) {
+	if _, err := parseSessionID(sessionID); err != nil {
+		return nil, fmt.Errorf("invalid session ID: %w", err)
+	}
+
+	stats := make(map[string]int)
+	recovery, ok := o.recoveryManager()
+	if !ok {
+		return stats, nil
+	}
+
+	prefix := recoveryOperationID(sessionID, "")
+	for operationID, attempts := range recovery.AttemptsWithPrefix(prefix) {
+		stats[strings.TrimPrefix(operationID, prefix)] = attempts
+	}
+	return stats, nil
+}
+
+// analyzeWithRecovery analyzes a file, retrying failures for as long as the
+// registered RecoveryManager allows. The last analysis error is returned
+// once it gives up.
+func (o *OrchestratorImpl) analyzeWithRecovery(ctx context.Context, sessionID, filePath string) (*FileAnalysis, error) {
+	recovery, hasRecovery := o.recoveryManager()
+	operationID := recoveryOperationID(sessionID, filePath)
+
+	for {
+		analysis, err := o.analyzeFile(ctx, sessionID, filePath)
+		if err == nil || !hasRecovery || ctx.Err() != nil {
+			return analysis, err
+		}
+		// Retrying cannot make a file fit the context window or the
+		// provider serve another model, and processors reject files
+		// deliberately
+		var tooLarge *FileTooLargeError
+		var processorErr *ProcessorError
+		if errors.As(err, &tooLarge) || errors.As(err, &processorErr) || errors.Is(err, ErrUnsupportedModel) {
+			return nil, err
+		}
+		if recoveryErr := recovery.HandleError(ctx, err, operationID); recoveryErr != nil {
+			return nil, err
+		}
+	}
+}
+
+// resetRecoveryStats forgets the recovery attempts of a session's files.
+func (o *OrchestratorImpl) resetRecoveryStats(sessionID string) {
+	recovery, ok := o.recoveryManager()
+	if !ok {
+		return
+	}
+	for operationID := range recovery.AttemptsWithPrefix(recoveryOperationID(sessionID, "")) {
+		recovery.ResetAttempts(operationID)
+	}
+}
+
+// recoveryOperationID keys recovery attempts by session and file.
+func recoveryOperationID(sessionID, filePath string) string {
+	return sessionID + ":" + filePath
+}
+
+// recoveryManager returns the registered RecoveryManager, if any.
+func (o *OrchestratorImpl) recoveryManager() (RecoveryManager, bool) {
+	if o.container == nil {
+		return nil, false
+	}
+	service, err := o.container.Get(RecoveryManagerName)
+	if err != nil {
+		return nil, false
+	}
+	recovery, ok := service.(RecoveryManager)
+	return recovery, ok
+}
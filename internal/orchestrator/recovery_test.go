@@ -0,0 +1,148 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingRecovery allows a fixed number of retries per operation without
+// waiting, mirroring errors.RecoveryManager's attempt accounting.
+type countingRecovery struct {
+	mu         sync.Mutex
+	maxRetries int
+	attempts   map[string]int
+}
+
+func newCountingRecovery(maxRetries int) *countingRecovery {
+	return &countingRecovery{maxRetries: maxRetries, attempts: make(map[string]int)}
+}
+
+func (r *countingRecovery) HandleError(ctx context.Context, err error, operationID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts[operationID]++
+	if r.attempts[operationID] > r.maxRetries {
+		return fmt.Errorf("max recovery attempts exceeded: %w", err)
+	}
+	return nil
+}
+
+func (r *countingRecovery) ResetAttempts(operationID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.attempts, operationID)
+}
+
+func (r *countingRecovery) AttemptsWithPrefix(prefix string) map[string]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	attempts := make(map[string]int)
+	for id, n := range r.attempts {
+		if strings.HasPrefix(id, prefix) {
+			attempts[id] = n
+		}
+	}
+	return attempts
+}
+
+func TestGetRecoveryStats(t *testing.T) {
+	ctx := context.Background()
+	o, mockSession, _, _ := createTestOrchestrator(t)
+	sessionID := "550e8400-e29b-41d4-a716-446655440b00"
+	setupBatchSession(t, o, mockSession, sessionID, 3)
+
+	recovery := newCountingRecovery(3)
+	require.NoError(t, o.container.Register(RecoveryManagerName, recovery))
+
+	// file0 is flaky and succeeds on its third call, file1 always fails
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &stubAIService{
+		analyzeFunc: func(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error) {
+			mu.Lock()
+			calls[req.FilePath]++
+			n := calls[req.FilePath]
+			mu.Unlock()
+
+			switch {
+			case req.FilePath == "/project/file0.go" && n < 3:
+				return nil, errors.New("timeout")
+			case req.FilePath == "/project/file1.go":
+				return nil, errors.New("rate limited")
+			}
+			return &services.FileAnalysisResponse{Summary: "ok"}, nil
+		},
+	}))
+
+	results, err := o.ProcessFiles(ctx, sessionID, 1)
+	assert.Error(t, err)
+	assert.Len(t, results, 2)
+
+	stats, err := o.GetRecoveryStats(sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{
+		"/project/file0.go": 2,
+		"/project/file1.go": 4, // three retries, then the limit was hit
+	}, stats)
+	assert.Equal(t, 4, calls["/project/file1.go"])
+
+	// Other sessions are not affected and stats can be cleared
+	other, err := o.GetRecoveryStats("550e8400-e29b-41d4-a716-446655440b01")
+	require.NoError(t, err)
+	assert.Empty(t, other)
+
+	o.resetRecoveryStats(sessionID)
+	stats, err = o.GetRecoveryStats(sessionID)
+	require.NoError(t, err)
+	assert.Empty(t, stats)
+
+	_, err = o.GetRecoveryStats("not-a-uuid")
+	assert.ErrorContains(t, err, "invalid session ID")
+}
+
+func TestNewOrchestratorWithRecoveryManager(t *testing.T) {
+	ctx := context.Background()
+	recovery := newCountingRecovery(2)
+
+	o, err := NewOrchestrator(unreachableDatabaseConfig(t), WithRecoveryManager(recovery))
+	require.NoError(t, err)
+	t.Cleanup(func() { o.Shutdown(ctx) })
+
+	registered, ok := o.recoveryManager()
+	require.True(t, ok)
+	assert.Same(t, recovery, registered)
+
+	// Each failed analysis is retried until the manager gives up
+	require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &stubAIService{
+		analyzeFunc: func(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error) {
+			return nil, errors.New("timeout")
+		},
+	}))
+	sessionID := "550e8400-e29b-41d4-a716-446655440b00"
+	_, err = o.analyzeWithRecovery(ctx, sessionID, "/project/main.go")
+	require.Error(t, err)
+
+	stats, err := o.GetRecoveryStats(sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"/project/main.go": 3}, stats)
+
+	// A nil manager is rejected rather than silently disabling retries
+	_, err = NewOrchestrator(unreachableDatabaseConfig(t), WithRecoveryManager(nil))
+	assert.ErrorIs(t, err, ErrNilService)
+}
+
+func TestGetRecoveryStatsWithoutManager(t *testing.T) {
+	o, _, _, _ := createTestOrchestrator(t)
+
+	stats, err := o.GetRecoveryStats("550e8400-e29b-41d4-a716-446655440b00")
+	require.NoError(t, err)
+	assert.Empty(t, stats)
+}
@@ -0,0 +1,123 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/workflow"
+)
+
+// BatchResult summarizes a RunToCompletion run.
+type BatchResult struct {
+	// SessionID identifies the session that was run
+	SessionID string `json:"session_id"`
+
+	// Analyses are the files analyzed during this run
+	Analyses []*FileAnalysis `json:"analyses"`
+
+	// Processed, Failed and Skipped count the session's files by outcome,
+	// including files finished before this run
+	Processed int `json:"processed"`
+	Failed    int `json:"failed"`
+	Skipped   int `json:"skipped"`
+
+	// Completed reports whether the session was completed
+	Completed bool `json:"completed"`
+
+	// Paused reports that the run was interrupted and the session paused;
+	// running it again picks up the remaining files
+	Paused bool `json:"paused"`
+}
+
+// RunToCompletion processes every queued file of a session with ProcessFiles
+// and then completes it with opts. Files that fail are recorded on the
+// session and counted in the result rather than returned as errors. A paused
+// session is resumed first. If ctx is cancelled, interrupted files return to
+// the queue and the session is paused, so a later run continues where this
+// one stopped.
+func (o *OrchestratorImpl) RunToCompletion(ctx context.Context, sessionID string, concurrency int, opts CompleteOptions) (*BatchResult, error) {
+	ctx = ContextWithSessionLogger(ctx, sessionID)
+	result := &BatchResult{SessionID: sessionID}
+
+	if _, err := o.GetSession(ctx, sessionID); err != nil {
+		return nil, err
+	}
+	if err := o.resumeSession(ctx, sessionID); err != nil {
+		return nil, err
+	}
+
+	for {
+		analyses, err := o.ProcessFiles(ctx, sessionID, concurrency)
+		result.Analyses = append(result.Analyses, analyses...)
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			// Finish the pause even though the caller has given up
+			if pauseErr := o.pauseSession(context.WithoutCancel(ctx), sessionID); pauseErr != nil {
+				return result, errors.Join(ctxErr, pauseErr)
+			}
+			result.Paused = true
+			return result, fmt.Errorf("run of session %s interrupted, session paused: %w", sessionID, ctxErr)
+		}
+		if errors.Is(err, ErrSessionFailedFast) || errors.Is(err, ErrConsecutiveFailures) {
+			return result, err
+		}
+
+		progress, progressErr := o.todoManager.GetProgress(ctx, sessionID)
+		if progressErr != nil {
+			return result, fmt.Errorf("failed to get TODO progress: %w", progressErr)
+		}
+		if progress.Pending+progress.InProgress == 0 {
+			result.Processed = progress.Complete
+			result.Failed = progress.Failed
+			result.Skipped = progress.Skipped
+			break
+		}
+		if err != nil {
+			return result, err
+		}
+		// Files were added while the batch ran; keep going
+	}
+
+	if err := o.CompleteSession(ctx, sessionID, opts); err != nil {
+		return result, err
+	}
+	result.Completed = true
+
+	LoggerFromContext(ctx).Info().
+		Int("processed", result.Processed).
+		Int("failed", result.Failed).
+		Int("skipped", result.Skipped).
+		Msg("Session run to completion")
+
+	return result, nil
+}
+
+// resumeSession resumes a session if its workflow is paused.
+func (o *OrchestratorImpl) resumeSession(ctx context.Context, sessionID string) error {
+	return o.triggerFrom(ctx, sessionID, workflow.WorkflowStatePaused, workflow.EventResume)
+}
+
+// pauseSession pauses a session if its workflow is processing.
+func (o *OrchestratorImpl) pauseSession(ctx context.Context, sessionID string) error {
+	return o.triggerFrom(ctx, sessionID, workflow.WorkflowStateProcessing, workflow.EventPause)
+}
+
+// triggerFrom fires event on a session whose workflow is in state from,
+// doing nothing in any other state.
+func (o *OrchestratorImpl) triggerFrom(ctx context.Context, sessionID string, from workflow.WorkflowState, event workflow.WorkflowEvent) error {
+	state, err := o.workflowEngine.GetState(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get workflow state: %w", err)
+	}
+	if state != from {
+		return nil
+	}
+
+	target, _ := o.workflowEngine.CanTransition(from, event)
+	if err := o.workflowEngine.Trigger(ctx, sessionID, event); err != nil {
+		return fmt.Errorf("failed to %s session: %w", event, err)
+	}
+	o.metrics().StateTransition(WorkflowState(from), WorkflowState(target))
+	return nil
+}
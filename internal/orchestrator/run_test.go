@@ -0,0 +1,114 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/services"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/session"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/todolist"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// queueFiles adds n files to a session's TODO list.
+func queueFiles(t *testing.T, o *OrchestratorImpl, sessionID string, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		require.NoError(t, o.todoManager.AddItem(context.Background(), sessionID, todolist.TodoItem{FilePath: fmt.Sprintf("/project/file%d.go", i)}))
+	}
+}
+
+func TestRunToCompletion(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("processes every file and completes the session", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		sessionID := "550e8400-e29b-41d4-a716-446655440900"
+		sess := newStatefulSession(t, o, mockSession, sessionID)
+		queueFiles(t, o, sessionID, 5)
+		require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &stubAIService{
+			analyzeFunc: func(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error) {
+				if req.FilePath == "/project/file3.go" {
+					return nil, errors.New("unparseable")
+				}
+				return &services.FileAnalysisResponse{Summary: req.FilePath}, nil
+			},
+		}))
+
+		result, err := o.RunToCompletion(ctx, sessionID, 2, CompleteOptions{})
+		require.NoError(t, err)
+		assert.True(t, result.Completed)
+		assert.False(t, result.Paused)
+		assert.Equal(t, sessionID, result.SessionID)
+		assert.Len(t, result.Analyses, 4)
+		assert.Equal(t, 4, result.Processed)
+		assert.Equal(t, 1, result.Failed)
+		assert.Equal(t, 0, result.Skipped)
+
+		assert.Equal(t, session.StatusCompleted, sess.Status)
+		assert.Equal(t, 4, sess.Progress.ProcessedFiles)
+		state, err := o.workflowEngine.GetState(ctx, sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, workflow.WorkflowStateComplete, state)
+	})
+
+	t.Run("cancellation pauses the session and a later run finishes it", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		sessionID := "550e8400-e29b-41d4-a716-446655440901"
+		sess := newStatefulSession(t, o, mockSession, sessionID)
+		queueFiles(t, o, sessionID, 4)
+
+		runCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		var calls int32
+		require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &stubAIService{
+			analyzeFunc: func(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error) {
+				if atomic.AddInt32(&calls, 1) == 2 {
+					// The caller gives up while the second file is in flight
+					cancel()
+					<-ctx.Done()
+					return nil, ctx.Err()
+				}
+				return &services.FileAnalysisResponse{Summary: req.FilePath}, nil
+			},
+		}))
+
+		result, err := o.RunToCompletion(runCtx, sessionID, 1, CompleteOptions{})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.True(t, result.Paused)
+		assert.False(t, result.Completed)
+		assert.Len(t, result.Analyses, 1)
+
+		state, err := o.workflowEngine.GetState(ctx, sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, workflow.WorkflowStatePaused, state)
+		progress, err := o.todoManager.GetProgress(ctx, sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, progress.Complete)
+		assert.Equal(t, 3, progress.Pending, "the interrupted file returns to the queue")
+
+		result, err = o.RunToCompletion(ctx, sessionID, 1, CompleteOptions{})
+		require.NoError(t, err)
+		assert.True(t, result.Completed)
+		assert.Len(t, result.Analyses, 3)
+		assert.Equal(t, 4, result.Processed)
+		assert.Equal(t, session.StatusCompleted, sess.Status)
+	})
+
+	t.Run("unknown session", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		sessionID := "550e8400-e29b-41d4-a716-446655440902"
+		mockSession.On("Get", uuid.MustParse(sessionID)).Return(nil, errors.New("not found"))
+
+		result, err := o.RunToCompletion(ctx, sessionID, 1, CompleteOptions{})
+		assert.Error(t, err)
+		assert.Nil(t, result)
+	})
+}
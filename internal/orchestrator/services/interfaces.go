@@ -4,6 +4,7 @@ package services
 
 import (
 	"context"
+	"io"
 )
 
 // MCPHandler processes Model Context Protocol requests.
@@ -29,6 +30,10 @@ type FileSystemService interface {
 	// ReadFile reads the contents of a file
 	ReadFile(ctx context.Context, path string) ([]byte, error)
 
+	// OpenFile opens a file to be read in pieces, for files too large to
+	// read whole. The caller must close the reader
+	OpenFile(ctx context.Context, path string) (io.ReadCloser, error)
+
 	// WriteFile writes content to a file
 	WriteFile(ctx context.Context, path string, content []byte) error
 
@@ -51,6 +56,29 @@ type AIService interface {
 	CountTokens(ctx context.Context, text string) (int, error)
 }
 
+// Pinger is implemented by services that can check whether their backend,
+// such as an AI provider's API, is reachable.
+type Pinger interface {
+	// Ping returns an error if the service cannot currently serve requests
+	Ping(ctx context.Context) error
+}
+
+// ModelRestricted is implemented by AI services that serve only a fixed set
+// of models.
+type ModelRestricted interface {
+	// SupportedModels returns the model names FileAnalysisRequest.Model
+	// may take
+	SupportedModels() []string
+}
+
+// ConcurrencyLimited is implemented by AI services that cap how many
+// requests they accept at once, typically because of provider rate limits.
+type ConcurrencyLimited interface {
+	// MaxConcurrency returns the maximum number of in-flight AnalyzeFile
+	// and GenerateDocumentation calls; 0 or less means unlimited
+	MaxConcurrency() int
+}
+
 // MemoryService manages the Zettelkasten memory system.
 type MemoryService interface {
 	// StoreMemory saves a memory node
@@ -59,8 +87,10 @@ type MemoryService interface {
 	// RetrieveMemory gets a memory by ID
 	RetrieveMemory(ctx context.Context, id string) (*Memory, error)
 
-	// SearchMemories finds memories by query
-	SearchMemories(ctx context.Context, query string) ([]*Memory, error)
+	// SearchMemories finds memories by query. When only some backend calls
+	// fail the memories that were fetched are returned with Partial set; an
+	// error is returned only when nothing could be fetched
+	SearchMemories(ctx context.Context, query string) (*MemorySearchResult, error)
 
 	// EvolveMemories runs the evolution algorithm
 	EvolveMemories(ctx context.Context) error
@@ -123,6 +153,10 @@ type ListFilesRequest struct {
 	Patterns        []string `json:"patterns"`
 	ExcludePatterns []string `json:"exclude_patterns"`
 	MaxDepth        int      `json:"max_depth"`
+
+	// Concurrency is how many directories may be read at once, for
+	// services that walk in parallel; 0 uses the service's own setting
+	Concurrency int `json:"concurrency,omitempty"`
 }
 
 // FileInfo contains metadata about a file.
@@ -139,6 +173,8 @@ type FileAnalysisRequest struct {
 	FilePath string `json:"file_path"`
 	Content  string `json:"content"`
 	Language string `json:"language"`
+	Prompt   string `json:"prompt,omitempty"`
+	Model    string `json:"model,omitempty"`
 }
 
 // FileAnalysisResponse contains analysis results.
@@ -163,6 +199,13 @@ type DocumentationResponse struct {
 	TokenCount int    `json:"token_count"`
 }
 
+// MemorySearchResult contains the memories found by a search.
+type MemorySearchResult struct {
+	Memories []*Memory `json:"memories"`
+	Partial  bool      `json:"partial"`
+	Warnings []string  `json:"warnings,omitempty"`
+}
+
 // Memory represents a Zettelkasten memory node.
 type Memory struct {
 	ID          string            `json:"id"`
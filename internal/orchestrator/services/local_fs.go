@@ -0,0 +1,602 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultMaxReadSize is the largest file LocalFileSystem reads when no
+// limit is configured.
+const DefaultMaxReadSize int64 = 10 << 20
+
+// DefaultListCacheSize is how many ListFiles results LocalFileSystem keeps
+// when caching is enabled without a size.
+const DefaultListCacheSize = 32
+
+// ErrFileTooLarge is returned by ReadFile for files over the read limit.
+var ErrFileTooLarge = errors.New("file exceeds maximum read size")
+
+var _ FileSystemService = (*LocalFileSystem)(nil)
+
+// LocalFileSystem is a FileSystemService backed by the local disk. Every
+// path must resolve inside its root directory.
+type LocalFileSystem struct {
+	root        string
+	maxReadSize int64
+	walkers     int
+
+	cacheTTL  time.Duration
+	cacheSize int
+	cacheMu   sync.Mutex
+	cache     map[string]listCacheEntry
+	now       func() time.Time
+
+	// walks counts the tree walks ListFiles has made
+	walks atomic.Int64
+}
+
+// listCacheEntry is a cached ListFiles result.
+type listCacheEntry struct {
+	files       []FileInfo
+	rootModTime time.Time
+	storedAt    time.Time
+}
+
+// LocalFileSystemConfig configures a LocalFileSystem.
+type LocalFileSystemConfig struct {
+	// Root is the directory every path must resolve inside
+	Root string
+
+	// MaxReadSize is the largest file ReadFile accepts; 0 uses
+	// DefaultMaxReadSize
+	MaxReadSize int64
+
+	// Walkers is how many directories ListFiles reads at once; 0 or 1
+	// walks the tree sequentially
+	Walkers int
+
+	// CacheTTL is how long ListFiles results are reused; 0 disables the
+	// cache
+	CacheTTL time.Duration
+
+	// CacheSize is how many results the cache holds; 0 uses
+	// DefaultListCacheSize
+	CacheSize int
+}
+
+// NewLocalFileSystem creates a file system rooted at root. ReadFile refuses
+// files larger than maxReadSize bytes; 0 uses DefaultMaxReadSize.
+func NewLocalFileSystem(root string, maxReadSize int64) (*LocalFileSystem, error) {
+	return NewLocalFileSystemWithConfig(LocalFileSystemConfig{Root: root, MaxReadSize: maxReadSize})
+}
+
+// NewLocalFileSystemWithConfig creates a file system from config.
+func NewLocalFileSystemWithConfig(config LocalFileSystemConfig) (*LocalFileSystem, error) {
+	maxReadSize := config.MaxReadSize
+	if maxReadSize < 0 {
+		return nil, fmt.Errorf("max read size cannot be negative")
+	}
+	if maxReadSize == 0 {
+		maxReadSize = DefaultMaxReadSize
+	}
+	if config.Walkers < 0 {
+		return nil, fmt.Errorf("walkers cannot be negative")
+	}
+	if config.CacheTTL < 0 {
+		return nil, fmt.Errorf("cache TTL cannot be negative")
+	}
+	if config.CacheSize < 0 {
+		return nil, fmt.Errorf("cache size cannot be negative")
+	}
+	cacheSize := config.CacheSize
+	if cacheSize == 0 {
+		cacheSize = DefaultListCacheSize
+	}
+
+	absRoot, err := filepath.Abs(config.Root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid root %s: %w", config.Root, err)
+	}
+
+	return &LocalFileSystem{
+		root:        absRoot,
+		maxReadSize: maxReadSize,
+		walkers:     config.Walkers,
+		cacheTTL:    config.CacheTTL,
+		cacheSize:   cacheSize,
+		cache:       make(map[string]listCacheEntry),
+		now:         time.Now,
+	}, nil
+}
+
+// MaxReadSize returns the largest file size ReadFile accepts.
+func (l *LocalFileSystem) MaxReadSize() int64 {
+	return l.maxReadSize
+}
+
+// ListFiles walks req.RootPath and returns the regular files whose names
+// match req.Patterns (all files when empty) and none of req.ExcludePatterns,
+// sorted by path. A MaxDepth of 0 does not limit the depth. Symlinks are
+// neither followed nor listed, so the walk cannot leave the root through
+// one.
+//
+// With a CacheTTL configured, an identical request within the TTL reuses the
+// previous result as long as the root's modification time is unchanged. That
+// check only sees entries added to or removed from the root itself; deeper
+// changes show up once the cached result expires.
+func (l *LocalFileSystem) ListFiles(ctx context.Context, req ListFilesRequest) ([]FileInfo, error) {
+	if err := l.ValidatePath(ctx, req.RootPath); err != nil {
+		return nil, err
+	}
+	root := filepath.Clean(req.RootPath)
+	info, statErr := os.Stat(root)
+
+	key := listCacheKey(root, req)
+	caching := l.cacheTTL > 0 && statErr == nil
+	if caching {
+		if files, ok := l.cachedFiles(key, info.ModTime()); ok {
+			return files, nil
+		}
+	}
+
+	walkers := l.walkers
+	if req.Concurrency > 0 {
+		walkers = req.Concurrency
+	}
+
+	l.walks.Add(1)
+	var files []FileInfo
+	var err error
+	if walkers > 1 && statErr == nil && info.IsDir() {
+		files, err = walkParallel(ctx, root, req, walkers)
+	} else {
+		files, err = walkSequential(ctx, root, req)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files in %s: %w", req.RootPath, err)
+	}
+
+	// Parallel walks finish directories in any order, so sort either way to
+	// keep discovery reproducible
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+
+	if caching {
+		l.storeFiles(key, info.ModTime(), files)
+	}
+	return files, nil
+}
+
+// listCacheKey identifies a ListFiles request independent of the order of
+// its patterns.
+func listCacheKey(root string, req ListFilesRequest) string {
+	patterns := append([]string(nil), req.Patterns...)
+	sort.Strings(patterns)
+	excludes := append([]string(nil), req.ExcludePatterns...)
+	sort.Strings(excludes)
+
+	return fmt.Sprintf("%q %q %q %d", root, patterns, excludes, req.MaxDepth)
+}
+
+// cachedFiles returns a copy of the cached result for key if it has not
+// expired and the root has not been modified since it was stored.
+func (l *LocalFileSystem) cachedFiles(key string, rootModTime time.Time) ([]FileInfo, bool) {
+	l.cacheMu.Lock()
+	defer l.cacheMu.Unlock()
+
+	entry, ok := l.cache[key]
+	if !ok {
+		return nil, false
+	}
+	if l.now().Sub(entry.storedAt) >= l.cacheTTL || !entry.rootModTime.Equal(rootModTime) {
+		delete(l.cache, key)
+		return nil, false
+	}
+	return append([]FileInfo(nil), entry.files...), true
+}
+
+// storeFiles caches a copy of files under key, dropping expired entries and
+// then the oldest entry to stay within the cache size.
+func (l *LocalFileSystem) storeFiles(key string, rootModTime time.Time, files []FileInfo) {
+	l.cacheMu.Lock()
+	defer l.cacheMu.Unlock()
+
+	now := l.now()
+	for k, entry := range l.cache {
+		if now.Sub(entry.storedAt) >= l.cacheTTL {
+			delete(l.cache, k)
+		}
+	}
+	if _, ok := l.cache[key]; !ok && len(l.cache) >= l.cacheSize {
+		var oldest string
+		var oldestAt time.Time
+		for k, entry := range l.cache {
+			if oldest == "" || entry.storedAt.Before(oldestAt) {
+				oldest, oldestAt = k, entry.storedAt
+			}
+		}
+		delete(l.cache, oldest)
+	}
+
+	l.cache[key] = listCacheEntry{
+		files:       append([]FileInfo(nil), files...),
+		rootModTime: rootModTime,
+		storedAt:    now,
+	}
+}
+
+// walkSequential lists the files under root one directory at a time.
+func walkSequential(ctx context.Context, root string, req ListFilesRequest) ([]FileInfo, error) {
+	var files []FileInfo
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if rel != "." && tooDeep(rel, req.MaxDepth) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		file, ok, err := listedFile(req, path, rel, d)
+		if ok {
+			files = append(files, file)
+		}
+		return err
+	})
+	return files, err
+}
+
+// walkParallel lists the files under root, reading up to walkers
+// directories at once. The first error or a cancelled ctx stops the walk.
+func walkParallel(ctx context.Context, root string, req ListFilesRequest, walkers int) ([]FileInfo, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		files    []FileInfo
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+	// The calling goroutine is a walker too
+	sem := make(chan struct{}, walkers-1)
+
+	var walk func(dir string)
+	walk = func(dir string) {
+		if err := ctx.Err(); err != nil {
+			fail(err)
+			return
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			fail(err)
+			return
+		}
+
+		for _, d := range entries {
+			if err := ctx.Err(); err != nil {
+				fail(err)
+				return
+			}
+			path := filepath.Join(dir, d.Name())
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				fail(err)
+				return
+			}
+			if d.IsDir() {
+				if tooDeep(rel, req.MaxDepth) {
+					continue
+				}
+				// Hand the directory to a new walker if one is free
+				select {
+				case sem <- struct{}{}:
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						defer func() { <-sem }()
+						walk(path)
+					}()
+				default:
+					walk(path)
+				}
+				continue
+			}
+
+			file, ok, err := listedFile(req, path, rel, d)
+			if err != nil {
+				fail(err)
+				return
+			}
+			if ok {
+				mu.Lock()
+				files = append(files, file)
+				mu.Unlock()
+			}
+		}
+	}
+
+	walk(root)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return files, nil
+}
+
+// tooDeep reports whether a directory at rel, relative to the listing root,
+// is below maxDepth. A maxDepth of 0 does not limit the depth.
+func tooDeep(rel string, maxDepth int) bool {
+	return maxDepth > 0 && strings.Count(rel, string(filepath.Separator))+1 > maxDepth
+}
+
+// listedFile returns the FileInfo of a non-directory entry and whether
+// ListFiles includes it.
+func listedFile(req ListFilesRequest, path, rel string, d fs.DirEntry) (FileInfo, bool, error) {
+	if !d.Type().IsRegular() {
+		return FileInfo{}, false, nil
+	}
+	if len(req.Patterns) > 0 && !matchesAny(req.Patterns, d.Name(), rel) {
+		return FileInfo{}, false, nil
+	}
+	if matchesAny(req.ExcludePatterns, d.Name(), rel) {
+		return FileInfo{}, false, nil
+	}
+
+	info, err := d.Info()
+	if err != nil {
+		return FileInfo{}, false, err
+	}
+	return fileInfo(path, info), true, nil
+}
+
+// ReadFile reads a file, refusing files larger than the configured maximum
+// read size with ErrFileTooLarge.
+func (l *LocalFileSystem) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	info, err := l.GetFileInfo(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+	if info.Size > l.maxReadSize {
+		return nil, fmt.Errorf("%w: %s is %d bytes, limit is %d", ErrFileTooLarge, path, info.Size, l.maxReadSize)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	// The file may have grown since it was checked, so cap the read too
+	content, err := io.ReadAll(io.LimitReader(f, l.maxReadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if int64(len(content)) > l.maxReadSize {
+		return nil, fmt.Errorf("%w: %s grew past the limit of %d bytes", ErrFileTooLarge, path, l.maxReadSize)
+	}
+
+	return content, nil
+}
+
+// OpenFile opens a file to be read in pieces. Unlike ReadFile it applies no
+// size limit, since the file is never held in memory whole. Reads fail once
+// ctx is done. The caller must close the reader.
+func (l *LocalFileSystem) OpenFile(ctx context.Context, path string) (io.ReadCloser, error) {
+	if err := l.ValidatePath(ctx, path); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if info.IsDir() {
+		f.Close()
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+
+	return &contextReader{ctx: ctx, r: f, close: f.Close}, nil
+}
+
+// contextReader is the reader OpenFile returns. Reads fail with the
+// context's error once it is done, and with fs.ErrClosed after Close.
+type contextReader struct {
+	ctx    context.Context
+	r      io.Reader
+	close  func() error
+	closed bool
+}
+
+// Read reads from the underlying reader.
+func (c *contextReader) Read(p []byte) (int, error) {
+	if c.closed {
+		return 0, fs.ErrClosed
+	}
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// Close releases the underlying file, if any.
+func (c *contextReader) Close() error {
+	if c.closed {
+		return fs.ErrClosed
+	}
+	c.closed = true
+	if c.close == nil {
+		return nil
+	}
+	return c.close()
+}
+
+// WriteFile writes content to a file, creating parent directories as needed.
+func (l *LocalFileSystem) WriteFile(ctx context.Context, path string, content []byte) error {
+	if err := l.ValidatePath(ctx, path); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// GetFileInfo returns metadata about a file.
+func (l *LocalFileSystem) GetFileInfo(ctx context.Context, path string) (*FileInfo, error) {
+	if err := l.ValidatePath(ctx, path); err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	fi := fileInfo(path, info)
+	return &fi, nil
+}
+
+// ValidatePath ensures path is absolute and inside the root directory, both
+// as written and with symlinks resolved, so a link inside the root cannot
+// lead outside it. Components that don't exist yet are checked as written.
+func (l *LocalFileSystem) ValidatePath(ctx context.Context, path string) error {
+	if err := validateInRoot(l.root, path); err != nil {
+		return err
+	}
+
+	root, err := resolveSymlinks(l.root)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", l.root, err)
+	}
+	resolved, err := resolveSymlinks(filepath.Clean(path))
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	if validateInRoot(root, resolved) != nil {
+		return fmt.Errorf("path %s resolves to %s, outside %s", path, resolved, l.root)
+	}
+	return nil
+}
+
+// maxSymlinks bounds the links resolveSymlinks follows, as the kernel does.
+const maxSymlinks = 40
+
+// resolveSymlinks resolves the symlinks in path like filepath.EvalSymlinks,
+// but also resolves paths that don't fully exist: the missing components
+// are kept as written, and a dangling link resolves to its target.
+func resolveSymlinks(path string) (string, error) {
+	for range maxSymlinks {
+		resolved, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			return resolved, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+
+		// A dangling link resolves to where it points
+		if info, err := os.Lstat(path); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return "", err
+			}
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(path), target)
+			}
+			path = filepath.Clean(target)
+			continue
+		}
+
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path, nil
+		}
+		resolvedParent, err := resolveSymlinks(parent)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(resolvedParent, filepath.Base(path)), nil
+	}
+	return "", fmt.Errorf("too many links in %s", path)
+}
+
+// validateInRoot ensures path is absolute and inside root.
+func validateInRoot(root, path string) error {
+	if path == "" {
+		return fmt.Errorf("path is required")
+	}
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("path %s must be absolute", path)
+	}
+
+	rel, err := filepath.Rel(root, filepath.Clean(path))
+	if err != nil || !filepath.IsLocal(rel) && rel != "." {
+		return fmt.Errorf("path %s is outside %s", path, root)
+	}
+	return nil
+}
+
+// matchesAny reports whether the file name or its path relative to the
+// listing root matches one of the glob patterns.
+func matchesAny(patterns []string, name, rel string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// fileInfo converts os file metadata to a FileInfo.
+func fileInfo(path string, info fs.FileInfo) FileInfo {
+	return FileInfo{
+		Path:     path,
+		Size:     info.Size(),
+		IsDir:    info.IsDir(),
+		Modified: info.ModTime().Unix(),
+	}
+}
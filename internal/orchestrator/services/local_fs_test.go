@@ -0,0 +1,420 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestFile(t *testing.T, path string, size int) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, bytes.Repeat([]byte("x"), size), 0o644))
+}
+
+func TestLocalFileSystemReadFile(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	small := filepath.Join(root, "small.go")
+	large := filepath.Join(root, "large.go")
+	writeTestFile(t, small, 100)
+	writeTestFile(t, large, 2048)
+
+	t.Run("under the limit", func(t *testing.T) {
+		lfs, err := NewLocalFileSystem(root, 1024)
+		require.NoError(t, err)
+
+		content, err := lfs.ReadFile(ctx, small)
+		require.NoError(t, err)
+		assert.Len(t, content, 100)
+	})
+
+	t.Run("over the limit", func(t *testing.T) {
+		lfs, err := NewLocalFileSystem(root, 1024)
+		require.NoError(t, err)
+
+		content, err := lfs.ReadFile(ctx, large)
+		assert.ErrorIs(t, err, ErrFileTooLarge)
+		assert.Contains(t, err.Error(), "2048 bytes, limit is 1024")
+		assert.Nil(t, content)
+	})
+
+	t.Run("limit is configurable", func(t *testing.T) {
+		lfs, err := NewLocalFileSystem(root, 4096)
+		require.NoError(t, err)
+		assert.Equal(t, int64(4096), lfs.MaxReadSize())
+
+		content, err := lfs.ReadFile(ctx, large)
+		require.NoError(t, err)
+		assert.Len(t, content, 2048)
+	})
+
+	t.Run("default limit", func(t *testing.T) {
+		lfs, err := NewLocalFileSystem(root, 0)
+		require.NoError(t, err)
+		assert.Equal(t, DefaultMaxReadSize, lfs.MaxReadSize())
+	})
+
+	t.Run("negative limit", func(t *testing.T) {
+		_, err := NewLocalFileSystem(root, -1)
+		assert.Error(t, err)
+	})
+
+	t.Run("outside the root", func(t *testing.T) {
+		lfs, err := NewLocalFileSystem(filepath.Join(root, "sub"), 0)
+		require.NoError(t, err)
+
+		_, err = lfs.ReadFile(ctx, small)
+		assert.ErrorContains(t, err, "outside")
+	})
+}
+
+// openFDs counts the process's open file descriptors.
+func openFDs(t *testing.T) int {
+	t.Helper()
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skip("open file descriptors cannot be counted on this platform")
+	}
+	return len(entries)
+}
+
+func TestLocalFileSystemOpenFile(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	large := filepath.Join(root, "large.go")
+	writeTestFile(t, large, 2048)
+
+	lfs, err := NewLocalFileSystem(root, 1024)
+	require.NoError(t, err)
+
+	t.Run("streams files over the read limit", func(t *testing.T) {
+		_, err := lfs.ReadFile(ctx, large)
+		require.ErrorIs(t, err, ErrFileTooLarge)
+
+		r, err := lfs.OpenFile(ctx, large)
+		require.NoError(t, err)
+		defer r.Close()
+
+		head := make([]byte, 16)
+		_, err = io.ReadFull(r, head)
+		require.NoError(t, err)
+		assert.Equal(t, bytes.Repeat([]byte("x"), 16), head)
+
+		// The rest is read from disk as it is consumed, not when opened
+		f, err := os.OpenFile(large, os.O_WRONLY, 0)
+		require.NoError(t, err)
+		_, err = f.WriteAt([]byte("y"), 2047)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		rest, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Len(t, rest, 2048-16)
+		assert.Equal(t, byte('y'), rest[len(rest)-1])
+	})
+
+	t.Run("reads fail after close", func(t *testing.T) {
+		r, err := lfs.OpenFile(ctx, large)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+
+		_, err = r.Read(make([]byte, 1))
+		assert.ErrorIs(t, err, fs.ErrClosed)
+		assert.ErrorIs(t, r.Close(), fs.ErrClosed)
+	})
+
+	t.Run("reads fail once the context is done", func(t *testing.T) {
+		readCtx, cancel := context.WithCancel(ctx)
+		r, err := lfs.OpenFile(readCtx, large)
+		require.NoError(t, err)
+		defer r.Close()
+
+		_, err = r.Read(make([]byte, 16))
+		require.NoError(t, err)
+		cancel()
+		_, err = r.Read(make([]byte, 16))
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("failed opens leave no file open", func(t *testing.T) {
+		before := openFDs(t)
+		for i := 0; i < 20; i++ {
+			_, err := lfs.OpenFile(ctx, root)
+			assert.EqualError(t, err, root+" is a directory")
+		}
+		assert.Equal(t, before, openFDs(t))
+
+		_, err := lfs.OpenFile(ctx, filepath.Join(root, "missing.go"))
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+		_, err = lfs.OpenFile(ctx, "/etc/passwd")
+		assert.ErrorContains(t, err, "outside")
+	})
+}
+
+func TestLocalFileSystemListFiles(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "main.go"), 1)
+	writeTestFile(t, filepath.Join(root, "main_test.go"), 1)
+	writeTestFile(t, filepath.Join(root, "README.md"), 1)
+	writeTestFile(t, filepath.Join(root, "pkg", "util.go"), 1)
+	writeTestFile(t, filepath.Join(root, "pkg", "deep", "inner.go"), 1)
+
+	lfs, err := NewLocalFileSystem(root, 0)
+	require.NoError(t, err)
+
+	paths := func(files []FileInfo) []string {
+		var out []string
+		for _, f := range files {
+			rel, err := filepath.Rel(root, f.Path)
+			require.NoError(t, err)
+			out = append(out, filepath.ToSlash(rel))
+		}
+		return out
+	}
+
+	files, err := lfs.ListFiles(ctx, ListFilesRequest{
+		RootPath:        root,
+		Patterns:        []string{"*.go"},
+		ExcludePatterns: []string{"*_test.go"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main.go", "pkg/deep/inner.go", "pkg/util.go"}, paths(files))
+
+	files, err = lfs.ListFiles(ctx, ListFilesRequest{RootPath: root, Patterns: []string{"*.go"}, MaxDepth: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main.go", "main_test.go", "pkg/util.go"}, paths(files))
+}
+
+func TestLocalFileSystemSymlinks(t *testing.T) {
+	ctx := context.Background()
+	outside := t.TempDir()
+	writeTestFile(t, filepath.Join(outside, "secret.txt"), 1)
+
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "main.go"), 1)
+	writeTestFile(t, filepath.Join(root, "pkg", "util.go"), 1)
+	require.NoError(t, os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(root, "secret.txt")))
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "escape")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "new.txt"), filepath.Join(root, "dangling")))
+	require.NoError(t, os.Symlink(filepath.Join(root, "pkg"), filepath.Join(root, "inside")))
+
+	lfs, err := NewLocalFileSystem(root, 0)
+	require.NoError(t, err)
+
+	// Links that resolve outside the root are refused, whether or not
+	// their target exists yet
+	for _, path := range []string{
+		filepath.Join(root, "secret.txt"),
+		filepath.Join(root, "escape"),
+		filepath.Join(root, "escape", "secret.txt"),
+		filepath.Join(root, "escape", "missing", "file.go"),
+		filepath.Join(root, "dangling"),
+	} {
+		assert.ErrorContains(t, lfs.ValidatePath(ctx, path), "outside", path)
+	}
+	_, err = lfs.ReadFile(ctx, filepath.Join(root, "escape", "secret.txt"))
+	assert.Error(t, err)
+	assert.Error(t, lfs.WriteFile(ctx, filepath.Join(root, "dangling"), []byte("x")))
+	_, err = os.Stat(filepath.Join(outside, "new.txt"))
+	assert.ErrorIs(t, err, fs.ErrNotExist, "nothing is written through the link")
+
+	// Links within the root, and files not written yet, are fine
+	assert.NoError(t, lfs.ValidatePath(ctx, filepath.Join(root, "inside", "util.go")))
+	assert.NoError(t, lfs.ValidatePath(ctx, filepath.Join(root, "pkg", "new", "file.go")))
+
+	// The walk skips links rather than following them out of the root
+	files, err := lfs.ListFiles(ctx, ListFilesRequest{RootPath: root})
+	require.NoError(t, err)
+	var listed []string
+	for _, f := range files {
+		listed = append(listed, f.Path)
+	}
+	assert.Equal(t, []string{filepath.Join(root, "main.go"), filepath.Join(root, "pkg", "util.go")}, listed)
+}
+
+func TestLocalFileSystemListFilesDeterministic(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	for d := 0; d < 6; d++ {
+		for s := 0; s < 4; s++ {
+			for f := 0; f < 5; f++ {
+				writeTestFile(t, filepath.Join(root, fmt.Sprintf("dir%d", d), fmt.Sprintf("sub%d", s), fmt.Sprintf("file%d.go", f)), 1)
+			}
+			writeTestFile(t, filepath.Join(root, fmt.Sprintf("dir%d", d), fmt.Sprintf("sub%d", s), "notes.md"), 1)
+		}
+		writeTestFile(t, filepath.Join(root, fmt.Sprintf("dir%d", d), "top.go"), 1)
+	}
+
+	requests := []ListFilesRequest{
+		{RootPath: root},
+		{RootPath: root, Patterns: []string{"*.go"}},
+		{RootPath: root, Patterns: []string{"*.go"}, MaxDepth: 1},
+	}
+
+	sequential, err := NewLocalFileSystem(root, 0)
+	require.NoError(t, err)
+	parallel, err := NewLocalFileSystemWithConfig(LocalFileSystemConfig{Root: root, Walkers: 8})
+	require.NoError(t, err)
+
+	for _, req := range requests {
+		want, err := sequential.ListFiles(ctx, req)
+		require.NoError(t, err)
+		require.NotEmpty(t, want)
+		assert.True(t, sort.SliceIsSorted(want, func(i, j int) bool {
+			return want[i].Path < want[j].Path
+		}))
+
+		for i := 0; i < 10; i++ {
+			got, err := parallel.ListFiles(ctx, req)
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		}
+
+		// A request can ask a sequential file system to walk in parallel
+		concurrent := req
+		concurrent.Concurrency = 8
+		got, err := sequential.ListFiles(ctx, concurrent)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err = NewLocalFileSystemWithConfig(LocalFileSystemConfig{Root: root, Walkers: -1})
+	assert.Error(t, err)
+}
+
+func TestLocalFileSystemListFilesParallelCancelled(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "pkg", "main.go"), 1)
+
+	lfs, err := NewLocalFileSystemWithConfig(LocalFileSystemConfig{Root: root, Walkers: 4})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = lfs.ListFiles(ctx, ListFilesRequest{RootPath: root})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestLocalFileSystemListFilesCache(t *testing.T) {
+	ctx := context.Background()
+
+	newCached := func(t *testing.T, size int) (*LocalFileSystem, string, *time.Time) {
+		root := t.TempDir()
+		writeTestFile(t, filepath.Join(root, "main.go"), 1)
+		writeTestFile(t, filepath.Join(root, "pkg", "util.go"), 1)
+
+		lfs, err := NewLocalFileSystemWithConfig(LocalFileSystemConfig{Root: root, CacheTTL: time.Minute, CacheSize: size})
+		require.NoError(t, err)
+		now := time.Now()
+		lfs.now = func() time.Time { return now }
+		return lfs, root, &now
+	}
+
+	t.Run("identical request within TTL is cached", func(t *testing.T) {
+		lfs, root, _ := newCached(t, 0)
+
+		first, err := lfs.ListFiles(ctx, ListFilesRequest{RootPath: root, Patterns: []string{"*.go", "*.md"}})
+		require.NoError(t, err)
+		second, err := lfs.ListFiles(ctx, ListFilesRequest{RootPath: root, Patterns: []string{"*.md", "*.go"}})
+		require.NoError(t, err)
+
+		assert.Equal(t, first, second)
+		assert.Len(t, second, 2)
+		assert.Equal(t, int64(1), lfs.walks.Load())
+
+		// Callers may modify the result without touching the cache
+		second[0].Path = "changed"
+		third, err := lfs.ListFiles(ctx, ListFilesRequest{RootPath: root, Patterns: []string{"*.go", "*.md"}})
+		require.NoError(t, err)
+		assert.Equal(t, first, third)
+	})
+
+	t.Run("changed tree invalidates the cache", func(t *testing.T) {
+		lfs, root, _ := newCached(t, 0)
+
+		_, err := lfs.ListFiles(ctx, ListFilesRequest{RootPath: root})
+		require.NoError(t, err)
+
+		writeTestFile(t, filepath.Join(root, "new.go"), 1)
+		// Coarse file system timestamps may not move on their own
+		later := time.Now().Add(time.Hour)
+		require.NoError(t, os.Chtimes(root, later, later))
+
+		files, err := lfs.ListFiles(ctx, ListFilesRequest{RootPath: root})
+		require.NoError(t, err)
+		assert.Len(t, files, 3)
+		assert.Equal(t, int64(2), lfs.walks.Load())
+	})
+
+	t.Run("expired result is walked again", func(t *testing.T) {
+		lfs, root, now := newCached(t, 0)
+
+		_, err := lfs.ListFiles(ctx, ListFilesRequest{RootPath: root})
+		require.NoError(t, err)
+		*now = now.Add(time.Minute)
+		_, err = lfs.ListFiles(ctx, ListFilesRequest{RootPath: root})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), lfs.walks.Load())
+	})
+
+	t.Run("cache is bounded", func(t *testing.T) {
+		lfs, root, now := newCached(t, 2)
+
+		for depth := 1; depth <= 3; depth++ {
+			_, err := lfs.ListFiles(ctx, ListFilesRequest{RootPath: root, MaxDepth: depth})
+			require.NoError(t, err)
+			*now = now.Add(time.Second)
+		}
+		assert.Len(t, lfs.cache, 2)
+
+		// The oldest request was evicted
+		_, err := lfs.ListFiles(ctx, ListFilesRequest{RootPath: root, MaxDepth: 1})
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), lfs.walks.Load())
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		root := t.TempDir()
+		writeTestFile(t, filepath.Join(root, "main.go"), 1)
+		lfs, err := NewLocalFileSystem(root, 0)
+		require.NoError(t, err)
+
+		for i := 0; i < 2; i++ {
+			_, err := lfs.ListFiles(ctx, ListFilesRequest{RootPath: root})
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int64(2), lfs.walks.Load())
+	})
+
+	_, err := NewLocalFileSystemWithConfig(LocalFileSystemConfig{Root: t.TempDir(), CacheTTL: -time.Second})
+	assert.Error(t, err)
+}
+
+func TestLocalFileSystemWriteFile(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	lfs, err := NewLocalFileSystem(root, 0)
+	require.NoError(t, err)
+
+	path := filepath.Join(root, "docs", "README.md")
+	require.NoError(t, lfs.WriteFile(ctx, path, []byte("# Docs")))
+
+	content, err := lfs.ReadFile(ctx, path)
+	require.NoError(t, err)
+	assert.Equal(t, "# Docs", string(content))
+
+	assert.Error(t, lfs.WriteFile(ctx, filepath.Join(root, "..", "escape.md"), nil))
+	assert.Error(t, lfs.WriteFile(ctx, "relative.md", nil))
+}
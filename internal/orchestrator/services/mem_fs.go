@@ -0,0 +1,234 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ FileSystemService = (*MemFileSystem)(nil)
+
+// MemFileSystem is a FileSystemService that keeps files in memory, for code
+// that is not on disk and for tests. It follows LocalFileSystem's rules:
+// every path must resolve inside the root, and directories exist implicitly
+// wherever a file is stored below them.
+type MemFileSystem struct {
+	root  string
+	mu    sync.RWMutex
+	files map[string]memFile
+}
+
+// memFile is a file stored in a MemFileSystem.
+type memFile struct {
+	content  []byte
+	modified time.Time
+}
+
+// NewMemFileSystem creates a file system rooted at root holding files, keyed
+// by path. Relative keys are resolved against root. The contents are copied.
+func NewMemFileSystem(root string, files map[string][]byte) (*MemFileSystem, error) {
+	if !filepath.IsAbs(root) {
+		return nil, fmt.Errorf("root %s must be absolute", root)
+	}
+
+	m := &MemFileSystem{root: filepath.Clean(root), files: make(map[string]memFile, len(files))}
+	now := time.Now()
+	for path, content := range files {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(m.root, path)
+		}
+		if err := validateInRoot(m.root, path); err != nil {
+			return nil, err
+		}
+		m.files[filepath.Clean(path)] = memFile{content: append([]byte(nil), content...), modified: now}
+	}
+	return m, nil
+}
+
+// MaxReadSize returns the largest file size ReadFile accepts.
+func (m *MemFileSystem) MaxReadSize() int64 {
+	return DefaultMaxReadSize
+}
+
+// ListFiles returns the files under req.RootPath whose names match
+// req.Patterns (all files when empty) and none of req.ExcludePatterns,
+// sorted by path. A MaxDepth of 0 does not limit the depth.
+func (m *MemFileSystem) ListFiles(ctx context.Context, req ListFilesRequest) ([]FileInfo, error) {
+	if err := m.ValidatePath(ctx, req.RootPath); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list files in %s: %w", req.RootPath, err)
+	}
+	root := filepath.Clean(req.RootPath)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// A file as the root lists just that file, as a walk would
+	if file, ok := m.files[root]; ok {
+		var files []FileInfo
+		if memListed(req, filepath.Base(root), ".") {
+			files = append(files, memFileInfo(root, file))
+		}
+		return files, nil
+	}
+	if !m.isDir(root) {
+		return nil, fmt.Errorf("failed to list files in %s: %w", req.RootPath, fs.ErrNotExist)
+	}
+
+	var files []FileInfo
+	for path, file := range m.files {
+		rel, ok := below(root, path)
+		if !ok {
+			continue
+		}
+		if dir := filepath.Dir(rel); dir != "." && tooDeep(dir, req.MaxDepth) {
+			continue
+		}
+		if memListed(req, filepath.Base(path), rel) {
+			files = append(files, memFileInfo(path, file))
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files, nil
+}
+
+// memListed reports whether ListFiles includes a file with the given name
+// and path relative to the listing root.
+func memListed(req ListFilesRequest, name, rel string) bool {
+	if len(req.Patterns) > 0 && !matchesAny(req.Patterns, name, rel) {
+		return false
+	}
+	return !matchesAny(req.ExcludePatterns, name, rel)
+}
+
+// ReadFile returns a copy of a file's contents, refusing files larger than
+// DefaultMaxReadSize with ErrFileTooLarge.
+func (m *MemFileSystem) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	info, err := m.GetFileInfo(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+	if info.Size > DefaultMaxReadSize {
+		return nil, fmt.Errorf("%w: %s is %d bytes, limit is %d", ErrFileTooLarge, path, info.Size, DefaultMaxReadSize)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	file, ok := m.files[filepath.Clean(path)]
+	if !ok {
+		return nil, fmt.Errorf("failed to open %s: %w", path, fs.ErrNotExist)
+	}
+	return append([]byte(nil), file.content...), nil
+}
+
+// OpenFile opens a file to be read in pieces, with no size limit. The
+// reader sees the file as it was when opened. Reads fail once ctx is done.
+func (m *MemFileSystem) OpenFile(ctx context.Context, path string) (io.ReadCloser, error) {
+	if err := m.ValidatePath(ctx, path); err != nil {
+		return nil, err
+	}
+	clean := filepath.Clean(path)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	file, ok := m.files[clean]
+	if !ok {
+		if m.isDir(clean) {
+			return nil, fmt.Errorf("%s is a directory", path)
+		}
+		return nil, fmt.Errorf("failed to open %s: %w", path, fs.ErrNotExist)
+	}
+	// WriteFile replaces contents rather than changing them, so the reader
+	// can share them
+	return &contextReader{ctx: ctx, r: bytes.NewReader(file.content)}, nil
+}
+
+// WriteFile stores a copy of content at path, replacing any existing file.
+func (m *MemFileSystem) WriteFile(ctx context.Context, path string, content []byte) error {
+	if err := m.ValidatePath(ctx, path); err != nil {
+		return err
+	}
+	path = filepath.Clean(path)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.isDir(path) {
+		return fmt.Errorf("failed to write %s: is a directory", path)
+	}
+	m.files[path] = memFile{content: append([]byte(nil), content...), modified: time.Now()}
+	return nil
+}
+
+// GetFileInfo returns metadata about a file or directory.
+func (m *MemFileSystem) GetFileInfo(ctx context.Context, path string) (*FileInfo, error) {
+	if err := m.ValidatePath(ctx, path); err != nil {
+		return nil, err
+	}
+	clean := filepath.Clean(path)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if file, ok := m.files[clean]; ok {
+		info := memFileInfo(path, file)
+		return &info, nil
+	}
+	if m.isDir(clean) {
+		return &FileInfo{Path: path, IsDir: true}, nil
+	}
+	return nil, fmt.Errorf("failed to stat %s: %w", path, fs.ErrNotExist)
+}
+
+// ValidatePath ensures path is absolute and inside the root directory.
+func (m *MemFileSystem) ValidatePath(ctx context.Context, path string) error {
+	return validateInRoot(m.root, path)
+}
+
+// isDir reports whether path is the root or has files below it. The caller
+// must hold m.mu.
+func (m *MemFileSystem) isDir(path string) bool {
+	if path == m.root {
+		return true
+	}
+	for file := range m.files {
+		if _, ok := below(path, file); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// below returns path relative to dir if path is inside it.
+func below(dir, path string) (string, bool) {
+	prefix := dir
+	if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+		prefix += string(filepath.Separator)
+	}
+	if !strings.HasPrefix(path, prefix) {
+		return "", false
+	}
+	return path[len(prefix):], true
+}
+
+// memFileInfo converts a stored file to a FileInfo.
+func memFileInfo(path string, file memFile) FileInfo {
+	return FileInfo{
+		Path:     path,
+		Size:     int64(len(file.content)),
+		Modified: file.modified.Unix(),
+	}
+}
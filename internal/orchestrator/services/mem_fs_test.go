@@ -0,0 +1,163 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemFileSystemListFilesMatchesLocal(t *testing.T) {
+	ctx := context.Background()
+	tree := []string{
+		"main.go",
+		"main_test.go",
+		"README.md",
+		"pkg/util.go",
+		"pkg/deep/inner.go",
+		"vendor/lib/lib.go",
+		"docs/guide/intro.md",
+	}
+
+	root := t.TempDir()
+	files := make(map[string][]byte)
+	for _, rel := range tree {
+		writeTestFile(t, filepath.Join(root, rel), 1)
+		files[rel] = []byte("x")
+	}
+
+	local, err := NewLocalFileSystem(root, 0)
+	require.NoError(t, err)
+	mem, err := NewMemFileSystem(root, files)
+	require.NoError(t, err)
+
+	requests := []ListFilesRequest{
+		{RootPath: root},
+		{RootPath: root, Patterns: []string{"*.go"}},
+		{RootPath: root, Patterns: []string{"*.go"}, ExcludePatterns: []string{"*_test.go", "vendor/*/*"}},
+		{RootPath: root, MaxDepth: 1},
+		{RootPath: root, MaxDepth: 2},
+		{RootPath: filepath.Join(root, "pkg")},
+		{RootPath: filepath.Join(root, "main.go")},
+	}
+	paths := func(files []FileInfo) []string {
+		var out []string
+		for _, f := range files {
+			out = append(out, f.Path)
+		}
+		return out
+	}
+
+	for _, req := range requests {
+		want, err := local.ListFiles(ctx, req)
+		require.NoError(t, err)
+		got, err := mem.ListFiles(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, paths(want), paths(got), "request %+v", req)
+	}
+
+	_, err = mem.ListFiles(ctx, ListFilesRequest{RootPath: filepath.Join(root, "missing")})
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	_, err = mem.ListFiles(ctx, ListFilesRequest{RootPath: "/elsewhere"})
+	assert.Error(t, err)
+}
+
+func TestMemFileSystem(t *testing.T) {
+	ctx := context.Background()
+	mem, err := NewMemFileSystem("/project", map[string][]byte{
+		"main.go":              []byte("package main"),
+		"/project/pkg/util.go": []byte("package pkg"),
+	})
+	require.NoError(t, err)
+
+	t.Run("read returns a copy", func(t *testing.T) {
+		content, err := mem.ReadFile(ctx, "/project/main.go")
+		require.NoError(t, err)
+		assert.Equal(t, "package main", string(content))
+
+		content[0] = 'X'
+		again, err := mem.ReadFile(ctx, "/project/main.go")
+		require.NoError(t, err)
+		assert.Equal(t, "package main", string(again))
+	})
+
+	t.Run("file info", func(t *testing.T) {
+		info, err := mem.GetFileInfo(ctx, "/project/pkg/util.go")
+		require.NoError(t, err)
+		assert.Equal(t, int64(len("package pkg")), info.Size)
+		assert.False(t, info.IsDir)
+		assert.NotZero(t, info.Modified)
+
+		info, err = mem.GetFileInfo(ctx, "/project/pkg")
+		require.NoError(t, err)
+		assert.True(t, info.IsDir)
+
+		_, err = mem.GetFileInfo(ctx, "/project/missing.go")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("write then read", func(t *testing.T) {
+		require.NoError(t, mem.WriteFile(ctx, "/project/gen/api.go", []byte("package gen")))
+		content, err := mem.ReadFile(ctx, "/project/gen/api.go")
+		require.NoError(t, err)
+		assert.Equal(t, "package gen", string(content))
+
+		assert.Error(t, mem.WriteFile(ctx, "/project/gen", []byte("x")), "directories cannot be overwritten")
+	})
+
+	t.Run("open streams a snapshot", func(t *testing.T) {
+		require.NoError(t, mem.WriteFile(ctx, "/project/big.go", bytes.Repeat([]byte("x"), 64)))
+		r, err := mem.OpenFile(ctx, "/project/big.go")
+		require.NoError(t, err)
+
+		head := make([]byte, 16)
+		n, err := r.Read(head)
+		require.NoError(t, err)
+		assert.Equal(t, 16, n)
+
+		// Writes after opening don't reach the reader
+		require.NoError(t, mem.WriteFile(ctx, "/project/big.go", []byte("package big")))
+		rest, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, bytes.Repeat([]byte("x"), 48), rest)
+
+		require.NoError(t, r.Close())
+		_, err = r.Read(head)
+		assert.ErrorIs(t, err, fs.ErrClosed)
+	})
+
+	t.Run("open fails for directories and missing files", func(t *testing.T) {
+		_, err := mem.OpenFile(ctx, "/project/pkg")
+		assert.EqualError(t, err, "/project/pkg is a directory")
+		_, err = mem.OpenFile(ctx, "/project/missing.go")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+		_, err = mem.OpenFile(ctx, "/etc/passwd")
+		assert.Error(t, err)
+	})
+
+	t.Run("reading a directory fails", func(t *testing.T) {
+		_, err := mem.ReadFile(ctx, "/project/pkg")
+		assert.EqualError(t, err, "/project/pkg is a directory")
+	})
+
+	t.Run("paths must stay inside the root", func(t *testing.T) {
+		assert.NoError(t, mem.ValidatePath(ctx, "/project/pkg/util.go"))
+		assert.Error(t, mem.ValidatePath(ctx, "/project/../etc/passwd"))
+		assert.Error(t, mem.ValidatePath(ctx, "relative.go"))
+		_, err := mem.ReadFile(ctx, "/etc/passwd")
+		assert.Error(t, err)
+		assert.Error(t, mem.WriteFile(ctx, "/etc/passwd", nil))
+	})
+
+	t.Run("constructor validation", func(t *testing.T) {
+		_, err := NewMemFileSystem("relative", nil)
+		assert.Error(t, err)
+		_, err = NewMemFileSystem("/project", map[string][]byte{"../escape.go": nil})
+		assert.Error(t, err)
+	})
+}
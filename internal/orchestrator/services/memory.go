@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// MemoryShard is one backend collection a memory search is spread across,
+// such as a single ChromaDB collection.
+type MemoryShard interface {
+	// Name identifies the shard in warnings and errors
+	Name() string
+
+	// Query returns the shard's memories matching query
+	Query(ctx context.Context, query string) ([]*Memory, error)
+}
+
+// SearchMemoryShards queries every shard and merges what they return,
+// dropping memories already found in an earlier shard. A failing shard is
+// reported as a warning and marks the result partial; an error is returned
+// only when every shard fails.
+func SearchMemoryShards(ctx context.Context, query string, shards []MemoryShard) (*MemorySearchResult, error) {
+	result := &MemorySearchResult{Memories: []*Memory{}}
+	seen := make(map[string]bool)
+	var errs []error
+
+	for _, shard := range shards {
+		memories, err := shard.Query(ctx, query)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("shard %s: %w", shard.Name(), err))
+			result.Warnings = append(result.Warnings, fmt.Sprintf("shard %s unavailable: %v", shard.Name(), err))
+			continue
+		}
+		for _, memory := range memories {
+			if memory == nil || seen[memory.ID] {
+				continue
+			}
+			seen[memory.ID] = true
+			result.Memories = append(result.Memories, memory)
+		}
+	}
+
+	if len(shards) > 0 && len(errs) == len(shards) {
+		return nil, fmt.Errorf("memory search failed: %w", errors.Join(errs...))
+	}
+	result.Partial = len(errs) > 0
+	return result, nil
+}
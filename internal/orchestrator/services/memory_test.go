@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubShard is a memory shard returning canned results.
+type stubShard struct {
+	name     string
+	memories []*Memory
+	err      error
+}
+
+func (s *stubShard) Name() string {
+	return s.name
+}
+
+func (s *stubShard) Query(ctx context.Context, query string) ([]*Memory, error) {
+	return s.memories, s.err
+}
+
+func TestSearchMemoryShards(t *testing.T) {
+	ctx := context.Background()
+	errDown := errors.New("connection refused")
+
+	t.Run("all shards succeed", func(t *testing.T) {
+		result, err := SearchMemoryShards(ctx, "parser", []MemoryShard{
+			&stubShard{name: "code", memories: []*Memory{{ID: "m1"}, {ID: "m2"}}},
+			&stubShard{name: "docs", memories: []*Memory{{ID: "m2"}, {ID: "m3"}}},
+		})
+		require.NoError(t, err)
+		assert.False(t, result.Partial)
+		assert.Empty(t, result.Warnings)
+
+		var ids []string
+		for _, memory := range result.Memories {
+			ids = append(ids, memory.ID)
+		}
+		assert.Equal(t, []string{"m1", "m2", "m3"}, ids)
+	})
+
+	t.Run("one shard fails", func(t *testing.T) {
+		result, err := SearchMemoryShards(ctx, "parser", []MemoryShard{
+			&stubShard{name: "code", memories: []*Memory{{ID: "m1"}}},
+			&stubShard{name: "docs", err: errDown},
+		})
+		require.NoError(t, err)
+		assert.True(t, result.Partial)
+		require.Len(t, result.Memories, 1)
+		assert.Equal(t, "m1", result.Memories[0].ID)
+		require.Len(t, result.Warnings, 1)
+		assert.Contains(t, result.Warnings[0], "docs")
+		assert.Contains(t, result.Warnings[0], "connection refused")
+	})
+
+	t.Run("every shard fails", func(t *testing.T) {
+		result, err := SearchMemoryShards(ctx, "parser", []MemoryShard{
+			&stubShard{name: "code", err: errDown},
+			&stubShard{name: "docs", err: errDown},
+		})
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, errDown)
+		assert.Contains(t, err.Error(), "shard code")
+		assert.Contains(t, err.Error(), "shard docs")
+	})
+
+	t.Run("no shards", func(t *testing.T) {
+		result, err := SearchMemoryShards(ctx, "parser", nil)
+		require.NoError(t, err)
+		assert.False(t, result.Partial)
+		assert.Empty(t, result.Memories)
+	})
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimitError is returned by AI services when the provider rejects a
+// request for exceeding its rate limit, typically with HTTP 429. Retrying
+// before RetryAfter has passed is likely to be rejected again.
+type RateLimitError struct {
+	// Provider names the AI service that was rate limited
+	Provider string
+
+	// RetryAfter is the delay the provider asked for, 0 if it gave none
+	RetryAfter time.Duration
+
+	// Err is the underlying error, if any
+	Err error
+}
+
+// NewRateLimitError builds a RateLimitError from a rate-limited HTTP
+// response, reading the delay from its Retry-After header.
+func NewRateLimitError(provider string, resp *http.Response) *RateLimitError {
+	return &RateLimitError{
+		Provider:   provider,
+		RetryAfter: ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		Err:        fmt.Errorf("unexpected status %s", resp.Status),
+	}
+}
+
+// Error implements the error interface.
+func (e *RateLimitError) Error() string {
+	msg := fmt.Sprintf("%s rate limited", e.Provider)
+	if e.RetryAfter > 0 {
+		msg += fmt.Sprintf(", retry after %s", e.RetryAfter)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *RateLimitError) Unwrap() error {
+	return e.Err
+}
+
+// ParseRetryAfter parses a Retry-After header value, given either as a
+// number of seconds or as an HTTP date relative to now. Empty, invalid and
+// past values return 0.
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds <= 0 || seconds > int64(time.Duration(1<<63-1)/time.Second) {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	at, err := http.ParseTime(value)
+	if err != nil || !at.After(now) {
+		return 0
+	}
+	return at.Sub(now)
+}
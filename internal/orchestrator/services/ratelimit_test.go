@@ -0,0 +1,49 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "seconds", value: "5", want: 5 * time.Second},
+		{name: "padded seconds", value: " 120 ", want: 2 * time.Minute},
+		{name: "http date", value: now.Add(30 * time.Second).Format(http.TimeFormat), want: 30 * time.Second},
+		{name: "past http date", value: now.Add(-time.Minute).Format(http.TimeFormat), want: 0},
+		{name: "empty", value: "", want: 0},
+		{name: "negative", value: "-3", want: 0},
+		{name: "garbage", value: "soon", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseRetryAfter(tt.value, now))
+		})
+	}
+}
+
+func TestNewRateLimitError(t *testing.T) {
+	resp := &http.Response{
+		Status:     "429 Too Many Requests",
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{"5"}},
+	}
+
+	err := NewRateLimitError("openai", resp)
+	assert.Equal(t, 5*time.Second, err.RetryAfter)
+	assert.EqualError(t, err, "openai rate limited, retry after 5s: unexpected status 429 Too Many Requests")
+
+	var rateLimited *RateLimitError
+	assert.True(t, errors.As(errors.Join(errors.New("other"), err), &rateLimited))
+}
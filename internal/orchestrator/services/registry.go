@@ -1,7 +1,10 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"sort"
 	"sync"
 )
 
@@ -34,6 +37,10 @@ type Registry interface {
 
 	// ListServices returns all registered service names
 	ListServices() []string
+
+	// Close closes every registered service that implements io.Closer,
+	// such as AI services releasing idle HTTP connections
+	Close() error
 }
 
 // RegistryImpl implements the Registry interface.
@@ -176,3 +183,41 @@ func (r *RegistryImpl) ListServices() []string {
 
 	return services
 }
+
+// Close closes every registered service that implements io.Closer, in a
+// fixed order: the MCP handler, the file system, the memory service, then
+// AI services by name. Every closer is called even when an earlier one
+// fails; the failures are returned together.
+func (r *RegistryImpl) Close() error {
+	r.mu.RLock()
+	type namedService struct {
+		name    string
+		service interface{}
+	}
+	all := []namedService{
+		{"mcp_handler", r.mcpHandler},
+		{"file_system", r.fileSystem},
+		{"memory_service", r.memoryService},
+	}
+	names := make([]string, 0, len(r.aiServices))
+	for name := range r.aiServices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		all = append(all, namedService{"ai_service:" + name, r.aiServices[name]})
+	}
+	r.mu.RUnlock()
+
+	var errs []error
+	for _, s := range all {
+		closer, ok := s.service.(io.Closer)
+		if !ok {
+			continue
+		}
+		if err := closer.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close %s: %w", s.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
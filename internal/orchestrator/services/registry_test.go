@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// closingFileSystem is a LocalFileSystem that records when it is closed.
+type closingFileSystem struct {
+	*LocalFileSystem
+	closed *[]string
+}
+
+func (f closingFileSystem) Close() error {
+	*f.closed = append(*f.closed, "file_system")
+	return nil
+}
+
+// closingAIService is an AI service that records when it is closed.
+type closingAIService struct {
+	name   string
+	err    error
+	closed *[]string
+}
+
+func (s closingAIService) AnalyzeFile(ctx context.Context, req FileAnalysisRequest) (*FileAnalysisResponse, error) {
+	return &FileAnalysisResponse{}, nil
+}
+
+func (s closingAIService) GenerateDocumentation(ctx context.Context, req DocumentationRequest) (*DocumentationResponse, error) {
+	return &DocumentationResponse{}, nil
+}
+
+func (s closingAIService) CountTokens(ctx context.Context, text string) (int, error) {
+	return len(text), nil
+}
+
+func (s closingAIService) Close() error {
+	*s.closed = append(*s.closed, s.name)
+	return s.err
+}
+
+func TestRegistryClose(t *testing.T) {
+	var closed []string
+	registry := NewRegistry()
+
+	lfs, err := NewLocalFileSystem(t.TempDir(), 0)
+	require.NoError(t, err)
+	require.NoError(t, registry.RegisterFileSystem(closingFileSystem{LocalFileSystem: lfs, closed: &closed}))
+	require.NoError(t, registry.RegisterAIService("openai", closingAIService{name: "openai", closed: &closed}))
+	require.NoError(t, registry.RegisterAIService("gemini", closingAIService{name: "gemini", err: errors.New("busy"), closed: &closed}))
+
+	err = registry.Close()
+	assert.EqualError(t, err, "failed to close ai_service:gemini: busy")
+
+	// Every closer runs, in a fixed order, despite the failure
+	assert.Equal(t, []string{"file_system", "gemini", "openai"}, closed)
+}
+
+func TestRegistryCloseWithoutClosers(t *testing.T) {
+	registry := NewRegistry()
+	lfs, err := NewLocalFileSystem(t.TempDir(), 0)
+	require.NoError(t, err)
+	require.NoError(t, registry.RegisterFileSystem(lfs))
+
+	assert.NoError(t, registry.Close())
+}
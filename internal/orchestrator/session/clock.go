@@ -0,0 +1,38 @@
+package session
+
+import "time"
+
+// Clock is the time source used by the session manager. Tests substitute
+// a fake to drive the expiry cycle without waiting on real time.
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+
+	// NewTicker returns a ticker that fires every d
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers periodic ticks, mirroring time.Ticker.
+type Ticker interface {
+	// C returns the channel on which ticks are delivered
+	C() <-chan time.Time
+
+	// Stop turns off the ticker
+	Stop()
+}
+
+// realClock is the Clock backed by the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+// realTicker adapts time.Ticker to the Ticker interface.
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time { return t.Ticker.C }
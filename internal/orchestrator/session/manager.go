@@ -4,9 +4,12 @@
 package session
 
 import (
+	"container/list"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,20 +18,30 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// ErrNotFound is returned when no session has the requested ID
+var ErrNotFound = errors.New("not found")
+
 // DefaultManager implements the Manager interface with PostgreSQL storage
 type DefaultManager struct {
 	db              *sql.DB
 	cache           *sessionCache
 	config          SessionConfig
-	expiryTicker    *time.Ticker
+	clock           Clock
+	newID           func() uuid.UUID
+	expiryTicker    Ticker
 	shutdownCh      chan struct{}
 	wg              sync.WaitGroup
 }
 
-// sessionCache provides thread-safe in-memory caching
+// sessionCache provides thread-safe in-memory caching with LRU eviction
 type sessionCache struct {
 	sessions map[uuid.UUID]*Session
 	mu       sync.RWMutex
+
+	// capacity bounds the number of cached sessions; 0 means unbounded
+	capacity int
+	order    *list.List
+	elements map[uuid.UUID]*list.Element
 }
 
 // NewManager creates a new session manager instance
@@ -44,10 +57,21 @@ func NewManager(db *sql.DB, config SessionConfig) *DefaultManager {
 		config.MaxSessions = 1000
 	}
 
+	clock := config.Clock
+	if clock == nil {
+		clock = realClock{}
+	}
+	newID := config.NewID
+	if newID == nil {
+		newID = uuid.New
+	}
+
 	m := &DefaultManager{
 		db:         db,
-		cache:      &sessionCache{sessions: make(map[uuid.UUID]*Session)},
+		cache:      &sessionCache{sessions: make(map[uuid.UUID]*Session), capacity: config.MaxSessions},
 		config:     config,
+		clock:      clock,
+		newID:      newID,
 		shutdownCh: make(chan struct{}),
 	}
 
@@ -58,10 +82,52 @@ func NewManager(db *sql.DB, config SessionConfig) *DefaultManager {
 }
 
 // Create creates a new documentation session
-func (m *DefaultManager) Create(workspaceID, moduleName string, filePaths []string) (*Session, error) {
-	session := &Session{
-		ID:          uuid.New(),
+func (m *DefaultManager) Create(workspaceID, projectPath, moduleName string, filePaths []string) (*Session, error) {
+	session := m.newSession(workspaceID, projectPath, moduleName, filePaths)
+
+	// Save to database
+	err := m.saveToDatabase(m.db, session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save session: %w", err)
+	}
+
+	m.created(session)
+	return session, nil
+}
+
+// CreateAtomic inserts a new session and runs init in the same transaction,
+// committing only if init succeeds. When init fails the insert is rolled
+// back and init's error is returned unwrapped.
+func (m *DefaultManager) CreateAtomic(workspaceID, projectPath, moduleName string, filePaths []string, init func(tx *sql.Tx, session *Session) error) (*Session, error) {
+	session := m.newSession(workspaceID, projectPath, moduleName, filePaths)
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// Rollback is a no-op once the transaction is committed
+	defer tx.Rollback()
+
+	if err := m.saveToDatabase(tx, session); err != nil {
+		return nil, fmt.Errorf("failed to save session: %w", err)
+	}
+	if err := init(tx, session); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit session: %w", err)
+	}
+
+	m.created(session)
+	return session, nil
+}
+
+// newSession builds a pending session with a fresh ID and expiry
+func (m *DefaultManager) newSession(workspaceID, projectPath, moduleName string, filePaths []string) *Session {
+	return &Session{
+		ID:          m.newID(),
 		WorkspaceID: workspaceID,
+		ProjectPath: projectPath,
 		ModuleName:  moduleName,
 		Status:      StatusPending,
 		FilePaths:   filePaths,
@@ -72,28 +138,23 @@ func (m *DefaultManager) Create(workspaceID, moduleName string, filePaths []stri
 		},
 		Notes:     []SessionNote{},
 		Version:   1,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		ExpiresAt: time.Now().Add(m.config.DefaultTTL),
-	}
-
-	// Save to database
-	err := m.saveToDatabase(session)
-	if err != nil {
-		return nil, fmt.Errorf("failed to save session: %w", err)
+		CreatedAt: m.clock.Now(),
+		UpdatedAt: m.clock.Now(),
+		ExpiresAt: m.clock.Now().Add(m.config.DefaultTTL),
 	}
+}
 
-	// Add to cache
+// created caches a newly persisted session and logs its creation
+func (m *DefaultManager) created(session *Session) {
 	m.cache.set(session)
 
 	log.Info().
 		Str("session_id", session.ID.String()).
-		Str("workspace_id", workspaceID).
-		Str("module_name", moduleName).
-		Int("file_count", len(filePaths)).
+		Str("workspace_id", session.WorkspaceID).
+		Str("project_path", session.ProjectPath).
+		Str("module_name", session.ModuleName).
+		Int("file_count", len(session.FilePaths)).
 		Msg("Session created")
-
-	return session, nil
 }
 
 // Get retrieves a session by ID
@@ -128,17 +189,30 @@ func (m *DefaultManager) Update(id uuid.UUID, updates SessionUpdate) error {
 	}
 	if updates.Progress != nil {
 		session.Progress = *updates.Progress
+		session.Progress.Percent = session.Progress.ComputePercent()
 	}
 	if updates.CurrentFile != nil {
 		session.Progress.CurrentFile = *updates.CurrentFile
 	}
 	if updates.Note != nil {
-		session.Notes = append(session.Notes, *updates.Note)
+		note := *updates.Note
+		if note.Severity == "" {
+			note.Severity = NoteSeverityInfo
+		}
+		if note.CreatedAt.IsZero() {
+			note.CreatedAt = m.clock.Now()
+		}
+		session.Notes = trimNotes(append(session.Notes, note), m.config.MaxNotes)
 	}
 
-	session.UpdatedAt = time.Now()
+	session.UpdatedAt = m.clock.Now()
 	session.Version++
 
+	// Keep actively processed sessions alive
+	if m.config.SlidingExpiry && (updates.Progress != nil || updates.CurrentFile != nil) && !session.Status.IsTerminal() {
+		session.ExpiresAt = m.slidingExpiry(session)
+	}
+
 	// Save to database with optimistic locking
 	err = m.updateInDatabase(session)
 	if err != nil {
@@ -151,6 +225,22 @@ func (m *DefaultManager) Update(id uuid.UUID, updates SessionUpdate) error {
 	return nil
 }
 
+// slidingExpiry returns the session's expiry extended to DefaultTTL from
+// now, capped at MaxLifetime after creation. An expiry already further out
+// is kept.
+func (m *DefaultManager) slidingExpiry(session *Session) time.Time {
+	expiresAt := m.clock.Now().Add(m.config.DefaultTTL)
+	if m.config.MaxLifetime > 0 {
+		if limit := session.CreatedAt.Add(m.config.MaxLifetime); expiresAt.After(limit) {
+			expiresAt = limit
+		}
+	}
+	if expiresAt.Before(session.ExpiresAt) {
+		return session.ExpiresAt
+	}
+	return expiresAt
+}
+
 // Delete removes a session
 func (m *DefaultManager) Delete(id uuid.UUID) error {
 	query := `DELETE FROM documentation_sessions WHERE id = $1`
@@ -172,9 +262,13 @@ func (m *DefaultManager) Delete(id uuid.UUID) error {
 
 // List returns sessions matching criteria
 func (m *DefaultManager) List(filter SessionFilter) ([]*Session, error) {
+	orderBy, err := orderClause(filter.OrderBy, filter.OrderDir)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
-		SELECT id, workspace_id, module_name, status, file_paths, 
-		       version, created_at, updated_at, expires_at, progress
+		SELECT `+sessionColumns+`
 		FROM documentation_sessions
 		WHERE 1=1
 	`
@@ -192,6 +286,11 @@ func (m *DefaultManager) List(filter SessionFilter) ([]*Session, error) {
 		query += fmt.Sprintf(" AND status = $%d", argCount)
 		args = append(args, *filter.Status)
 	}
+	if filter.ProjectPath != nil {
+		argCount++
+		query += fmt.Sprintf(" AND project_path = $%d", argCount)
+		args = append(args, *filter.ProjectPath)
+	}
 	if filter.ModuleName != nil {
 		argCount++
 		query += fmt.Sprintf(" AND module_name = $%d", argCount)
@@ -207,9 +306,20 @@ func (m *DefaultManager) List(filter SessionFilter) ([]*Session, error) {
 		query += fmt.Sprintf(" AND created_at < $%d", argCount)
 		args = append(args, *filter.CreatedBefore)
 	}
+	if filter.MinAge != nil {
+		argCount++
+		query += fmt.Sprintf(" AND created_at <= $%d", argCount)
+		args = append(args, m.clock.Now().Add(-*filter.MinAge))
+	}
+	if filter.MaxProgressPercent != nil {
+		// Rows written before percent was stored count as not started
+		argCount++
+		query += fmt.Sprintf(" AND COALESCE((progress->>'percent')::float8, 0) <= $%d", argCount)
+		args = append(args, *filter.MaxProgressPercent)
+	}
+
+	query += orderBy
 
-	query += " ORDER BY created_at DESC"
-	
 	if filter.Limit > 0 {
 		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
 	}
@@ -225,38 +335,115 @@ func (m *DefaultManager) List(filter SessionFilter) ([]*Session, error) {
 
 	sessions := []*Session{}
 	for rows.Next() {
-		session := &Session{}
-		var progressJSON []byte
-		
-		err := rows.Scan(
-			&session.ID,
-			&session.WorkspaceID,
-			&session.ModuleName,
-			&session.Status,
-			pq.Array(&session.FilePaths),
-			&session.Version,
-			&session.CreatedAt,
-			&session.UpdatedAt,
-			&session.ExpiresAt,
-			&progressJSON,
-		)
+		session, err := scanSession(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan session: %w", err)
+			return nil, err
 		}
+		sessions = append(sessions, session)
+	}
 
-		// Unmarshal progress
-		if len(progressJSON) > 0 {
-			if err := json.Unmarshal(progressJSON, &session.Progress); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal progress: %w", err)
-			}
+	if filter.PopulateCache {
+		// Insert in reverse so the first results are the most recently used
+		// and survive eviction when the list exceeds the cache capacity
+		for i := len(sessions) - 1; i >= 0; i-- {
+			m.cache.setIfNewer(sessions[i])
 		}
+	}
+
+	return sessions, nil
+}
+
+// orderColumns maps each sortable column to the SQL expression it sorts by.
+// Only these expressions are ever written into an ORDER BY clause.
+var orderColumns = map[SessionOrder]string{
+	OrderByCreatedAt: "created_at",
+	OrderByUpdatedAt: "updated_at",
+	OrderByStatus:    "status",
+	OrderByProgress:  "(progress->>'processed_files')::int",
+}
+
+// orderClause builds the ORDER BY clause for List from whitelisted columns
+// and directions, rejecting anything else. Ties are broken by creation time.
+func orderClause(orderBy SessionOrder, dir SortDirection) (string, error) {
+	if orderBy == "" {
+		orderBy = OrderByCreatedAt
+	}
+	column, ok := orderColumns[orderBy]
+	if !ok {
+		return "", fmt.Errorf("invalid order_by: %q", orderBy)
+	}
+
+	var direction string
+	switch dir {
+	case SortDesc, "":
+		direction = "DESC"
+	case SortAsc:
+		direction = "ASC"
+	default:
+		return "", fmt.Errorf("invalid order_dir: %q", dir)
+	}
+
+	clause := fmt.Sprintf(" ORDER BY %s %s", column, direction)
+	if orderBy != OrderByCreatedAt {
+		clause += ", created_at DESC"
+	}
+	return clause, nil
+}
+
+// Search returns the sessions of a workspace whose project path, module name
+// or a note's message or file path contain the query, ignoring case, most
+// recently updated first
+func (m *DefaultManager) Search(workspaceID, query string) ([]*Session, error) {
+	if len(query) > MaxSearchQueryLength {
+		return nil, fmt.Errorf("search query exceeds %d bytes", MaxSearchQueryLength)
+	}
+
+	sqlQuery := `
+		SELECT `+sessionColumns+`
+		FROM documentation_sessions
+		WHERE workspace_id = $1
+		  AND (project_path ILIKE $2 ESCAPE '\' OR module_name ILIKE $2 ESCAPE '\'
+		    OR EXISTS (
+		      SELECT 1 FROM jsonb_array_elements(notes) n
+		      WHERE n->>'message' ILIKE $2 ESCAPE '\' OR n->>'file_path' ILIKE $2 ESCAPE '\'
+		    ))
+		ORDER BY updated_at DESC
+	`
+
+	// The query matches literally; only the surrounding wildcards are patterns
+	rows, err := m.db.Query(sqlQuery, workspaceID, "%"+escapeLike(query)+"%")
+	if err != nil {
+		return nil, fmt.Errorf("failed to search sessions: %w", err)
+	}
+	defer rows.Close()
 
+	sessions := []*Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
 		sessions = append(sessions, session)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search sessions: %w", err)
+	}
 
 	return sessions, nil
 }
 
+// MaxSearchQueryLength bounds the length of a Search query in bytes
+const MaxSearchQueryLength = 256
+
+// likeEscaper escapes the characters LIKE and ILIKE treat specially
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike escapes a user-supplied term so that a LIKE pattern using
+// ESCAPE '\' matches it literally
+func escapeLike(term string) string {
+	return likeEscaper.Replace(term)
+}
+
 // ExpireSessions marks expired sessions
 func (m *DefaultManager) ExpireSessions() error {
 	query := `
@@ -267,8 +454,8 @@ func (m *DefaultManager) ExpireSessions() error {
 
 	result, err := m.db.Exec(query,
 		StatusExpired,
-		time.Now(),
-		time.Now(),
+		m.clock.Now(),
+		m.clock.Now(),
 		StatusPending,
 		StatusInProgress,
 	)
@@ -286,6 +473,85 @@ func (m *DefaultManager) ExpireSessions() error {
 	return nil
 }
 
+// PurgeTerminalSessions deletes terminal sessions last updated before the
+// retention period, in batches to keep lock times short. Related TODOs and
+// events are removed by the database cascade. It returns the number of
+// deleted sessions and does nothing when no retention is configured.
+func (m *DefaultManager) PurgeTerminalSessions() (int, error) {
+	if m.config.TerminalRetention <= 0 {
+		return 0, nil
+	}
+
+	batchSize := m.config.RetentionBatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultRetentionBatchSize
+	}
+	cutoff := m.clock.Now().Add(-m.config.TerminalRetention)
+
+	query := `
+		DELETE FROM documentation_sessions
+		WHERE id IN (
+			SELECT id FROM documentation_sessions
+			WHERE status IN ($1, $2, $3) AND updated_at < $4
+			ORDER BY updated_at
+			LIMIT $5
+		)
+		RETURNING id
+	`
+
+	total := 0
+	for {
+		deleted, err := m.purgeBatch(query, cutoff, batchSize)
+		if err != nil {
+			return total, err
+		}
+		total += deleted
+		if deleted < batchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		log.Info().
+			Int("count", total).
+			Dur("retention", m.config.TerminalRetention).
+			Msg("Purged terminal sessions")
+	}
+
+	return total, nil
+}
+
+// purgeBatch deletes one batch of terminal sessions and evicts them from
+// the cache
+func (m *DefaultManager) purgeBatch(query string, cutoff time.Time, batchSize int) (int, error) {
+	rows, err := m.db.Query(query,
+		StatusCompleted,
+		StatusFailed,
+		StatusExpired,
+		cutoff,
+		batchSize,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge terminal sessions: %w", err)
+	}
+	defer rows.Close()
+
+	deleted := 0
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return deleted, fmt.Errorf("failed to scan purged session: %w", err)
+		}
+		m.cache.delete(id)
+		deleted++
+	}
+	if err := rows.Err(); err != nil {
+		return deleted, fmt.Errorf("failed to purge terminal sessions: %w", err)
+	}
+
+	return deleted, nil
+}
+
 // Shutdown gracefully stops the manager
 func (m *DefaultManager) Shutdown() error {
 	close(m.shutdownCh)
@@ -293,23 +559,34 @@ func (m *DefaultManager) Shutdown() error {
 	return nil
 }
 
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
 // saveToDatabase persists session to PostgreSQL
-func (m *DefaultManager) saveToDatabase(session *Session) error {
+func (m *DefaultManager) saveToDatabase(db execer, session *Session) error {
 	progressJSON, err := json.Marshal(session.Progress)
 	if err != nil {
 		return fmt.Errorf("failed to marshal progress: %w", err)
 	}
 
+	notesJSON, err := marshalNotes(session.Notes)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		INSERT INTO documentation_sessions 
-		(id, workspace_id, module_name, status, file_paths, version, 
-		 created_at, updated_at, expires_at, progress)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		(id, workspace_id, project_path, module_name, status, file_paths, version, 
+		 created_at, updated_at, expires_at, progress, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 	`
 
-	_, err = m.db.Exec(query,
+	_, err = db.Exec(query,
 		session.ID,
 		session.WorkspaceID,
+		session.ProjectPath,
 		session.ModuleName,
 		session.Status,
 		pq.Array(session.FilePaths),
@@ -318,11 +595,65 @@ func (m *DefaultManager) saveToDatabase(session *Session) error {
 		session.UpdatedAt,
 		session.ExpiresAt,
 		progressJSON,
+		notesJSON,
 	)
 
 	return err
 }
 
+// trimNotes drops the oldest notes until at most limit remain, keeping error
+// notes in preference to less serious ones. The kept notes stay in order. A
+// limit of 0 or less keeps every note.
+func trimNotes(notes []SessionNote, limit int) []SessionNote {
+	if limit <= 0 || len(notes) <= limit {
+		return notes
+	}
+
+	errorCount := 0
+	for _, note := range notes {
+		if note.Severity == NoteSeverityError {
+			errorCount++
+		}
+	}
+
+	// Walk from the newest note, keeping errors first and filling the rest
+	// of the cap with the most recent other notes
+	keepErrors := min(errorCount, limit)
+	keepOthers := limit - keepErrors
+	keep := make([]bool, len(notes))
+	for i := len(notes) - 1; i >= 0; i-- {
+		if notes[i].Severity == NoteSeverityError {
+			if keepErrors > 0 {
+				keep[i] = true
+				keepErrors--
+			}
+		} else if keepOthers > 0 {
+			keep[i] = true
+			keepOthers--
+		}
+	}
+
+	trimmed := make([]SessionNote, 0, limit)
+	for i, note := range notes {
+		if keep[i] {
+			trimmed = append(trimmed, note)
+		}
+	}
+	return trimmed
+}
+
+// marshalNotes encodes session notes, storing nil as an empty array
+func marshalNotes(notes []SessionNote) ([]byte, error) {
+	if notes == nil {
+		notes = []SessionNote{}
+	}
+	notesJSON, err := json.Marshal(notes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal notes: %w", err)
+	}
+	return notesJSON, nil
+}
+
 // updateInDatabase updates session with optimistic locking
 func (m *DefaultManager) updateInDatabase(session *Session) error {
 	progressJSON, err := json.Marshal(session.Progress)
@@ -330,10 +661,15 @@ func (m *DefaultManager) updateInDatabase(session *Session) error {
 		return fmt.Errorf("failed to marshal progress: %w", err)
 	}
 
+	notesJSON, err := marshalNotes(session.Notes)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE documentation_sessions 
-		SET status = $1, updated_at = $2, version = $3, progress = $4
-		WHERE id = $5 AND version = $6
+		SET status = $1, updated_at = $2, version = $3, progress = $4, notes = $5, expires_at = $6
+		WHERE id = $7 AND version = $8
 	`
 
 	result, err := m.db.Exec(query,
@@ -341,6 +677,8 @@ func (m *DefaultManager) updateInDatabase(session *Session) error {
 		session.UpdatedAt,
 		session.Version,
 		progressJSON,
+		notesJSON,
+		session.ExpiresAt,
 		session.ID,
 		session.Version-1, // Check previous version
 	)
@@ -362,19 +700,41 @@ func (m *DefaultManager) updateInDatabase(session *Session) error {
 
 // loadFromDatabase retrieves a session from PostgreSQL
 func (m *DefaultManager) loadFromDatabase(id uuid.UUID) (*Session, error) {
-	session := &Session{Notes: []SessionNote{}}
-	var progressJSON []byte
-
 	query := `
-		SELECT id, workspace_id, module_name, status, file_paths, 
-		       version, created_at, updated_at, expires_at, progress
+		SELECT `+sessionColumns+`
 		FROM documentation_sessions
 		WHERE id = $1
 	`
 
-	err := m.db.QueryRow(query, id).Scan(
+	session, err := scanSession(m.db.QueryRow(query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("session %s %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+
+	return session, nil
+}
+
+// sessionColumns lists the columns read by scanSession, in order.
+const sessionColumns = `id, workspace_id, project_path, module_name, status, file_paths,
+		       version, created_at, updated_at, expires_at, progress, notes`
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanSession reads a session selected with sessionColumns.
+func scanSession(row rowScanner) (*Session, error) {
+	session := &Session{Notes: []SessionNote{}}
+	var progressJSON, notesJSON []byte
+
+	err := row.Scan(
 		&session.ID,
 		&session.WorkspaceID,
+		&session.ProjectPath,
 		&session.ModuleName,
 		&session.Status,
 		pq.Array(&session.FilePaths),
@@ -383,12 +743,10 @@ func (m *DefaultManager) loadFromDatabase(id uuid.UUID) (*Session, error) {
 		&session.UpdatedAt,
 		&session.ExpiresAt,
 		&progressJSON,
+		&notesJSON,
 	)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("session %s not found", id)
-	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to load session: %w", err)
+		return nil, fmt.Errorf("failed to scan session: %w", err)
 	}
 
 	// Unmarshal progress
@@ -398,41 +756,99 @@ func (m *DefaultManager) loadFromDatabase(id uuid.UUID) (*Session, error) {
 		}
 	}
 
+	// Unmarshal notes
+	if len(notesJSON) > 0 {
+		if err := json.Unmarshal(notesJSON, &session.Notes); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal notes: %w", err)
+		}
+	}
+
 	return session, nil
 }
 
 // Cache implementation
 func (c *sessionCache) get(id uuid.UUID) *Session {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.sessions[id]
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	session := c.sessions[id]
+	if session != nil {
+		c.touchLocked(id)
+	}
+	return session
 }
 
 func (c *sessionCache) set(session *Session) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.sessions[session.ID] = session
+	c.setLocked(session)
+}
+
+// setIfNewer caches a session unless the cache already holds the same or a
+// later version of it, which may carry unsaved references held by callers
+func (c *sessionCache) setIfNewer(session *Session) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached := c.sessions[session.ID]; cached != nil && cached.Version >= session.Version {
+		c.touchLocked(session.ID)
+		return
+	}
+	c.setLocked(session)
 }
 
 func (c *sessionCache) delete(id uuid.UUID) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	delete(c.sessions, id)
+	if element, ok := c.elements[id]; ok {
+		c.order.Remove(element)
+		delete(c.elements, id)
+	}
+}
+
+// setLocked stores a session and evicts the least recently used sessions
+// beyond capacity. The caller must hold c.mu.
+func (c *sessionCache) setLocked(session *Session) {
+	c.sessions[session.ID] = session
+	c.touchLocked(session.ID)
+
+	for c.capacity > 0 && len(c.sessions) > c.capacity {
+		oldest := c.order.Back()
+		id := oldest.Value.(uuid.UUID)
+		c.order.Remove(oldest)
+		delete(c.elements, id)
+		delete(c.sessions, id)
+	}
+}
+
+// touchLocked marks a session as most recently used. The caller must hold c.mu.
+func (c *sessionCache) touchLocked(id uuid.UUID) {
+	if c.order == nil {
+		c.order = list.New()
+		c.elements = make(map[uuid.UUID]*list.Element)
+	}
+	if element, ok := c.elements[id]; ok {
+		c.order.MoveToFront(element)
+		return
+	}
+	c.elements[id] = c.order.PushFront(id)
 }
 
 // startExpiryHandler runs periodic cleanup
 func (m *DefaultManager) startExpiryHandler() {
-	m.expiryTicker = time.NewTicker(m.config.CleanupInterval)
+	m.expiryTicker = m.clock.NewTicker(m.config.CleanupInterval)
 	m.wg.Add(1)
 
 	go func() {
 		defer m.wg.Done()
 		for {
 			select {
-			case <-m.expiryTicker.C:
+			case <-m.expiryTicker.C():
 				if err := m.ExpireSessions(); err != nil {
 					log.Error().Err(err).Msg("Failed to expire sessions")
 				}
+				if _, err := m.PurgeTerminalSessions(); err != nil {
+					log.Error().Err(err).Msg("Failed to purge terminal sessions")
+				}
 			case <-m.shutdownCh:
 				m.expiryTicker.Stop()
 				return
@@ -0,0 +1,296 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// setupSessionDB starts a PostgreSQL container with the sessions table.
+// The test is skipped when Docker is unavailable.
+func setupSessionDB(t *testing.T) *sql.DB {
+	t.Helper()
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+	ctx := context.Background()
+
+	container, err := postgres.Run(ctx,
+		"postgres:15-alpine",
+		postgres.WithDatabase("testdb"),
+		postgres.WithUsername("testuser"),
+		postgres.WithPassword("testpass"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if err := container.Terminate(ctx); err != nil {
+			t.Logf("failed to terminate postgres container: %v", err)
+		}
+	})
+
+	connStr, err := container.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+
+	db, err := sql.Open("postgres", connStr)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`
+		CREATE TABLE documentation_sessions (
+			id UUID PRIMARY KEY,
+			workspace_id VARCHAR(255) NOT NULL,
+			project_path TEXT NOT NULL DEFAULT '',
+			module_name VARCHAR(255) NOT NULL DEFAULT '',
+			status VARCHAR(50) NOT NULL,
+			file_paths TEXT[] NOT NULL DEFAULT '{}',
+			progress JSONB NOT NULL DEFAULT '{"total_files": 0, "processed_files": 0, "failed_files": []}'::jsonb,
+			notes JSONB NOT NULL DEFAULT '[]'::jsonb,
+			version INTEGER NOT NULL DEFAULT 1,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			expires_at TIMESTAMP WITH TIME ZONE
+		)
+	`)
+	require.NoError(t, err)
+
+	return db
+}
+
+func TestManager_SearchDatabase(t *testing.T) {
+	db := setupSessionDB(t)
+	manager := NewManager(db, SessionConfig{})
+	defer manager.Shutdown()
+
+	billing, err := manager.Create("workspace-1", "/repos/Billing-Service", "", nil)
+	require.NoError(t, err)
+	_, err = manager.Create("workspace-1", "/repos/auth", "", nil)
+	require.NoError(t, err)
+	noted, err := manager.Create("workspace-1", "/repos/gateway", "", nil)
+	require.NoError(t, err)
+	_, err = manager.Create("workspace-2", "/repos/billing-legacy", "", nil)
+	require.NoError(t, err)
+
+	// Make the noted session the most recently updated
+	require.NoError(t, manager.Update(noted.ID, SessionUpdate{
+		Note: &SessionNote{FilePath: "/repos/gateway/billing_client.go", MemoryID: "mem-1"},
+	}))
+
+	sessions, err := manager.Search("workspace-1", "BILLING")
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, noted.ID, sessions[0].ID) // matched by note, updated last
+	assert.Equal(t, billing.ID, sessions[1].ID)
+	assert.Len(t, sessions[0].Notes, 1)
+
+	sessions, err = manager.Search("workspace-1", "payments")
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+func TestManager_SearchNoteFieldsDatabase(t *testing.T) {
+	db := setupSessionDB(t)
+	manager := NewManager(db, SessionConfig{})
+	defer manager.Shutdown()
+
+	noted, err := manager.Create("workspace-1", "/repos/gateway", "", nil)
+	require.NoError(t, err)
+	require.NoError(t, manager.Update(noted.ID, SessionUpdate{
+		Note: &SessionNote{FilePath: "/repos/gateway/client.go", MemoryID: "mem-1", Message: "Retry budget exhausted"},
+	}))
+
+	// Messages and file paths of notes match
+	for _, query := range []string{"retry budget", "client.go"} {
+		sessions, err := manager.Search("workspace-1", query)
+		require.NoError(t, err)
+		require.Len(t, sessions, 1, query)
+		assert.Equal(t, noted.ID, sessions[0].ID)
+	}
+
+	// The JSON encoding of the notes does not
+	for _, query := range []string{"file_path", "memory_id", "created_at", `"status"`} {
+		sessions, err := manager.Search("workspace-1", query)
+		require.NoError(t, err)
+		assert.Empty(t, sessions, query)
+	}
+}
+
+func TestManager_SearchLiteralDatabase(t *testing.T) {
+	db := setupSessionDB(t)
+	manager := NewManager(db, SessionConfig{})
+	defer manager.Shutdown()
+
+	percent, err := manager.Create("workspace-1", "/repos/50%-off", "", nil)
+	require.NoError(t, err)
+	_, err = manager.Create("workspace-1", "/repos/500-off", "", nil)
+	require.NoError(t, err)
+	underscore, err := manager.Create("workspace-1", "/repos/a_b", "", nil)
+	require.NoError(t, err)
+	_, err = manager.Create("workspace-1", "/repos/axb", "", nil)
+	require.NoError(t, err)
+
+	sessions, err := manager.Search("workspace-1", "50%")
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, percent.ID, sessions[0].ID)
+
+	sessions, err = manager.Search("workspace-1", "a_b")
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, underscore.ID, sessions[0].ID)
+}
+
+func TestManager_ProjectPathRoundTripDatabase(t *testing.T) {
+	db := setupSessionDB(t)
+	manager := NewManager(db, SessionConfig{})
+	defer manager.Shutdown()
+
+	created, err := manager.Create("workspace-1", "/repos/platform", "billing", []string{"/repos/platform/billing/invoice.go"})
+	require.NoError(t, err)
+	whole, err := manager.Create("workspace-1", "/repos/platform", "", nil)
+	require.NoError(t, err)
+
+	// Bypass the cache so the fields are read back from the database
+	manager.cache.delete(created.ID)
+	loaded, err := manager.Get(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "/repos/platform", loaded.ProjectPath)
+	assert.Equal(t, "billing", loaded.ModuleName)
+
+	projectPath := "/repos/platform"
+	sessions, err := manager.List(SessionFilter{ProjectPath: &projectPath})
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	for _, sess := range sessions {
+		assert.Equal(t, "/repos/platform", sess.ProjectPath)
+		if sess.ID == whole.ID {
+			assert.Empty(t, sess.ModuleName)
+		}
+	}
+}
+
+func TestManager_PurgeTerminalSessionsDatabase(t *testing.T) {
+	db := setupSessionDB(t)
+	manager := NewManager(db, SessionConfig{
+		TerminalRetention:  24 * time.Hour,
+		RetentionBatchSize: 2,
+	})
+	defer manager.Shutdown()
+
+	now := time.Now()
+	old := now.Add(-48 * time.Hour)
+	insert := func(status SessionStatus, updatedAt time.Time) uuid.UUID {
+		id := uuid.New()
+		_, err := db.Exec(`
+			INSERT INTO documentation_sessions (id, workspace_id, status, created_at, updated_at, expires_at)
+			VALUES ($1, 'workspace-1', $2, $3, $3, $4)
+		`, id, status, updatedAt, now.Add(time.Hour))
+		require.NoError(t, err)
+		return id
+	}
+
+	expired := []uuid.UUID{
+		insert(StatusCompleted, old),
+		insert(StatusFailed, old),
+		insert(StatusExpired, old),
+	}
+	kept := []uuid.UUID{
+		insert(StatusCompleted, now),
+		insert(StatusFailed, now),
+		insert(StatusPending, old),
+		insert(StatusInProgress, old),
+	}
+
+	deleted, err := manager.PurgeTerminalSessions()
+	require.NoError(t, err)
+	assert.Equal(t, len(expired), deleted)
+
+	for _, id := range expired {
+		_, err := manager.Get(id)
+		assert.Error(t, err, "session %s should have been purged", id)
+	}
+	for _, id := range kept {
+		_, err := manager.Get(id)
+		assert.NoError(t, err, "session %s should have been kept", id)
+	}
+}
+
+func TestManager_ListStuckDatabase(t *testing.T) {
+	db := setupSessionDB(t)
+	manager := NewManager(db, SessionConfig{})
+	defer manager.Shutdown()
+
+	now := time.Now()
+	insert := func(status SessionStatus, createdAt time.Time, progress string) uuid.UUID {
+		id := uuid.New()
+		_, err := db.Exec(`
+			INSERT INTO documentation_sessions (id, workspace_id, status, progress, created_at, updated_at, expires_at)
+			VALUES ($1, 'workspace-1', $2, $3, $4, $4, $5)
+		`, id, status, progress, createdAt, now.Add(24*time.Hour))
+		require.NoError(t, err)
+		return id
+	}
+
+	old := now.Add(-6 * time.Hour)
+	stuck := []uuid.UUID{
+		insert(StatusInProgress, old, `{"total_files": 10, "processed_files": 1, "percent": 10}`),
+		insert(StatusInProgress, old, `{"total_files": 10, "processed_files": 2, "percent": 20}`),
+		// Progress stored before percent was tracked counts as not started
+		insert(StatusInProgress, old, `{"total_files": 10, "processed_files": 0}`),
+	}
+	insert(StatusInProgress, old, `{"total_files": 10, "processed_files": 8, "percent": 80}`)
+	insert(StatusInProgress, now, `{"total_files": 10, "processed_files": 1, "percent": 10}`)
+	insert(StatusPending, old, `{"total_files": 10, "processed_files": 0, "percent": 0}`)
+
+	status := StatusInProgress
+	minAge := time.Hour
+	maxPercent := 25.0
+	sessions, err := manager.List(SessionFilter{Status: &status, MinAge: &minAge, MaxProgressPercent: &maxPercent})
+	require.NoError(t, err)
+
+	var got []uuid.UUID
+	for _, s := range sessions {
+		got = append(got, s.ID)
+	}
+	assert.ElementsMatch(t, stuck, got)
+}
+
+func TestManager_CreateAtomicRollbackDatabase(t *testing.T) {
+	db := setupSessionDB(t)
+	manager := NewManager(db, SessionConfig{DefaultTTL: time.Hour})
+	defer manager.Shutdown()
+
+	var created uuid.UUID
+	initErr := errors.New("workflow unavailable")
+	_, err := manager.CreateAtomic("workspace-1", "/repos/app", "", []string{"/repos/app/main.go"}, func(tx *sql.Tx, session *Session) error {
+		created = session.ID
+		// The row is visible inside the transaction
+		var count int
+		require.NoError(t, tx.QueryRow(`SELECT COUNT(*) FROM documentation_sessions WHERE id = $1`, session.ID).Scan(&count))
+		assert.Equal(t, 1, count)
+		return initErr
+	})
+	require.ErrorIs(t, err, initErr)
+
+	// No orphaned row remains once init fails
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM documentation_sessions WHERE id = $1`, created).Scan(&count))
+	assert.Equal(t, 0, count)
+	_, err = manager.Get(created)
+	assert.Error(t, err)
+
+	committed, err := manager.CreateAtomic("workspace-1", "/repos/app", "", nil, func(*sql.Tx, *Session) error { return nil })
+	require.NoError(t, err)
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM documentation_sessions WHERE id = $1`, committed.ID).Scan(&count))
+	assert.Equal(t, 1, count)
+}
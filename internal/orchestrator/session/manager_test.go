@@ -2,7 +2,12 @@ package session
 
 import (
 	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -74,6 +79,7 @@ func TestManager_Create(t *testing.T) {
 	defer manager.Shutdown()
 
 	workspaceID := "workspace-123"
+	projectPath := "/path/to/project"
 	moduleName := "test-module"
 	filePaths := []string{"/path/to/file1.go", "/path/to/file2.go"}
 
@@ -82,6 +88,7 @@ func TestManager_Create(t *testing.T) {
 		WithArgs(
 			sqlmock.AnyArg(), // ID
 			workspaceID,
+			projectPath,
 			moduleName,
 			StatusPending,
 			pq.Array(filePaths),
@@ -90,13 +97,15 @@ func TestManager_Create(t *testing.T) {
 			sqlmock.AnyArg(), // updated_at
 			sqlmock.AnyArg(), // expires_at
 			sqlmock.AnyArg(), // progress JSON
+			[]byte("[]"),     // notes JSON
 		).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	session, err := manager.Create(workspaceID, moduleName, filePaths)
+	session, err := manager.Create(workspaceID, projectPath, moduleName, filePaths)
 	require.NoError(t, err)
 	assert.NotNil(t, session)
 	assert.Equal(t, workspaceID, session.WorkspaceID)
+	assert.Equal(t, projectPath, session.ProjectPath)
 	assert.Equal(t, moduleName, session.ModuleName)
 	assert.Equal(t, StatusPending, session.Status)
 	assert.Equal(t, filePaths, session.FilePaths)
@@ -114,6 +123,137 @@ func TestManager_Create(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestManager_CreateIDGenerator(t *testing.T) {
+	t.Run("injected generator produces the expected IDs", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		ids := []uuid.UUID{
+			uuid.MustParse("00000000-0000-0000-0000-000000000001"),
+			uuid.MustParse("00000000-0000-0000-0000-000000000002"),
+		}
+		next := 0
+		manager := NewManager(db, SessionConfig{NewID: func() uuid.UUID {
+			id := ids[next]
+			next++
+			return id
+		}})
+		defer manager.Shutdown()
+
+		for _, id := range ids {
+			mock.ExpectExec("INSERT INTO documentation_sessions").
+				WithArgs(id, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+					sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+		}
+
+		for _, want := range ids {
+			session, err := manager.Create("workspace-123", "/path/to/project", "", nil)
+			require.NoError(t, err)
+			assert.Equal(t, want, session.ID)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("default generator is random", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		manager := NewManager(db, SessionConfig{})
+		defer manager.Shutdown()
+
+		mock.ExpectExec("INSERT INTO documentation_sessions").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO documentation_sessions").WillReturnResult(sqlmock.NewResult(1, 1))
+
+		first, err := manager.Create("workspace-123", "/path/to/project", "", nil)
+		require.NoError(t, err)
+		second, err := manager.Create("workspace-123", "/path/to/project", "", nil)
+		require.NoError(t, err)
+
+		assert.NotEqual(t, uuid.Nil, first.ID)
+		assert.NotEqual(t, first.ID, second.ID)
+		assert.Equal(t, uuid.Version(4), first.ID.Version())
+	})
+}
+
+func TestManager_CreateAtomic(t *testing.T) {
+	filePaths := []string{"/path/to/file1.go"}
+	expectInsert := func(mock sqlmock.Sqlmock) *sqlmock.ExpectedExec {
+		return mock.ExpectExec("INSERT INTO documentation_sessions").
+			WithArgs(
+				sqlmock.AnyArg(), "workspace-123", "/path/to/project", "", StatusPending, pq.Array(filePaths), 1,
+				sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), []byte("[]"),
+			)
+	}
+
+	t.Run("commits when init succeeds", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		manager := NewManager(db, SessionConfig{DefaultTTL: time.Hour})
+		defer manager.Shutdown()
+
+		mock.ExpectBegin()
+		expectInsert(mock).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		var initID uuid.UUID
+		session, err := manager.CreateAtomic("workspace-123", "/path/to/project", "", filePaths, func(tx *sql.Tx, session *Session) error {
+			assert.NotNil(t, tx)
+			initID = session.ID
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, initID, session.ID)
+		assert.NotNil(t, manager.cache.get(session.ID))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("rolls back the insert when init fails", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		manager := NewManager(db, SessionConfig{DefaultTTL: time.Hour})
+		defer manager.Shutdown()
+
+		mock.ExpectBegin()
+		expectInsert(mock).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectRollback()
+
+		initErr := errors.New("workflow unavailable")
+		var initID uuid.UUID
+		session, err := manager.CreateAtomic("workspace-123", "/path/to/project", "", filePaths, func(tx *sql.Tx, session *Session) error {
+			initID = session.ID
+			return initErr
+		})
+		assert.Nil(t, session)
+		assert.Equal(t, initErr, err)
+		assert.Nil(t, manager.cache.get(initID))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("init is not run when the insert fails", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		manager := NewManager(db, SessionConfig{DefaultTTL: time.Hour})
+		defer manager.Shutdown()
+
+		mock.ExpectBegin()
+		expectInsert(mock).WillReturnError(errors.New("disk full"))
+		mock.ExpectRollback()
+
+		_, err = manager.CreateAtomic("workspace-123", "/path/to/project", "", filePaths, func(*sql.Tx, *Session) error {
+			t.Error("init ran after a failed insert")
+			return nil
+		})
+		assert.EqualError(t, err, "failed to save session: disk full")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 func TestManager_Get(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
@@ -162,11 +302,11 @@ func TestManager_Get(t *testing.T) {
 
 		// Setup mock query
 		rows := sqlmock.NewRows([]string{
-			"id", "workspace_id", "module_name", "status", "file_paths",
-			"version", "created_at", "updated_at", "expires_at", "progress",
+			"id", "workspace_id", "project_path", "module_name", "status", "file_paths",
+			"version", "created_at", "updated_at", "expires_at", "progress", "notes",
 		}).AddRow(
-			sessionID, workspaceID, moduleName, StatusPending, pq.Array(filePaths),
-			1, time.Now(), time.Now(), time.Now().Add(24*time.Hour), progressJSON,
+			sessionID, workspaceID, "/path/to/project", moduleName, StatusPending, pq.Array(filePaths),
+			1, time.Now(), time.Now(), time.Now().Add(24*time.Hour), progressJSON, []byte("[]"),
 		)
 
 		mock.ExpectQuery("SELECT .+ FROM documentation_sessions WHERE id =").
@@ -177,6 +317,8 @@ func TestManager_Get(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, sessionID, result.ID)
 		assert.Equal(t, workspaceID, result.WorkspaceID)
+		assert.Equal(t, "/path/to/project", result.ProjectPath)
+		assert.Equal(t, moduleName, result.ModuleName)
 
 		// Verify cached
 		cached := manager.cache.get(sessionID)
@@ -232,7 +374,10 @@ func TestManager_Update(t *testing.T) {
 		ProcessedFiles: 1,
 		CurrentFile:    "/path/to/file1.go",
 	}
-	progressJSON, _ := json.Marshal(newProgress)
+	// The manager stores the progress with its percentage filled in
+	persisted := newProgress
+	persisted.Percent = 100
+	progressJSON, _ := json.Marshal(persisted)
 
 	// Expect update query with optimistic locking
 	mock.ExpectExec("UPDATE documentation_sessions").
@@ -241,6 +386,8 @@ func TestManager_Update(t *testing.T) {
 			sqlmock.AnyArg(), // updated_at
 			2,                // new version
 			progressJSON,
+			[]byte("[]"), // notes
+			sqlmock.AnyArg(), // expires_at
 			sessionID,
 			1, // previous version
 		).
@@ -256,7 +403,7 @@ func TestManager_Update(t *testing.T) {
 	cached := manager.cache.get(sessionID)
 	assert.Equal(t, newStatus, cached.Status)
 	assert.Equal(t, 2, cached.Version)
-	assert.Equal(t, newProgress, cached.Progress)
+	assert.Equal(t, persisted, cached.Progress)
 }
 
 func TestManager_Delete(t *testing.T) {
@@ -312,11 +459,11 @@ func TestManager_List(t *testing.T) {
 	progressJSON, _ := json.Marshal(progress)
 
 	rows := sqlmock.NewRows([]string{
-		"id", "workspace_id", "module_name", "status", "file_paths",
-		"version", "created_at", "updated_at", "expires_at", "progress",
+		"id", "workspace_id", "project_path", "module_name", "status", "file_paths",
+		"version", "created_at", "updated_at", "expires_at", "progress", "notes",
 	}).AddRow(
-		sessionID, workspaceID, moduleName, status, pq.Array([]string{"/file1.go"}),
-		1, time.Now(), time.Now(), time.Now().Add(24*time.Hour), progressJSON,
+		sessionID, workspaceID, "/path/to/project", moduleName, status, pq.Array([]string{"/file1.go"}),
+		1, time.Now(), time.Now(), time.Now().Add(24*time.Hour), progressJSON, []byte("[]"),
 	)
 
 	// Expect query with filters
@@ -330,6 +477,549 @@ func TestManager_List(t *testing.T) {
 	assert.Equal(t, sessionID, sessions[0].ID)
 }
 
+func TestManager_ListStuck(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	manager := NewManager(db, SessionConfig{Clock: newFakeClock(now)})
+	defer manager.Shutdown()
+
+	status := StatusInProgress
+	minAge := 2 * time.Hour
+	maxPercent := 25.0
+
+	id := uuid.New()
+	mock.ExpectQuery(regexp.QuoteMeta(
+		"AND status = $1 AND created_at <= $2 AND COALESCE((progress->>'percent')::float8, 0) <= $3")).
+		WithArgs(status, now.Add(-minAge), maxPercent).
+		WillReturnRows(sessionRows(id))
+
+	sessions, err := manager.List(SessionFilter{Status: &status, MinAge: &minAge, MaxProgressPercent: &maxPercent})
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, id, sessions[0].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// sessionRows returns mock rows for sessions with the given IDs.
+func sessionRows(ids ...uuid.UUID) *sqlmock.Rows {
+	progressJSON, _ := json.Marshal(Progress{})
+	rows := sqlmock.NewRows([]string{
+		"id", "workspace_id", "project_path", "module_name", "status", "file_paths",
+		"version", "created_at", "updated_at", "expires_at", "progress", "notes",
+	})
+	for _, id := range ids {
+		rows.AddRow(
+			id, "workspace-123", "/path/to/project", "test-module", StatusPending, pq.Array([]string{}),
+			1, time.Now(), time.Now(), time.Now().Add(24*time.Hour), progressJSON, []byte("[]"),
+		)
+	}
+	return rows
+}
+
+func TestManager_ListOrdering(t *testing.T) {
+	tests := []struct {
+		name    string
+		orderBy SessionOrder
+		dir     SortDirection
+		want    string
+	}{
+		{"default", "", "", " ORDER BY created_at DESC"},
+		{"created_at ascending", OrderByCreatedAt, SortAsc, " ORDER BY created_at ASC"},
+		{"updated_at descending", OrderByUpdatedAt, SortDesc, " ORDER BY updated_at DESC, created_at DESC"},
+		{"updated_at default direction", OrderByUpdatedAt, "", " ORDER BY updated_at DESC, created_at DESC"},
+		{"status ascending", OrderByStatus, SortAsc, " ORDER BY status ASC, created_at DESC"},
+		{"progress descending", OrderByProgress, SortDesc, " ORDER BY (progress->>'processed_files')::int DESC, created_at DESC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			manager := NewManager(db, SessionConfig{})
+			defer manager.Shutdown()
+
+			id := uuid.New()
+			mock.ExpectQuery(regexp.QuoteMeta(tt.want + " LIMIT 5")).
+				WillReturnRows(sessionRows(id))
+
+			sessions, err := manager.List(SessionFilter{OrderBy: tt.orderBy, OrderDir: tt.dir, Limit: 5})
+			require.NoError(t, err)
+			require.Len(t, sessions, 1)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+
+	t.Run("invalid ordering is rejected before querying", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		manager := NewManager(db, SessionConfig{})
+		defer manager.Shutdown()
+
+		_, err = manager.List(SessionFilter{OrderBy: "id; DROP TABLE documentation_sessions"})
+		assert.EqualError(t, err, `invalid order_by: "id; DROP TABLE documentation_sessions"`)
+
+		_, err = manager.List(SessionFilter{OrderBy: OrderByStatus, OrderDir: "sideways"})
+		assert.EqualError(t, err, `invalid order_dir: "sideways"`)
+
+		// sqlmock fails any query that was not expected
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestManager_ListPopulatesCache(t *testing.T) {
+	t.Run("get after populating list is served from cache", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		manager := NewManager(db, SessionConfig{})
+		defer manager.Shutdown()
+
+		first, second := uuid.New(), uuid.New()
+		mock.ExpectQuery("SELECT .+ FROM documentation_sessions").
+			WillReturnRows(sessionRows(first, second))
+
+		sessions, err := manager.List(SessionFilter{PopulateCache: true})
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+
+		// No further query is expected; sqlmock fails unexpected ones
+		for _, id := range []uuid.UUID{first, second} {
+			got, err := manager.Get(id)
+			require.NoError(t, err)
+			assert.Equal(t, id, got.ID)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("plain list leaves the cache alone", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		manager := NewManager(db, SessionConfig{})
+		defer manager.Shutdown()
+
+		id := uuid.New()
+		mock.ExpectQuery("SELECT .+ FROM documentation_sessions").
+			WillReturnRows(sessionRows(id))
+		mock.ExpectQuery("SELECT .+ FROM documentation_sessions WHERE id = \\$1").
+			WithArgs(id).
+			WillReturnRows(sessionRows(id))
+
+		_, err = manager.List(SessionFilter{})
+		require.NoError(t, err)
+		assert.Nil(t, manager.cache.get(id))
+
+		_, err = manager.Get(id)
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("respects the cache capacity", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		manager := NewManager(db, SessionConfig{MaxSessions: 2})
+		defer manager.Shutdown()
+
+		ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+		mock.ExpectQuery("SELECT .+ FROM documentation_sessions").
+			WillReturnRows(sessionRows(ids...))
+
+		_, err = manager.List(SessionFilter{PopulateCache: true})
+		require.NoError(t, err)
+
+		// The leading results are kept, the tail is evicted
+		assert.NotNil(t, manager.cache.get(ids[0]))
+		assert.NotNil(t, manager.cache.get(ids[1]))
+		assert.Nil(t, manager.cache.get(ids[2]))
+	})
+
+	t.Run("newer cached version is kept", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		manager := NewManager(db, SessionConfig{})
+		defer manager.Shutdown()
+
+		id := uuid.New()
+		cached := &Session{ID: id, Status: StatusInProgress, Version: 3}
+		manager.cache.set(cached)
+
+		mock.ExpectQuery("SELECT .+ FROM documentation_sessions").
+			WillReturnRows(sessionRows(id))
+
+		_, err = manager.List(SessionFilter{PopulateCache: true})
+		require.NoError(t, err)
+		assert.Same(t, cached, manager.cache.get(id))
+	})
+}
+
+func TestManager_Search(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	manager := NewManager(db, SessionConfig{})
+	defer manager.Shutdown()
+
+	sessionID := uuid.New()
+	progressJSON, _ := json.Marshal(Progress{TotalFiles: 2})
+	notes := []SessionNote{{FilePath: "/src/billing/invoice.go", MemoryID: "mem-1", Status: "documented"}}
+	notesJSON, _ := json.Marshal(notes)
+
+	rows := sqlmock.NewRows([]string{
+		"id", "workspace_id", "project_path", "module_name", "status", "file_paths",
+		"version", "created_at", "updated_at", "expires_at", "progress", "notes",
+	}).AddRow(
+		sessionID, "workspace-123", "/src/billing", "", StatusInProgress, pq.Array([]string{}),
+		3, time.Now(), time.Now(), time.Now().Add(24*time.Hour), progressJSON, notesJSON,
+	)
+
+	mock.ExpectQuery(`SELECT .+ FROM documentation_sessions WHERE workspace_id = \$1 AND \(project_path ILIKE \$2 ESCAPE '\\' OR module_name ILIKE \$2 ESCAPE '\\' OR EXISTS \( SELECT 1 FROM jsonb_array_elements\(notes\) n WHERE n->>'message' ILIKE \$2 ESCAPE '\\' OR n->>'file_path' ILIKE \$2 ESCAPE '\\' \)\) ORDER BY updated_at DESC`).
+		WithArgs("workspace-123", "%Billing%").
+		WillReturnRows(rows)
+
+	sessions, err := manager.Search("workspace-123", "Billing")
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, sessionID, sessions[0].ID)
+	assert.Equal(t, notes, sessions[0].Notes)
+	assert.Equal(t, 2, sessions[0].Progress.TotalFiles)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	t.Run("query error", func(t *testing.T) {
+		mock.ExpectQuery("SELECT .+ FROM documentation_sessions").
+			WillReturnError(sql.ErrConnDone)
+
+		_, err := manager.Search("workspace-123", "billing")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to search sessions")
+	})
+
+	t.Run("wildcards in the query are escaped", func(t *testing.T) {
+		mock.ExpectQuery("SELECT .+ FROM documentation_sessions").
+			WithArgs("workspace-123", `%50\% off\_sale\\x%`).
+			WillReturnRows(sessionRows())
+
+		_, err := manager.Search("workspace-123", `50% off_sale\x`)
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("overlong query is rejected", func(t *testing.T) {
+		_, err := manager.Search("workspace-123", strings.Repeat("a", MaxSearchQueryLength+1))
+		assert.EqualError(t, err, "search query exceeds 256 bytes")
+	})
+}
+
+func TestEscapeLike(t *testing.T) {
+	tests := []struct {
+		term string
+		want string
+	}{
+		{"billing", "billing"},
+		{"50%", `50\%`},
+		{"file_name", `file\_name`},
+		{`C:\repo`, `C:\\repo`},
+		{`%_\`, `\%\_\\`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.term, func(t *testing.T) {
+			assert.Equal(t, tt.want, escapeLike(tt.term))
+		})
+	}
+}
+
+func TestManager_UpdatePersistsNotes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	manager := NewManager(db, SessionConfig{})
+	defer manager.Shutdown()
+
+	sessionID := uuid.New()
+	manager.cache.set(&Session{ID: sessionID, Status: StatusInProgress, Version: 1})
+
+	note := SessionNote{
+		FilePath:  "/src/main.go",
+		MemoryID:  "mem-42",
+		Status:    "documented",
+		Severity:  NoteSeverityInfo,
+		Source:    "memory",
+		CreatedAt: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+	notesJSON, _ := json.Marshal([]SessionNote{note})
+
+	mock.ExpectExec("UPDATE documentation_sessions").
+		WithArgs(
+			StatusInProgress,
+			sqlmock.AnyArg(), // updated_at
+			2,
+			sqlmock.AnyArg(), // progress
+			notesJSON,
+			sqlmock.AnyArg(), // expires_at
+			sessionID,
+			1,
+		).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = manager.Update(sessionID, SessionUpdate{Note: &note})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_UpdateNoteDefaults(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	manager := NewManager(db, SessionConfig{Clock: newFakeClock(now)})
+	defer manager.Shutdown()
+
+	sessionID := uuid.New()
+	manager.cache.set(&Session{ID: sessionID, Status: StatusInProgress, Version: 1})
+	mock.ExpectExec("UPDATE documentation_sessions").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	note := SessionNote{Source: "processor", Message: "file skipped"}
+	require.NoError(t, manager.Update(sessionID, SessionUpdate{Note: &note}))
+
+	notes := manager.cache.get(sessionID).Notes
+	require.Len(t, notes, 1)
+	assert.Equal(t, NoteSeverityInfo, notes[0].Severity)
+	assert.Equal(t, now, notes[0].CreatedAt)
+	assert.Equal(t, "processor", notes[0].Source)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_UpdateMaxNotes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	manager := NewManager(db, SessionConfig{MaxNotes: 4})
+	defer manager.Shutdown()
+
+	sessionID := uuid.New()
+	manager.cache.set(&Session{ID: sessionID, Status: StatusInProgress, Version: 1})
+
+	// Notes 1 and 3 are errors; the rest are info and warnings
+	for i := 0; i < 10; i++ {
+		note := SessionNote{Message: fmt.Sprintf("note %d", i), Severity: NoteSeverityInfo}
+		switch i {
+		case 1, 3:
+			note.Severity = NoteSeverityError
+		case 8:
+			note.Severity = NoteSeverityWarn
+		}
+		mock.ExpectExec("UPDATE documentation_sessions").WillReturnResult(sqlmock.NewResult(0, 1))
+		require.NoError(t, manager.Update(sessionID, SessionUpdate{Note: &note}))
+		assert.LessOrEqual(t, len(manager.cache.get(sessionID).Notes), 4)
+	}
+
+	var messages []string
+	for _, note := range manager.cache.get(sessionID).Notes {
+		messages = append(messages, note.Message)
+	}
+	assert.Equal(t, []string{"note 1", "note 3", "note 8", "note 9"}, messages)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTrimNotes(t *testing.T) {
+	notes := func(severities ...NoteSeverity) []SessionNote {
+		out := make([]SessionNote, len(severities))
+		for i, severity := range severities {
+			out[i] = SessionNote{Message: fmt.Sprintf("note %d", i), Severity: severity}
+		}
+		return out
+	}
+	messages := func(notes []SessionNote) []string {
+		out := []string{}
+		for _, note := range notes {
+			out = append(out, note.Message)
+		}
+		return out
+	}
+	info, warn, errs := NoteSeverityInfo, NoteSeverityWarn, NoteSeverityError
+
+	tests := []struct {
+		name  string
+		notes []SessionNote
+		limit int
+		want  []string
+	}{
+		{"unlimited", notes(info, info, info), 0, []string{"note 0", "note 1", "note 2"}},
+		{"under the cap", notes(info, info), 3, []string{"note 0", "note 1"}},
+		{"oldest dropped", notes(info, warn, info, info), 2, []string{"note 2", "note 3"}},
+		{"errors kept over newer notes", notes(errs, info, info, info), 2, []string{"note 0", "note 3"}},
+		{"only the newest errors fit", notes(errs, errs, info, errs), 2, []string{"note 1", "note 3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, messages(trimNotes(tt.notes, tt.limit)))
+		})
+	}
+}
+
+// percentArg matches a progress JSON argument with the given percentage.
+type percentArg float64
+
+func (p percentArg) Match(v driver.Value) bool {
+	raw, ok := v.([]byte)
+	if !ok {
+		return false
+	}
+	var progress Progress
+	return json.Unmarshal(raw, &progress) == nil && progress.Percent == float64(p)
+}
+
+func TestManager_UpdateProgressPercent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	manager := NewManager(db, SessionConfig{})
+	defer manager.Shutdown()
+
+	sessionID := uuid.New()
+	manager.cache.set(&Session{ID: sessionID, Status: StatusInProgress, Version: 1, Progress: Progress{TotalFiles: 4}})
+
+	for processed, want := range []float64{0, 25, 50, 75, 100} {
+		mock.ExpectExec("UPDATE documentation_sessions").
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), percentArg(want),
+				sqlmock.AnyArg(), sqlmock.AnyArg(), sessionID, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		progress := Progress{TotalFiles: 4, ProcessedFiles: processed}
+		require.NoError(t, manager.Update(sessionID, SessionUpdate{Progress: &progress}))
+		assert.Equal(t, want, manager.cache.get(sessionID).Progress.Percent)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	t.Run("empty session stays at zero", func(t *testing.T) {
+		emptyID := uuid.New()
+		manager.cache.set(&Session{ID: emptyID, Status: StatusInProgress, Version: 1})
+		mock.ExpectExec("UPDATE documentation_sessions").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		progress := Progress{}
+		require.NoError(t, manager.Update(emptyID, SessionUpdate{Progress: &progress}))
+		assert.Zero(t, manager.cache.get(emptyID).Progress.Percent)
+	})
+}
+
+func TestManager_SlidingExpiry(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	newManager := func(t *testing.T, sliding bool) (*DefaultManager, sqlmock.Sqlmock, *fakeClock, uuid.UUID) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		clock := newFakeClock(start)
+		manager := NewManager(db, SessionConfig{
+			DefaultTTL:      2 * time.Hour,
+			CleanupInterval: 24 * time.Hour,
+			SlidingExpiry:   sliding,
+			MaxLifetime:     5 * time.Hour,
+			Clock:           clock,
+		})
+		t.Cleanup(func() { manager.Shutdown() })
+
+		sessionID := uuid.New()
+		manager.cache.set(&Session{
+			ID:        sessionID,
+			Status:    StatusInProgress,
+			Version:   1,
+			CreatedAt: start,
+			ExpiresAt: start.Add(2 * time.Hour),
+		})
+		return manager, mock, clock, sessionID
+	}
+
+	expectUpdate := func(mock sqlmock.Sqlmock, sessionID uuid.UUID, version int, expiresAt time.Time) {
+		mock.ExpectExec("UPDATE documentation_sessions").
+			WithArgs(
+				sqlmock.AnyArg(), // status
+				sqlmock.AnyArg(), // updated_at
+				version+1,
+				sqlmock.AnyArg(), // progress
+				sqlmock.AnyArg(), // notes
+				expiresAt,
+				sessionID,
+				version,
+			).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	t.Run("progress pushes expiry forward up to the max lifetime", func(t *testing.T) {
+		manager, mock, clock, sessionID := newManager(t, true)
+
+		wantExpiry := []time.Time{
+			start.Add(3 * time.Hour),
+			start.Add(4 * time.Hour),
+			start.Add(5 * time.Hour),
+			start.Add(5 * time.Hour), // capped
+		}
+		for i, want := range wantExpiry {
+			clock.Advance(time.Hour)
+			expectUpdate(mock, sessionID, i+1, want)
+
+			progress := Progress{ProcessedFiles: i + 1}
+			require.NoError(t, manager.Update(sessionID, SessionUpdate{Progress: &progress}))
+			assert.Equal(t, want, manager.cache.get(sessionID).ExpiresAt)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("updates without progress keep the expiry", func(t *testing.T) {
+		manager, mock, clock, sessionID := newManager(t, true)
+
+		clock.Advance(time.Hour)
+		expectUpdate(mock, sessionID, 1, start.Add(2*time.Hour))
+
+		note := SessionNote{FilePath: "/src/main.go"}
+		require.NoError(t, manager.Update(sessionID, SessionUpdate{Note: &note}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("terminal sessions are not extended", func(t *testing.T) {
+		manager, mock, clock, sessionID := newManager(t, true)
+
+		clock.Advance(time.Hour)
+		expectUpdate(mock, sessionID, 1, start.Add(2*time.Hour))
+
+		status := StatusCompleted
+		progress := Progress{ProcessedFiles: 1}
+		require.NoError(t, manager.Update(sessionID, SessionUpdate{Status: &status, Progress: &progress}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		manager, mock, clock, sessionID := newManager(t, false)
+
+		clock.Advance(time.Hour)
+		expectUpdate(mock, sessionID, 1, start.Add(2*time.Hour))
+
+		progress := Progress{ProcessedFiles: 1}
+		require.NoError(t, manager.Update(sessionID, SessionUpdate{Progress: &progress}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 func TestManager_ExpireSessions(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
@@ -377,6 +1067,8 @@ func TestManager_ConcurrentAccess(t *testing.T) {
 				sqlmock.AnyArg(), // updated_at
 				sqlmock.AnyArg(), // version
 				sqlmock.AnyArg(), // progress
+				sqlmock.AnyArg(), // notes
+				sqlmock.AnyArg(), // expires_at
 				sessionID,
 				sqlmock.AnyArg(), // previous version
 			).
@@ -437,6 +1129,8 @@ func TestManager_OptimisticLocking(t *testing.T) {
 			sqlmock.AnyArg(), // updated_at
 			2,                // new version
 			sqlmock.AnyArg(), // progress
+			sqlmock.AnyArg(), // notes
+			sqlmock.AnyArg(), // expires_at
 			sessionID,
 			1, // previous version
 		).
@@ -500,33 +1194,106 @@ func TestSessionCache(t *testing.T) {
 	wg.Wait()
 }
 
+func TestSessionCache_LRUEviction(t *testing.T) {
+	cache := &sessionCache{
+		sessions: make(map[uuid.UUID]*Session),
+		capacity: 2,
+	}
+
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	cache.set(&Session{ID: a})
+	cache.set(&Session{ID: b})
+
+	// Reading a makes b the least recently used
+	assert.NotNil(t, cache.get(a))
+	cache.set(&Session{ID: c})
+
+	assert.NotNil(t, cache.get(a))
+	assert.Nil(t, cache.get(b))
+	assert.NotNil(t, cache.get(c))
+	assert.Len(t, cache.sessions, 2)
+
+	cache.delete(a)
+	assert.Len(t, cache.sessions, 1)
+	assert.Len(t, cache.elements, 1)
+}
+
 func TestManager_ExpiryHandler(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
 	defer db.Close()
 
-	// Create manager with short cleanup interval
+	clock := newFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
 	manager := NewManager(db, SessionConfig{
-		CleanupInterval: 100 * time.Millisecond,
+		CleanupInterval: time.Minute,
+		Clock:           clock,
 	})
 
-	// Expect at least one expiry call
+	// Nothing runs before the interval elapses
+	clock.Advance(59 * time.Second)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
 	mock.ExpectExec("UPDATE documentation_sessions").
 		WithArgs(
 			StatusExpired,
-			sqlmock.AnyArg(),
-			sqlmock.AnyArg(),
+			clock.Now().Add(time.Second),
+			clock.Now().Add(time.Second),
 			StatusPending,
 			StatusInProgress,
 		).
 		WillReturnResult(sqlmock.NewResult(0, 0))
 
-	// Wait for expiry handler to run
-	time.Sleep(150 * time.Millisecond)
+	clock.Advance(time.Second)
 
-	// Shutdown and verify
+	// Shutdown waits for the in-flight expiry cycle to finish
 	err = manager.Shutdown()
 	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_PurgeTerminalSessions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	manager := NewManager(db, SessionConfig{
+		TerminalRetention:  time.Hour,
+		RetentionBatchSize: 2,
+	})
+	defer manager.Shutdown()
+
+	first, second, third := uuid.New(), uuid.New(), uuid.New()
+	manager.cache.set(&Session{ID: first})
+	manager.cache.set(&Session{ID: third})
+
+	// A full batch triggers another round; a short one ends the purge
+	mock.ExpectQuery("DELETE FROM documentation_sessions").
+		WithArgs(StatusCompleted, StatusFailed, StatusExpired, sqlmock.AnyArg(), 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(first).AddRow(second))
+	mock.ExpectQuery("DELETE FROM documentation_sessions").
+		WithArgs(StatusCompleted, StatusFailed, StatusExpired, sqlmock.AnyArg(), 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(third))
+
+	deleted, err := manager.PurgeTerminalSessions()
+	require.NoError(t, err)
+	assert.Equal(t, 3, deleted)
+	assert.Nil(t, manager.cache.get(first))
+	assert.Nil(t, manager.cache.get(third))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_PurgeTerminalSessionsDisabled(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	manager := NewManager(db, SessionConfig{})
+	defer manager.Shutdown()
+
+	deleted, err := manager.PurgeTerminalSessions()
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
 // BenchmarkSessionCache tests cache performance
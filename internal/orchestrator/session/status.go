@@ -0,0 +1,75 @@
+package session
+
+import (
+	"fmt"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/workflow"
+)
+
+// statusWorkflowStates maps each session status to the workflow state it
+// represents. The workflow engine has no expired state, so expired sessions
+// map to failed here; the orchestrator reports them as expired.
+var statusWorkflowStates = map[SessionStatus]workflow.WorkflowState{
+	StatusPending:    workflow.WorkflowStateIdle,
+	StatusInProgress: workflow.WorkflowStateProcessing,
+	StatusCompleted:  workflow.WorkflowStateComplete,
+	StatusFailed:     workflow.WorkflowStateFailed,
+	StatusExpired:    workflow.WorkflowStateFailed,
+}
+
+// workflowStateStatuses maps each workflow state to the status persisted
+// for it. States without a status of their own use the closest match.
+var workflowStateStatuses = map[workflow.WorkflowState]SessionStatus{
+	workflow.WorkflowStateIdle:        StatusPending,
+	workflow.WorkflowStateInitialized: StatusPending,
+	workflow.WorkflowStateProcessing:  StatusInProgress,
+	workflow.WorkflowStatePaused:      StatusInProgress,
+	workflow.WorkflowStateComplete:    StatusCompleted,
+	workflow.WorkflowStateCompleted:   StatusCompleted,
+	workflow.WorkflowStateFailed:      StatusFailed,
+	workflow.WorkflowStateCancelled:   StatusFailed,
+}
+
+// Valid reports whether s is a known session status.
+func (s SessionStatus) Valid() bool {
+	_, ok := statusWorkflowStates[s]
+	return ok
+}
+
+// IsTerminal reports whether s is a final status that no longer changes.
+func (s SessionStatus) IsTerminal() bool {
+	switch s {
+	case StatusCompleted, StatusFailed, StatusExpired:
+		return true
+	}
+	return false
+}
+
+// ParseStatus converts a string to a SessionStatus, rejecting unknown values.
+func ParseStatus(value string) (SessionStatus, error) {
+	status := SessionStatus(value)
+	if !status.Valid() {
+		return "", fmt.Errorf("unknown session status: %q", value)
+	}
+	return status, nil
+}
+
+// StatusToWorkflowState returns the workflow state a session status
+// represents.
+func StatusToWorkflowState(status SessionStatus) (workflow.WorkflowState, error) {
+	state, ok := statusWorkflowStates[status]
+	if !ok {
+		return "", fmt.Errorf("unknown session status: %q", status)
+	}
+	return state, nil
+}
+
+// WorkflowStateToStatus returns the session status persisted for a
+// workflow state.
+func WorkflowStateToStatus(state workflow.WorkflowState) (SessionStatus, error) {
+	status, ok := workflowStateStatuses[state]
+	if !ok {
+		return "", fmt.Errorf("unknown workflow state: %q", state)
+	}
+	return status, nil
+}
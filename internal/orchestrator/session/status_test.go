@@ -0,0 +1,93 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionStatusMapping(t *testing.T) {
+	tests := []struct {
+		status SessionStatus
+		state  workflow.WorkflowState
+		// roundTrip is false when several statuses share a workflow state
+		roundTrip bool
+	}{
+		{status: StatusPending, state: workflow.WorkflowStateIdle, roundTrip: true},
+		{status: StatusInProgress, state: workflow.WorkflowStateProcessing, roundTrip: true},
+		{status: StatusCompleted, state: workflow.WorkflowStateComplete, roundTrip: true},
+		{status: StatusFailed, state: workflow.WorkflowStateFailed, roundTrip: true},
+		{status: StatusExpired, state: workflow.WorkflowStateFailed, roundTrip: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			assert.True(t, tt.status.Valid())
+
+			parsed, err := ParseStatus(string(tt.status))
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, parsed)
+
+			state, err := StatusToWorkflowState(tt.status)
+			require.NoError(t, err)
+			assert.Equal(t, tt.state, state)
+
+			back, err := WorkflowStateToStatus(state)
+			require.NoError(t, err)
+			if tt.roundTrip {
+				assert.Equal(t, tt.status, back)
+			}
+		})
+	}
+}
+
+func TestWorkflowStateToStatus(t *testing.T) {
+	tests := []struct {
+		state workflow.WorkflowState
+		want  SessionStatus
+	}{
+		{state: workflow.WorkflowStateIdle, want: StatusPending},
+		{state: workflow.WorkflowStateInitialized, want: StatusPending},
+		{state: workflow.WorkflowStateProcessing, want: StatusInProgress},
+		{state: workflow.WorkflowStatePaused, want: StatusInProgress},
+		{state: workflow.WorkflowStateComplete, want: StatusCompleted},
+		{state: workflow.WorkflowStateCompleted, want: StatusCompleted},
+		{state: workflow.WorkflowStateFailed, want: StatusFailed},
+		{state: workflow.WorkflowStateCancelled, want: StatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.state), func(t *testing.T) {
+			status, err := WorkflowStateToStatus(tt.state)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, status)
+		})
+	}
+}
+
+func TestSessionStatusRejectsUnknownValues(t *testing.T) {
+	for _, value := range []string{"", "running", "PENDING", "complete"} {
+		t.Run(value, func(t *testing.T) {
+			assert.False(t, SessionStatus(value).Valid())
+
+			_, err := ParseStatus(value)
+			assert.Error(t, err)
+
+			_, err = StatusToWorkflowState(SessionStatus(value))
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := WorkflowStateToStatus("sleeping")
+	assert.Error(t, err)
+}
+
+func TestSessionStatusIsTerminal(t *testing.T) {
+	assert.False(t, StatusPending.IsTerminal())
+	assert.False(t, StatusInProgress.IsTerminal())
+	assert.True(t, StatusCompleted.IsTerminal())
+	assert.True(t, StatusFailed.IsTerminal())
+	assert.True(t, StatusExpired.IsTerminal())
+}
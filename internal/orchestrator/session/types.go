@@ -1,6 +1,7 @@
 package session
 
 import (
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,6 +22,7 @@ const (
 type Session struct {
 	ID          uuid.UUID     `json:"id" db:"id"`
 	WorkspaceID string        `json:"workspace_id" db:"workspace_id"`
+	ProjectPath string        `json:"project_path" db:"project_path"`
 	ModuleName  string        `json:"module_name" db:"module_name"`
 	Status      SessionStatus `json:"status" db:"status"`
 	FilePaths   []string      `json:"file_paths" db:"file_paths"`
@@ -37,26 +39,88 @@ func (s *Session) GetID() string {
 	return s.ID.String()
 }
 
-// Progress tracks session progress
+// Progress tracks session progress. CurrentFile holds a single file, so it is
+// only reliable while the session processes one file at a time
 type Progress struct {
 	TotalFiles     int      `json:"total_files"`
 	ProcessedFiles int      `json:"processed_files"`
 	CurrentFile    string   `json:"current_file"`
 	FailedFiles    []string `json:"failed_files"`
+	SkippedFiles   []string `json:"skipped_files,omitempty"`
+
+	// Percent is the share of files finished, whether processed, failed or
+	// skipped, from 0 to 100; the manager keeps it current
+	Percent float64 `json:"percent"`
+}
+
+// ComputePercent returns the share of files finished, from 0 to 100. A
+// session without files is at 0.
+func (p Progress) ComputePercent() float64 {
+	return Percent(p.ProcessedFiles+len(p.FailedFiles)+len(p.SkippedFiles), p.TotalFiles)
 }
 
-// SessionNote links a file to its documentation memory
+// Percent returns finished as a share of total, from 0 to 100, or 0 when
+// there are no files. Every progress percentage is computed with it so
+// they agree.
+func Percent(finished, total int) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return min(float64(finished)*100/float64(total), 100)
+}
+
+// SessionNote records an event in a session's history, such as a file
+// failing or being skipped, or links a file to its documentation memory
 type SessionNote struct {
-	FilePath  string    `json:"file_path"`
-	MemoryID  string    `json:"memory_id"`
-	Status    string    `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
+	FilePath  string       `json:"file_path"`
+	MemoryID  string       `json:"memory_id"`
+	Status    string       `json:"status"`
+	Severity  NoteSeverity `json:"severity,omitempty"`
+	Source    string       `json:"source,omitempty"`
+	Message   string       `json:"message,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// NoteSeverity grades how serious a session note is
+type NoteSeverity string
+
+const (
+	NoteSeverityInfo  NoteSeverity = "info"
+	NoteSeverityWarn  NoteSeverity = "warn"
+	NoteSeverityError NoteSeverity = "error"
+)
+
+// noteSeverityRanks orders severities from least to most serious
+var noteSeverityRanks = map[NoteSeverity]int{
+	NoteSeverityInfo:  1,
+	NoteSeverityWarn:  2,
+	NoteSeverityError: 3,
+}
+
+// IsValid reports whether the severity is one of the known severities
+func (s NoteSeverity) IsValid() bool {
+	_, ok := noteSeverityRanks[s]
+	return ok
+}
+
+// AtLeast reports whether the severity is at least as serious as min. Notes
+// without a severity count as info.
+func (s NoteSeverity) AtLeast(min NoteSeverity) bool {
+	if s == "" {
+		s = NoteSeverityInfo
+	}
+	return noteSeverityRanks[s] >= noteSeverityRanks[min]
 }
 
 // Manager defines the session management interface
 type Manager interface {
-	// Create creates a new session
-	Create(workspaceID, moduleName string, filePaths []string) (*Session, error)
+	// Create creates a new session documenting the project at projectPath,
+	// optionally restricted to a single module
+	Create(workspaceID, projectPath, moduleName string, filePaths []string) (*Session, error)
+
+	// CreateAtomic creates a session like Create, running init in the same
+	// database transaction. The session is only committed if init succeeds
+	CreateAtomic(workspaceID, projectPath, moduleName string, filePaths []string, init func(tx *sql.Tx, session *Session) error) (*Session, error)
 
 	// Get retrieves a session by ID
 	Get(id uuid.UUID) (*Session, error)
@@ -70,6 +134,10 @@ type Manager interface {
 	// List returns sessions matching criteria
 	List(filter SessionFilter) ([]*Session, error)
 
+	// Search returns a workspace's sessions whose project path, module name
+	// or notes contain the query, most recently updated first
+	Search(workspaceID, query string) ([]*Session, error)
+
 	// ExpireSessions marks expired sessions
 	ExpireSessions() error
 
@@ -89,20 +157,99 @@ type SessionUpdate struct {
 type SessionFilter struct {
 	WorkspaceID *string        `json:"workspace_id,omitempty"`
 	Status      *SessionStatus `json:"status,omitempty"`
+	ProjectPath *string        `json:"project_path,omitempty"`
 	ModuleName  *string        `json:"module_name,omitempty"`
 	CreatedAfter *time.Time    `json:"created_after,omitempty"`
 	CreatedBefore *time.Time   `json:"created_before,omitempty"`
 	Limit       int            `json:"limit,omitempty"`
 	Offset      int            `json:"offset,omitempty"`
+
+	// MinAge keeps sessions created at least this long ago
+	MinAge *time.Duration `json:"min_age,omitempty"`
+
+	// MaxProgressPercent keeps sessions at most this percent complete;
+	// combined with MinAge and an in_progress status it finds stuck sessions
+	MaxProgressPercent *float64 `json:"max_progress_percent,omitempty"`
+
+	// PopulateCache stores the listed sessions in the session cache so
+	// follow-up Get calls avoid the database. Leave unset for large lists
+	// to avoid evicting hot sessions.
+	PopulateCache bool `json:"populate_cache,omitempty"`
+
+	// OrderBy selects the column results are sorted by; empty sorts by
+	// creation time
+	OrderBy SessionOrder `json:"order_by,omitempty"`
+
+	// OrderDir is the sort direction; empty sorts descending
+	OrderDir SortDirection `json:"order_dir,omitempty"`
 }
 
+// SessionOrder is a column List can sort sessions by
+type SessionOrder string
+
+const (
+	// OrderByCreatedAt sorts by creation time
+	OrderByCreatedAt SessionOrder = "created_at"
+
+	// OrderByUpdatedAt sorts by last update time
+	OrderByUpdatedAt SessionOrder = "updated_at"
+
+	// OrderByStatus sorts by status name
+	OrderByStatus SessionOrder = "status"
+
+	// OrderByProgress sorts by the number of processed files
+	OrderByProgress SessionOrder = "progress"
+)
+
+// SortDirection is the direction List sorts sessions in
+type SortDirection string
+
+const (
+	// SortAsc sorts smallest first
+	SortAsc SortDirection = "asc"
+
+	// SortDesc sorts largest first
+	SortDesc SortDirection = "desc"
+)
+
 // SessionConfig holds session manager configuration
 type SessionConfig struct {
 	DefaultTTL      time.Duration `json:"default_ttl"`
 	MaxSessions     int           `json:"max_sessions"`
 	CleanupInterval time.Duration `json:"cleanup_interval"`
+
+	// TerminalRetention is how long completed, failed and expired sessions
+	// are kept before the cleanup routine deletes them; 0 keeps them forever
+	TerminalRetention time.Duration `json:"terminal_retention"`
+
+	// RetentionBatchSize limits how many sessions one delete statement
+	// removes; 0 uses DefaultRetentionBatchSize
+	RetentionBatchSize int `json:"retention_batch_size"`
+
+	// SlidingExpiry pushes a session's expiry to DefaultTTL from now each
+	// time its progress is updated
+	SlidingExpiry bool `json:"sliding_expiry"`
+
+	// MaxLifetime caps how far sliding expiry can extend a session, measured
+	// from its creation; 0 leaves it uncapped
+	MaxLifetime time.Duration `json:"max_lifetime"`
+
+	// MaxNotes caps how many notes a session keeps. Appending past the cap
+	// drops the oldest notes, keeping error notes over less serious ones;
+	// 0 keeps every note
+	MaxNotes int `json:"max_notes"`
+
+	// Clock is the time source for timestamps and the expiry cycle; nil
+	// uses the system clock
+	Clock Clock `json:"-"`
+
+	// NewID generates the IDs of created sessions; nil uses uuid.New
+	NewID func() uuid.UUID `json:"-"`
 }
 
+// DefaultRetentionBatchSize is the number of sessions purged per statement
+const DefaultRetentionBatchSize = 500
+
 // Event represents an event that occurred during a session
 type Event struct {
 	ID        string                 `json:"id"`
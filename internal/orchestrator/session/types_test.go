@@ -83,6 +83,27 @@ func TestProgress_Structure(t *testing.T) {
 	assert.Len(t, progress.FailedFiles, 2)
 }
 
+func TestProgress_ComputePercent(t *testing.T) {
+	tests := []struct {
+		name     string
+		progress Progress
+		want     float64
+	}{
+		{"empty session", Progress{}, 0},
+		{"nothing finished", Progress{TotalFiles: 4}, 0},
+		{"one of four processed", Progress{TotalFiles: 4, ProcessedFiles: 1}, 25},
+		{"failed and skipped files count as finished", Progress{TotalFiles: 4, ProcessedFiles: 1, FailedFiles: []string{"/a.go"}, SkippedFiles: []string{"/b.go"}}, 75},
+		{"all processed", Progress{TotalFiles: 3, ProcessedFiles: 3}, 100},
+		{"never above 100", Progress{TotalFiles: 2, ProcessedFiles: 3}, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, tt.progress.ComputePercent(), 1e-9)
+		})
+	}
+}
+
 func TestSessionNote_Structure(t *testing.T) {
 	now := time.Now()
 	note := SessionNote{
@@ -98,6 +119,31 @@ func TestSessionNote_Structure(t *testing.T) {
 	assert.Equal(t, now, note.CreatedAt)
 }
 
+func TestNoteSeverity_AtLeast(t *testing.T) {
+	tests := []struct {
+		severity NoteSeverity
+		min      NoteSeverity
+		want     bool
+	}{
+		{NoteSeverityInfo, NoteSeverityInfo, true},
+		{NoteSeverityInfo, NoteSeverityWarn, false},
+		{NoteSeverityWarn, NoteSeverityInfo, true},
+		{NoteSeverityWarn, NoteSeverityError, false},
+		{NoteSeverityError, NoteSeverityWarn, true},
+		{"", NoteSeverityInfo, true},
+		{"", NoteSeverityWarn, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.severity)+">="+string(tt.min), func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.severity.AtLeast(tt.min))
+		})
+	}
+
+	assert.True(t, NoteSeverityWarn.IsValid())
+	assert.False(t, NoteSeverity("fatal").IsValid())
+}
+
 func TestSessionUpdate_Structure(t *testing.T) {
 	status := StatusInProgress
 	progress := Progress{
@@ -0,0 +1,42 @@
+package session
+
+// SessionUpdateBuilder builds a SessionUpdate without callers having to take
+// the address of a local for every optional field
+type SessionUpdateBuilder struct {
+	update SessionUpdate
+}
+
+// NewSessionUpdate starts an update that changes nothing
+func NewSessionUpdate() *SessionUpdateBuilder {
+	return &SessionUpdateBuilder{}
+}
+
+// WithStatus sets the session status
+func (b *SessionUpdateBuilder) WithStatus(status SessionStatus) *SessionUpdateBuilder {
+	b.update.Status = &status
+	return b
+}
+
+// WithProgress replaces the session progress
+func (b *SessionUpdateBuilder) WithProgress(progress Progress) *SessionUpdateBuilder {
+	b.update.Progress = &progress
+	return b
+}
+
+// WithCurrentFile sets the file currently being processed
+func (b *SessionUpdateBuilder) WithCurrentFile(file string) *SessionUpdateBuilder {
+	b.update.CurrentFile = &file
+	return b
+}
+
+// WithNote appends a note to the session
+func (b *SessionUpdateBuilder) WithNote(note SessionNote) *SessionUpdateBuilder {
+	b.update.Note = &note
+	return b
+}
+
+// Build returns the update. The builder can keep being used afterwards
+// without affecting updates it already built
+func (b *SessionUpdateBuilder) Build() SessionUpdate {
+	return b.update
+}
@@ -0,0 +1,76 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionUpdateBuilder(t *testing.T) {
+	progress := Progress{TotalFiles: 10, ProcessedFiles: 4}
+	note := SessionNote{FilePath: "main.go", Message: "skipped"}
+
+	tests := []struct {
+		name   string
+		build  func() SessionUpdate
+		expect SessionUpdate
+	}{
+		{
+			name:   "empty",
+			build:  func() SessionUpdate { return NewSessionUpdate().Build() },
+			expect: SessionUpdate{},
+		},
+		{
+			name:  "status only",
+			build: func() SessionUpdate { return NewSessionUpdate().WithStatus(StatusCompleted).Build() },
+			expect: SessionUpdate{
+				Status: statusPtr(StatusCompleted),
+			},
+		},
+		{
+			name: "status progress and note",
+			build: func() SessionUpdate {
+				return NewSessionUpdate().WithStatus(StatusFailed).WithProgress(progress).WithNote(note).Build()
+			},
+			expect: SessionUpdate{
+				Status:   statusPtr(StatusFailed),
+				Progress: &progress,
+				Note:     &note,
+			},
+		},
+		{
+			name:  "current file only",
+			build: func() SessionUpdate { return NewSessionUpdate().WithCurrentFile("pkg/util.go").Build() },
+			expect: SessionUpdate{
+				CurrentFile: stringPtr("pkg/util.go"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expect, tt.build())
+		})
+	}
+}
+
+func TestSessionUpdateBuilder_CopiesValues(t *testing.T) {
+	progress := Progress{TotalFiles: 3}
+	builder := NewSessionUpdate().WithProgress(progress).WithStatus(StatusInProgress)
+	first := builder.Build()
+
+	// Changing the caller's value or reusing the builder leaves the built
+	// update untouched
+	progress.TotalFiles = 7
+	builder.WithStatus(StatusCompleted)
+
+	require.NotNil(t, first.Progress)
+	assert.Equal(t, 3, first.Progress.TotalFiles)
+	assert.Equal(t, StatusInProgress, *first.Status)
+	assert.Equal(t, StatusCompleted, *builder.Build().Status)
+}
+
+func statusPtr(s SessionStatus) *SessionStatus { return &s }
+
+func stringPtr(s string) *string { return &s }
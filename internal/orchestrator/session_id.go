@@ -0,0 +1,41 @@
+package orchestrator
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// sessionIDSeparator joins the configured prefix to a session's UUID.
+const sessionIDSeparator = "_"
+
+// formatSessionID renders id as the string handed to callers, prefixed with
+// SessionConfig.IDPrefix when one is configured.
+func (o *OrchestratorImpl) formatSessionID(id uuid.UUID) string {
+	if o.config == nil || o.config.Session.IDPrefix == "" {
+		return id.String()
+	}
+	return o.config.Session.IDPrefix + sessionIDSeparator + id.String()
+}
+
+// parseSessionID strips any prefix from sessionID and parses the UUID that
+// remains. Plain UUIDs are accepted whether or not a prefix is configured.
+func parseSessionID(sessionID string) (uuid.UUID, error) {
+	if i := strings.LastIndex(sessionID, sessionIDSeparator); i >= 0 {
+		sessionID = sessionID[i+len(sessionIDSeparator):]
+	}
+	return uuid.Parse(sessionID)
+}
+
+// validSessionIDPrefix reports whether prefix only uses letters, digits and
+// hyphens, so it can't be mistaken for part of the UUID or the separator.
+func validSessionIDPrefix(prefix string) bool {
+	for _, r := range prefix {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
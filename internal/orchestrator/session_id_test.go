@@ -0,0 +1,92 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/todolist"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSessionID(t *testing.T) {
+	want := uuid.MustParse("550e8400-e29b-41d4-a716-446655440900")
+
+	tests := []struct {
+		name      string
+		sessionID string
+		wantErr   bool
+	}{
+		{name: "plain UUID", sessionID: "550e8400-e29b-41d4-a716-446655440900"},
+		{name: "prefixed", sessionID: "doc_550e8400-e29b-41d4-a716-446655440900"},
+		{name: "prefix with hyphens", sessionID: "my-workspace_550e8400-e29b-41d4-a716-446655440900"},
+		{name: "not a UUID", sessionID: "doc_not-a-uuid", wantErr: true},
+		{name: "empty after prefix", sessionID: "doc_", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSessionID(tt.sessionID)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestSessionIDPrefix(t *testing.T) {
+	const rawID = "550e8400-e29b-41d4-a716-446655440901"
+	ctx := context.Background()
+
+	t.Run("created sessions get the prefix", func(t *testing.T) {
+		o, mockSession, mockWorkflow, _ := createTestOrchestrator(t)
+		o.config.Session.IDPrefix = "doc"
+		o.todoManager = todolist.NewManager()
+		fs := &fakeFileSystem{files: map[string][]byte{"/project/main.go": []byte("package main")}}
+		require.NoError(t, o.serviceRegistry.RegisterFileSystem(fs))
+
+		sess := createMockSession(rawID, "workspace-123", "/project")
+		mockSession.On("Create", "workspace-123", "/project", "", []string{"/project/main.go"}).Return(sess, nil)
+		mockWorkflow.On("Initialize", mock.Anything, "doc_"+rawID, workflow.WorkflowStateIdle).Return(nil)
+		mockWorkflow.On("Trigger", mock.Anything, "doc_"+rawID, workflow.EventStart).Return(nil)
+
+		docSess, err := o.StartDocumentation(ctx, DocumentationRequest{
+			ProjectPath: "/project",
+			WorkspaceID: "workspace-123",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "doc_"+rawID, docSess.ID)
+		mockWorkflow.AssertExpectations(t)
+	})
+
+	t.Run("GetSession accepts prefixed and plain IDs", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		o.config.Session.IDPrefix = "doc"
+
+		sess := createMockSession(rawID, "workspace-123", "/project")
+		mockSession.On("Get", sess.ID).Return(sess, nil)
+
+		for _, id := range []string{"doc_" + rawID, rawID} {
+			docSess, err := o.GetSession(ctx, id)
+			require.NoError(t, err, id)
+			assert.Equal(t, "doc_"+rawID, docSess.ID)
+		}
+	})
+
+	t.Run("no prefix keeps plain UUIDs", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+
+		sess := createMockSession(rawID, "workspace-123", "/project")
+		mockSession.On("Get", sess.ID).Return(sess, nil)
+
+		docSess, err := o.GetSession(ctx, rawID)
+		require.NoError(t, err)
+		assert.Equal(t, rawID, docSess.ID)
+	})
+}
@@ -0,0 +1,170 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrShuttingDown is returned when an operation is attempted after Shutdown
+// has been called.
+var ErrShuttingDown = errors.New("orchestrator is shutting down")
+
+// ErrShutdownTimedOut is wrapped by the error Shutdown returns when its
+// context expired before in-flight operations finished and they were
+// cancelled.
+var ErrShutdownTimedOut = errors.New("shutdown timed out")
+
+// shutdownRollbackWait bounds how long a forced shutdown waits for cancelled
+// operations to return their files to the queue.
+const shutdownRollbackWait = 5 * time.Second
+
+// Shutdown gracefully stops the orchestrator. The sequence is deterministic:
+//
+//  1. Stop accepting new StartDocumentation/ProcessNextFile calls
+//  2. Wait for in-flight operations to return; each operation persists its
+//     own progress before returning
+//  3. If ctx expires first (or Workflow.ShutdownTimeout passes, when ctx
+//     has no deadline), cancel the worker context so in-flight analyses
+//     abort and return their files to the queue, and report how many were
+//     requeued
+//  4. Flush analyses a FlushingAnalysisStore is still buffering; if ctx
+//     has already expired, the flush gets shutdownRollbackWait of its own
+//  5. Close the registered ProgressLog if it implements io.Closer
+//  6. Stop background goroutines (session expiry handler, TODO list sweeper)
+//  7. Close registered services that implement io.Closer, such as AI
+//     services holding HTTP clients
+//  8. Close the database connection
+//
+// Errors from each step are aggregated into the returned error.
+func (o *OrchestratorImpl) Shutdown(ctx context.Context) error {
+	o.lifecycleMu.Lock()
+	if o.shuttingDown {
+		o.lifecycleMu.Unlock()
+		return ErrShuttingDown
+	}
+	o.shuttingDown = true
+	o.lifecycleMu.Unlock()
+
+	log.Info().
+		Str("component", "orchestrator").
+		Msg("Shutting down orchestrator")
+
+	var errs []error
+
+	if _, ok := ctx.Deadline(); !ok && o.config != nil && o.config.Workflow.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.config.Workflow.ShutdownTimeout)
+		defer cancel()
+	}
+
+	// Wait for in-flight operations to finish
+	done := make(chan struct{})
+	go func() {
+		o.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		o.stopWorkers()
+	case <-ctx.Done():
+		// Interrupt the remaining analyses, which requeue their files
+		o.stopWorkers()
+		select {
+		case <-done:
+		case <-time.After(shutdownRollbackWait):
+			errs = append(errs, fmt.Errorf("in-flight operations did not stop within %s of being cancelled", shutdownRollbackWait))
+		}
+		requeued := o.shutdownRequeued.Load()
+		log.Warn().
+			Str("component", "orchestrator").
+			Int64("requeued", requeued).
+			Msg("Shutdown timed out, in-flight work cancelled")
+		errs = append(errs, fmt.Errorf("%w, %d files requeued: %w", ErrShutdownTimedOut, requeued, ctx.Err()))
+	}
+
+	// Persist buffered analyses while the database is still open
+	flushCtx := ctx
+	if ctx.Err() != nil {
+		var cancel context.CancelFunc
+		flushCtx, cancel = context.WithTimeout(context.WithoutCancel(ctx), shutdownRollbackWait)
+		defer cancel()
+	}
+	if err := o.flushAnalyses(flushCtx); err != nil {
+		errs = append(errs, err)
+	}
+
+	if progressLog, ok := o.progressLog(); ok {
+		if closer, ok := progressLog.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("failed to close progress log: %w", err))
+			}
+		}
+	}
+
+	// Stop background goroutines
+	if o.sessionManager != nil {
+		if err := o.sessionManager.Shutdown(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to shut down session manager: %w", err))
+		}
+	}
+	if o.todoManager != nil {
+		if err := o.todoManager.Shutdown(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to shut down TODO manager: %w", err))
+		}
+	}
+
+	// Release service resources such as idle HTTP connections
+	if o.serviceRegistry != nil {
+		if err := o.serviceRegistry.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close services: %w", err))
+		}
+	}
+
+	// Close the database last so no operation observes a closed pool
+	if o.db != nil {
+		if err := o.db.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close database: %w", err))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("shutdown completed with errors: %w", err)
+	}
+
+	log.Info().
+		Str("component", "orchestrator").
+		Msg("Orchestrator shut down")
+
+	return nil
+}
+
+// stopWorkers cancels the worker context, interrupting in-flight analyses.
+func (o *OrchestratorImpl) stopWorkers() {
+	if o.cancelWorkers != nil {
+		o.cancelWorkers()
+	}
+}
+
+// beginOperation registers an in-flight operation, rejecting it if the
+// orchestrator is shutting down. Callers must defer endOperation on success.
+func (o *OrchestratorImpl) beginOperation() error {
+	o.lifecycleMu.RLock()
+	defer o.lifecycleMu.RUnlock()
+
+	if o.shuttingDown {
+		return ErrShuttingDown
+	}
+	o.inFlight.Add(1)
+	return nil
+}
+
+// endOperation marks an in-flight operation as finished.
+func (o *OrchestratorImpl) endOperation() {
+	o.inFlight.Done()
+}
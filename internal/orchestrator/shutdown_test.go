@@ -0,0 +1,243 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/services"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/session"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/todolist"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShutdown(t *testing.T) {
+	t.Run("timed out shutdown cancels and requeues in-flight files", func(t *testing.T) {
+		o, mockSession, mockWorkflow, _ := createTestOrchestrator(t)
+		o.todoManager = todolist.NewManager()
+		ctx := context.Background()
+
+		sessionID := "550e8400-e29b-41d4-a716-446655440400"
+		sess := createMockSession(sessionID, "workspace-123", "/path/to/project")
+		sess.Status = session.StatusInProgress
+		mockSession.On("Get", uuid.MustParse(sessionID)).Return(sess, nil)
+		mockSession.On("Update", uuid.MustParse(sessionID), mock.AnythingOfType("session.SessionUpdate")).Return(nil)
+		mockSession.On("Shutdown").Return(nil)
+		mockWorkflow.On("GetState", mock.Anything, sessionID).Return(workflow.WorkflowStateProcessing, nil)
+
+		require.NoError(t, o.todoManager.CreateList(ctx, sessionID))
+		require.NoError(t, o.todoManager.AddItem(ctx, sessionID, todolist.TodoItem{FilePath: "/fast.go", Priority: 10}))
+		require.NoError(t, o.todoManager.AddItem(ctx, sessionID, todolist.TodoItem{FilePath: "/slow.go", Priority: 5}))
+		require.NoError(t, o.todoManager.AddItem(ctx, sessionID, todolist.TodoItem{FilePath: "/queued.go", Priority: 1}))
+
+		slowStarted := make(chan struct{})
+		require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &stubAIService{
+			analyzeFunc: func(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error) {
+				if req.FilePath == "/fast.go" {
+					return &services.FileAnalysisResponse{Summary: "fast"}, nil
+				}
+				close(slowStarted)
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+		}))
+
+		// The first file completes before shutdown begins
+		analysis, err := o.ProcessNextFile(ctx, sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, "/fast.go", analysis.FilePath)
+
+		// The second file is mid-analysis when shutdown is requested
+		var wg sync.WaitGroup
+		var processErr error
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, processErr = o.ProcessNextFile(ctx, sessionID)
+		}()
+		<-slowStarted
+
+		// The slow analysis outlasts the deadline, so it is cancelled
+		shutdownCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		started := time.Now()
+		err = o.Shutdown(shutdownCtx)
+		wg.Wait()
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrShutdownTimedOut)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Contains(t, err.Error(), "shutdown timed out, 1 files requeued")
+		assert.Less(t, time.Since(started), time.Second)
+
+		assert.ErrorIs(t, processErr, context.Canceled)
+
+		progress, err := o.todoManager.GetProgress(ctx, sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, progress.Complete)
+		assert.Equal(t, 2, progress.Pending) // slow.go was requeued
+		assert.Equal(t, 0, progress.InProgress)
+		assert.Equal(t, 3, progress.Total)
+
+		mockSession.AssertExpectations(t)
+	})
+
+	t.Run("waits for in-flight files to finish", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		ctx := context.Background()
+		sessionID := "550e8400-e29b-41d4-a716-446655440402"
+		newStatefulSession(t, o, mockSession, sessionID)
+		mockSession.On("Shutdown").Return(nil)
+		require.NoError(t, o.todoManager.AddItem(ctx, sessionID, todolist.TodoItem{FilePath: "/slow.go"}))
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		require.NoError(t, o.serviceRegistry.RegisterAIService(DefaultAIProvider, &stubAIService{
+			analyzeFunc: func(ctx context.Context, req services.FileAnalysisRequest) (*services.FileAnalysisResponse, error) {
+				close(started)
+				select {
+				case <-release:
+					return &services.FileAnalysisResponse{Summary: "slow"}, nil
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			},
+		}))
+
+		var processErr error
+		processed := make(chan struct{})
+		go func() {
+			defer close(processed)
+			_, processErr = o.ProcessNextFile(ctx, sessionID)
+		}()
+		<-started
+
+		shutdownErr := make(chan error, 1)
+		go func() {
+			shutdownCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+			shutdownErr <- o.Shutdown(shutdownCtx)
+		}()
+
+		// Shutdown holds off until the analysis finishes
+		select {
+		case err := <-shutdownErr:
+			t.Fatalf("shutdown returned before in-flight work finished: %v", err)
+		case <-time.After(20 * time.Millisecond):
+		}
+		close(release)
+		<-processed
+
+		require.NoError(t, <-shutdownErr)
+		require.NoError(t, processErr)
+		progress, err := o.todoManager.GetProgress(ctx, sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, progress.Complete)
+	})
+
+	t.Run("rejects new work after shutdown", func(t *testing.T) {
+		o, mockSession, _, mockTodo := createTestOrchestrator(t)
+		mockSession.On("Shutdown").Return(nil)
+		mockTodo.On("Shutdown").Return(nil)
+
+		require.NoError(t, o.Shutdown(context.Background()))
+
+		_, err := o.ProcessNextFile(context.Background(), "550e8400-e29b-41d4-a716-446655440401")
+		assert.ErrorIs(t, err, ErrShuttingDown)
+
+		_, err = o.StartDocumentation(context.Background(), DocumentationRequest{
+			WorkspaceID: "workspace-123",
+			ProjectPath: "/path/to/project",
+		})
+		assert.ErrorIs(t, err, ErrShuttingDown)
+
+		// A second shutdown is reported rather than repeated
+		assert.ErrorIs(t, o.Shutdown(context.Background()), ErrShuttingDown)
+	})
+
+	t.Run("aggregates step errors", func(t *testing.T) {
+		o, mockSession, _, mockTodo := createTestOrchestrator(t)
+		mockSession.On("Shutdown").Return(errors.New("expiry handler stuck"))
+		mockTodo.On("Shutdown").Return(errors.New("sweeper stuck"))
+
+		err := o.Shutdown(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to shut down session manager")
+		assert.Contains(t, err.Error(), "expiry handler stuck")
+		assert.Contains(t, err.Error(), "failed to shut down TODO manager")
+		assert.Contains(t, err.Error(), "sweeper stuck")
+	})
+
+	t.Run("closes services that implement io.Closer", func(t *testing.T) {
+		o, mockSession, _, mockTodo := createTestOrchestrator(t)
+		mockSession.On("Shutdown").Return(nil)
+		mockTodo.On("Shutdown").Return(nil)
+
+		openai := &closingAIService{}
+		gemini := &closingAIService{closeErr: errors.New("transport busy")}
+		require.NoError(t, o.serviceRegistry.RegisterAIService("openai", openai))
+		require.NoError(t, o.serviceRegistry.RegisterAIService("gemini", gemini))
+		require.NoError(t, o.serviceRegistry.RegisterAIService("plain", &stubAIService{}))
+
+		err := o.Shutdown(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to close services")
+		assert.Contains(t, err.Error(), "failed to close ai_service:gemini: transport busy")
+		assert.Equal(t, 1, openai.closed)
+		assert.Equal(t, 1, gemini.closed)
+	})
+
+	t.Run("flushes buffered analyses and closes the progress log", func(t *testing.T) {
+		o, mockSession, _, mockTodo := createTestOrchestrator(t)
+		mockSession.On("Shutdown").Return(nil)
+		mockTodo.On("Shutdown").Return(nil)
+		ctx := context.Background()
+
+		store := newBufferingAnalysisStore()
+		require.NoError(t, o.container.Register(AnalysisStoreName, store))
+		require.NoError(t, store.SaveAnalysis(ctx, "/project", &FileAnalysis{FilePath: "/project/main.go", Content: "entry point"}))
+		progressLog, err := OpenFileProgressLog(filepath.Join(t.TempDir(), "progress.log"))
+		require.NoError(t, err)
+		require.NoError(t, o.container.Register(ProgressLogName, progressLog))
+
+		require.NoError(t, o.Shutdown(ctx))
+
+		analysis, err := store.memoryAnalysisStore.GetAnalysis(ctx, "/project", "/project/main.go")
+		require.NoError(t, err)
+		assert.Equal(t, "entry point", analysis.Content)
+		_, err = progressLog.Append(ctx, ProgressLogEntry{SessionID: "session-1", FilePath: "/project/main.go"})
+		assert.Error(t, err, "the log is closed")
+	})
+
+	t.Run("reports a failed flush", func(t *testing.T) {
+		o, mockSession, _, mockTodo := createTestOrchestrator(t)
+		mockSession.On("Shutdown").Return(nil)
+		mockTodo.On("Shutdown").Return(nil)
+
+		store := newBufferingAnalysisStore()
+		store.flushErr = errors.New("disk full")
+		require.NoError(t, o.container.Register(AnalysisStoreName, store))
+
+		err := o.Shutdown(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to flush analyses: disk full")
+	})
+}
+
+// closingAIService is a stub AI service that counts calls to Close.
+type closingAIService struct {
+	stubAIService
+	closed   int
+	closeErr error
+}
+
+func (s *closingAIService) Close() error {
+	s.closed++
+	return s.closeErr
+}
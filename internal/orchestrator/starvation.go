@@ -0,0 +1,82 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/session"
+)
+
+// ErrSessionStarved is returned by ProcessNextFile when a session's oldest
+// pending file has waited longer than SessionConfig.MaxQueueWait and the
+// configured starvation action is StarvationFail.
+var ErrSessionStarved = errors.New("session starved: queue wait exceeded")
+
+// enforceQueueWait checks how long the session's oldest pending file has
+// been queued and, once it exceeds MaxQueueWait, either promotes the starved
+// files or fails the session depending on StarvationAction.
+func (o *OrchestratorImpl) enforceQueueWait(ctx context.Context, sess *DocumentationSession) error {
+	if o.config == nil || o.config.Session.MaxQueueWait <= 0 {
+		return nil
+	}
+	maxWait := o.config.Session.MaxQueueWait
+
+	stats, err := o.todoManager.QueueStats(ctx, sess.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get queue stats: %w", err)
+	}
+	if stats.Pending == 0 || stats.OldestPendingAge <= maxWait {
+		return nil
+	}
+
+	logger := LoggerFromContext(ctx)
+	logger.Warn().
+		Dur("oldest_pending_age", stats.OldestPendingAge).
+		Dur("max_queue_wait", maxWait).
+		Str("action", o.starvationAction()).
+		Msg("Session queue wait exceeded")
+
+	if o.starvationAction() == StarvationFail {
+		return o.failStarvedSession(ctx, sess)
+	}
+
+	promoted, err := o.todoManager.PromoteStarved(ctx, sess.ID, maxWait)
+	if err != nil {
+		return fmt.Errorf("failed to promote starved files: %w", err)
+	}
+	if len(promoted) > 0 {
+		logger.Info().
+			Strs("files", promoted).
+			Msg("Promoted starved files")
+		o.addSessionNote(ctx, sess.ID, session.SessionNote{
+			Severity: session.NoteSeverityWarn,
+			Source:   NoteSourceScheduler,
+			Message:  fmt.Sprintf("promoted %d starved files", len(promoted)),
+		})
+	}
+	return nil
+}
+
+// failStarvedSession moves a starved session to the failed state.
+func (o *OrchestratorImpl) failStarvedSession(ctx context.Context, sess *DocumentationSession) error {
+	err := o.failSession(ctx, sess, session.SessionNote{
+		Severity: session.NoteSeverityError,
+		Source:   NoteSourceScheduler,
+		Message:  "session failed: queue wait exceeded",
+	})
+	if err != nil {
+		return err
+	}
+
+	return fmt.Errorf("%w: session %s", ErrSessionStarved, sess.ID)
+}
+
+// starvationAction returns the configured starvation action, defaulting to
+// StarvationBoost.
+func (o *OrchestratorImpl) starvationAction() string {
+	if o.config == nil || o.config.Session.StarvationAction == "" {
+		return StarvationBoost
+	}
+	return o.config.Session.StarvationAction
+}
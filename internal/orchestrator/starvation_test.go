@@ -0,0 +1,112 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/session"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/todolist"
+	"github.com/nixlim/codedoc-mcp-server/internal/orchestrator/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// queueClock is a manually advanced clock for TODO list wait times.
+type queueClock struct {
+	now time.Time
+}
+
+func (c *queueClock) Now() time.Time {
+	return c.now
+}
+
+func (c *queueClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// setupStarvedSession queues an old low-priority file behind a newer
+// high-priority one and advances the clock past the old file's limit.
+func setupStarvedSession(t *testing.T, o *OrchestratorImpl, mockSession *mockSessionManager, sessionID string) {
+	t.Helper()
+	ctx := context.Background()
+
+	sess := createMockSession(sessionID, "workspace-123", "/project")
+	sess.Status = session.StatusInProgress
+	sess.Progress.TotalFiles = 2
+	mockSession.On("Get", sess.ID).Return(sess, nil)
+	mockSession.On("Update", sess.ID, mock.AnythingOfType("session.SessionUpdate")).Return(nil)
+	if engine, ok := o.workflowEngine.(*mockWorkflowEngine); ok {
+		engine.On("GetState", mock.Anything, sessionID).Return(workflow.WorkflowStateProcessing, nil).Maybe()
+	}
+
+	clock := &queueClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	o.todoManager = todolist.NewManagerWithClock(clock)
+	require.NoError(t, o.todoManager.CreateList(ctx, sessionID))
+	require.NoError(t, o.todoManager.AddItem(ctx, sessionID, todolist.TodoItem{FilePath: "/project/old.go", Priority: 1}))
+	clock.Advance(20 * time.Minute)
+	require.NoError(t, o.todoManager.AddItem(ctx, sessionID, todolist.TodoItem{FilePath: "/project/new.go", Priority: 10}))
+}
+
+func TestProcessNextFileQueueWait(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		sessionID := "550e8400-e29b-41d4-a716-446655440720"
+		setupStarvedSession(t, o, mockSession, sessionID)
+
+		analysis, err := o.ProcessNextFile(context.Background(), sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, "/project/new.go", analysis.FilePath)
+	})
+
+	t.Run("boost serves the starved file first", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		o.config.Session.MaxQueueWait = 15 * time.Minute
+		o.config.Session.StarvationAction = StarvationBoost
+		sessionID := "550e8400-e29b-41d4-a716-446655440721"
+		setupStarvedSession(t, o, mockSession, sessionID)
+
+		analysis, err := o.ProcessNextFile(context.Background(), sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, "/project/old.go", analysis.FilePath)
+
+		analysis, err = o.ProcessNextFile(context.Background(), sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, "/project/new.go", analysis.FilePath)
+	})
+
+	t.Run("wait under the limit leaves the order alone", func(t *testing.T) {
+		o, mockSession, _, _ := createTestOrchestrator(t)
+		o.config.Session.MaxQueueWait = time.Hour
+		sessionID := "550e8400-e29b-41d4-a716-446655440722"
+		setupStarvedSession(t, o, mockSession, sessionID)
+
+		analysis, err := o.ProcessNextFile(context.Background(), sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, "/project/new.go", analysis.FilePath)
+	})
+
+	t.Run("fail marks the session failed", func(t *testing.T) {
+		o, mockSession, mockWorkflow, _ := createTestOrchestrator(t)
+		o.config.Session.MaxQueueWait = 15 * time.Minute
+		o.config.Session.StarvationAction = StarvationFail
+		sessionID := "550e8400-e29b-41d4-a716-446655440723"
+		setupStarvedSession(t, o, mockSession, sessionID)
+		mockWorkflow.On("Transition", mock.Anything, sessionID, workflow.WorkflowStateFailed).Return(nil)
+
+		analysis, err := o.ProcessNextFile(context.Background(), sessionID)
+		assert.ErrorIs(t, err, ErrSessionStarved)
+		assert.Nil(t, analysis)
+
+		mockWorkflow.AssertExpectations(t)
+		mockSession.AssertCalled(t, "Update", mock.Anything, mock.MatchedBy(func(u session.SessionUpdate) bool {
+			return u.Status != nil && *u.Status == session.StatusFailed
+		}))
+
+		// Nothing was handed out
+		progress, err := o.todoManager.GetProgress(context.Background(), sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, progress.Pending)
+	})
+}
@@ -0,0 +1,45 @@
+package orchestrator
+
+import "sort"
+
+// Documentation templates understood by the AI services.
+const (
+	// TemplateReference produces API reference documentation
+	TemplateReference = "reference"
+
+	// TemplateTutorial produces a guided, example-driven walkthrough
+	TemplateTutorial = "tutorial"
+
+	// DefaultTemplate is used when a request does not choose a template
+	DefaultTemplate = TemplateReference
+)
+
+// documentationTemplates lists the known templates and what they produce.
+var documentationTemplates = map[string]string{
+	TemplateReference: "API reference describing each exported symbol",
+	TemplateTutorial:  "step-by-step guide explaining how to use the module",
+}
+
+// IsKnownTemplate reports whether name is a registered documentation template.
+func IsKnownTemplate(name string) bool {
+	_, ok := documentationTemplates[name]
+	return ok
+}
+
+// KnownTemplates returns the registered template names in sorted order.
+func KnownTemplates() []string {
+	names := make([]string, 0, len(documentationTemplates))
+	for name := range documentationTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolveTemplate returns name, or the default template if name is empty.
+func resolveTemplate(name string) string {
+	if name == "" {
+		return DefaultTemplate
+	}
+	return name
+}
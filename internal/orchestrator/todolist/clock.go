@@ -0,0 +1,18 @@
+package todolist
+
+import "time"
+
+// Clock provides the current time to the TODO list components.
+// It allows tests to control enqueue timestamps and age calculations.
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+}
+
+// realClock implements Clock using the system time.
+type realClock struct{}
+
+// Now returns the current system time.
+func (realClock) Now() time.Time {
+	return time.Now()
+}
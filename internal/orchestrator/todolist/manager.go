@@ -5,7 +5,11 @@ package todolist
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Manager handles TODO lists for documentation sessions.
@@ -13,20 +17,62 @@ type Manager interface {
 	// CreateList creates a new TODO list for a session
 	CreateList(ctx context.Context, sessionID string) error
 
-	// AddItem adds a file to the TODO list with priority
+	// CreateListWithOptions creates a new TODO list for a session with
+	// the given ordering options
+	CreateListWithOptions(ctx context.Context, sessionID string, opts ListOptions) error
+
+	// AddItem adds a file to the TODO list with priority, updating the
+	// file's item instead if the list already has one
 	AddItem(ctx context.Context, sessionID string, item TodoItem) error
 
+	// UpsertItem adds a file to the TODO list like AddItem and reports
+	// whether a new item was inserted rather than an existing one updated
+	UpsertItem(ctx context.Context, sessionID string, item TodoItem) (bool, error)
+
+	// AddItems adds or updates several files of the TODO list in one
+	// operation
+	AddItems(ctx context.Context, sessionID string, items []TodoItem) error
+
 	// GetNext retrieves the next highest priority item
 	GetNext(ctx context.Context, sessionID string) (string, error)
 
+	// GetNextAcrossSessions retrieves the next item from one of several
+	// lists, rotating between them so no session starves the others
+	GetNextAcrossSessions(ctx context.Context, sessionIDs []string) (string, TodoItem, error)
+
 	// UpdateProgress updates the progress of an item
 	UpdateProgress(ctx context.Context, sessionID string, filePath string, status ItemStatus) error
 
+	// SkipRemaining marks all pending and in-progress items as skipped
+	SkipRemaining(ctx context.Context, sessionID string) ([]string, error)
+
 	// GetProgress returns the current progress of the TODO list
 	GetProgress(ctx context.Context, sessionID string) (*Progress, error)
 
+	// GetProgressBatch returns the progress of several TODO lists, keyed by
+	// session ID; sessions without a list are omitted
+	GetProgressBatch(ctx context.Context, sessionIDs []string) (map[string]*Progress, error)
+
+	// ListItems returns every item of a TODO list with its current status,
+	// sorted by file path
+	ListItems(ctx context.Context, sessionID string) ([]TodoItem, error)
+
+	// GetItem returns a single item of a TODO list with its current status
+	// and timestamps
+	GetItem(ctx context.Context, sessionID string, filePath string) (TodoItem, error)
+
 	// DeleteList removes a TODO list
 	DeleteList(ctx context.Context, sessionID string) error
+
+	// QueueStats returns queue depth and wait time statistics for a list
+	QueueStats(ctx context.Context, sessionID string) (*QueueStats, error)
+
+	// PromoteStarved moves pending items that have waited longer than
+	// maxWait ahead of the list's other pending items
+	PromoteStarved(ctx context.Context, sessionID string, maxWait time.Duration) ([]string, error)
+
+	// Shutdown stops the idle list sweeper
+	Shutdown() error
 }
 
 // TodoItem represents a file to be processed.
@@ -42,6 +88,19 @@ type TodoItem struct {
 
 	// Metadata contains additional information
 	Metadata map[string]string `json:"metadata"`
+
+	// EnqueuedAt is when the item was added to the list
+	EnqueuedAt time.Time `json:"enqueued_at"`
+
+	// StartedAt is when the item was last handed out for processing
+	StartedAt time.Time `json:"started_at"`
+
+	// CompletedAt is when the item last reached a final status
+	CompletedAt time.Time `json:"completed_at"`
+
+	// DependsOn lists the file paths of items that must finish before this
+	// one is handed out when the list orders by dependencies
+	DependsOn []string `json:"depends_on,omitempty"`
 }
 
 // ItemStatus represents the processing status of a TODO item.
@@ -85,21 +144,128 @@ type Progress struct {
 	Skipped int `json:"skipped"`
 }
 
+// ListOptions configures how a TODO list orders its items.
+type ListOptions struct {
+	// AgingFactor is the priority a pending item gains per minute it has
+	// waited, so old low-priority items eventually surface (0 disables aging)
+	AgingFactor float64 `json:"aging_factor"`
+
+	// DependencyOrder holds back pending items until every item they depend
+	// on has finished, choosing by priority among the ready ones
+	DependencyOrder bool `json:"dependency_order"`
+}
+
+// DependencyCycleError is returned when adding items would make their
+// declared dependencies circular.
+type DependencyCycleError struct {
+	// Cycle is the file paths around the cycle, starting and ending with
+	// the same path
+	Cycle []string
+}
+
+func (e *DependencyCycleError) Error() string {
+	return fmt.Sprintf("dependency cycle: %s", strings.Join(e.Cycle, " -> "))
+}
+
+// QueueStats summarizes the state of a TODO list queue for monitoring.
+type QueueStats struct {
+	// Pending is the number of items waiting to be processed
+	Pending int `json:"pending"`
+
+	// InProgress is the number of items currently being processed
+	InProgress int `json:"in_progress"`
+
+	// OldestEnqueuedAt is when the oldest pending item was enqueued
+	OldestEnqueuedAt time.Time `json:"oldest_enqueued_at,omitempty"`
+
+	// OldestPendingAge is how long the oldest pending item has been waiting
+	OldestPendingAge time.Duration `json:"oldest_pending_age"`
+}
+
 // ManagerImpl implements the Manager interface with in-memory storage.
 type ManagerImpl struct {
 	lists map[string]*PriorityQueue
+	clock Clock
 	mu    sync.RWMutex
+
+	// turn counts items handed out by GetNextAcrossSessions
+	turn uint64
+
+	// lastServed records the turn on which each session was last served
+	lastServed map[string]uint64
+
+	// touched holds when each list was last used, as Unix nanoseconds. The
+	// values are atomic so read-locked methods can update them.
+	touched map[string]*atomic.Int64
+
+	// Idle list sweeping
+	maxIdle      time.Duration
+	shutdownCh   chan struct{}
+	shutdownOnce sync.Once
+	wg           sync.WaitGroup
+}
+
+// ManagerConfig configures a TODO list manager.
+type ManagerConfig struct {
+	// Clock provides enqueue timestamps and idle times (nil uses the system clock)
+	Clock Clock
+
+	// MaxIdle is how long a list may go unused before the sweeper deletes
+	// it, independently of the session's expiry (0 disables sweeping)
+	MaxIdle time.Duration
+
+	// SweepInterval is how often idle lists are swept (defaults to MaxIdle)
+	SweepInterval time.Duration
 }
 
 // NewManager creates a new TODO list manager.
 func NewManager() Manager {
-	return &ManagerImpl{
-		lists: make(map[string]*PriorityQueue),
+	return NewManagerWithClock(realClock{})
+}
+
+// NewManagerWithClock creates a new TODO list manager whose lists use the
+// given clock for enqueue timestamps and wait time calculations.
+func NewManagerWithClock(clock Clock) Manager {
+	return NewManagerWithConfig(ManagerConfig{Clock: clock})
+}
+
+// NewManagerWithConfig creates a new TODO list manager. When MaxIdle is set
+// it starts a background sweeper, which Shutdown stops.
+func NewManagerWithConfig(config ManagerConfig) *ManagerImpl {
+	if config.Clock == nil {
+		config.Clock = realClock{}
 	}
+	if config.SweepInterval <= 0 {
+		config.SweepInterval = config.MaxIdle
+	}
+
+	m := &ManagerImpl{
+		lists:      make(map[string]*PriorityQueue),
+		clock:      config.Clock,
+		touched:    make(map[string]*atomic.Int64),
+		maxIdle:    config.MaxIdle,
+		shutdownCh: make(chan struct{}),
+	}
+
+	if m.maxIdle > 0 {
+		m.startSweeper(config.SweepInterval)
+	}
+
+	return m
 }
 
 // CreateList creates a new TODO list for a session.
 func (m *ManagerImpl) CreateList(ctx context.Context, sessionID string) error {
+	return m.CreateListWithOptions(ctx, sessionID, ListOptions{})
+}
+
+// CreateListWithOptions creates a new TODO list for a session with the given
+// ordering options.
+func (m *ManagerImpl) CreateListWithOptions(ctx context.Context, sessionID string, opts ListOptions) error {
+	if opts.AgingFactor < 0 {
+		return fmt.Errorf("aging factor cannot be negative")
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -107,12 +273,48 @@ func (m *ManagerImpl) CreateList(ctx context.Context, sessionID string) error {
 		return fmt.Errorf("TODO list already exists for session %s", sessionID)
 	}
 
-	m.lists[sessionID] = NewPriorityQueue()
+	clock := m.clock
+	if clock == nil {
+		clock = realClock{}
+	}
+	m.lists[sessionID] = NewPriorityQueueWithOptions(clock, opts)
+	if m.touched == nil {
+		m.touched = make(map[string]*atomic.Int64)
+	}
+	m.touched[sessionID] = newTouched(clock.Now())
 	return nil
 }
 
-// AddItem adds a file to the TODO list with priority.
+// AddItem adds a file to the TODO list with priority. If the list already
+// has an item for the file, that item is updated instead.
 func (m *ManagerImpl) AddItem(ctx context.Context, sessionID string, item TodoItem) error {
+	_, err := m.UpsertItem(ctx, sessionID, item)
+	return err
+}
+
+// UpsertItem adds a file to the TODO list, or updates the list's item for
+// the file with item's priority and, where item sets them, its metadata,
+// dependencies and status. It reports whether a new item was inserted.
+func (m *ManagerImpl) UpsertItem(ctx context.Context, sessionID string, item TodoItem) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list, exists := m.lists[sessionID]
+	if !exists {
+		return false, fmt.Errorf("no TODO list found for session %s", sessionID)
+	}
+	m.touch(sessionID)
+
+	if err := list.CheckDependencies([]TodoItem{item}); err != nil {
+		return false, err
+	}
+
+	return list.Upsert(item), nil
+}
+
+// AddItems adds several files to the TODO list under a single lock, updating
+// the items of files the list already has as AddItem does.
+func (m *ManagerImpl) AddItems(ctx context.Context, sessionID string, items []TodoItem) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -120,13 +322,16 @@ func (m *ManagerImpl) AddItem(ctx context.Context, sessionID string, item TodoIt
 	if !exists {
 		return fmt.Errorf("no TODO list found for session %s", sessionID)
 	}
+	m.touch(sessionID)
 
-	// Default to pending status
-	if item.Status == "" {
-		item.Status = ItemStatusPending
+	// Reject the whole batch if it would create a cycle
+	if err := list.CheckDependencies(items); err != nil {
+		return err
 	}
 
-	list.Push(item)
+	for _, item := range items {
+		list.Upsert(item)
+	}
 	return nil
 }
 
@@ -139,6 +344,7 @@ func (m *ManagerImpl) GetNext(ctx context.Context, sessionID string) (string, er
 	if !exists {
 		return "", fmt.Errorf("no TODO list found for session %s", sessionID)
 	}
+	m.touch(sessionID)
 
 	item, err := list.PopNext()
 	if err != nil {
@@ -148,6 +354,48 @@ func (m *ManagerImpl) GetNext(ctx context.Context, sessionID string) (string, er
 	return item.FilePath, nil
 }
 
+// GetNextAcrossSessions retrieves the next item from the given sessions'
+// lists. Sessions are served least recently served first, so each one with
+// pending work gets a turn before any other gets a second; priority only
+// orders items within a session.
+func (m *ManagerImpl) GetNextAcrossSessions(ctx context.Context, sessionIDs []string) (string, TodoItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(sessionIDs) == 0 {
+		return "", TodoItem{}, fmt.Errorf("no sessions to schedule")
+	}
+	for _, sessionID := range sessionIDs {
+		if _, exists := m.lists[sessionID]; !exists {
+			return "", TodoItem{}, fmt.Errorf("no TODO list found for session %s", sessionID)
+		}
+	}
+	for _, sessionID := range sessionIDs {
+		m.touch(sessionID)
+	}
+
+	candidates := append([]string(nil), sessionIDs...)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return m.lastServed[candidates[i]] < m.lastServed[candidates[j]]
+	})
+
+	for _, sessionID := range candidates {
+		item, err := m.lists[sessionID].PopNext()
+		if err != nil {
+			continue
+		}
+
+		if m.lastServed == nil {
+			m.lastServed = make(map[string]uint64)
+		}
+		m.turn++
+		m.lastServed[sessionID] = m.turn
+		return sessionID, *item, nil
+	}
+
+	return "", TodoItem{}, &NoMoreTodosError{SessionID: strings.Join(sessionIDs, ", ")}
+}
+
 // UpdateProgress updates the progress of an item.
 func (m *ManagerImpl) UpdateProgress(ctx context.Context, sessionID string, filePath string, status ItemStatus) error {
 	m.mu.Lock()
@@ -157,10 +405,25 @@ func (m *ManagerImpl) UpdateProgress(ctx context.Context, sessionID string, file
 	if !exists {
 		return fmt.Errorf("no TODO list found for session %s", sessionID)
 	}
+	m.touch(sessionID)
 
 	return list.UpdateStatus(filePath, status)
 }
 
+// SkipRemaining marks all unfinished items as skipped and returns their paths.
+func (m *ManagerImpl) SkipRemaining(ctx context.Context, sessionID string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list, exists := m.lists[sessionID]
+	if !exists {
+		return nil, fmt.Errorf("no TODO list found for session %s", sessionID)
+	}
+	m.touch(sessionID)
+
+	return list.SkipRemaining(), nil
+}
+
 // GetProgress returns the current progress of the TODO list.
 func (m *ManagerImpl) GetProgress(ctx context.Context, sessionID string) (*Progress, error) {
 	m.mu.RLock()
@@ -170,10 +433,63 @@ func (m *ManagerImpl) GetProgress(ctx context.Context, sessionID string) (*Progr
 	if !exists {
 		return nil, fmt.Errorf("no TODO list found for session %s", sessionID)
 	}
+	m.touch(sessionID)
 
 	return list.GetProgress(), nil
 }
 
+// GetProgressBatch returns the progress of every requested list under a
+// single read lock. Session IDs without a list are left out of the result.
+func (m *ManagerImpl) GetProgressBatch(ctx context.Context, sessionIDs []string) (map[string]*Progress, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	progress := make(map[string]*Progress, len(sessionIDs))
+	for _, sessionID := range sessionIDs {
+		list, exists := m.lists[sessionID]
+		if !exists {
+			continue
+		}
+		m.touch(sessionID)
+		progress[sessionID] = list.GetProgress()
+	}
+
+	return progress, nil
+}
+
+// ListItems returns every item of a TODO list with its current status,
+// sorted by file path.
+func (m *ManagerImpl) ListItems(ctx context.Context, sessionID string) ([]TodoItem, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	list, exists := m.lists[sessionID]
+	if !exists {
+		return nil, fmt.Errorf("no TODO list found for session %s", sessionID)
+	}
+	m.touch(sessionID)
+
+	return list.Items(), nil
+}
+
+// GetItem returns a single item of a TODO list.
+func (m *ManagerImpl) GetItem(ctx context.Context, sessionID string, filePath string) (TodoItem, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	list, exists := m.lists[sessionID]
+	if !exists {
+		return TodoItem{}, fmt.Errorf("no TODO list found for session %s", sessionID)
+	}
+	m.touch(sessionID)
+
+	item, ok := list.Item(filePath)
+	if !ok {
+		return TodoItem{}, fmt.Errorf("no TODO item %s in session %s", filePath, sessionID)
+	}
+	return item, nil
+}
+
 // DeleteList removes a TODO list.
 func (m *ManagerImpl) DeleteList(ctx context.Context, sessionID string) error {
 	m.mu.Lock()
@@ -184,9 +500,40 @@ func (m *ManagerImpl) DeleteList(ctx context.Context, sessionID string) error {
 	}
 
 	delete(m.lists, sessionID)
+	delete(m.lastServed, sessionID)
+	delete(m.touched, sessionID)
 	return nil
 }
 
+// QueueStats returns queue depth and wait time statistics for a list.
+func (m *ManagerImpl) QueueStats(ctx context.Context, sessionID string) (*QueueStats, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	list, exists := m.lists[sessionID]
+	if !exists {
+		return nil, fmt.Errorf("no TODO list found for session %s", sessionID)
+	}
+	m.touch(sessionID)
+
+	return list.Stats(), nil
+}
+
+// PromoteStarved moves items waiting longer than maxWait to the front of
+// the list and returns their paths.
+func (m *ManagerImpl) PromoteStarved(ctx context.Context, sessionID string, maxWait time.Duration) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list, exists := m.lists[sessionID]
+	if !exists {
+		return nil, fmt.Errorf("no TODO list found for session %s", sessionID)
+	}
+	m.touch(sessionID)
+
+	return list.PromoteStarved(maxWait), nil
+}
+
 // NoMoreTodosError indicates the TODO list is empty.
 type NoMoreTodosError struct {
 	SessionID string
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewManager(t *testing.T) {
@@ -176,6 +178,55 @@ func TestManagerAddItem(t *testing.T) {
 	}
 }
 
+func TestManagerUpsertItem(t *testing.T) {
+	ctx := context.Background()
+	manager := NewManager()
+	require.NoError(t, manager.CreateList(ctx, "session-123"))
+
+	inserted, err := manager.UpsertItem(ctx, "session-123", TodoItem{FilePath: "/a.go", Priority: 1, Metadata: map[string]string{"lang": "go"}})
+	require.NoError(t, err)
+	assert.True(t, inserted)
+	require.NoError(t, manager.AddItem(ctx, "session-123", TodoItem{FilePath: "/b.go", Priority: 5}))
+
+	// Adding the same path again updates the item rather than duplicating it
+	inserted, err = manager.UpsertItem(ctx, "session-123", TodoItem{FilePath: "/a.go", Priority: 10})
+	require.NoError(t, err)
+	assert.False(t, inserted)
+
+	items, err := manager.ListItems(ctx, "session-123")
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, "/a.go", items[0].FilePath)
+	assert.Equal(t, 10, items[0].Priority)
+	assert.Equal(t, ItemStatusPending, items[0].Status)
+	assert.Equal(t, map[string]string{"lang": "go"}, items[0].Metadata, "metadata the update leaves unset is kept")
+
+	progress, err := manager.GetProgress(ctx, "session-123")
+	require.NoError(t, err)
+	assert.Equal(t, 2, progress.Total)
+	assert.Equal(t, 2, progress.Pending)
+
+	// The latest priority orders the queue, and the file is handed out once
+	next, err := manager.GetNext(ctx, "session-123")
+	require.NoError(t, err)
+	assert.Equal(t, "/a.go", next)
+	next, err = manager.GetNext(ctx, "session-123")
+	require.NoError(t, err)
+	assert.Equal(t, "/b.go", next)
+	_, err = manager.GetNext(ctx, "session-123")
+	assert.Error(t, err)
+
+	// Re-adding an in-progress file leaves it in progress
+	require.NoError(t, manager.AddItems(ctx, "session-123", []TodoItem{{FilePath: "/a.go", Priority: 3}}))
+	item, err := manager.GetItem(ctx, "session-123", "/a.go")
+	require.NoError(t, err)
+	assert.Equal(t, ItemStatusInProgress, item.Status)
+	assert.Equal(t, 3, item.Priority)
+
+	_, err = manager.UpsertItem(ctx, "missing", TodoItem{FilePath: "/a.go"})
+	assert.Error(t, err)
+}
+
 func TestManagerGetNext(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -216,7 +267,7 @@ func TestManagerGetNext(t *testing.T) {
 				// Verify the returned item had the highest priority
 				progress := list.GetProgress()
 				assert.Equal(t, 2, progress.Pending)
-				assert.Equal(t, 0, progress.InProgress) // Item was removed
+				assert.Equal(t, 1, progress.InProgress) // Item is tracked while processing
 			},
 		},
 		{
@@ -469,6 +520,105 @@ func TestManagerGetProgress(t *testing.T) {
 	}
 }
 
+func TestManagerGetProgressBatch(t *testing.T) {
+	ctx := context.Background()
+	manager := NewManager()
+
+	require.NoError(t, manager.CreateList(ctx, "session-1"))
+	require.NoError(t, manager.AddItems(ctx, "session-1", []TodoItem{
+		{FilePath: "/1.go", Priority: 1},
+		{FilePath: "/2.go", Priority: 2},
+	}))
+	require.NoError(t, manager.UpdateProgress(ctx, "session-1", "/2.go", ItemStatusComplete))
+
+	require.NoError(t, manager.CreateList(ctx, "session-2"))
+	require.NoError(t, manager.AddItem(ctx, "session-2", TodoItem{FilePath: "/3.go", Priority: 1}))
+	require.NoError(t, manager.UpdateProgress(ctx, "session-2", "/3.go", ItemStatusFailed))
+
+	require.NoError(t, manager.CreateList(ctx, "empty"))
+
+	t.Run("returns progress per session", func(t *testing.T) {
+		progress, err := manager.GetProgressBatch(ctx, []string{"session-1", "session-2", "empty"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]*Progress{
+			"session-1": {Total: 2, Pending: 1, Complete: 1},
+			"session-2": {Total: 1, Failed: 1},
+			"empty":     {},
+		}, progress)
+	})
+
+	t.Run("unknown sessions are omitted", func(t *testing.T) {
+		progress, err := manager.GetProgressBatch(ctx, []string{"session-2", "missing"})
+		require.NoError(t, err)
+		assert.Len(t, progress, 1)
+		assert.Equal(t, &Progress{Total: 1, Failed: 1}, progress["session-2"])
+		assert.NotContains(t, progress, "missing")
+	})
+
+	t.Run("no sessions", func(t *testing.T) {
+		progress, err := manager.GetProgressBatch(ctx, nil)
+		require.NoError(t, err)
+		assert.Empty(t, progress)
+	})
+}
+
+func TestManagerListItems(t *testing.T) {
+	ctx := context.Background()
+	manager := NewManager()
+
+	require.NoError(t, manager.CreateList(ctx, "session-1"))
+	require.NoError(t, manager.AddItems(ctx, "session-1", []TodoItem{
+		{FilePath: "/c.go", Priority: 1},
+		{FilePath: "/a.go", Priority: 3},
+		{FilePath: "/b.go", Priority: 2},
+	}))
+
+	// Dequeued items are listed with their current status
+	next, err := manager.GetNext(ctx, "session-1")
+	require.NoError(t, err)
+	require.Equal(t, "/a.go", next)
+	require.NoError(t, manager.UpdateProgress(ctx, "session-1", "/a.go", ItemStatusComplete))
+	_, err = manager.GetNext(ctx, "session-1")
+	require.NoError(t, err)
+
+	items, err := manager.ListItems(ctx, "session-1")
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+	assert.Equal(t, "/a.go", items[0].FilePath)
+	assert.Equal(t, ItemStatusComplete, items[0].Status)
+	assert.Equal(t, "/b.go", items[1].FilePath)
+	assert.Equal(t, ItemStatusInProgress, items[1].Status)
+	assert.Equal(t, "/c.go", items[2].FilePath)
+	assert.Equal(t, ItemStatusPending, items[2].Status)
+
+	_, err = manager.ListItems(ctx, "missing")
+	assert.Error(t, err)
+}
+
+func TestManagerGetItem(t *testing.T) {
+	manager := NewManager()
+	ctx := context.Background()
+	require.NoError(t, manager.CreateList(ctx, "session-1"))
+	require.NoError(t, manager.AddItem(ctx, "session-1", TodoItem{FilePath: "/a.go", Priority: 3}))
+
+	item, err := manager.GetItem(ctx, "session-1", "/a.go")
+	require.NoError(t, err)
+	assert.Equal(t, 3, item.Priority)
+	assert.Equal(t, ItemStatusPending, item.Status)
+
+	_, err = manager.GetNext(ctx, "session-1")
+	require.NoError(t, err)
+	item, err = manager.GetItem(ctx, "session-1", "/a.go")
+	require.NoError(t, err)
+	assert.Equal(t, ItemStatusInProgress, item.Status)
+	assert.False(t, item.StartedAt.IsZero())
+
+	_, err = manager.GetItem(ctx, "session-1", "/missing.go")
+	assert.EqualError(t, err, "no TODO item /missing.go in session session-1")
+	_, err = manager.GetItem(ctx, "missing", "/a.go")
+	assert.EqualError(t, err, "no TODO list found for session missing")
+}
+
 func TestManagerDeleteList(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -632,6 +782,301 @@ func TestManagerConcurrency(t *testing.T) {
 	})
 }
 
+func TestManagerAddItems(t *testing.T) {
+	manager := NewManager()
+	ctx := context.Background()
+
+	assert.NoError(t, manager.CreateList(ctx, "batch-session"))
+	assert.NoError(t, manager.AddItems(ctx, "batch-session", []TodoItem{
+		{FilePath: "/low.go", Priority: 1},
+		{FilePath: "/high.go", Priority: 9},
+		{FilePath: "/done.go", Priority: 5, Status: ItemStatusComplete},
+	}))
+
+	progress, err := manager.GetProgress(ctx, "batch-session")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, progress.Total)
+	assert.Equal(t, 2, progress.Pending)
+	assert.Equal(t, 1, progress.Complete)
+
+	path, err := manager.GetNext(ctx, "batch-session")
+	assert.NoError(t, err)
+	assert.Equal(t, "/high.go", path)
+
+	err = manager.AddItems(ctx, "nonexistent", []TodoItem{{FilePath: "/a.go"}})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no TODO list found for session nonexistent")
+}
+
+func TestManagerSkipRemaining(t *testing.T) {
+	manager := NewManager()
+	ctx := context.Background()
+	sessionID := "skip-session"
+
+	assert.NoError(t, manager.CreateList(ctx, sessionID))
+	assert.NoError(t, manager.AddItems(ctx, sessionID, []TodoItem{
+		{FilePath: "/a.go", Priority: 3},
+		{FilePath: "/b.go", Priority: 2},
+		{FilePath: "/c.go", Priority: 1},
+		{FilePath: "/d.go", Priority: 0},
+	}))
+
+	// a.go completes, b.go is in flight, c.go and d.go are still queued
+	path, err := manager.GetNext(ctx, sessionID)
+	assert.NoError(t, err)
+	assert.NoError(t, manager.UpdateProgress(ctx, sessionID, path, ItemStatusComplete))
+	_, err = manager.GetNext(ctx, sessionID)
+	assert.NoError(t, err)
+
+	skipped, err := manager.SkipRemaining(ctx, sessionID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/b.go", "/c.go", "/d.go"}, skipped)
+
+	progress, err := manager.GetProgress(ctx, sessionID)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, progress.Pending)
+	assert.Equal(t, 0, progress.InProgress)
+	assert.Equal(t, 1, progress.Complete)
+	assert.Equal(t, 3, progress.Skipped)
+
+	// Nothing is left to hand out or skip
+	_, err = manager.GetNext(ctx, sessionID)
+	assert.Error(t, err)
+	skipped, err = manager.SkipRemaining(ctx, sessionID)
+	assert.NoError(t, err)
+	assert.Empty(t, skipped)
+
+	_, err = manager.SkipRemaining(ctx, "nonexistent")
+	assert.Error(t, err)
+}
+
+func TestManagerQueueStats(t *testing.T) {
+	clock := newFakeClock()
+	manager := NewManagerWithClock(clock)
+	ctx := context.Background()
+	sessionID := "stats-session"
+
+	assert.NoError(t, manager.CreateList(ctx, sessionID))
+
+	assert.NoError(t, manager.AddItem(ctx, sessionID, TodoItem{FilePath: "/old.go", Priority: 1}))
+	clock.Advance(5 * time.Minute)
+	assert.NoError(t, manager.AddItem(ctx, sessionID, TodoItem{FilePath: "/new.go", Priority: 10}))
+	assert.NoError(t, manager.AddItem(ctx, sessionID, TodoItem{FilePath: "/newer.go", Priority: 5}))
+	clock.Advance(1 * time.Minute)
+
+	// Take the highest priority item so it counts as in progress
+	path, err := manager.GetNext(ctx, sessionID)
+	assert.NoError(t, err)
+	assert.Equal(t, "/new.go", path)
+
+	stats, err := manager.QueueStats(ctx, sessionID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.Pending)
+	assert.Equal(t, 1, stats.InProgress)
+	assert.Equal(t, 6*time.Minute, stats.OldestPendingAge)
+
+	t.Run("unknown session", func(t *testing.T) {
+		_, err := manager.QueueStats(ctx, "nonexistent")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "no TODO list found for session nonexistent")
+	})
+}
+
+func TestManagerCreateListWithOptions(t *testing.T) {
+	clock := newFakeClock()
+	manager := NewManagerWithClock(clock)
+	ctx := context.Background()
+
+	assert.NoError(t, manager.CreateListWithOptions(ctx, "aging", ListOptions{AgingFactor: 1}))
+	assert.NoError(t, manager.CreateList(ctx, "strict"))
+	for _, sessionID := range []string{"aging", "strict"} {
+		assert.NoError(t, manager.AddItem(ctx, sessionID, TodoItem{FilePath: "/old.go", Priority: 1}))
+	}
+	clock.Advance(30 * time.Minute)
+	for _, sessionID := range []string{"aging", "strict"} {
+		assert.NoError(t, manager.AddItem(ctx, sessionID, TodoItem{FilePath: "/new.go", Priority: 10}))
+	}
+
+	path, err := manager.GetNext(ctx, "aging")
+	assert.NoError(t, err)
+	assert.Equal(t, "/old.go", path)
+
+	path, err = manager.GetNext(ctx, "strict")
+	assert.NoError(t, err)
+	assert.Equal(t, "/new.go", path)
+
+	t.Run("duplicate list", func(t *testing.T) {
+		err := manager.CreateListWithOptions(ctx, "aging", ListOptions{})
+		assert.Error(t, err)
+	})
+
+	t.Run("negative aging factor", func(t *testing.T) {
+		err := manager.CreateListWithOptions(ctx, "negative", ListOptions{AgingFactor: -1})
+		assert.EqualError(t, err, "aging factor cannot be negative")
+	})
+}
+
+func TestManagerDependencyOrder(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("DAG yields topological order", func(t *testing.T) {
+		manager := NewManager()
+		require.NoError(t, manager.CreateListWithOptions(ctx, "deps", ListOptions{DependencyOrder: true}))
+		items := []TodoItem{
+			{FilePath: "/main.go", Priority: 100, DependsOn: []string{"/server.go", "/config.go"}},
+			{FilePath: "/server.go", Priority: 50, DependsOn: []string{"/store.go"}},
+			{FilePath: "/config.go", Priority: 10},
+			{FilePath: "/store.go", Priority: 1},
+			{FilePath: "/util.go", Priority: 20},
+		}
+		require.NoError(t, manager.AddItems(ctx, "deps", items))
+
+		var order []string
+		for {
+			path, err := manager.GetNext(ctx, "deps")
+			if err != nil {
+				var noMore *NoMoreTodosError
+				require.ErrorAs(t, err, &noMore)
+				break
+			}
+			order = append(order, path)
+			require.NoError(t, manager.UpdateProgress(ctx, "deps", path, ItemStatusComplete))
+		}
+
+		// Ready items are taken by priority
+		assert.Equal(t, []string{"/util.go", "/config.go", "/store.go", "/server.go", "/main.go"}, order)
+		position := make(map[string]int, len(order))
+		for i, path := range order {
+			position[path] = i
+		}
+		for _, item := range items {
+			for _, dep := range item.DependsOn {
+				assert.Less(t, position[dep], position[item.FilePath], "%s before %s", dep, item.FilePath)
+			}
+		}
+	})
+
+	t.Run("blocked while dependency in progress", func(t *testing.T) {
+		manager := NewManager()
+		require.NoError(t, manager.CreateListWithOptions(ctx, "deps", ListOptions{DependencyOrder: true}))
+		require.NoError(t, manager.AddItems(ctx, "deps", []TodoItem{
+			{FilePath: "/a.go", Priority: 1},
+			{FilePath: "/b.go", Priority: 10, DependsOn: []string{"/a.go"}},
+		}))
+
+		path, err := manager.GetNext(ctx, "deps")
+		require.NoError(t, err)
+		assert.Equal(t, "/a.go", path)
+
+		_, err = manager.GetNext(ctx, "deps")
+		assert.Error(t, err)
+
+		// A failed dependency no longer holds its dependents back
+		require.NoError(t, manager.UpdateProgress(ctx, "deps", "/a.go", ItemStatusFailed))
+		path, err = manager.GetNext(ctx, "deps")
+		require.NoError(t, err)
+		assert.Equal(t, "/b.go", path)
+	})
+
+	t.Run("ignored without dependency order", func(t *testing.T) {
+		manager := NewManager()
+		require.NoError(t, manager.CreateList(ctx, "plain"))
+		require.NoError(t, manager.AddItems(ctx, "plain", []TodoItem{
+			{FilePath: "/a.go", Priority: 1},
+			{FilePath: "/b.go", Priority: 10, DependsOn: []string{"/a.go"}},
+		}))
+
+		path, err := manager.GetNext(ctx, "plain")
+		require.NoError(t, err)
+		assert.Equal(t, "/b.go", path)
+	})
+
+	t.Run("cycle is rejected", func(t *testing.T) {
+		manager := NewManager()
+		require.NoError(t, manager.CreateListWithOptions(ctx, "deps", ListOptions{DependencyOrder: true}))
+		require.NoError(t, manager.AddItem(ctx, "deps", TodoItem{FilePath: "/a.go", DependsOn: []string{"/b.go"}}))
+
+		err := manager.AddItems(ctx, "deps", []TodoItem{
+			{FilePath: "/b.go", DependsOn: []string{"/c.go"}},
+			{FilePath: "/c.go", DependsOn: []string{"/a.go"}},
+		})
+		var cycleErr *DependencyCycleError
+		require.ErrorAs(t, err, &cycleErr)
+		assert.Equal(t, []string{"/a.go", "/b.go", "/c.go", "/a.go"}, cycleErr.Cycle)
+		assert.EqualError(t, err, "dependency cycle: /a.go -> /b.go -> /c.go -> /a.go")
+
+		// Nothing from the rejected batch was added
+		progress, err := manager.GetProgress(ctx, "deps")
+		require.NoError(t, err)
+		assert.Equal(t, 1, progress.Total)
+	})
+
+	t.Run("self dependency is rejected", func(t *testing.T) {
+		manager := NewManager()
+		require.NoError(t, manager.CreateList(ctx, "deps"))
+		err := manager.AddItem(ctx, "deps", TodoItem{FilePath: "/a.go", DependsOn: []string{"/a.go"}})
+		assert.EqualError(t, err, "dependency cycle: /a.go -> /a.go")
+	})
+}
+
+func TestManagerGetNextAcrossSessions(t *testing.T) {
+	manager := NewManager()
+	ctx := context.Background()
+
+	// Session "a" has the deepest queue and the highest priorities
+	depths := map[string]int{"a": 5, "b": 2, "c": 1}
+	for _, sessionID := range []string{"a", "b", "c"} {
+		assert.NoError(t, manager.CreateList(ctx, sessionID))
+		for i := 0; i < depths[sessionID]; i++ {
+			priority := i
+			if sessionID == "a" {
+				priority += 100
+			}
+			assert.NoError(t, manager.AddItem(ctx, sessionID, TodoItem{
+				FilePath: fmt.Sprintf("/%s/%d.go", sessionID, i),
+				Priority: priority,
+			}))
+		}
+	}
+
+	var served []string
+	for {
+		sessionID, item, err := manager.GetNextAcrossSessions(ctx, []string{"a", "b", "c"})
+		if err != nil {
+			var noMore *NoMoreTodosError
+			assert.ErrorAs(t, err, &noMore)
+			break
+		}
+		assert.Contains(t, item.FilePath, "/"+sessionID+"/")
+		assert.Equal(t, ItemStatusInProgress, item.Status)
+		served = append(served, sessionID)
+	}
+
+	// Each session gets a turn per round until its queue runs dry
+	assert.Equal(t, []string{"a", "b", "c", "a", "b", "a", "a", "a"}, served)
+
+	t.Run("priority orders items within a session", func(t *testing.T) {
+		assert.NoError(t, manager.AddItem(ctx, "b", TodoItem{FilePath: "/b/low.go", Priority: 1}))
+		assert.NoError(t, manager.AddItem(ctx, "b", TodoItem{FilePath: "/b/high.go", Priority: 9}))
+
+		sessionID, item, err := manager.GetNextAcrossSessions(ctx, []string{"a", "b"})
+		assert.NoError(t, err)
+		assert.Equal(t, "b", sessionID)
+		assert.Equal(t, "/b/high.go", item.FilePath)
+	})
+
+	t.Run("unknown session", func(t *testing.T) {
+		_, _, err := manager.GetNextAcrossSessions(ctx, []string{"a", "nonexistent"})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "no TODO list found for session nonexistent")
+	})
+
+	t.Run("no sessions", func(t *testing.T) {
+		_, _, err := manager.GetNextAcrossSessions(ctx, nil)
+		assert.Error(t, err)
+	})
+}
+
 func TestNoMoreTodosError(t *testing.T) {
 	err := &NoMoreTodosError{SessionID: "test-session"}
 	assert.Equal(t, "no more TODO items for session test-session", err.Error())
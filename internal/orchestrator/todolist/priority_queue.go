@@ -3,6 +3,8 @@ package todolist
 import (
 	"container/heap"
 	"fmt"
+	"sort"
+	"time"
 )
 
 // PriorityQueue implements a priority queue for TODO items.
@@ -10,14 +12,32 @@ import (
 type PriorityQueue struct {
 	items    []TodoItem
 	itemMap  map[string]*TodoItem
+	dequeued map[string]*TodoItem
 	progress Progress
+	clock    Clock
+	options  ListOptions
 }
 
 // NewPriorityQueue creates a new priority queue.
 func NewPriorityQueue() *PriorityQueue {
+	return NewPriorityQueueWithClock(realClock{})
+}
+
+// NewPriorityQueueWithClock creates a new priority queue that uses the given
+// clock to timestamp enqueued items.
+func NewPriorityQueueWithClock(clock Clock) *PriorityQueue {
+	return NewPriorityQueueWithOptions(clock, ListOptions{})
+}
+
+// NewPriorityQueueWithOptions creates a new priority queue that uses the
+// given clock and ordering options.
+func NewPriorityQueueWithOptions(clock Clock, opts ListOptions) *PriorityQueue {
 	pq := &PriorityQueue{
-		items:   make([]TodoItem, 0),
-		itemMap: make(map[string]*TodoItem),
+		items:    make([]TodoItem, 0),
+		itemMap:  make(map[string]*TodoItem),
+		dequeued: make(map[string]*TodoItem),
+		clock:    clock,
+		options:  opts,
 		progress: Progress{
 			Total:      0,
 			Pending:    0,
@@ -39,19 +59,50 @@ func (pq *PriorityQueue) Len() int {
 // Less compares two items for ordering.
 // Higher priority items come first.
 func (pq *PriorityQueue) Less(i, j int) bool {
-	return pq.items[i].Priority > pq.items[j].Priority
+	return pq.before(&pq.items[i], &pq.items[j], pq.now())
+}
+
+// before reports whether a should be processed before b. With aging
+// enabled items are compared by their effective priority at now; since
+// every item ages at the same rate the order doesn't drift over time.
+func (pq *PriorityQueue) before(a, b *TodoItem, now time.Time) bool {
+	if pq.options.AgingFactor == 0 {
+		return a.Priority > b.Priority
+	}
+	return pq.effectivePriority(a, now) > pq.effectivePriority(b, now)
+}
+
+// effectivePriority returns an item's priority plus what it has gained by
+// waiting since it was enqueued.
+func (pq *PriorityQueue) effectivePriority(item *TodoItem, now time.Time) float64 {
+	waited := now.Sub(item.EnqueuedAt)
+	if waited < 0 {
+		waited = 0
+	}
+	return float64(item.Priority) + waited.Minutes()*pq.options.AgingFactor
 }
 
 // Swap exchanges two items in the queue.
 func (pq *PriorityQueue) Swap(i, j int) {
 	pq.items[i], pq.items[j] = pq.items[j], pq.items[i]
+	pq.itemMap[pq.items[i].FilePath] = &pq.items[i]
+	pq.itemMap[pq.items[j].FilePath] = &pq.items[j]
 }
 
 // Push adds an item to the queue.
 func (pq *PriorityQueue) Push(x interface{}) {
 	item := x.(TodoItem)
+	if item.EnqueuedAt.IsZero() {
+		item.EnqueuedAt = pq.now()
+	}
+	oldCap := cap(pq.items)
 	pq.items = append(pq.items, item)
-	pq.itemMap[item.FilePath] = &pq.items[len(pq.items)-1]
+	if cap(pq.items) != oldCap {
+		// The backing array moved, so every map entry must be re-pointed
+		pq.reindex()
+	} else {
+		pq.itemMap[item.FilePath] = &pq.items[len(pq.items)-1]
+	}
 
 	// Update progress
 	pq.progress.Total++
@@ -75,21 +126,79 @@ func (pq *PriorityQueue) Pop() interface{} {
 	return item
 }
 
-// AddItem adds a new TODO item to the queue.
+// AddItem adds a TODO item to the queue, or updates the item already held
+// for its file path as Upsert does.
 func (pq *PriorityQueue) AddItem(item TodoItem) {
-	heap.Push(pq, item)
+	pq.Upsert(item)
+}
+
+// Upsert adds item to the queue and reports true, unless the queue already
+// holds an item for its file path, queued or dequeued. That item then takes
+// item's priority, and its metadata, dependencies and status where item
+// sets them, and Upsert reports false. A new item without a status is
+// pending.
+func (pq *PriorityQueue) Upsert(item TodoItem) bool {
+	existing, ok := pq.itemMap[item.FilePath]
+	if !ok {
+		existing, ok = pq.dequeued[item.FilePath]
+	}
+	if !ok {
+		if item.Status == "" {
+			item.Status = ItemStatusPending
+		}
+		heap.Push(pq, item)
+		return true
+	}
+
+	if item.Metadata != nil {
+		existing.Metadata = item.Metadata
+	}
+	if item.DependsOn != nil {
+		existing.DependsOn = item.DependsOn
+	}
+	// Update the status last: requeueing a dequeued item moves it
+	status := existing.Status
+	_ = pq.UpdatePriority(item.FilePath, item.Priority)
+	if item.Status != "" && item.Status != status {
+		_ = pq.UpdateStatus(item.FilePath, item.Status)
+	}
+	return false
+}
+
+// UpdatePriority changes the priority of the item for filePath, queued or
+// dequeued.
+func (pq *PriorityQueue) UpdatePriority(filePath string, priority int) error {
+	if item, ok := pq.dequeued[filePath]; ok {
+		item.Priority = priority
+		return nil
+	}
+	for i := range pq.items {
+		if pq.items[i].FilePath == filePath {
+			pq.items[i].Priority = priority
+			heap.Fix(pq, i)
+			return nil
+		}
+	}
+	return fmt.Errorf("no item for %s", filePath)
 }
 
 // PopNext retrieves and removes the next pending item from the queue.
+// The returned item is marked in progress and remains tracked so that its
+// status can still be updated once processing finishes.
 func (pq *PriorityQueue) PopNext() (*TodoItem, error) {
 	// Find the highest priority pending item
 	var bestItem *TodoItem
 	bestIdx := -1
+	now := pq.now()
 
-	for i, item := range pq.items {
+	for i := range pq.items {
+		item := &pq.items[i]
 		if item.Status == ItemStatusPending {
-			if bestItem == nil || item.Priority > bestItem.Priority {
-				bestItem = &pq.items[i]
+			if pq.options.DependencyOrder && !pq.dependenciesMet(item) {
+				continue
+			}
+			if bestItem == nil || pq.before(item, bestItem, now) {
+				bestItem = item
 				bestIdx = i
 			}
 		}
@@ -101,13 +210,99 @@ func (pq *PriorityQueue) PopNext() (*TodoItem, error) {
 
 	// Mark as in progress
 	bestItem.Status = ItemStatusInProgress
+	bestItem.StartedAt = now
 	pq.updateStatusCount(ItemStatusPending, -1)
 	pq.updateStatusCount(ItemStatusInProgress, 1)
 
-	// Remove from queue
+	// Remove from queue; heap.Remove reuses the slot, so keep a copy
+	popped := *bestItem
 	heap.Remove(pq, bestIdx)
 
-	return bestItem, nil
+	// heap.Remove decremented the in-progress count via Pop; the item is
+	// still being processed, so restore it and keep tracking the item.
+	pq.updateStatusCount(ItemStatusInProgress, 1)
+	pq.dequeued[popped.FilePath] = &popped
+
+	result := popped
+	return &result, nil
+}
+
+// dependenciesMet reports whether every dependency of item has finished.
+// Dependencies that are not in the queue don't hold the item back, nor do
+// ones that failed or were skipped.
+func (pq *PriorityQueue) dependenciesMet(item *TodoItem) bool {
+	for _, dep := range item.DependsOn {
+		depItem, ok := pq.Item(dep)
+		if ok && !isFinal(depItem.Status) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckDependencies reports a DependencyCycleError if adding items to the
+// queue would make the declared dependencies circular.
+func (pq *PriorityQueue) CheckDependencies(items []TodoItem) error {
+	graph := make(map[string][]string, len(pq.items)+len(pq.dequeued)+len(items))
+	for _, item := range pq.items {
+		graph[item.FilePath] = item.DependsOn
+	}
+	for path, item := range pq.dequeued {
+		graph[path] = item.DependsOn
+	}
+	hasDeps := false
+	for _, item := range items {
+		graph[item.FilePath] = item.DependsOn
+		hasDeps = hasDeps || len(item.DependsOn) > 0
+	}
+	if !hasDeps {
+		// Existing items were checked when they were added
+		return nil
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(graph))
+	var stack []string
+	var visit func(path string) []string
+	visit = func(path string) []string {
+		switch state[path] {
+		case visiting:
+			// Report the cycle from the first visit of path
+			for i, p := range stack {
+				if p == path {
+					return append(append([]string{}, stack[i:]...), path)
+				}
+			}
+		case done:
+			return nil
+		}
+		state[path] = visiting
+		stack = append(stack, path)
+		for _, dep := range graph[path] {
+			if cycle := visit(dep); cycle != nil {
+				return cycle
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[path] = done
+		return nil
+	}
+
+	paths := make([]string, 0, len(graph))
+	for path := range graph {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if cycle := visit(path); cycle != nil {
+			return &DependencyCycleError{Cycle: cycle}
+		}
+	}
+	return nil
 }
 
 // UpdateStatus updates the status of an item.
@@ -115,11 +310,31 @@ func (pq *PriorityQueue) UpdateStatus(filePath string, status ItemStatus) error
 	item, exists := pq.itemMap[filePath]
 	if !exists {
 		// Item might have been popped, check if we're updating a processed item
-		return nil
+		item, exists = pq.dequeued[filePath]
+		if !exists {
+			return nil
+		}
+
+		// A dequeued item reset to pending goes back into the queue
+		if status == ItemStatusPending {
+			requeued := *item
+			delete(pq.dequeued, filePath)
+			pq.updateStatusCount(requeued.Status, -1)
+			pq.progress.Total--
+
+			requeued.Status = ItemStatusPending
+			requeued.StartedAt = time.Time{}
+			requeued.CompletedAt = time.Time{}
+			heap.Push(pq, requeued)
+			return nil
+		}
 	}
 
 	oldStatus := item.Status
 	item.Status = status
+	if isFinal(status) {
+		item.CompletedAt = pq.now()
+	}
 
 	// Update progress counts
 	pq.updateStatusCount(oldStatus, -1)
@@ -128,6 +343,77 @@ func (pq *PriorityQueue) UpdateStatus(filePath string, status ItemStatus) error
 	return nil
 }
 
+// SkipRemaining marks every pending or in-progress item as skipped and
+// returns their file paths in sorted order.
+func (pq *PriorityQueue) SkipRemaining() []string {
+	now := pq.now()
+	var skipped []string
+	skip := func(item *TodoItem) {
+		if item.Status != ItemStatusPending && item.Status != ItemStatusInProgress {
+			return
+		}
+		pq.updateStatusCount(item.Status, -1)
+		pq.updateStatusCount(ItemStatusSkipped, 1)
+		item.Status = ItemStatusSkipped
+		item.CompletedAt = now
+		skipped = append(skipped, item.FilePath)
+	}
+
+	for i := range pq.items {
+		skip(&pq.items[i])
+	}
+	for _, item := range pq.dequeued {
+		skip(item)
+	}
+
+	sort.Strings(skipped)
+	return skipped
+}
+
+// PromoteStarved raises the priority of pending items that have waited
+// longer than maxWait so they are dequeued before every other pending item,
+// keeping their relative order. It returns the promoted paths, sorted.
+func (pq *PriorityQueue) PromoteStarved(maxWait time.Duration) []string {
+	now := pq.now()
+
+	var starved []int
+	minStarved, maxOthers := 0, 0
+	hasOthers := false
+	for i, item := range pq.items {
+		if item.Status != ItemStatusPending {
+			continue
+		}
+		if now.Sub(item.EnqueuedAt) > maxWait {
+			if len(starved) == 0 || item.Priority < minStarved {
+				minStarved = item.Priority
+			}
+			starved = append(starved, i)
+			continue
+		}
+		if !hasOthers || item.Priority > maxOthers {
+			maxOthers = item.Priority
+			hasOthers = true
+		}
+	}
+
+	// Nothing to do if the starved items already come first
+	if len(starved) == 0 || !hasOthers || minStarved > maxOthers {
+		return nil
+	}
+
+	boost := maxOthers - minStarved + 1
+	promoted := make([]string, 0, len(starved))
+	for _, i := range starved {
+		pq.items[i].Priority += boost
+		promoted = append(promoted, pq.items[i].FilePath)
+	}
+	heap.Init(pq)
+	pq.reindex()
+
+	sort.Strings(promoted)
+	return promoted
+}
+
 // GetProgress returns the current progress statistics.
 func (pq *PriorityQueue) GetProgress() *Progress {
 	// Return a copy to prevent external modification
@@ -135,6 +421,75 @@ func (pq *PriorityQueue) GetProgress() *Progress {
 	return &progress
 }
 
+// Items returns a copy of every item, queued or dequeued, sorted by file
+// path.
+func (pq *PriorityQueue) Items() []TodoItem {
+	items := make([]TodoItem, 0, len(pq.items)+len(pq.dequeued))
+	items = append(items, pq.items...)
+	for _, item := range pq.dequeued {
+		items = append(items, *item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].FilePath < items[j].FilePath
+	})
+	return items
+}
+
+// Item returns a copy of the item for filePath, queued or dequeued.
+func (pq *PriorityQueue) Item(filePath string) (TodoItem, bool) {
+	if item, ok := pq.itemMap[filePath]; ok {
+		return *item, true
+	}
+	if item, ok := pq.dequeued[filePath]; ok {
+		return *item, true
+	}
+	return TodoItem{}, false
+}
+
+// isFinal reports whether status ends an item's processing.
+func isFinal(status ItemStatus) bool {
+	return status == ItemStatusComplete || status == ItemStatusFailed || status == ItemStatusSkipped
+}
+
+// Stats returns queue depth statistics, including the age of the oldest
+// pending item relative to the queue's clock.
+func (pq *PriorityQueue) Stats() *QueueStats {
+	stats := &QueueStats{
+		InProgress: pq.progress.InProgress,
+	}
+
+	for _, item := range pq.items {
+		if item.Status != ItemStatusPending {
+			continue
+		}
+		stats.Pending++
+		if stats.OldestEnqueuedAt.IsZero() || item.EnqueuedAt.Before(stats.OldestEnqueuedAt) {
+			stats.OldestEnqueuedAt = item.EnqueuedAt
+		}
+	}
+
+	if !stats.OldestEnqueuedAt.IsZero() {
+		stats.OldestPendingAge = pq.now().Sub(stats.OldestEnqueuedAt)
+	}
+
+	return stats
+}
+
+// reindex points every item map entry at its current slot in the queue.
+func (pq *PriorityQueue) reindex() {
+	for i := range pq.items {
+		pq.itemMap[pq.items[i].FilePath] = &pq.items[i]
+	}
+}
+
+// now returns the current time from the queue's clock.
+func (pq *PriorityQueue) now() time.Time {
+	if pq.clock == nil {
+		return time.Now()
+	}
+	return pq.clock.Now()
+}
+
 // updateStatusCount updates the progress count for a status.
 func (pq *PriorityQueue) updateStatusCount(status ItemStatus, delta int) {
 	switch status {
@@ -155,6 +510,7 @@ func (pq *PriorityQueue) updateStatusCount(status ItemStatus, delta int) {
 func (pq *PriorityQueue) Clear() {
 	pq.items = make([]TodoItem, 0)
 	pq.itemMap = make(map[string]*TodoItem)
+	pq.dequeued = make(map[string]*TodoItem)
 	pq.progress = Progress{
 		Total:      0,
 		Pending:    0,
@@ -4,10 +4,29 @@ import (
 	"container/heap"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// fakeClock is a manually advanced Clock for deterministic tests.
+type fakeClock struct {
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
 func TestNewPriorityQueue(t *testing.T) {
 	pq := NewPriorityQueue()
 	assert.NotNil(t, pq)
@@ -134,6 +153,40 @@ func TestPriorityQueueAddItem(t *testing.T) {
 	assert.Equal(t, "/high.go", topItem.FilePath)
 }
 
+func TestPriorityQueueUpsert(t *testing.T) {
+	pq := NewPriorityQueue()
+	assert.True(t, pq.Upsert(TodoItem{FilePath: "/a.go", Priority: 1}))
+	assert.True(t, pq.Upsert(TodoItem{FilePath: "/b.go", Priority: 2}))
+	assert.True(t, pq.Upsert(TodoItem{FilePath: "/c.go", Priority: 3}))
+
+	assert.False(t, pq.Upsert(TodoItem{FilePath: "/a.go", Priority: 9}))
+	pq.AddItem(TodoItem{FilePath: "/c.go", Priority: 0})
+	assert.Equal(t, 3, pq.Len())
+	assert.Equal(t, 3, pq.GetProgress().Total)
+
+	var order []string
+	for {
+		item, err := pq.PopNext()
+		if err != nil {
+			break
+		}
+		order = append(order, item.FilePath)
+	}
+	assert.Equal(t, []string{"/a.go", "/b.go", "/c.go"}, order)
+
+	// An explicit status on a finished item requeues it
+	require.NoError(t, pq.UpdateStatus("/b.go", ItemStatusFailed))
+	assert.False(t, pq.Upsert(TodoItem{FilePath: "/b.go", Priority: 4, Status: ItemStatusPending}))
+	item, ok := pq.Item("/b.go")
+	require.True(t, ok)
+	assert.Equal(t, ItemStatusPending, item.Status)
+	assert.Equal(t, 4, item.Priority)
+	assert.Equal(t, 1, pq.GetProgress().Pending)
+	assert.Equal(t, 0, pq.GetProgress().Failed)
+
+	assert.Error(t, pq.UpdatePriority("/missing.go", 1))
+}
+
 func TestPriorityQueuePopNext(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -591,3 +644,240 @@ func TestPriorityQueueComplexScenarios(t *testing.T) {
 		assert.Equal(t, 20, progress.Pending)
 	})
 }
+
+func TestPriorityQueueStats(t *testing.T) {
+	t.Run("empty queue", func(t *testing.T) {
+		pq := NewPriorityQueueWithClock(newFakeClock())
+
+		stats := pq.Stats()
+		assert.Equal(t, 0, stats.Pending)
+		assert.Equal(t, 0, stats.InProgress)
+		assert.True(t, stats.OldestEnqueuedAt.IsZero())
+		assert.Equal(t, time.Duration(0), stats.OldestPendingAge)
+	})
+
+	t.Run("oldest pending age and depth", func(t *testing.T) {
+		clock := newFakeClock()
+		pq := NewPriorityQueueWithClock(clock)
+
+		start := clock.Now()
+		pq.AddItem(TodoItem{FilePath: "/first.go", Priority: 1, Status: ItemStatusPending})
+		clock.Advance(2 * time.Minute)
+		pq.AddItem(TodoItem{FilePath: "/second.go", Priority: 50, Status: ItemStatusPending})
+		clock.Advance(3 * time.Minute)
+		pq.AddItem(TodoItem{FilePath: "/done.go", Priority: 5, Status: ItemStatusComplete})
+		clock.Advance(10 * time.Minute)
+
+		stats := pq.Stats()
+		assert.Equal(t, 2, stats.Pending)
+		assert.Equal(t, 0, stats.InProgress)
+		assert.Equal(t, start, stats.OldestEnqueuedAt)
+		assert.Equal(t, 15*time.Minute, stats.OldestPendingAge)
+
+		// Processing the oldest item moves the age to the next oldest
+		pq.UpdateStatus("/first.go", ItemStatusInProgress)
+		stats = pq.Stats()
+		assert.Equal(t, 1, stats.Pending)
+		assert.Equal(t, 1, stats.InProgress)
+		assert.Equal(t, 13*time.Minute, stats.OldestPendingAge)
+	})
+
+	t.Run("explicit enqueue time is preserved", func(t *testing.T) {
+		clock := newFakeClock()
+		pq := NewPriorityQueueWithClock(clock)
+
+		enqueued := clock.Now().Add(-1 * time.Hour)
+		pq.AddItem(TodoItem{FilePath: "/seeded.go", Status: ItemStatusPending, EnqueuedAt: enqueued})
+
+		stats := pq.Stats()
+		assert.Equal(t, enqueued, stats.OldestEnqueuedAt)
+		assert.Equal(t, time.Hour, stats.OldestPendingAge)
+	})
+}
+
+func TestPriorityQueuePromoteStarved(t *testing.T) {
+	t.Run("starved items move ahead of newer ones", func(t *testing.T) {
+		clock := newFakeClock()
+		pq := NewPriorityQueueWithClock(clock)
+
+		pq.AddItem(TodoItem{FilePath: "/old-low.go", Priority: 1, Status: ItemStatusPending})
+		pq.AddItem(TodoItem{FilePath: "/old-mid.go", Priority: 3, Status: ItemStatusPending})
+		clock.Advance(10 * time.Minute)
+		pq.AddItem(TodoItem{FilePath: "/new-high.go", Priority: 10, Status: ItemStatusPending})
+		pq.AddItem(TodoItem{FilePath: "/new-low.go", Priority: 0, Status: ItemStatusPending})
+
+		promoted := pq.PromoteStarved(5 * time.Minute)
+		assert.Equal(t, []string{"/old-low.go", "/old-mid.go"}, promoted)
+
+		// Starved items keep their relative order and come first
+		var order []string
+		for i := 0; i < 4; i++ {
+			item, err := pq.PopNext()
+			assert.NoError(t, err)
+			order = append(order, item.FilePath)
+		}
+		assert.Equal(t, []string{"/old-mid.go", "/old-low.go", "/new-high.go", "/new-low.go"}, order)
+	})
+
+	t.Run("nothing starved", func(t *testing.T) {
+		clock := newFakeClock()
+		pq := NewPriorityQueueWithClock(clock)
+		pq.AddItem(TodoItem{FilePath: "/a.go", Priority: 1, Status: ItemStatusPending})
+		clock.Advance(time.Minute)
+
+		assert.Empty(t, pq.PromoteStarved(5*time.Minute))
+	})
+
+	t.Run("starved items already first are left alone", func(t *testing.T) {
+		clock := newFakeClock()
+		pq := NewPriorityQueueWithClock(clock)
+		pq.AddItem(TodoItem{FilePath: "/old.go", Priority: 10, Status: ItemStatusPending})
+		clock.Advance(10 * time.Minute)
+		pq.AddItem(TodoItem{FilePath: "/new.go", Priority: 1, Status: ItemStatusPending})
+
+		assert.Empty(t, pq.PromoteStarved(5*time.Minute))
+		item, err := pq.PopNext()
+		assert.NoError(t, err)
+		assert.Equal(t, "/old.go", item.FilePath)
+		assert.Equal(t, 10, item.Priority)
+	})
+}
+
+func TestPriorityQueueAging(t *testing.T) {
+	popAll := func(t *testing.T, pq *PriorityQueue, n int) []string {
+		t.Helper()
+		var order []string
+		for i := 0; i < n; i++ {
+			item, err := pq.PopNext()
+			require.NoError(t, err)
+			order = append(order, item.FilePath)
+		}
+		return order
+	}
+
+	tests := []struct {
+		name   string
+		aging  float64
+		waited time.Duration
+		want   []string
+	}{
+		{
+			name:   "aging disabled keeps strict priority",
+			aging:  0,
+			waited: 24 * time.Hour,
+			want:   []string{"/new-high.go", "/old-low.go"},
+		},
+		{
+			name:   "not waited long enough",
+			aging:  1,
+			waited: 5 * time.Minute,
+			want:   []string{"/new-high.go", "/old-low.go"},
+		},
+		{
+			name:   "old low priority item overtakes",
+			aging:  1,
+			waited: 15 * time.Minute,
+			want:   []string{"/old-low.go", "/new-high.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			pq := NewPriorityQueueWithOptions(clock, ListOptions{AgingFactor: tt.aging})
+
+			pq.AddItem(TodoItem{FilePath: "/old-low.go", Priority: 1, Status: ItemStatusPending})
+			clock.Advance(tt.waited)
+			pq.AddItem(TodoItem{FilePath: "/new-high.go", Priority: 10, Status: ItemStatusPending})
+			clock.Advance(time.Minute)
+
+			assert.Equal(t, tt.want, popAll(t, pq, 2))
+		})
+	}
+
+	t.Run("heap order agrees with PopNext", func(t *testing.T) {
+		clock := newFakeClock()
+		pq := NewPriorityQueueWithOptions(clock, ListOptions{AgingFactor: 0.5})
+		pq.AddItem(TodoItem{FilePath: "/a.go", Priority: 1, Status: ItemStatusPending})
+		clock.Advance(10 * time.Minute)
+		pq.AddItem(TodoItem{FilePath: "/b.go", Priority: 4, Status: ItemStatusPending})
+		clock.Advance(10 * time.Minute)
+		pq.AddItem(TodoItem{FilePath: "/c.go", Priority: 10, Status: ItemStatusPending})
+
+		// a: 1+10, b: 4+5, c: 10+0
+		assert.Equal(t, "/a.go", pq.items[0].FilePath)
+		assert.Equal(t, []string{"/a.go", "/c.go", "/b.go"}, popAll(t, pq, 3))
+	})
+}
+
+func TestPriorityQueueDequeuedTracking(t *testing.T) {
+	pq := NewPriorityQueue()
+	pq.AddItem(TodoItem{FilePath: "/low.go", Priority: 1, Status: ItemStatusPending})
+	pq.AddItem(TodoItem{FilePath: "/high.go", Priority: 10, Status: ItemStatusPending})
+	pq.AddItem(TodoItem{FilePath: "/mid.go", Priority: 5, Status: ItemStatusPending})
+
+	item, err := pq.PopNext()
+	assert.NoError(t, err)
+	assert.Equal(t, "/high.go", item.FilePath)
+	assert.Equal(t, ItemStatusInProgress, item.Status)
+	assert.Equal(t, 1, pq.GetProgress().InProgress)
+
+	t.Run("completing a dequeued item updates counts", func(t *testing.T) {
+		assert.NoError(t, pq.UpdateStatus("/high.go", ItemStatusComplete))
+		progress := pq.GetProgress()
+		assert.Equal(t, 0, progress.InProgress)
+		assert.Equal(t, 1, progress.Complete)
+		assert.Equal(t, 3, progress.Total)
+	})
+
+	t.Run("resetting a dequeued item requeues it", func(t *testing.T) {
+		item, err := pq.PopNext()
+		assert.NoError(t, err)
+		assert.Equal(t, "/mid.go", item.FilePath)
+
+		assert.NoError(t, pq.UpdateStatus("/mid.go", ItemStatusPending))
+		progress := pq.GetProgress()
+		assert.Equal(t, 0, progress.InProgress)
+		assert.Equal(t, 2, progress.Pending)
+		assert.Equal(t, 3, progress.Total)
+
+		item, err = pq.PopNext()
+		assert.NoError(t, err)
+		assert.Equal(t, "/mid.go", item.FilePath)
+	})
+}
+
+func TestPriorityQueueItemTimestamps(t *testing.T) {
+	clock := newFakeClock()
+	pq := NewPriorityQueueWithClock(clock)
+	enqueued := clock.Now()
+	pq.Push(TodoItem{FilePath: "/a.go", Status: ItemStatusPending})
+
+	clock.Advance(time.Minute)
+	_, err := pq.PopNext()
+	require.NoError(t, err)
+	started := clock.Now()
+
+	clock.Advance(5 * time.Second)
+	require.NoError(t, pq.UpdateStatus("/a.go", ItemStatusComplete))
+
+	item, ok := pq.Item("/a.go")
+	require.True(t, ok)
+	assert.Equal(t, enqueued, item.EnqueuedAt)
+	assert.Equal(t, started, item.StartedAt)
+	assert.Equal(t, started.Add(5*time.Second), item.CompletedAt)
+
+	t.Run("requeueing clears the processing timestamps", func(t *testing.T) {
+		require.NoError(t, pq.UpdateStatus("/a.go", ItemStatusPending))
+		item, ok := pq.Item("/a.go")
+		require.True(t, ok)
+		assert.Equal(t, enqueued, item.EnqueuedAt)
+		assert.True(t, item.StartedAt.IsZero())
+		assert.True(t, item.CompletedAt.IsZero())
+	})
+
+	t.Run("unknown item", func(t *testing.T) {
+		_, ok := pq.Item("/missing.go")
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,88 @@
+package todolist
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// touch records that a list was just used. The caller must hold m.mu, for
+// reading or writing.
+func (m *ManagerImpl) touch(sessionID string) {
+	if touched, ok := m.touched[sessionID]; ok {
+		touched.Store(m.now().UnixNano())
+	}
+}
+
+// now returns the manager clock's current time.
+func (m *ManagerImpl) now() time.Time {
+	if m.clock == nil {
+		return time.Now()
+	}
+	return m.clock.Now()
+}
+
+// SweepIdle deletes every list that has not been used for longer than the
+// configured MaxIdle and returns their session IDs, sorted. It does nothing
+// when MaxIdle is not set.
+func (m *ManagerImpl) SweepIdle() []string {
+	if m.maxIdle <= 0 {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cutoff := m.now().Add(-m.maxIdle).UnixNano()
+	var swept []string
+	for sessionID, touched := range m.touched {
+		if touched.Load() >= cutoff {
+			continue
+		}
+		delete(m.lists, sessionID)
+		delete(m.lastServed, sessionID)
+		delete(m.touched, sessionID)
+		swept = append(swept, sessionID)
+	}
+
+	sort.Strings(swept)
+	return swept
+}
+
+// Shutdown stops the idle list sweeper and waits for it to exit. It is safe
+// to call more than once.
+func (m *ManagerImpl) Shutdown() error {
+	m.shutdownOnce.Do(func() {
+		if m.shutdownCh != nil {
+			close(m.shutdownCh)
+		}
+	})
+	m.wg.Wait()
+	return nil
+}
+
+// startSweeper runs SweepIdle every interval until Shutdown is called.
+func (m *ManagerImpl) startSweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	m.wg.Add(1)
+
+	go func() {
+		defer m.wg.Done()
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.SweepIdle()
+			case <-m.shutdownCh:
+				return
+			}
+		}
+	}()
+}
+
+// newTouched returns a last-used timestamp set to t.
+func newTouched(t time.Time) *atomic.Int64 {
+	touched := new(atomic.Int64)
+	touched.Store(t.UnixNano())
+	return touched
+}
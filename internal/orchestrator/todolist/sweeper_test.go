@@ -0,0 +1,111 @@
+package todolist
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lockedClock is a fakeClock that is safe to advance while the sweeper
+// goroutine reads it.
+type lockedClock struct {
+	mu    sync.Mutex
+	clock *fakeClock
+}
+
+func (c *lockedClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.clock.Now()
+}
+
+func (c *lockedClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clock.Advance(d)
+}
+
+func TestManagerSweepIdle(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("idle lists are swept and touched lists survive", func(t *testing.T) {
+		clock := newFakeClock()
+		// An interval this long never fires during the test
+		manager := NewManagerWithConfig(ManagerConfig{Clock: clock, MaxIdle: 30 * time.Minute, SweepInterval: time.Hour})
+		defer manager.Shutdown()
+
+		require.NoError(t, manager.CreateList(ctx, "idle"))
+		require.NoError(t, manager.CreateList(ctx, "busy"))
+		require.NoError(t, manager.AddItem(ctx, "busy", TodoItem{FilePath: "/a.go", Priority: 1}))
+
+		clock.Advance(20 * time.Minute)
+		_, err := manager.GetProgress(ctx, "busy")
+		require.NoError(t, err)
+
+		// Not idle long enough yet
+		clock.Advance(10 * time.Minute)
+		assert.Empty(t, manager.SweepIdle())
+
+		clock.Advance(time.Second)
+		assert.Equal(t, []string{"idle"}, manager.SweepIdle())
+
+		_, err = manager.GetProgress(ctx, "idle")
+		assert.Error(t, err)
+		progress, err := manager.GetProgress(ctx, "busy")
+		require.NoError(t, err)
+		assert.Equal(t, 1, progress.Pending)
+
+		clock.Advance(31 * time.Minute)
+		assert.Equal(t, []string{"busy"}, manager.SweepIdle())
+	})
+
+	t.Run("a swept list can be recreated", func(t *testing.T) {
+		clock := newFakeClock()
+		manager := NewManagerWithConfig(ManagerConfig{Clock: clock, MaxIdle: time.Minute, SweepInterval: time.Hour})
+		defer manager.Shutdown()
+
+		require.NoError(t, manager.CreateList(ctx, "session-1"))
+		clock.Advance(2 * time.Minute)
+		require.Equal(t, []string{"session-1"}, manager.SweepIdle())
+
+		require.NoError(t, manager.CreateList(ctx, "session-1"))
+		assert.Empty(t, manager.SweepIdle())
+	})
+
+	t.Run("sweeping is disabled without a max idle", func(t *testing.T) {
+		clock := newFakeClock()
+		manager := NewManagerWithConfig(ManagerConfig{Clock: clock})
+		defer manager.Shutdown()
+
+		require.NoError(t, manager.CreateList(ctx, "session-1"))
+		clock.Advance(24 * time.Hour)
+		assert.Empty(t, manager.SweepIdle())
+
+		_, err := manager.GetProgress(ctx, "session-1")
+		assert.NoError(t, err)
+	})
+
+	t.Run("background sweeper runs until shutdown", func(t *testing.T) {
+		clock := &lockedClock{clock: newFakeClock()}
+		manager := NewManagerWithConfig(ManagerConfig{Clock: clock, MaxIdle: time.Minute, SweepInterval: time.Millisecond})
+
+		require.NoError(t, manager.CreateList(ctx, "session-1"))
+		clock.Advance(2 * time.Minute)
+
+		// Polling through the Manager would count as use, so inspect the map
+		assert.Eventually(t, func() bool {
+			manager.mu.RLock()
+			defer manager.mu.RUnlock()
+			_, exists := manager.lists["session-1"]
+			return !exists
+		}, time.Second, time.Millisecond)
+
+		require.NoError(t, manager.Shutdown())
+		// Shutdown is idempotent
+		require.NoError(t, manager.Shutdown())
+	})
+}
@@ -0,0 +1,22 @@
+package orchestrator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError reports every problem found while validating a request.
+// The errors package converts it into an OrchestratorError whose
+// Details["errors"] holds the individual problems.
+type ValidationError struct {
+	// Message is the generic description of what failed validation
+	Message string `json:"message"`
+
+	// Errors lists each problem that was found
+	Errors []string `json:"errors"`
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Message, strings.Join(e.Errors, "; "))
+}
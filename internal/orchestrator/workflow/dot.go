@@ -0,0 +1,82 @@
+package workflow
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dotStartNode names the node the first history entry leaves from.
+const dotStartNode = "start"
+
+// HistoryToDOT renders a session's transition history as a Graphviz DOT
+// digraph, for example to pipe into `dot -Tsvg`. Nodes are the states the
+// session visited, with the current state drawn bold; edges are the
+// transitions in order, labeled with their sequence number, reason and
+// time. Forced transitions are drawn dashed.
+func (e *EngineImpl) HistoryToDOT(sessionID string) (string, error) {
+	e.mu.RLock()
+	history, exists := e.history[sessionID]
+	current := e.states[sessionID]
+	history = append([]StateTransition(nil), history...)
+	e.mu.RUnlock()
+
+	if !exists {
+		return "", fmt.Errorf("no workflow found for session %s", sessionID)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(sessionID))
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [shape=ellipse];\n")
+
+	// Declare each state once, in the order it was first visited
+	visited := make(map[WorkflowState]bool)
+	declare := func(state WorkflowState) {
+		if visited[state] {
+			return
+		}
+		visited[state] = true
+		attrs := ""
+		if state == current {
+			attrs = " [style=bold]"
+		}
+		fmt.Fprintf(&b, "\t%s%s;\n", dotQuote(string(state)), attrs)
+	}
+	for _, entry := range history {
+		if entry.From == "" {
+			fmt.Fprintf(&b, "\t%s [shape=point];\n", dotQuote(dotStartNode))
+			break
+		}
+	}
+	for _, entry := range history {
+		if entry.From != "" {
+			declare(entry.From)
+		}
+		declare(entry.To)
+	}
+
+	for i, entry := range history {
+		from := string(entry.From)
+		if from == "" {
+			from = dotStartNode
+		}
+		label := fmt.Sprintf("%d. %s\n%s", i+1, entry.Reason, entry.Timestamp.UTC().Format(time.RFC3339))
+		attrs := "label=" + dotQuote(label)
+		if entry.Forced {
+			attrs += ", style=dashed"
+		}
+		fmt.Fprintf(&b, "\t%s -> %s [%s];\n", dotQuote(from), dotQuote(string(entry.To)), attrs)
+	}
+
+	b.WriteString("}\n")
+	return b.String(), nil
+}
+
+// dotQuote returns s as a DOT quoted string.
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,57 @@
+package workflow
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngineHistoryToDOT(t *testing.T) {
+	ctx := context.Background()
+	engine, err := NewEngine(WorkflowConfig{})
+	require.NoError(t, err)
+	impl := engine.(*EngineImpl)
+
+	require.NoError(t, engine.Initialize(ctx, "session-1", WorkflowStateIdle))
+	start := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	impl.history["session-1"] = []StateTransition{
+		{From: "", To: WorkflowStateIdle, Timestamp: start, Reason: "workflow initialized"},
+		{From: WorkflowStateIdle, To: WorkflowStateProcessing, Timestamp: start.Add(time.Minute), Reason: "started"},
+		{From: WorkflowStateProcessing, To: WorkflowStatePaused, Timestamp: start.Add(2 * time.Minute), Reason: `provider "outage"`},
+		{From: WorkflowStatePaused, To: WorkflowStateProcessing, Timestamp: start.Add(3 * time.Minute), Reason: "resumed"},
+		{From: WorkflowStateProcessing, To: WorkflowStateFailed, Timestamp: start.Add(4 * time.Minute), Reason: "admin", Forced: true},
+	}
+	impl.states["session-1"] = WorkflowStateFailed
+
+	dot, err := engine.HistoryToDOT("session-1")
+	require.NoError(t, err)
+
+	for _, line := range []string{
+		`"start" [shape=point];`,
+		`"idle";`,
+		`"processing";`,
+		`"paused";`,
+		`"failed" [style=bold];`,
+		`"start" -> "idle" [label="1. workflow initialized\n2025-03-01T09:00:00Z"];`,
+		`"idle" -> "processing" [label="2. started\n2025-03-01T09:01:00Z"];`,
+		`"processing" -> "paused" [label="3. provider \"outage\"\n2025-03-01T09:02:00Z"];`,
+		`"paused" -> "processing" [label="4. resumed\n2025-03-01T09:03:00Z"];`,
+		`"processing" -> "failed" [label="5. admin\n2025-03-01T09:04:00Z", style=dashed];`,
+	} {
+		assert.Contains(t, dot, "\t"+line+"\n")
+	}
+
+	// Each visited state is declared once and the graph is well formed
+	assert.Equal(t, 1, strings.Count(dot, "\t\"processing\";\n"))
+	assert.Equal(t, 5, strings.Count(dot, " -> "))
+	assert.True(t, strings.HasPrefix(dot, "digraph \"session-1\" {\n"))
+	assert.True(t, strings.HasSuffix(dot, "}\n"))
+	assert.Equal(t, 0, strings.Count(strings.ReplaceAll(dot, `\"`, ""), `"`)%2)
+
+	_, err = engine.HistoryToDOT("missing")
+	assert.EqualError(t, err, "no workflow found for session missing")
+}
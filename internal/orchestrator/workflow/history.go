@@ -0,0 +1,148 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// HistoryFilter selects entries of a session's history. Zero fields match
+// every entry.
+type HistoryFilter struct {
+	// To keeps transitions into this state
+	To WorkflowState `json:"to,omitempty"`
+
+	// From keeps transitions out of this state
+	From WorkflowState `json:"from,omitempty"`
+
+	// Since keeps transitions at or after this time
+	Since time.Time `json:"since,omitempty"`
+
+	// Until keeps transitions before this time
+	Until time.Time `json:"until,omitempty"`
+}
+
+// Matches reports whether entry satisfies the filter.
+func (f HistoryFilter) Matches(entry StateTransition) bool {
+	if f.To != "" && entry.To != f.To {
+		return false
+	}
+	if f.From != "" && entry.From != f.From {
+		return false
+	}
+	if !f.Since.IsZero() && entry.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !entry.Timestamp.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// GetHistoryFiltered returns copies of the entries of a session's history
+// that match filter, oldest first.
+func (e *EngineImpl) GetHistoryFiltered(ctx context.Context, sessionID string, filter HistoryFilter) ([]StateTransition, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	history, exists := e.history[sessionID]
+	if !exists {
+		return nil, fmt.Errorf("no workflow found for session %s", sessionID)
+	}
+
+	matched := []StateTransition{}
+	for _, entry := range history {
+		if filter.Matches(entry) {
+			matched = append(matched, entry)
+		}
+	}
+	return matched, nil
+}
+
+// StateVisitCounts returns how many times a session's workflow entered each
+// state, counted from the To state of its history entries. The initial
+// state counts as one visit. An entry summarized by CompactHistory counts
+// once, since the states it passed through are not kept.
+func (e *EngineImpl) StateVisitCounts(sessionID string) (map[WorkflowState]int, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	history, exists := e.history[sessionID]
+	if !exists {
+		return nil, fmt.Errorf("no workflow found for session %s", sessionID)
+	}
+
+	counts := make(map[WorkflowState]int)
+	for _, entry := range history {
+		counts[entry.To]++
+	}
+	return counts, nil
+}
+
+// CompactHistory collapses noisy stretches of a session's history. A
+// transition followed by its reverse (processing→paused→processing) becomes
+// a single summary entry from and to the starting state, and consecutive
+// summaries or no-op entries for the same state are merged. The first
+// entry, forced entries and transitions into terminal states are kept as
+// they are.
+func (e *EngineImpl) CompactHistory(sessionID string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	history, exists := e.history[sessionID]
+	if !exists {
+		return fmt.Errorf("no workflow found for session %s", sessionID)
+	}
+
+	compacted := make([]StateTransition, 0, len(history))
+	for i, entry := range history {
+		compacted = append(compacted, entry)
+		if i == 0 {
+			continue
+		}
+
+		// The first entry records how the workflow started and never merges
+		for len(compacted) >= 3 {
+			prev, last := compacted[len(compacted)-2], compacted[len(compacted)-1]
+			if !compactable(prev) || !compactable(last) {
+				break
+			}
+
+			pingPong := prev.From != prev.To && prev.From == last.To && prev.To == last.From
+			repeated := prev.From == prev.To && last.From == last.To && prev.To == last.To
+			if !pingPong && !repeated {
+				break
+			}
+
+			count := compactedCount(prev) + compactedCount(last)
+			compacted = append(compacted[:len(compacted)-2], StateTransition{
+				From:      prev.From,
+				To:        last.To,
+				Timestamp: last.Timestamp,
+				Reason:    fmt.Sprintf("compacted %d transitions returning to %s", count, last.To),
+				Compacted: count,
+			})
+		}
+	}
+
+	e.history[sessionID] = compacted
+	return nil
+}
+
+// compactable reports whether a history entry may be folded into a summary.
+func compactable(entry StateTransition) bool {
+	return !entry.Forced && !isTerminal(entry.To)
+}
+
+// compactedCount returns how many original transitions an entry stands for.
+func compactedCount(entry StateTransition) int {
+	if entry.Compacted > 0 {
+		return entry.Compacted
+	}
+	return 1
+}
+
+// isTerminal reports whether no transitions lead out of state.
+func isTerminal(state WorkflowState) bool {
+	return len(validTransitions[state]) == 0
+}
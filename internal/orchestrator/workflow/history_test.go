@@ -0,0 +1,210 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngineCompactHistory(t *testing.T) {
+	ctx := context.Background()
+
+	newFlappyEngine := func(t *testing.T, sessionID string) Engine {
+		t.Helper()
+		engine, err := NewEngine(WorkflowConfig{})
+		require.NoError(t, err)
+
+		require.NoError(t, engine.Initialize(ctx, sessionID, WorkflowStateIdle))
+		require.NoError(t, engine.Trigger(ctx, sessionID, EventStart))
+		require.NoError(t, engine.Trigger(ctx, sessionID, EventProcess))
+		for i := 0; i < 3; i++ {
+			require.NoError(t, engine.Trigger(ctx, sessionID, EventPause))
+			require.NoError(t, engine.Trigger(ctx, sessionID, EventResume))
+		}
+		return engine
+	}
+
+	states := func(history []StateTransition) [][2]WorkflowState {
+		var out [][2]WorkflowState
+		for _, entry := range history {
+			out = append(out, [2]WorkflowState{entry.From, entry.To})
+		}
+		return out
+	}
+
+	t.Run("pause and resume cycles collapse", func(t *testing.T) {
+		engine := newFlappyEngine(t, "session-1")
+		require.NoError(t, engine.Trigger(ctx, "session-1", EventComplete))
+
+		before, err := engine.GetHistory(ctx, "session-1")
+		require.NoError(t, err)
+		require.Len(t, before, 10)
+
+		require.NoError(t, engine.CompactHistory("session-1"))
+
+		after, err := engine.GetHistory(ctx, "session-1")
+		require.NoError(t, err)
+		assert.Equal(t, [][2]WorkflowState{
+			{"", WorkflowStateIdle},
+			{WorkflowStateIdle, WorkflowStateInitialized},
+			{WorkflowStateInitialized, WorkflowStateProcessing},
+			{WorkflowStateProcessing, WorkflowStateProcessing},
+			{WorkflowStateProcessing, WorkflowStateCompleted},
+		}, states(after))
+
+		summary := after[3]
+		assert.Equal(t, 6, summary.Compacted)
+		assert.Equal(t, before[8].Timestamp, summary.Timestamp)
+		assert.Contains(t, summary.Reason, "compacted 6 transitions")
+
+		// Endpoints are untouched
+		assert.Equal(t, before[0], after[0])
+		assert.Equal(t, before[9], after[4])
+
+		// The current state is unaffected
+		state, err := engine.GetState(ctx, "session-1")
+		require.NoError(t, err)
+		assert.Equal(t, WorkflowStateCompleted, state)
+	})
+
+	t.Run("compaction is idempotent", func(t *testing.T) {
+		engine := newFlappyEngine(t, "session-2")
+		require.NoError(t, engine.CompactHistory("session-2"))
+		first, err := engine.GetHistory(ctx, "session-2")
+		require.NoError(t, err)
+
+		require.NoError(t, engine.CompactHistory("session-2"))
+		second, err := engine.GetHistory(ctx, "session-2")
+		require.NoError(t, err)
+		assert.Equal(t, first, second)
+	})
+
+	t.Run("forced transitions are kept", func(t *testing.T) {
+		engine, err := NewEngine(WorkflowConfig{})
+		require.NoError(t, err)
+		require.NoError(t, engine.Initialize(ctx, "session-3", WorkflowStateProcessing))
+		require.NoError(t, engine.Trigger(ctx, "session-3", EventPause))
+		require.NoError(t, engine.ForceState(ctx, "session-3", WorkflowStateProcessing, "operator override"))
+
+		require.NoError(t, engine.CompactHistory("session-3"))
+
+		history, err := engine.GetHistory(ctx, "session-3")
+		require.NoError(t, err)
+		require.Len(t, history, 3)
+		assert.True(t, history[2].Forced)
+		assert.Equal(t, 0, history[1].Compacted)
+	})
+
+	t.Run("nothing to compact", func(t *testing.T) {
+		engine, err := NewEngine(WorkflowConfig{})
+		require.NoError(t, err)
+		require.NoError(t, engine.Initialize(ctx, "session-4", WorkflowStateIdle))
+		require.NoError(t, engine.Trigger(ctx, "session-4", EventStart))
+		before, err := engine.GetHistory(ctx, "session-4")
+		require.NoError(t, err)
+
+		require.NoError(t, engine.CompactHistory("session-4"))
+
+		after, err := engine.GetHistory(ctx, "session-4")
+		require.NoError(t, err)
+		assert.Equal(t, before, after)
+	})
+
+	t.Run("unknown session", func(t *testing.T) {
+		engine, err := NewEngine(WorkflowConfig{})
+		require.NoError(t, err)
+		assert.Error(t, engine.CompactHistory("missing"))
+	})
+}
+
+func TestEngineStateVisitCounts(t *testing.T) {
+	ctx := context.Background()
+	engine, err := NewEngine(WorkflowConfig{})
+	require.NoError(t, err)
+
+	require.NoError(t, engine.Initialize(ctx, "session-1", WorkflowStateIdle))
+	require.NoError(t, engine.Trigger(ctx, "session-1", EventStart))
+	require.NoError(t, engine.Trigger(ctx, "session-1", EventProcess))
+	for i := 0; i < 50; i++ {
+		require.NoError(t, engine.Trigger(ctx, "session-1", EventPause))
+		require.NoError(t, engine.Trigger(ctx, "session-1", EventResume))
+	}
+	require.NoError(t, engine.ForceState(ctx, "session-1", WorkflowStatePaused, "operator pause"))
+	require.NoError(t, engine.Trigger(ctx, "session-1", EventResume))
+	require.NoError(t, engine.Trigger(ctx, "session-1", EventComplete))
+
+	counts, err := engine.StateVisitCounts("session-1")
+	require.NoError(t, err)
+	assert.Equal(t, map[WorkflowState]int{
+		WorkflowStateIdle:        1,
+		WorkflowStateInitialized: 1,
+		WorkflowStateProcessing:  52,
+		WorkflowStatePaused:      51,
+		WorkflowStateCompleted:   1,
+	}, counts)
+
+	_, err = engine.StateVisitCounts("unknown")
+	assert.Error(t, err)
+}
+
+func TestEngineGetHistoryFiltered(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	history := []StateTransition{
+		{From: "", To: WorkflowStateIdle, Timestamp: base},
+		{From: WorkflowStateIdle, To: WorkflowStateProcessing, Timestamp: base.Add(time.Hour)},
+		{From: WorkflowStateProcessing, To: WorkflowStateFailed, Timestamp: base.Add(2 * day), Reason: "first failure"},
+		{From: WorkflowStateFailed, To: WorkflowStateProcessing, Timestamp: base.Add(3 * day), Forced: true},
+		{From: WorkflowStateProcessing, To: WorkflowStatePaused, Timestamp: base.Add(8 * day)},
+		{From: WorkflowStatePaused, To: WorkflowStateProcessing, Timestamp: base.Add(9 * day)},
+		{From: WorkflowStateProcessing, To: WorkflowStateFailed, Timestamp: base.Add(10 * day), Reason: "second failure"},
+	}
+	engine := &EngineImpl{
+		states:  map[string]WorkflowState{"session-1": WorkflowStateFailed},
+		history: map[string][]StateTransition{"session-1": history},
+	}
+
+	tests := []struct {
+		name   string
+		filter HistoryFilter
+		want   []int
+	}{
+		{name: "empty filter matches everything", filter: HistoryFilter{}, want: []int{0, 1, 2, 3, 4, 5, 6}},
+		{name: "by target state", filter: HistoryFilter{To: WorkflowStateFailed}, want: []int{2, 6}},
+		{name: "by source state", filter: HistoryFilter{From: WorkflowStateProcessing}, want: []int{2, 4, 6}},
+		{name: "by source and target", filter: HistoryFilter{From: WorkflowStateFailed, To: WorkflowStateProcessing}, want: []int{3}},
+		{name: "time range is half open", filter: HistoryFilter{Since: base.Add(3 * day), Until: base.Add(9 * day)}, want: []int{3, 4}},
+		{name: "into failed last week", filter: HistoryFilter{To: WorkflowStateFailed, Since: base.Add(7 * day), Until: base.Add(14 * day)}, want: []int{6}},
+		{name: "nothing matches", filter: HistoryFilter{To: WorkflowStateCompleted}, want: []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := engine.GetHistoryFiltered(ctx, "session-1", tt.filter)
+			require.NoError(t, err)
+
+			want := make([]StateTransition, 0, len(tt.want))
+			for _, i := range tt.want {
+				want = append(want, history[i])
+			}
+			assert.Equal(t, want, got)
+		})
+	}
+
+	t.Run("result is a copy", func(t *testing.T) {
+		got, err := engine.GetHistoryFiltered(ctx, "session-1", HistoryFilter{To: WorkflowStateFailed})
+		require.NoError(t, err)
+		got[0].Reason = "rewritten"
+		assert.Equal(t, "first failure", engine.history["session-1"][2].Reason)
+	})
+
+	t.Run("unknown session", func(t *testing.T) {
+		_, err := engine.GetHistoryFiltered(ctx, "missing", HistoryFilter{})
+		assert.EqualError(t, err, "no workflow found for session missing")
+	})
+}
@@ -0,0 +1,73 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+)
+
+// MaxFollowUpEvents bounds how many follow-up events a single Trigger or
+// Transition call may cascade into, so hooks that keep queuing events
+// can't loop forever.
+const MaxFollowUpEvents = 100
+
+// EnterHook runs after a session enters a state, while the engine still
+// holds its lock. A hook must not call back into the engine; it queues
+// follow-up events instead, which are triggered once the lock is released.
+type EnterHook func(ctx context.Context, sessionID string, followUps *EventQueue)
+
+// EventQueue collects the follow-up events queued by enter hooks.
+type EventQueue struct {
+	events []WorkflowEvent
+}
+
+// Enqueue schedules event to be triggered after the current transition.
+func (q *EventQueue) Enqueue(event WorkflowEvent) {
+	q.events = append(q.events, event)
+}
+
+// RegisterEnterHook adds a hook run each time a session enters state. Hooks
+// for the same state run in registration order.
+func (e *EngineImpl) RegisterEnterHook(state WorkflowState, hook EnterHook) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.enterHooks == nil {
+		e.enterHooks = make(map[WorkflowState][]EnterHook)
+	}
+	e.enterHooks[state] = append(e.enterHooks[state], hook)
+}
+
+// runEnterHooks runs the hooks registered for state and returns the events
+// they queued. The caller must hold e.mu.
+func (e *EngineImpl) runEnterHooks(ctx context.Context, sessionID string, state WorkflowState) []WorkflowEvent {
+	hooks := e.enterHooks[state]
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	queue := &EventQueue{}
+	for _, hook := range hooks {
+		hook(ctx, sessionID, queue)
+	}
+	return queue.events
+}
+
+// runFollowUps triggers queued follow-up events in order, appending any
+// events their own hooks queue. It must be called without holding e.mu.
+func (e *EngineImpl) runFollowUps(ctx context.Context, sessionID string, queue []WorkflowEvent) error {
+	for processed := 0; len(queue) > 0; processed++ {
+		if processed == MaxFollowUpEvents {
+			return fmt.Errorf("follow-up events for session %s exceeded the limit of %d", sessionID, MaxFollowUpEvents)
+		}
+
+		event := queue[0]
+		queue = queue[1:]
+
+		followUps, err := e.trigger(ctx, sessionID, event)
+		if err != nil {
+			return fmt.Errorf("follow-up event %s failed: %w", event, err)
+		}
+		queue = append(queue, followUps...)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package workflow
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newHookEngine(t *testing.T) *EngineImpl {
+	t.Helper()
+	engine, err := NewEngine(WorkflowConfig{})
+	require.NoError(t, err)
+	return engine.(*EngineImpl)
+}
+
+func TestEngineEnterHookFollowUps(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("hook cascades into a follow-up transition", func(t *testing.T) {
+		engine := newHookEngine(t)
+		var entered []WorkflowState
+		engine.RegisterEnterHook(WorkflowStateInitialized, func(ctx context.Context, sessionID string, followUps *EventQueue) {
+			entered = append(entered, WorkflowStateInitialized)
+			followUps.Enqueue(EventProcess)
+		})
+		engine.RegisterEnterHook(WorkflowStateProcessing, func(ctx context.Context, sessionID string, followUps *EventQueue) {
+			entered = append(entered, WorkflowStateProcessing)
+		})
+
+		require.NoError(t, engine.Initialize(ctx, "session-1", WorkflowStateIdle))
+
+		done := make(chan error, 1)
+		go func() { done <- engine.Trigger(ctx, "session-1", EventStart) }()
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Trigger deadlocked")
+		}
+
+		assert.Equal(t, []WorkflowState{WorkflowStateInitialized, WorkflowStateProcessing}, entered)
+
+		state, err := engine.GetState(ctx, "session-1")
+		require.NoError(t, err)
+		assert.Equal(t, WorkflowStateProcessing, state)
+
+		history, err := engine.GetHistory(ctx, "session-1")
+		require.NoError(t, err)
+		require.Len(t, history, 3)
+		assert.Equal(t, WorkflowStateInitialized, history[1].To)
+		assert.Equal(t, WorkflowStateProcessing, history[2].To)
+	})
+
+	t.Run("follow-ups run in queue order", func(t *testing.T) {
+		engine := newHookEngine(t)
+		engine.RegisterEnterHook(WorkflowStateProcessing, func(ctx context.Context, sessionID string, followUps *EventQueue) {
+			followUps.Enqueue(EventPause)
+		})
+
+		require.NoError(t, engine.Initialize(ctx, "session-2", WorkflowStateInitialized))
+		require.NoError(t, engine.Trigger(ctx, "session-2", EventProcess))
+
+		state, err := engine.GetState(ctx, "session-2")
+		require.NoError(t, err)
+		assert.Equal(t, WorkflowStatePaused, state)
+
+		// Resuming re-enters processing, which pauses again
+		require.NoError(t, engine.Trigger(ctx, "session-2", EventResume))
+		state, err = engine.GetState(ctx, "session-2")
+		require.NoError(t, err)
+		assert.Equal(t, WorkflowStatePaused, state)
+	})
+
+	t.Run("legacy transitions run hooks too", func(t *testing.T) {
+		engine := newHookEngine(t)
+		engine.RegisterEnterHook(WorkflowStateProcessing, func(ctx context.Context, sessionID string, followUps *EventQueue) {
+			followUps.Enqueue(EventComplete)
+		})
+
+		require.NoError(t, engine.Initialize(ctx, "session-3", WorkflowStateInitialized))
+		require.NoError(t, engine.Transition(ctx, "session-3", WorkflowStateProcessing))
+
+		state, err := engine.GetState(ctx, "session-3")
+		require.NoError(t, err)
+		assert.Equal(t, WorkflowStateCompleted, state)
+	})
+
+	t.Run("invalid follow-up is reported", func(t *testing.T) {
+		engine := newHookEngine(t)
+		engine.RegisterEnterHook(WorkflowStateInitialized, func(ctx context.Context, sessionID string, followUps *EventQueue) {
+			followUps.Enqueue(EventResume)
+		})
+
+		require.NoError(t, engine.Initialize(ctx, "session-4", WorkflowStateIdle))
+		err := engine.Trigger(ctx, "session-4", EventStart)
+		assert.ErrorContains(t, err, "follow-up event resume failed")
+
+		// The original transition still happened
+		state, err := engine.GetState(ctx, "session-4")
+		require.NoError(t, err)
+		assert.Equal(t, WorkflowStateInitialized, state)
+	})
+
+	t.Run("endless cascades are cut off", func(t *testing.T) {
+		engine := newHookEngine(t)
+		engine.RegisterEnterHook(WorkflowStateProcessing, func(ctx context.Context, sessionID string, followUps *EventQueue) {
+			followUps.Enqueue(EventPause)
+		})
+		engine.RegisterEnterHook(WorkflowStatePaused, func(ctx context.Context, sessionID string, followUps *EventQueue) {
+			followUps.Enqueue(EventResume)
+		})
+
+		require.NoError(t, engine.Initialize(ctx, "session-5", WorkflowStateInitialized))
+		err := engine.Trigger(ctx, "session-5", EventProcess)
+		assert.ErrorContains(t, err, "exceeded the limit")
+	})
+
+	t.Run("concurrent sessions", func(t *testing.T) {
+		engine := newHookEngine(t)
+		engine.RegisterEnterHook(WorkflowStateInitialized, func(ctx context.Context, sessionID string, followUps *EventQueue) {
+			followUps.Enqueue(EventProcess)
+		})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			sessionID := "concurrent-" + string(rune('a'+i))
+			require.NoError(t, engine.Initialize(ctx, sessionID, WorkflowStateIdle))
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, engine.Trigger(ctx, sessionID, EventStart))
+			}()
+		}
+		wg.Wait()
+	})
+}
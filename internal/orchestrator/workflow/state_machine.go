@@ -31,6 +31,30 @@ type Engine interface {
 
 	// GetHistory returns the state transition history for a session
 	GetHistory(ctx context.Context, sessionID string) ([]StateTransition, error)
+
+	// GetHistoryFiltered returns the entries of a session's history that
+	// match filter, oldest first
+	GetHistoryFiltered(ctx context.Context, sessionID string, filter HistoryFilter) ([]StateTransition, error)
+
+	// ForceState sets the state of a workflow without validating the
+	// transition. It is intended for admin recovery tooling only; the
+	// history entry is marked as forced.
+	ForceState(ctx context.Context, sessionID string, state WorkflowState, reason string) error
+
+	// CompactHistory collapses back-and-forth and no-op transitions in a
+	// session's history into summary entries. It only runs when called.
+	CompactHistory(sessionID string) error
+
+	// StateVisitCounts returns how many times a session's workflow entered
+	// each state
+	StateVisitCounts(sessionID string) (map[WorkflowState]int, error)
+
+	// Remove deletes a session's workflow and its history
+	Remove(ctx context.Context, sessionID string) error
+
+	// HistoryToDOT renders a session's transition history as a Graphviz
+	// DOT graph
+	HistoryToDOT(sessionID string) (string, error)
 }
 
 // StateTransition represents a change in workflow state.
@@ -46,6 +70,13 @@ type StateTransition struct {
 
 	// Reason provides context for the transition
 	Reason string `json:"reason"`
+
+	// Forced is set when the transition bypassed validation via ForceState
+	Forced bool `json:"forced,omitempty"`
+
+	// Compacted is the number of transitions this entry summarizes, set on
+	// entries produced by CompactHistory
+	Compacted int `json:"compacted,omitempty"`
 }
 
 // EngineImpl implements the Engine interface with state validation.
@@ -56,6 +87,7 @@ type EngineImpl struct {
 	mu          sync.RWMutex
 	config      WorkflowConfig
 	validators  map[WorkflowState]StateValidator
+	enterHooks  map[WorkflowState][]EnterHook
 }
 
 // transitionKey represents a state transition trigger.
@@ -64,17 +96,21 @@ type transitionKey struct {
 	Event WorkflowEvent
 }
 
-// StateValidator validates conditions for entering a state.
-type StateValidator func(ctx context.Context, sessionID string) error
-
-// NewEngine creates a new workflow engine instance.
+// NewEngine creates a new workflow engine instance. The config is validated
+// and zero durations are replaced with their defaults.
 func NewEngine(config WorkflowConfig) (Engine, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid workflow config: %w", err)
+	}
+	config = config.withDefaults()
+
 	engine := &EngineImpl{
 		states:      make(map[string]WorkflowState),
 		history:     make(map[string][]StateTransition),
 		transitions: make(map[transitionKey]WorkflowState),
 		config:      config,
 		validators:  make(map[WorkflowState]StateValidator),
+		enterHooks:  make(map[WorkflowState][]EnterHook),
 	}
 
 	// Register state validators
@@ -123,24 +159,32 @@ func (e *EngineImpl) GetState(ctx context.Context, sessionID string) (WorkflowSt
 
 // Transition attempts to move the workflow to a new state.
 func (e *EngineImpl) Transition(ctx context.Context, sessionID string, newState WorkflowState) error {
+	followUps, err := e.transition(ctx, sessionID, newState)
+	if err != nil {
+		return err
+	}
+	return e.runFollowUps(ctx, sessionID, followUps)
+}
+
+// transition performs a state transition under the lock and returns the
+// follow-up events queued by enter hooks.
+func (e *EngineImpl) transition(ctx context.Context, sessionID string, newState WorkflowState) ([]WorkflowEvent, error) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
 	currentState, exists := e.states[sessionID]
 	if !exists {
-		return fmt.Errorf("no workflow found for session %s", sessionID)
+		return nil, fmt.Errorf("no workflow found for session %s", sessionID)
 	}
 
 	// Validate transition
 	if err := e.ValidateTransition(currentState, newState); err != nil {
-		return err
+		return nil, err
 	}
 
 	// Run state validator if exists
-	if validator, ok := e.validators[newState]; ok {
-		if err := validator(ctx, sessionID); err != nil {
-			return fmt.Errorf("state validation failed: %w", err)
-		}
+	if err := e.runValidator(ctx, sessionID, newState); err != nil {
+		return nil, err
 	}
 
 	// Perform transition
@@ -152,30 +196,40 @@ func (e *EngineImpl) Transition(ctx context.Context, sessionID string, newState
 		Reason:    fmt.Sprintf("transitioned from %s to %s", currentState, newState),
 	})
 
-	return nil
+	return e.runEnterHooks(ctx, sessionID, newState), nil
 }
 
-// Trigger executes a state transition based on an event.
+// Trigger executes a state transition based on an event. Events queued by
+// enter hooks are triggered in order once the transition is complete.
 func (e *EngineImpl) Trigger(ctx context.Context, sessionID string, event WorkflowEvent) error {
+	followUps, err := e.trigger(ctx, sessionID, event)
+	if err != nil {
+		return err
+	}
+	return e.runFollowUps(ctx, sessionID, followUps)
+}
+
+// trigger performs an event transition under the lock and returns the
+// follow-up events queued by enter hooks.
+func (e *EngineImpl) trigger(ctx context.Context, sessionID string, event WorkflowEvent) ([]WorkflowEvent, error) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
 	currentState, exists := e.states[sessionID]
 	if !exists {
-		return fmt.Errorf("no workflow found for session %s", sessionID)
+		return nil, fmt.Errorf("no workflow found for session %s", sessionID)
 	}
 
-	// Check if transition is valid
-	newState, canTransition := e.CanTransition(currentState, event)
+	// Check if transition is valid. The transitions map is read directly
+	// because CanTransition would re-acquire e.mu and deadlock.
+	newState, canTransition := e.transitions[transitionKey{From: currentState, Event: event}]
 	if !canTransition {
-		return fmt.Errorf("invalid transition: %s + %s from state %s", currentState, event, currentState)
+		return nil, fmt.Errorf("invalid transition: %s + %s from state %s", currentState, event, currentState)
 	}
 
 	// Run state validator if exists
-	if validator, ok := e.validators[newState]; ok {
-		if err := validator(ctx, sessionID); err != nil {
-			return fmt.Errorf("state validation failed: %w", err)
-		}
+	if err := e.runValidator(ctx, sessionID, newState); err != nil {
+		return nil, err
 	}
 
 	// Perform transition
@@ -187,7 +241,7 @@ func (e *EngineImpl) Trigger(ctx context.Context, sessionID string, event Workfl
 		Reason:    fmt.Sprintf("event %s triggered transition from %s to %s", event, currentState, newState),
 	})
 
-	return nil
+	return e.runEnterHooks(ctx, sessionID, newState), nil
 }
 
 // CanTransition checks if an event can trigger a transition from current state.
@@ -200,45 +254,46 @@ func (e *EngineImpl) CanTransition(from WorkflowState, event WorkflowEvent) (Wor
 	return to, exists
 }
 
+// validTransitions lists the states reachable from each state.
+// Updated to support new states with legacy compatibility.
+var validTransitions = map[WorkflowState][]WorkflowState{
+	WorkflowStateIdle: {
+		WorkflowStateInitialized,
+		WorkflowStateFailed,
+	},
+	WorkflowStateInitialized: {
+		WorkflowStateProcessing,
+		WorkflowStateCancelled,
+	},
+	WorkflowStateProcessing: {
+		WorkflowStateCompleted,
+		WorkflowStateComplete, // Legacy compatibility
+		WorkflowStateFailed,
+		WorkflowStatePaused,
+		WorkflowStateCancelled,
+	},
+	WorkflowStatePaused: {
+		WorkflowStateProcessing,
+		WorkflowStateCancelled,
+	},
+	WorkflowStateCompleted: {
+		// Terminal state - no transitions allowed
+	},
+	WorkflowStateComplete: {
+		// Legacy terminal state - no transitions allowed
+	},
+	WorkflowStateFailed: {
+		// Failed workflows can be retried
+		WorkflowStateInitialized,
+		WorkflowStateCancelled,
+	},
+	WorkflowStateCancelled: {
+		// Terminal state - no transitions allowed
+	},
+}
+
 // ValidateTransition checks if a state transition is allowed.
 func (e *EngineImpl) ValidateTransition(from, to WorkflowState) error {
-	// Updated to support new states with legacy compatibility
-	validTransitions := map[WorkflowState][]WorkflowState{
-		WorkflowStateIdle: {
-			WorkflowStateInitialized,
-			WorkflowStateFailed,
-		},
-		WorkflowStateInitialized: {
-			WorkflowStateProcessing,
-			WorkflowStateCancelled,
-		},
-		WorkflowStateProcessing: {
-			WorkflowStateCompleted,
-			WorkflowStateComplete, // Legacy compatibility
-			WorkflowStateFailed,
-			WorkflowStatePaused,
-			WorkflowStateCancelled,
-		},
-		WorkflowStatePaused: {
-			WorkflowStateProcessing,
-			WorkflowStateCancelled,
-		},
-		WorkflowStateCompleted: {
-			// Terminal state - no transitions allowed
-		},
-		WorkflowStateComplete: {
-			// Legacy terminal state - no transitions allowed
-		},
-		WorkflowStateFailed: {
-			// Failed workflows can be retried
-			WorkflowStateInitialized,
-			WorkflowStateCancelled,
-		},
-		WorkflowStateCancelled: {
-			// Terminal state - no transitions allowed
-		},
-	}
-
 	allowedStates, ok := validTransitions[from]
 	if !ok {
 		return fmt.Errorf("unknown state: %s", from)
@@ -253,6 +308,36 @@ func (e *EngineImpl) ValidateTransition(from, to WorkflowState) error {
 	return fmt.Errorf("transition from %s to %s is not allowed", from, to)
 }
 
+// ForceState sets the state of a workflow, bypassing transition rules and
+// state validators. Use it only to recover stuck sessions.
+func (e *EngineImpl) ForceState(ctx context.Context, sessionID string, state WorkflowState, reason string) error {
+	if reason == "" {
+		return fmt.Errorf("a reason is required to force a state")
+	}
+	if _, ok := validTransitions[state]; !ok {
+		return fmt.Errorf("unknown state: %s", state)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	currentState, exists := e.states[sessionID]
+	if !exists {
+		return fmt.Errorf("no workflow found for session %s", sessionID)
+	}
+
+	e.states[sessionID] = state
+	e.history[sessionID] = append(e.history[sessionID], StateTransition{
+		From:      currentState,
+		To:        state,
+		Timestamp: time.Now(),
+		Reason:    reason,
+		Forced:    true,
+	})
+
+	return nil
+}
+
 // GetHistory returns the state transition history for a session.
 func (e *EngineImpl) GetHistory(ctx context.Context, sessionID string) ([]StateTransition, error) {
 	e.mu.RLock()
@@ -270,10 +355,23 @@ func (e *EngineImpl) GetHistory(ctx context.Context, sessionID string) ([]StateT
 	return historyCopy, nil
 }
 
+// Remove deletes a session's workflow and its history.
+func (e *EngineImpl) Remove(ctx context.Context, sessionID string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, exists := e.states[sessionID]; !exists {
+		return fmt.Errorf("no workflow found for session %s", sessionID)
+	}
+	delete(e.states, sessionID)
+	delete(e.history, sessionID)
+	return nil
+}
+
 // registerValidators sets up state-specific validation logic.
 func (e *EngineImpl) registerValidators() {
 	// Validator for processing state
-	e.validators[WorkflowStateProcessing] = func(ctx context.Context, sessionID string) error {
+	e.validators[WorkflowStateProcessing] = func(ctx context.Context, view StateView, sessionID string) error {
 		// In a real implementation, this might check:
 		// - Session has files to process
 		// - Required services are available
@@ -282,7 +380,7 @@ func (e *EngineImpl) registerValidators() {
 	}
 
 	// Validator for completed state
-	e.validators[WorkflowStateCompleted] = func(ctx context.Context, sessionID string) error {
+	e.validators[WorkflowStateCompleted] = func(ctx context.Context, view StateView, sessionID string) error {
 		// In a real implementation, this might check:
 		// - All files have been processed
 		// - No pending operations
@@ -290,7 +388,7 @@ func (e *EngineImpl) registerValidators() {
 	}
 
 	// Legacy validator for complete state
-	e.validators[WorkflowStateComplete] = func(ctx context.Context, sessionID string) error {
+	e.validators[WorkflowStateComplete] = func(ctx context.Context, view StateView, sessionID string) error {
 		return nil
 	}
 }
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewEngine(t *testing.T) {
@@ -30,6 +31,58 @@ func TestNewEngine(t *testing.T) {
 	assert.Equal(t, config, impl.config)
 }
 
+func TestNewEngineConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config WorkflowConfig
+		want   WorkflowConfig
+		errMsg string
+	}{
+		{
+			name:   "zero values get defaults",
+			config: WorkflowConfig{},
+			want: WorkflowConfig{
+				MaxRetries:        0,
+				RetryDelay:        DefaultRetryDelay,
+				TransitionTimeout: DefaultTransitionTimeout,
+			},
+		},
+		{
+			name:   "explicit values are kept",
+			config: WorkflowConfig{MaxRetries: 2, RetryDelay: 5 * time.Second, TransitionTimeout: time.Minute},
+			want:   WorkflowConfig{MaxRetries: 2, RetryDelay: 5 * time.Second, TransitionTimeout: time.Minute},
+		},
+		{
+			name:   "negative max retries",
+			config: WorkflowConfig{MaxRetries: -1},
+			errMsg: "invalid workflow config: max_retries cannot be negative",
+		},
+		{
+			name:   "negative retry delay",
+			config: WorkflowConfig{RetryDelay: -time.Second},
+			errMsg: "invalid workflow config: retry_delay cannot be negative",
+		},
+		{
+			name:   "negative transition timeout",
+			config: WorkflowConfig{TransitionTimeout: -time.Second},
+			errMsg: "invalid workflow config: transition_timeout cannot be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, err := NewEngine(tt.config)
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				assert.Nil(t, engine)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, engine.(*EngineImpl).config)
+		})
+	}
+}
+
 func TestEngineInitialize(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -252,7 +305,7 @@ func TestEngineTransition(t *testing.T) {
 			newState:  WorkflowStateInitialized,
 			setupFunc: func(e *EngineImpl) {
 				e.states["validator-fail"] = WorkflowStateIdle
-				e.validators[WorkflowStateInitialized] = func(ctx context.Context, sessionID string) error {
+				e.validators[WorkflowStateInitialized] = func(ctx context.Context, view StateView, sessionID string) error {
 					return fmt.Errorf("validation failed")
 				}
 			},
@@ -580,11 +633,11 @@ func TestRegisterValidators(t *testing.T) {
 	assert.NotNil(t, engine.validators[WorkflowStateComplete])
 
 	// Test processing validator
-	err := engine.validators[WorkflowStateProcessing](context.Background(), "test-session")
+	err := engine.validators[WorkflowStateProcessing](context.Background(), engineView{engine}, "test-session")
 	assert.NoError(t, err)
 
 	// Test complete validator
-	err = engine.validators[WorkflowStateComplete](context.Background(), "test-session")
+	err = engine.validators[WorkflowStateComplete](context.Background(), engineView{engine}, "test-session")
 	assert.NoError(t, err)
 }
 
@@ -617,5 +670,140 @@ func TestHistoryImmutability(t *testing.T) {
 	assert.Equal(t, "test", engine.history[sessionID][0].Reason)
 }
 
+func TestEngineTrigger(t *testing.T) {
+	ctx := context.Background()
+	engine, err := NewEngine(WorkflowConfig{})
+	assert.NoError(t, err)
+	assert.NoError(t, engine.Initialize(ctx, "session-1", WorkflowStateIdle))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- engine.Trigger(ctx, "session-1", EventStart)
+	}()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Trigger did not return")
+	}
+
+	state, err := engine.GetState(ctx, "session-1")
+	assert.NoError(t, err)
+	assert.Equal(t, WorkflowStateInitialized, state)
+
+	history, err := engine.GetHistory(ctx, "session-1")
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, "event start triggered transition from idle to initialized", history[1].Reason)
+
+	err = engine.Trigger(ctx, "session-1", EventStart)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid transition")
+
+	err = engine.Trigger(ctx, "missing", EventStart)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no workflow found for session missing")
+}
+
+func TestEngineForceState(t *testing.T) {
+	ctx := context.Background()
+
+	newCompletedEngine := func(t *testing.T) *EngineImpl {
+		engine := &EngineImpl{
+			states:  make(map[string]WorkflowState),
+			history: make(map[string][]StateTransition),
+		}
+		assert.NoError(t, engine.Initialize(ctx, "session-1", WorkflowStateProcessing))
+		assert.NoError(t, engine.Transition(ctx, "session-1", WorkflowStateCompleted))
+		return engine
+	}
+
+	t.Run("moves completed session to failed", func(t *testing.T) {
+		engine := newCompletedEngine(t)
+
+		// Normal transitions forbid leaving a terminal state
+		assert.Error(t, engine.Transition(ctx, "session-1", WorkflowStateFailed))
+
+		err := engine.ForceState(ctx, "session-1", WorkflowStateFailed, "output was corrupted")
+		assert.NoError(t, err)
+
+		state, err := engine.GetState(ctx, "session-1")
+		assert.NoError(t, err)
+		assert.Equal(t, WorkflowStateFailed, state)
+
+		history, err := engine.GetHistory(ctx, "session-1")
+		assert.NoError(t, err)
+		assert.Len(t, history, 3)
+		assert.False(t, history[1].Forced)
+
+		forced := history[2]
+		assert.True(t, forced.Forced)
+		assert.Equal(t, WorkflowStateCompleted, forced.From)
+		assert.Equal(t, WorkflowStateFailed, forced.To)
+		assert.Equal(t, "output was corrupted", forced.Reason)
+	})
+
+	tests := []struct {
+		name      string
+		sessionID string
+		state     WorkflowState
+		reason    string
+		errMsg    string
+	}{
+		{
+			name:      "missing reason",
+			sessionID: "session-1",
+			state:     WorkflowStateFailed,
+			errMsg:    "a reason is required",
+		},
+		{
+			name:      "unknown state",
+			sessionID: "session-1",
+			state:     WorkflowState("bogus"),
+			reason:    "testing",
+			errMsg:    "unknown state: bogus",
+		},
+		{
+			name:      "unknown session",
+			sessionID: "missing",
+			state:     WorkflowStateFailed,
+			reason:    "testing",
+			errMsg:    "no workflow found for session missing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := newCompletedEngine(t)
+
+			err := engine.ForceState(ctx, tt.sessionID, tt.state, tt.reason)
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+
+			history, _ := engine.GetHistory(ctx, "session-1")
+			assert.Len(t, history, 2)
+		})
+	}
+}
+
 // Helper to ensure interface compliance
 var _ Engine = (*EngineImpl)(nil)
+
+func TestEngineRemove(t *testing.T) {
+	ctx := context.Background()
+	engine, err := NewEngine(WorkflowConfig{})
+	require.NoError(t, err)
+	require.NoError(t, engine.Initialize(ctx, "session-1", WorkflowStateIdle))
+
+	require.NoError(t, engine.Remove(ctx, "session-1"))
+	_, err = engine.GetState(ctx, "session-1")
+	assert.Error(t, err)
+	_, err = engine.GetHistory(ctx, "session-1")
+	assert.Error(t, err)
+
+	// The session ID can be reused once removed
+	assert.NoError(t, engine.Initialize(ctx, "session-1", WorkflowStateIdle))
+
+	assert.EqualError(t, engine.Remove(ctx, "missing"), "no workflow found for session missing")
+}
@@ -1,6 +1,18 @@
 package workflow
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
+
+// Defaults applied by NewEngine to zero-valued WorkflowConfig fields.
+const (
+	// DefaultRetryDelay is the delay between retry attempts
+	DefaultRetryDelay = 1 * time.Second
+
+	// DefaultTransitionTimeout is the maximum time for state transitions
+	DefaultTransitionTimeout = 30 * time.Second
+)
 
 // WorkflowConfig contains workflow state machine settings.
 type WorkflowConfig struct {
@@ -14,6 +26,33 @@ type WorkflowConfig struct {
 	TransitionTimeout time.Duration `json:"transition_timeout"`
 }
 
+// Validate checks that no setting is negative. Zero values are valid; they
+// are replaced with defaults by NewEngine.
+func (c WorkflowConfig) Validate() error {
+	if c.MaxRetries < 0 {
+		return fmt.Errorf("max_retries cannot be negative")
+	}
+	if c.RetryDelay < 0 {
+		return fmt.Errorf("retry_delay cannot be negative")
+	}
+	if c.TransitionTimeout < 0 {
+		return fmt.Errorf("transition_timeout cannot be negative")
+	}
+	return nil
+}
+
+// withDefaults returns a copy of the config with zero durations replaced by
+// their defaults. A zero MaxRetries is kept, since it disables retries.
+func (c WorkflowConfig) withDefaults() WorkflowConfig {
+	if c.RetryDelay == 0 {
+		c.RetryDelay = DefaultRetryDelay
+	}
+	if c.TransitionTimeout == 0 {
+		c.TransitionTimeout = DefaultTransitionTimeout
+	}
+	return c
+}
+
 // WorkflowState represents the current state of a documentation workflow.
 type WorkflowState string
 
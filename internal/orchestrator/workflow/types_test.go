@@ -34,8 +34,32 @@ func TestWorkflowConfig(t *testing.T) {
 			MaxRetries: -1,
 		}
 
-		// Negative retries should be allowed (could mean infinite retries)
 		assert.Equal(t, -1, config.MaxRetries)
+		assert.EqualError(t, config.Validate(), "max_retries cannot be negative")
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			config WorkflowConfig
+			errMsg string
+		}{
+			{name: "zero values", config: WorkflowConfig{}},
+			{name: "all set", config: WorkflowConfig{MaxRetries: 3, RetryDelay: time.Second, TransitionTimeout: time.Minute}},
+			{name: "negative retry delay", config: WorkflowConfig{RetryDelay: -time.Second}, errMsg: "retry_delay cannot be negative"},
+			{name: "negative transition timeout", config: WorkflowConfig{TransitionTimeout: -time.Second}, errMsg: "transition_timeout cannot be negative"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				err := tt.config.Validate()
+				if tt.errMsg == "" {
+					assert.NoError(t, err)
+				} else {
+					assert.EqualError(t, err, tt.errMsg)
+				}
+			})
+		}
 	})
 }
 
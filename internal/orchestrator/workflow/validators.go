@@ -0,0 +1,64 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+)
+
+// StateValidator validates conditions for entering a state. It runs while
+// the engine holds its write lock, so it must not call back into the engine;
+// it reads workflows through view instead.
+type StateValidator func(ctx context.Context, view StateView, sessionID string) error
+
+// StateView is the read-only view of the engine passed to state validators.
+// It reads without locking because the transition being validated already
+// holds the lock, so it is only valid for the duration of the call.
+type StateView interface {
+	// State returns the current state of a session's workflow, which during
+	// validation is the state being left
+	State(sessionID string) (WorkflowState, bool)
+
+	// History returns a copy of a session's transitions
+	History(sessionID string) []StateTransition
+}
+
+// engineView implements StateView over an engine whose lock is held.
+type engineView struct {
+	e *EngineImpl
+}
+
+// State returns the current state of a session's workflow.
+func (v engineView) State(sessionID string) (WorkflowState, bool) {
+	state, ok := v.e.states[sessionID]
+	return state, ok
+}
+
+// History returns a copy of a session's transitions.
+func (v engineView) History(sessionID string) []StateTransition {
+	return append([]StateTransition(nil), v.e.history[sessionID]...)
+}
+
+// RegisterValidator sets the validator run before a session enters state,
+// replacing any validator already registered for it.
+func (e *EngineImpl) RegisterValidator(state WorkflowState, validator StateValidator) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.validators == nil {
+		e.validators = make(map[WorkflowState]StateValidator)
+	}
+	e.validators[state] = validator
+}
+
+// runValidator runs the validator registered for newState, if any. The
+// caller must hold e.mu.
+func (e *EngineImpl) runValidator(ctx context.Context, sessionID string, newState WorkflowState) error {
+	validator, ok := e.validators[newState]
+	if !ok {
+		return nil
+	}
+	if err := validator(ctx, engineView{e}, sessionID); err != nil {
+		return fmt.Errorf("state validation failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngineValidatorReadsState(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("validator sees the pre-transition state without deadlocking", func(t *testing.T) {
+		engine := newHookEngine(t)
+		var seen WorkflowState
+		var seenHistory []StateTransition
+		engine.RegisterValidator(WorkflowStateProcessing, func(ctx context.Context, view StateView, sessionID string) error {
+			state, ok := view.State(sessionID)
+			require.True(t, ok)
+			seen = state
+			seenHistory = view.History(sessionID)
+			return nil
+		})
+
+		require.NoError(t, engine.Initialize(ctx, "session-1", WorkflowStateInitialized))
+
+		done := make(chan error, 1)
+		go func() { done <- engine.Trigger(ctx, "session-1", EventProcess) }()
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Trigger deadlocked")
+		}
+
+		assert.Equal(t, WorkflowStateInitialized, seen)
+		require.Len(t, seenHistory, 1)
+		assert.Equal(t, WorkflowStateInitialized, seenHistory[0].To)
+
+		// The view hands out copies
+		seenHistory[0].Reason = "changed"
+		history, err := engine.GetHistory(ctx, "session-1")
+		require.NoError(t, err)
+		assert.Equal(t, "workflow initialized", history[0].Reason)
+
+		state, err := engine.GetState(ctx, "session-1")
+		require.NoError(t, err)
+		assert.Equal(t, WorkflowStateProcessing, state)
+	})
+
+	t.Run("rejected transition leaves the state unchanged", func(t *testing.T) {
+		engine := newHookEngine(t)
+		engine.RegisterValidator(WorkflowStatePaused, func(ctx context.Context, view StateView, sessionID string) error {
+			if history := view.History(sessionID); len(history) < 3 {
+				return errors.New("nothing processed yet")
+			}
+			return nil
+		})
+
+		require.NoError(t, engine.Initialize(ctx, "session-2", WorkflowStateProcessing))
+		err := engine.Transition(ctx, "session-2", WorkflowStatePaused)
+		assert.EqualError(t, err, "state validation failed: nothing processed yet")
+
+		state, err := engine.GetState(ctx, "session-2")
+		require.NoError(t, err)
+		assert.Equal(t, WorkflowStateProcessing, state)
+	})
+
+	t.Run("unknown session is reported as missing", func(t *testing.T) {
+		engine := newHookEngine(t)
+		view := engineView{engine}
+		_, ok := view.State("missing")
+		assert.False(t, ok)
+		assert.Empty(t, view.History("missing"))
+	})
+}
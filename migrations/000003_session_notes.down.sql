@@ -0,0 +1,3 @@
+-- Remove session notes
+ALTER TABLE documentation_sessions
+DROP COLUMN IF EXISTS notes;
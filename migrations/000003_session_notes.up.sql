@@ -0,0 +1,3 @@
+-- Persist session notes so they survive restarts and can be searched
+ALTER TABLE documentation_sessions
+ADD COLUMN IF NOT EXISTS notes JSONB NOT NULL DEFAULT '[]'::jsonb;
@@ -0,0 +1,9 @@
+-- Fold the project path back into module_name
+DROP INDEX IF EXISTS idx_documentation_sessions_workspace_project_path;
+
+UPDATE documentation_sessions
+SET module_name = project_path
+WHERE module_name = '';
+
+ALTER TABLE documentation_sessions
+DROP COLUMN IF EXISTS project_path;
@@ -0,0 +1,10 @@
+-- Store the documented project separately from the module name
+ALTER TABLE documentation_sessions
+ADD COLUMN IF NOT EXISTS project_path TEXT NOT NULL DEFAULT '';
+
+-- Earlier versions stored the project path in module_name
+UPDATE documentation_sessions
+SET project_path = module_name, module_name = ''
+WHERE project_path = '';
+
+CREATE INDEX IF NOT EXISTS idx_documentation_sessions_workspace_project_path ON documentation_sessions(workspace_id, project_path);